@@ -23,4 +23,7 @@ type Server struct {
 type Worker struct {
 	PayoutInterval string
 	PayoutTimeUnit string
+	InstanceID     string
+	LeaseTTL       string
+	OutboxInterval string
 }
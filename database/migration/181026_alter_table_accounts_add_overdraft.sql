@@ -0,0 +1,2 @@
+ALTER TABLE accounts ADD COLUMN overdraft_limit NUMERIC(15, 2) NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD COLUMN overdraft_interest_rate NUMERIC(7, 4) NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN account_id UUID REFERENCES accounts(id);
+
+CREATE INDEX transactions_account_id_created_at_idx ON transactions (account_id, created_at);
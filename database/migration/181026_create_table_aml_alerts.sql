@@ -0,0 +1,21 @@
+CREATE TABLE aml_alerts (
+    id UUID PRIMARY KEY,
+    type VARCHAR(20) NOT NULL,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    transaction_ids UUID[] NOT NULL,
+    transaction_count INT NOT NULL,
+    total_amount NUMERIC(15, 2) NOT NULL,
+    window_start timestamp NOT NULL,
+    window_end timestamp NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reviewed_by VARCHAR(255),
+    note VARCHAR(255),
+    -- A detection run after an earlier one does not raise the same alert again.
+    dedupe_key VARCHAR(100) NOT NULL UNIQUE,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX aml_alerts_status_created_at_idx ON aml_alerts (status, created_at);
+CREATE INDEX aml_alerts_transaction_ids_idx ON aml_alerts USING GIN (transaction_ids);
@@ -0,0 +1,17 @@
+CREATE TABLE approval_requests (
+    id UUID PRIMARY KEY,
+    operation VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    maker VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    checker VARCHAR(255),
+    note VARCHAR(255),
+    message VARCHAR(255),
+    decided_at timestamp,
+    executed_at timestamp,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX approval_requests_status_idx ON approval_requests (status, created_at);
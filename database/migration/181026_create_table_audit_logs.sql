@@ -0,0 +1,33 @@
+CREATE TABLE audit_logs (
+    sequence BIGINT PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(255) NOT NULL,
+    target VARCHAR(255) NOT NULL,
+    -- JSON keeps the exact text that was hashed, JSONB would normalize it.
+    before JSON NOT NULL,
+    after JSON NOT NULL,
+    ip VARCHAR(255) NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL,
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX audit_logs_actor_idx ON audit_logs (actor, created_at);
+CREATE INDEX audit_logs_action_idx ON audit_logs (action, created_at);
+CREATE INDEX audit_logs_target_idx ON audit_logs (target, created_at);
+
+-- Entries are never changed nor removed; tampering below this guard is caught
+-- by the hash chain verification.
+CREATE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
@@ -0,0 +1,24 @@
+ALTER TYPE transaction_action ADD VALUE 'ADJUSTMENT';
+-- Balance of an account carried over from the history recorded before transactions.account_id.
+ALTER TYPE transaction_action ADD VALUE 'OPENING_BALANCE';
+
+CREATE TABLE balance_discrepancies (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    stored_balance NUMERIC(15, 2) NOT NULL,
+    computed_balance NUMERIC(15, 2) NOT NULL,
+    difference NUMERIC(15, 2) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    resolution VARCHAR(255),
+    resolved_by VARCHAR(255),
+    resolve_transaction_id UUID,
+    resolved_at timestamp,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    FOREIGN KEY (resolve_transaction_id) REFERENCES transactions(id)
+);
+
+-- An account has at most one open discrepancy, refreshed on every check.
+CREATE UNIQUE INDEX balance_discrepancies_open_idx ON balance_discrepancies (account_id) WHERE status = 'OPEN';
+CREATE INDEX balance_discrepancies_status_idx ON balance_discrepancies (status, created_at);
@@ -0,0 +1,33 @@
+ALTER TYPE transaction_action ADD VALUE 'FEE';
+
+CREATE TABLE fee_schedules (
+    code VARCHAR(255) PRIMARY KEY,
+    amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    free_count INT NOT NULL DEFAULT 0,
+    min_balance NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT false,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO fee_schedules (code) VALUES ('MAINTENANCE'), ('WITHDRAWAL'), ('BELOW_MIN_BALANCE');
+
+CREATE TABLE fees (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    transaction_id UUID NOT NULL,
+    code VARCHAR(255) NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    waived_by VARCHAR(255),
+    waive_reason VARCHAR(255),
+    waive_transaction_id UUID,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    FOREIGN KEY (code) REFERENCES fee_schedules(code)
+);
+
+-- Monthly fees are charged at most once per account and period.
+CREATE UNIQUE INDEX fees_monthly_once_idx ON fees (account_id, code, period) WHERE code IN ('MAINTENANCE', 'BELOW_MIN_BALANCE');
+CREATE INDEX fees_account_id_created_at_idx ON fees (account_id, created_at);
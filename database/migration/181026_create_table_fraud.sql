@@ -0,0 +1,48 @@
+ALTER TABLE authorizations ADD COLUMN password_changed_at timestamp;
+
+-- threshold and window_minutes are read per rule:
+-- VELOCITY: more than threshold transactions within window_minutes
+-- AMOUNT_SPIKE: amount above threshold times the average of the last window_minutes
+-- PASSWORD_CHANGE_WITHDRAWAL: first money out within window_minutes after a password change
+-- NEW_DEVICE: amount of at least threshold from a device never used by the user
+CREATE TABLE fraud_rules (
+    code VARCHAR(50) PRIMARY KEY,
+    action VARCHAR(10) NOT NULL DEFAULT 'REVIEW',
+    threshold NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    window_minutes INT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT false,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO fraud_rules (code, action, threshold, window_minutes, active) VALUES
+    ('VELOCITY', 'REVIEW', 10, 10, true),
+    ('AMOUNT_SPIKE', 'REVIEW', 5, 129600, true),
+    ('PASSWORD_CHANGE_WITHDRAWAL', 'REVIEW', 0, 1440, true),
+    ('NEW_DEVICE', 'REVIEW', 1000000, 0, true);
+
+CREATE TABLE fraud_cases (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    transaction_id UUID,
+    action VARCHAR(20) NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL,
+    decision VARCHAR(10) NOT NULL,
+    hits JSONB NOT NULL,
+    device VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    resolved_by VARCHAR(255),
+    note VARCHAR(255),
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at timestamp
+);
+
+CREATE INDEX fraud_cases_status_created_at_idx ON fraud_cases (status, created_at);
+
+CREATE TABLE user_devices (
+    user_id UUID NOT NULL REFERENCES users(id),
+    device VARCHAR(64) NOT NULL,
+    first_seen_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, device)
+);
@@ -0,0 +1,19 @@
+-- Existing users start unverified as well and have to submit their documents.
+ALTER TABLE users
+    ADD COLUMN kyc_status VARCHAR(20) NOT NULL DEFAULT 'UNVERIFIED',
+    ADD COLUMN kyc_note VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_users_kyc_status ON users(kyc_status);
+
+-- The document content is AES encrypted by the application.
+CREATE TABLE kyc_documents (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    document_type VARCHAR(20) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_kyc_documents_user_id ON kyc_documents(user_id, created_at);
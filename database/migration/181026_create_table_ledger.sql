@@ -0,0 +1,66 @@
+CREATE TABLE ledger_accounts (
+    code VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL
+);
+
+INSERT INTO ledger_accounts (code, name, type) VALUES
+    ('CASH', 'Cash', 'ASSET'),
+    ('LOANS_RECEIVABLE', 'Loans Receivable', 'ASSET'),
+    ('CUSTOMER_DEPOSITS', 'Customer Deposits', 'LIABILITY'),
+    ('TERM_DEPOSITS', 'Term Deposits', 'LIABILITY'),
+    ('TAX_PAYABLE', 'Withholding Tax Payable', 'LIABILITY'),
+    ('TRANSFER_CLEARING', 'Transfer Clearing', 'LIABILITY'),
+    ('RECONCILIATION_SUSPENSE', 'Reconciliation Suspense', 'LIABILITY'),
+    ('OPENING_BALANCES', 'Opening Balances', 'EQUITY'),
+    ('FEE_INCOME', 'Fee Income', 'INCOME'),
+    ('INTEREST_INCOME', 'Interest Income', 'INCOME'),
+    ('PENALTY_INCOME', 'Penalty Income', 'INCOME'),
+    ('INTEREST_EXPENSE', 'Interest Expense', 'EXPENSE');
+
+CREATE TABLE journal_entries (
+    id UUID PRIMARY KEY,
+    transaction_id UUID,
+    description VARCHAR(255) NOT NULL,
+    posted_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id)
+);
+
+CREATE UNIQUE INDEX journal_entries_transaction_id_idx ON journal_entries (transaction_id);
+CREATE INDEX journal_entries_posted_at_idx ON journal_entries (posted_at);
+
+CREATE TABLE journal_lines (
+    id UUID PRIMARY KEY,
+    journal_entry_id UUID NOT NULL,
+    ledger_account_code VARCHAR(255) NOT NULL,
+    account_id UUID,
+    debit NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    credit NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    CHECK (debit >= 0 AND credit >= 0 AND (debit = 0) <> (credit = 0)),
+    FOREIGN KEY (journal_entry_id) REFERENCES journal_entries(id),
+    FOREIGN KEY (ledger_account_code) REFERENCES ledger_accounts(code),
+    FOREIGN KEY (account_id) REFERENCES accounts(id)
+);
+
+CREATE INDEX journal_lines_journal_entry_id_idx ON journal_lines (journal_entry_id);
+CREATE INDEX journal_lines_ledger_account_code_idx ON journal_lines (ledger_account_code);
+
+-- Reject, at commit, any journal entry whose debits and credits do not match.
+CREATE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+DECLARE
+    difference NUMERIC;
+BEGIN
+    SELECT COALESCE(SUM(debit), 0) - COALESCE(SUM(credit), 0) INTO difference
+    FROM journal_lines
+    WHERE journal_entry_id = NEW.journal_entry_id;
+    IF difference <> 0 THEN
+        RAISE EXCEPTION 'journal entry % is unbalanced by %', NEW.journal_entry_id, difference;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER journal_lines_balanced
+    AFTER INSERT OR UPDATE ON journal_lines
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
@@ -0,0 +1,58 @@
+ALTER TYPE transaction_action ADD VALUE 'LOAN';
+
+CREATE TABLE loans (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    principal NUMERIC(15, 2) NOT NULL,
+    interest_rate NUMERIC(7, 4) NOT NULL,
+    penalty_rate NUMERIC(7, 4) NOT NULL DEFAULT 0,
+    term_months INT NOT NULL,
+    method VARCHAR(255) NOT NULL,
+    outstanding_principal NUMERIC(15, 2) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    disbursed_by VARCHAR(255) NOT NULL,
+    disbursed_at timestamp NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id)
+);
+
+CREATE INDEX loans_account_id_idx ON loans (account_id);
+
+CREATE TABLE loan_installments (
+    id UUID PRIMARY KEY,
+    loan_id UUID NOT NULL,
+    seq INT NOT NULL,
+    due_date timestamp NOT NULL,
+    principal_due NUMERIC(15, 2) NOT NULL,
+    interest_due NUMERIC(15, 2) NOT NULL,
+    penalty_due NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    principal_paid NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    interest_paid NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    penalty_paid NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(255) NOT NULL,
+    penalty_accrued_at timestamp,
+    paid_at timestamp,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (loan_id, seq),
+    FOREIGN KEY (loan_id) REFERENCES loans(id)
+);
+
+CREATE INDEX loan_installments_unpaid_due_idx ON loan_installments (due_date) WHERE status <> 'PAID';
+
+CREATE TABLE loan_payments (
+    id UUID PRIMARY KEY,
+    loan_id UUID NOT NULL,
+    installment_id UUID NOT NULL,
+    transaction_id UUID NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL,
+    principal NUMERIC(15, 2) NOT NULL,
+    interest NUMERIC(15, 2) NOT NULL,
+    penalty NUMERIC(15, 2) NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (loan_id) REFERENCES loans(id),
+    FOREIGN KEY (installment_id) REFERENCES loan_installments(id)
+);
+
+CREATE INDEX loan_payments_loan_id_idx ON loan_payments (loan_id, created_at);
@@ -0,0 +1,25 @@
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL REFERENCES users(id),
+    channel VARCHAR(255) NOT NULL,
+    -- AES encrypted email address or phone number.
+    address VARCHAR(1024) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, channel)
+);
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    type VARCHAR(255) NOT NULL,
+    channel VARCHAR(255) NOT NULL,
+    -- AES encrypted, like the preference it was sent to.
+    recipient VARCHAR(1024) NOT NULL,
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    error VARCHAR(255) NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX notifications_user_idx ON notifications (user_id, created_at);
@@ -0,0 +1,14 @@
+CREATE TABLE outbox_events (
+    id UUID PRIMARY KEY,
+    -- Drawn while the account row is locked, so it orders the events of an account.
+    sequence BIGSERIAL NOT NULL UNIQUE,
+    account_id UUID NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at timestamp
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (sequence) WHERE published_at IS NULL;
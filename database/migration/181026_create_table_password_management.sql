@@ -0,0 +1,26 @@
+-- The password age of existing users counts from this migration.
+ALTER TABLE authorizations
+    ADD COLUMN created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- Hashes of the passwords set so far, the latest ones cannot be reused.
+CREATE TABLE password_history (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    password VARCHAR(255) NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_history_user_id ON password_history(user_id, created_at DESC);
+
+-- Only the sha256 of a reset token is stored, a token is spent by setting used_at.
+CREATE TABLE password_reset_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    requested_by VARCHAR(255) NOT NULL,
+    expires_at timestamp NOT NULL,
+    used_at timestamp,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
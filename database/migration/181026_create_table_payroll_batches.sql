@@ -0,0 +1,44 @@
+ALTER TYPE transaction_action ADD VALUE 'PAYROLL';
+
+CREATE TABLE payroll_batches (
+    id UUID PRIMARY KEY,
+    file_name VARCHAR(255) NOT NULL,
+    format VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    line_count INT NOT NULL DEFAULT 0,
+    valid_count INT NOT NULL DEFAULT 0,
+    total_amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    completed_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    uploaded_by VARCHAR(255) NOT NULL,
+    reviewed_by VARCHAR(255),
+    review_note VARCHAR(255),
+    reviewed_at timestamp,
+    executed_at timestamp,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The same file cannot be uploaded twice unless the earlier batch was rejected.
+CREATE UNIQUE INDEX payroll_batches_checksum_idx ON payroll_batches (checksum) WHERE status <> 'REJECTED';
+CREATE INDEX payroll_batches_status_idx ON payroll_batches (status, created_at);
+
+CREATE TABLE payroll_lines (
+    id UUID PRIMARY KEY,
+    batch_id UUID NOT NULL,
+    line_no INT NOT NULL,
+    account_number VARCHAR(255) NOT NULL,
+    account_id UUID,
+    amount NUMERIC(15, 2) NOT NULL,
+    reference VARCHAR(255),
+    status VARCHAR(255) NOT NULL,
+    message VARCHAR(255),
+    transaction_id UUID,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (batch_id, line_no),
+    FOREIGN KEY (batch_id) REFERENCES payroll_batches(id),
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id)
+);
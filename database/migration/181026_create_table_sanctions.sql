@@ -0,0 +1,38 @@
+-- Users found on the watchlist are held until an admin reviews the hit.
+ALTER TABLE users
+    ADD COLUMN sanctions_status VARCHAR(20) NOT NULL DEFAULT 'CLEAR';
+
+-- The loaded watchlist, replaced as a whole on every reload. Aliases are stored
+-- as extra rows with the uid of their entry.
+CREATE TABLE sanctions_entries (
+    uid VARCHAR(50) NOT NULL,
+    name VARCHAR(500) NOT NULL,
+    entry_type VARCHAR(50) NOT NULL DEFAULT '',
+    program VARCHAR(255) NOT NULL DEFAULT '',
+    loaded_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_sanctions_entries_uid ON sanctions_entries(uid);
+
+-- A held transfer keeps its request in payload and runs once the hit is cleared.
+CREATE TABLE sanctions_hits (
+    id UUID PRIMARY KEY,
+    subject VARCHAR(20) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id),
+    screened_name VARCHAR(255) NOT NULL,
+    entry_uid VARCHAR(50) NOT NULL,
+    entry_name VARCHAR(500) NOT NULL,
+    program VARCHAR(255) NOT NULL DEFAULT '',
+    score NUMERIC(5,4) NOT NULL,
+    payload JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN',
+    reviewed_by VARCHAR(255),
+    note VARCHAR(255),
+    message VARCHAR(255),
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at timestamp,
+    executed_at timestamp
+);
+
+CREATE INDEX idx_sanctions_hits_status ON sanctions_hits(status, created_at);
+CREATE INDEX idx_sanctions_hits_user_id ON sanctions_hits(user_id);
@@ -0,0 +1,7 @@
+CREATE TABLE scheduler_leases (
+    name VARCHAR(255) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL,
+    acquired_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    renewed_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamptz NOT NULL
+);
@@ -0,0 +1,34 @@
+CREATE TABLE standing_orders (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    to_account_number VARCHAR(255),
+    amount NUMERIC(15, 2) NOT NULL,
+    frequency VARCHAR(255) NOT NULL,
+    day_of_month INT NOT NULL DEFAULT 0,
+    scheduled_for timestamp NOT NULL,
+    next_run_at timestamp NOT NULL,
+    end_date timestamp,
+    retry_count INT NOT NULL DEFAULT 0,
+    status VARCHAR(255) NOT NULL,
+    description VARCHAR(255),
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id)
+);
+
+CREATE INDEX standing_orders_account_id_idx ON standing_orders (account_id);
+CREATE INDEX standing_orders_active_next_run_idx ON standing_orders (next_run_at) WHERE status = 'ACTIVE';
+
+CREATE TABLE standing_order_executions (
+    id UUID PRIMARY KEY,
+    standing_order_id UUID NOT NULL,
+    scheduled_for timestamp NOT NULL,
+    attempt INT NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    message VARCHAR(255),
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (standing_order_id, scheduled_for, attempt),
+    FOREIGN KEY (standing_order_id) REFERENCES standing_orders(id)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE statements (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    opening_balance NUMERIC(15, 2) NOT NULL,
+    closing_balance NUMERIC(15, 2) NOT NULL,
+    pdf BYTEA NOT NULL,
+    csv BYTEA NOT NULL,
+    generated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, period),
+    FOREIGN KEY (account_id) REFERENCES accounts(id)
+);
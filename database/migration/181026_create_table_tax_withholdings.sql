@@ -0,0 +1,14 @@
+ALTER TYPE transaction_action ADD VALUE 'TAX';
+
+ALTER TABLE users ADD COLUMN tax_status VARCHAR(255) NOT NULL DEFAULT 'resident';
+ALTER TABLE users ADD COLUMN tax_exempt BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE tax_withholdings (
+    user_id UUID NOT NULL,
+    year INT NOT NULL,
+    gross_interest NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    tax_withheld NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, year),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
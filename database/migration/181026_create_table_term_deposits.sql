@@ -0,0 +1,25 @@
+ALTER TYPE transaction_action ADD VALUE 'TERM_DEPOSIT';
+ALTER TYPE transaction_action ADD VALUE 'PENALTY';
+
+CREATE TABLE term_deposits (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    principal NUMERIC(15, 2) NOT NULL,
+    interest_rate NUMERIC(7, 4) NOT NULL,
+    term_months INT NOT NULL,
+    maturity_instruction VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    start_date timestamp NOT NULL,
+    maturity_date timestamp NOT NULL,
+    interest_paid NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    tax_withheld NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    penalty NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    rolled_over_to UUID,
+    closed_at timestamp,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id)
+);
+
+CREATE INDEX term_deposits_account_id_idx ON term_deposits (account_id);
+CREATE INDEX term_deposits_active_maturity_idx ON term_deposits (maturity_date) WHERE status = 'ACTIVE';
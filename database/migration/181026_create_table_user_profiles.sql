@@ -0,0 +1,10 @@
+-- Every personal field is AES encrypted by the application.
+CREATE TABLE user_profiles (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    email VARCHAR(1024) NOT NULL DEFAULT '',
+    phone VARCHAR(1024) NOT NULL DEFAULT '',
+    date_of_birth VARCHAR(1024) NOT NULL DEFAULT '',
+    national_id VARCHAR(1024) NOT NULL DEFAULT '',
+    address VARCHAR(1024) NOT NULL DEFAULT '',
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -4,34 +4,123 @@ import (
 	"context"
 	"database/sql"
 	"log"
-	"time"
+	"os"
+	"strconv"
 
 	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	feebusiness "github.com/hanselacn/banking-transaction/internal/business/fee_business"
+	fraudbusiness "github.com/hanselacn/banking-transaction/internal/business/fraud_business"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	notificationbusiness "github.com/hanselacn/banking-transaction/internal/business/notification_business"
+	outboxbusiness "github.com/hanselacn/banking-transaction/internal/business/outbox_business"
+	sanctionsbusiness "github.com/hanselacn/banking-transaction/internal/business/sanctions_business"
+	streambusiness "github.com/hanselacn/banking-transaction/internal/business/stream_business"
 	"github.com/hanselacn/banking-transaction/internal/consts"
 	"github.com/hanselacn/banking-transaction/internal/entity"
 	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
 	"github.com/hanselacn/banking-transaction/internal/repo"
+	"github.com/pkg/errors"
 )
 
+const defaultLowBalanceThreshold = 100000
+
+// lowBalanceThreshold is the balance under which a withdrawal emits LowBalance.
+func lowBalanceThreshold() float64 {
+	threshold, err := strconv.ParseFloat(os.Getenv("LOW_BALANCE_THRESHOLD"), 64)
+	if err != nil || threshold < 0 {
+		return defaultLowBalanceThreshold
+	}
+	return threshold
+}
+
+// transactionStreamEvent is the stream notification of transaction reaching status.
+func transactionStreamEvent(transaction entity.Transaction, status string) entity.AccountStreamEvent {
+	return entity.AccountStreamEvent{
+		AccountID:     transaction.AccountID,
+		TransactionID: transaction.ID,
+		Type:          transaction.Type,
+		Action:        transaction.Action,
+		Amount:        transaction.Amount,
+		Status:        status,
+	}
+}
+
 type AccountBusiness interface {
 	Withdrawal(ctx context.Context, input entity.Withdrawal) error
 	Deposit(ctx context.Context, input entity.Deposit) error
+	Transfer(ctx context.Context, input entity.Transfer) error
 	GetAccountBalance(ctx context.Context, username string) (*entity.Account, error)
 	InterestPayout(ctx context.Context) ([]entity.Account, error)
-	InterestPayoutWorker(ctx context.Context) ([]entity.Account, int, int, error)
+	InterestPayoutWorker(ctx context.Context) (*entity.InterestPayoutProgress, error)
+	GetInterestPayoutProgress(ctx context.Context) entity.InterestPayoutProgress
 	UpdateInterestRate(ctx context.Context, input entity.UpdateInterestRate) error
+	UpdateOverdraft(ctx context.Context, input entity.UpdateOverdraft) error
+	GetOverdrawnAccounts(ctx context.Context) ([]entity.OverdrawnAccount, error)
+	OpenTermDeposit(ctx context.Context, input entity.OpenTermDeposit) (*entity.TermDeposit, error)
+	GetTermDeposits(ctx context.Context, username string) ([]entity.TermDeposit, error)
+	UpdateTermDepositInstruction(ctx context.Context, input entity.UpdateTermDepositInstruction) error
+	WithdrawTermDeposit(ctx context.Context, input entity.WithdrawTermDeposit) (*entity.TermDeposit, error)
+	TermDepositMaturityWorker(ctx context.Context) (int, int, error)
+	SanctionsReleaseWorker(ctx context.Context) (int, int, error)
 }
 
 type accountBusiness struct {
-	repo repo.Repo
-	db   *sql.DB
+	repo         repo.Repo
+	db           *sql.DB
+	fee          feebusiness.FeeBusiness
+	ledger       ledgerbusiness.LedgerBusiness
+	audit        auditbusiness.AuditBusiness
+	outbox       outboxbusiness.OutboxBusiness
+	stream       streambusiness.StreamBusiness
+	notification notificationbusiness.NotificationBusiness
+	fraud        fraudbusiness.FraudBusiness
+	sanctions    sanctionsbusiness.SanctionsBusiness
 }
 
 func NewAccountBusiness(db *sql.DB) AccountBusiness {
 	return &accountBusiness{
-		repo: repo.NewRepositories(db),
-		db:   db,
+		repo:         repo.NewRepositories(db),
+		db:           db,
+		fee:          feebusiness.NewFeeBusiness(db),
+		ledger:       ledgerbusiness.NewLedgerBusiness(db),
+		audit:        auditbusiness.NewAuditBusiness(db),
+		outbox:       outboxbusiness.NewOutboxBusiness(db),
+		stream:       streambusiness.NewStreamBusiness(db),
+		notification: notificationbusiness.NewNotificationBusiness(db),
+		fraud:        fraudbusiness.NewFraudBusiness(db),
+		sanctions:    sanctionsbusiness.NewSanctionsBusiness(db),
+	}
+}
+
+// screen runs the fraud rules on a transaction before it is written. A blocked
+// transaction opens its case right away and fails with ErrForbidden.
+func (b *accountBusiness) screen(ctx context.Context, check entity.FraudCheck) (*entity.FraudDecision, error) {
+	var eventName = "business.account.screen"
+
+	decision, err := b.fraud.Screen(ctx, check)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if decision.Decision != consts.FraudDecisionBLOCK {
+		return decision, nil
+	}
+	fraudCase, err := b.fraud.OpenCase(ctx, check, *decision, nil, nil)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
 	}
+	return nil, errbank.NewErrForbidden("transaction blocked by fraud checks, reference " + fraudCase.ID.String())
+}
+
+// reviewCase opens the case of a transaction let through for review, within tx.
+func (b *accountBusiness) reviewCase(ctx context.Context, check entity.FraudCheck, decision *entity.FraudDecision, transactionID uuid.UUID, tx *sql.Tx) error {
+	if decision.Decision != consts.FraudDecisionREVIEW {
+		return nil
+	}
+	_, err := b.fraud.OpenCase(ctx, check, *decision, &transactionID, tx)
+	return err
 }
 
 func (b *accountBusiness) Withdrawal(ctx context.Context, input entity.Withdrawal) error {
@@ -68,25 +157,50 @@ func (b *accountBusiness) Withdrawal(ctx context.Context, input entity.Withdrawa
 		log.Println(eventName, err)
 		return err
 	}
-	err = b.repo.Transaction.CreateTransaction(ctx, transactionInput)
+	err = sanctionsbusiness.CheckStatus(*user)
 	if err != nil {
-		log.Println(eventName, err)
 		return err
 	}
 	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
 	if err != nil {
 		log.Println(eventName, err)
-		if err := b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil); err != nil {
-			return err
-		}
 		return err
 	}
-	if input.Amount > account.Balance {
-		return errbank.NewErrUnprocessableEntity("insufficient balance")
+	err = b.checkKYC(ctx, *user, account.ID, consts.TxActionWITHDRAWAL, input.Amount)
+	if err != nil {
+		return err
+	}
+	if input.Amount > account.AvailableBalance() {
+		err = errbank.NewErrUnprocessableEntity("insufficient balance")
+		return err
+	}
+	fraudCheck := entity.FraudCheck{
+		User:    *user,
+		Account: *account,
+		Action:  consts.TxActionWITHDRAWAL,
+		Amount:  input.Amount,
+		Device:  fraudbusiness.Device(ctx, user.Username),
+	}
+	decision, err := b.screen(ctx, fraudCheck)
+	if err != nil {
+		return err
+	}
+	transactionInput.AccountID = account.ID
+	err = b.repo.Transaction.CreateTransaction(ctx, transactionInput, nil)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
 	}
 
 	// Update account balance
+	previousBalance := account.Balance
 	account.Balance -= input.Amount
+	_, err = b.fee.ChargeTransactionFees(ctx, account, consts.TxActionWITHDRAWAL, tx)
+	if err != nil {
+		b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
+		log.Println(eventName, "ChargeTransactionFees", err)
+		return err
+	}
 	err = b.repo.Account.UpdateBalance(ctx, entity.Account{
 		UserID:  user.ID,
 		Balance: account.Balance,
@@ -101,11 +215,49 @@ func (b *accountBusiness) Withdrawal(ctx context.Context, input entity.Withdrawa
 		log.Println(eventName, "UpdateTransactionStatus", err)
 		return err
 	}
+	err = b.ledger.PostTransaction(ctx, transactionInput, tx)
+	if err != nil {
+		log.Println(eventName, "PostTransaction", err)
+		return err
+	}
+	err = b.outbox.Enqueue(ctx, consts.EventWithdrawalCompleted, account.ID, entity.TransactionCompletedEvent{
+		AccountID:     account.ID,
+		TransactionID: transactionInput.ID,
+		Amount:        transactionInput.Amount,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "Enqueue", err)
+		return err
+	}
+	// Only the withdrawal crossing the threshold is reported, not every one below it.
+	if threshold := lowBalanceThreshold(); previousBalance >= threshold && account.Balance < threshold {
+		err = b.outbox.Enqueue(ctx, consts.EventLowBalance, account.ID, entity.LowBalanceEvent{
+			AccountID:     account.ID,
+			TransactionID: transactionInput.ID,
+			Threshold:     threshold,
+		}, tx)
+		if err != nil {
+			log.Println(eventName, "Enqueue", err)
+			return err
+		}
+	}
+	err = b.reviewCase(ctx, fraudCheck, decision, transactionInput.ID, tx)
+	if err != nil {
+		log.Println(eventName, "reviewCase", err)
+		return err
+	}
+	err = b.stream.Notify(ctx, transactionStreamEvent(transactionInput, consts.TxStatusCOMPLETED), tx)
+	if err != nil {
+		log.Println(eventName, "Notify", err)
+		return err
+	}
 	err = tx.Commit()
 	if err != nil {
 		log.Println(eventName, "commiting transaction error", err)
 		return err
 	}
+	b.fraud.RememberDevice(ctx, fraudCheck)
+	b.notification.Notify(ctx, notificationbusiness.TransactionNotice(consts.NotificationTypeWITHDRAWAL, user.ID, *account, transactionInput))
 	return nil
 }
 
@@ -143,24 +295,48 @@ func (b *accountBusiness) Deposit(ctx context.Context, input entity.Deposit) err
 		log.Println(eventName, err)
 		return err
 	}
+	err = sanctionsbusiness.CheckStatus(*user)
+	if err != nil {
+		return err
+	}
 
-	err = b.repo.Transaction.CreateTransaction(ctx, transactionInput)
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
 	if err != nil {
 		log.Println(eventName, err)
 		return err
 	}
+	err = b.checkKYC(ctx, *user, account.ID, consts.TxActionDEPOSIT, input.Amount)
+	if err != nil {
+		return err
+	}
 
-	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	fraudCheck := entity.FraudCheck{
+		User:    *user,
+		Account: *account,
+		Action:  consts.TxActionDEPOSIT,
+		Amount:  input.Amount,
+		Device:  fraudbusiness.Device(ctx, user.Username),
+	}
+	decision, err := b.screen(ctx, fraudCheck)
+	if err != nil {
+		return err
+	}
+
+	transactionInput.AccountID = account.ID
+	err = b.repo.Transaction.CreateTransaction(ctx, transactionInput, nil)
 	if err != nil {
 		log.Println(eventName, err)
-		if err := b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil); err != nil {
-			return err
-		}
 		return err
 	}
 
 	// Update account balance
 	account.Balance += input.Amount
+	_, err = b.fee.ChargeTransactionFees(ctx, account, consts.TxActionDEPOSIT, tx)
+	if err != nil {
+		b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
+		log.Println(eventName, "ChargeTransactionFees", err)
+		return err
+	}
 	err = b.repo.Account.UpdateBalance(ctx, entity.Account{
 		UserID:  user.ID,
 		Balance: account.Balance,
@@ -177,12 +353,190 @@ func (b *accountBusiness) Deposit(ctx context.Context, input entity.Deposit) err
 		log.Println(eventName, "UpdateTransactionStatus", err)
 		return err
 	}
+	err = b.ledger.PostTransaction(ctx, transactionInput, tx)
+	if err != nil {
+		b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
+		log.Println(eventName, "PostTransaction", err)
+		return err
+	}
+	err = b.outbox.Enqueue(ctx, consts.EventDepositCompleted, account.ID, entity.TransactionCompletedEvent{
+		AccountID:     account.ID,
+		TransactionID: transactionInput.ID,
+		Amount:        transactionInput.Amount,
+	}, tx)
+	if err != nil {
+		b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
+		log.Println(eventName, "Enqueue", err)
+		return err
+	}
+	err = b.reviewCase(ctx, fraudCheck, decision, transactionInput.ID, tx)
+	if err != nil {
+		b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
+		log.Println(eventName, "reviewCase", err)
+		return err
+	}
+	err = b.stream.Notify(ctx, transactionStreamEvent(transactionInput, consts.TxStatusCOMPLETED), tx)
+	if err != nil {
+		b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
+		log.Println(eventName, "Notify", err)
+		return err
+	}
 	err = tx.Commit()
 	if err != nil {
 		b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
 		log.Println(eventName, "commiting transaction error", err)
 		return err
 	}
+	b.fraud.RememberDevice(ctx, fraudCheck)
+	b.notification.Notify(ctx, notificationbusiness.TransactionNotice(consts.NotificationTypeDEPOSIT, user.ID, *account, transactionInput))
+	return nil
+}
+
+// Transfer moves funds to another account identified by its account number.
+// A transfer to a recipient found on the sanctions watchlist is held instead:
+// it returns an ErrAccepted and runs once the hit is cleared.
+func (b *accountBusiness) Transfer(ctx context.Context, input entity.Transfer) error {
+	return b.transfer(ctx, input, true)
+}
+
+// transfer locks both accounts in id order so concurrent transfers cannot deadlock.
+func (b *accountBusiness) transfer(ctx context.Context, input entity.Transfer, screenRecipient bool) error {
+	var (
+		eventName    = "business.account.transfer"
+		transactions []entity.Transaction
+	)
+
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	sourceRef, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	destinationRef, err := b.repo.Account.FindByAccountNumber(ctx, input.ToAccountNumber)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	if sourceRef.ID == destinationRef.ID {
+		return errbank.NewErrUnprocessableEntity("to_account_number: cannot transfer to the same account")
+	}
+	if err := b.checkKYC(ctx, *user, sourceRef.ID, consts.TxActionTRANSFER, input.Amount); err != nil {
+		return err
+	}
+	recipient, err := b.repo.Users.FindByID(ctx, destinationRef.UserID)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	// Incoming funds count against the deposit limit of the recipient.
+	if err := b.checkKYC(ctx, *recipient, destinationRef.ID, consts.TxActionDEPOSIT, input.Amount); err != nil {
+		if _, ok := errors.Cause(err).(errbank.ErrForbidden); ok {
+			return errbank.NewErrForbidden("to_account_number: the recipient cannot receive this amount")
+		}
+		return err
+	}
+	if err := sanctionsbusiness.CheckStatus(*user); err != nil {
+		return err
+	}
+	if err := sanctionsbusiness.CheckStatus(*recipient); err != nil {
+		return errbank.NewErrForbidden("to_account_number: the recipient cannot receive transfers")
+	}
+	if screenRecipient {
+		if err := b.holdTransfer(ctx, *user, *recipient, input); err != nil {
+			return err
+		}
+	}
+	fraudCheck := entity.FraudCheck{
+		User:    *user,
+		Account: *sourceRef,
+		Action:  consts.TxActionTRANSFER,
+		Amount:  input.Amount,
+		Device:  fraudbusiness.Device(ctx, user.Username),
+	}
+	decision, err := b.screen(ctx, fraudCheck)
+	if err != nil {
+		return err
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+			b.failTransactions(ctx, transactions)
+		}
+	}()
+
+	locked := map[uuid.UUID]*entity.Account{}
+	lockOrder := []uuid.UUID{sourceRef.ID, destinationRef.ID}
+	if destinationRef.ID.String() < sourceRef.ID.String() {
+		lockOrder[0], lockOrder[1] = lockOrder[1], lockOrder[0]
+	}
+	for _, id := range lockOrder {
+		var account *entity.Account
+		account, err = b.repo.Account.FindByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			log.Println(eventName, err)
+			return err
+		}
+		locked[id] = account
+	}
+	source, destination := locked[sourceRef.ID], locked[destinationRef.ID]
+
+	if input.Amount > source.AvailableBalance() {
+		err = errbank.NewErrUnprocessableEntity("insufficient balance")
+		return err
+	}
+
+	transactions = append(transactions,
+		newTransaction(source.ID, consts.TxTypeDEBIT, consts.TxActionTRANSFER, input.Amount),
+		newTransaction(destination.ID, consts.TxTypeCREDIT, consts.TxActionTRANSFER, input.Amount),
+	)
+	source.Balance -= input.Amount
+	destination.Balance += input.Amount
+
+	_, err = b.fee.ChargeTransactionFees(ctx, source, consts.TxActionTRANSFER, tx)
+	if err != nil {
+		log.Println(eventName, "ChargeTransactionFees", err)
+		return err
+	}
+	for _, account := range []*entity.Account{source, destination} {
+		err = b.repo.Account.UpdateBalance(ctx, *account, tx)
+		if err != nil {
+			log.Println(eventName, "UpdateBalance", err)
+			return err
+		}
+	}
+	err = b.postTransactions(ctx, transactions, tx)
+	if err != nil {
+		log.Println(eventName, "postTransactions", err)
+		return err
+	}
+	err = b.reviewCase(ctx, fraudCheck, decision, transactions[0].ID, tx)
+	if err != nil {
+		log.Println(eventName, "reviewCase", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	b.fraud.RememberDevice(ctx, fraudCheck)
 	return nil
 }
 
@@ -220,164 +574,192 @@ func (b *accountBusiness) UpdateInterestRate(ctx context.Context, input entity.U
 		return err
 	}
 
+	before := entity.UpdateInterestRate{Username: input.Username, InterestRate: account.InterestRate}
 	account.InterestRate = input.InterestRate
-	err = b.repo.Account.UpdateInterestRate(ctx, *account, nil)
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.Account.UpdateInterestRate(ctx, *account, tx)
 	if err != nil {
 		log.Println(eventName, err)
 		return err
 	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionUPDATEINTERESTRATE,
+		Target: account.AccountNumber,
+		Before: before,
+		After:  input,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
 	return nil
 }
 
-func (b *accountBusiness) InterestPayout(ctx context.Context) ([]entity.Account, error) {
+func (b *accountBusiness) UpdateOverdraft(ctx context.Context, input entity.UpdateOverdraft) error {
 	var (
-		eventName = "business.account.get_balance"
+		eventName = "business.account.update_overdraft"
 	)
 
-	accounts, err := b.repo.Account.GetListAccount(ctx, nil)
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
 	if err != nil {
 		log.Println(eventName, err)
-		return nil, err
+		return err
 	}
 
-	for i := range accounts {
-		tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
-			Isolation: sql.LevelSerializable,
-			ReadOnly:  false,
-		})
-		if err != nil {
-			log.Println(eventName, "BeginTx", err)
-			return nil, err
-		}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
 
-		currentBalance := accounts[i].Balance
-		lastPayoutTime := accounts[i].LastInterestPayout.Local()
-		currentTime := time.Now()
-		durationSinceLastPayout := currentTime.Sub(lastPayoutTime)
-		payoutPeriodDays := durationSinceLastPayout.Hours() / 24
-		interestAmount := currentBalance * (accounts[i].InterestRate * (payoutPeriodDays / 365))
+	if account.Balance < -input.OverdraftLimit {
+		return errbank.NewErrUnprocessableEntity("overdraft_limit: account is already overdrawn beyond the requested limit")
+	}
 
-		transactionInput := entity.Transaction{
-			ID:     uuid.New(),
-			Type:   consts.TxTypeCREDIT,
-			Amount: interestAmount,
-			Action: consts.TxActionINTEREST,
-			Status: consts.TxStatusINPROGRESS,
-		}
+	before := entity.UpdateOverdraft{
+		Username:              input.Username,
+		OverdraftLimit:        account.OverdraftLimit,
+		OverdraftInterestRate: account.OverdraftInterestRate,
+	}
+	account.OverdraftLimit = input.OverdraftLimit
+	account.OverdraftInterestRate = input.OverdraftInterestRate
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
 
-		err = b.repo.Transaction.CreateTransaction(ctx, transactionInput)
+	defer func() {
 		if err != nil {
-			log.Println(eventName, "CreateTransaction", err)
-			tx.Rollback()
-			return nil, err
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
 		}
+	}()
 
-		accounts[i].Balance += interestAmount
+	err = b.repo.Account.UpdateOverdraft(ctx, *account, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionUPDATEOVERDRAFT,
+		Target: account.AccountNumber,
+		Before: before,
+		After:  input,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return err
+	}
 
-		err = b.repo.Account.PayoutInterest(ctx, accounts[i], tx)
-		if err != nil {
-			log.Println(eventName, "PayoutInterest", err)
-			if rbErr := tx.Rollback(); rbErr != nil {
-				log.Println(eventName, "Rollback error", rbErr)
-			}
-			b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
-			continue
-		}
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	return nil
+}
 
-		err = b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusCOMPLETED, tx)
-		if err != nil {
-			log.Println(eventName, "UpdateTransactionStatus", err)
-			if rbErr := tx.Rollback(); rbErr != nil {
-				log.Println(eventName, "Rollback error", rbErr)
-			}
-			b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
-			return nil, err
-		}
+// GetOverdrawnAccounts scans every account in batches and returns those with a negative balance.
+func (b *accountBusiness) GetOverdrawnAccounts(ctx context.Context) ([]entity.OverdrawnAccount, error) {
+	var (
+		eventName = "business.account.get_overdrawn_accounts"
+		lastID    = uuid.Nil
+		batchSize = envInt("PAYOUT_BATCH_SIZE", defaultPayoutBatchSize)
+		results   = []entity.OverdrawnAccount{}
+	)
 
-		err = tx.Commit()
+	for {
+		accounts, err := b.repo.Account.GetAccountBatch(ctx, lastID, batchSize)
 		if err != nil {
-			log.Println(eventName, "Commit error", err)
-			b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
+			log.Println(eventName, err)
 			return nil, err
 		}
+		for _, account := range accounts {
+			if account.Balance >= 0 {
+				continue
+			}
+			user, err := b.repo.Users.FindByID(ctx, account.UserID)
+			if err != nil {
+				log.Println(eventName, err)
+				return nil, err
+			}
+			results = append(results, entity.OverdrawnAccount{
+				AccountID:             account.ID,
+				AccountNumber:         account.AccountNumber,
+				Username:              user.Username,
+				Fullname:              user.Fullname,
+				Balance:               account.Balance,
+				OverdraftLimit:        account.OverdraftLimit,
+				OverdraftInterestRate: account.OverdraftInterestRate,
+				AvailableBalance:      account.AvailableBalance(),
+			})
+		}
+		if len(accounts) < batchSize {
+			return results, nil
+		}
+		lastID = accounts[len(accounts)-1].ID
 	}
-
-	return accounts, nil
 }
 
-func (b *accountBusiness) InterestPayoutWorker(ctx context.Context) ([]entity.Account, int, int, error) {
+func (b *accountBusiness) InterestPayout(ctx context.Context) ([]entity.Account, error) {
 	var (
-		eventName    = "business.account.get_balance"
-		totalSuccess = 0
+		eventName = "business.account.interest_payout"
 	)
 
 	accounts, err := b.repo.Account.GetListAccount(ctx, nil)
 	if err != nil {
 		log.Println(eventName, err)
-		return nil, 0, 0, err
+		return nil, err
 	}
-	totalData := len(accounts)
-	for i := range accounts {
-		tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
-			Isolation: sql.LevelSerializable,
-			ReadOnly:  false,
-		})
-		if err != nil {
-			log.Println(eventName, "BeginTx", err)
-			return nil, totalData, totalSuccess, err
-		}
-
-		currentBalance := accounts[i].Balance
-		lastPayoutTime := accounts[i].LastInterestPayout.Local()
-		currentTime := time.Now()
-		durationSinceLastPayout := currentTime.Sub(lastPayoutTime)
-		payoutPeriodDays := durationSinceLastPayout.Hours() / 24
-		interestAmount := currentBalance * (accounts[i].InterestRate * (payoutPeriodDays / 365))
 
-		transactionInput := entity.Transaction{
-			ID:     uuid.New(),
-			Type:   consts.TxTypeCREDIT,
-			Amount: interestAmount,
-			Action: consts.TxActionINTEREST,
-			Status: consts.TxStatusINPROGRESS,
-		}
-
-		err = b.repo.Transaction.CreateTransaction(ctx, transactionInput)
-		if err != nil {
-			log.Println(eventName, "CreateTransaction", err)
-			tx.Rollback()
-			return nil, totalData, totalSuccess, err
-		}
-
-		accounts[i].Balance += interestAmount
-
-		err = b.repo.Account.PayoutInterest(ctx, accounts[i], tx)
+	var failed int
+	for i := range accounts {
+		account, err := payoutAccountInterest(ctx, accounts[i].ID, payoutMaxRetry(), b.payoutAccountInterestOnce)
 		if err != nil {
-			log.Println(eventName, "PayoutInterest", err)
-			if rbErr := tx.Rollback(); rbErr != nil {
-				log.Println(eventName, "Rollback error", rbErr)
-			}
-			b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
+			log.Println(eventName, "payoutAccountInterest", accounts[i].ID, err)
+			failed++
 			continue
 		}
+		accounts[i] = *account
+	}
 
-		err = b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusCOMPLETED, tx)
-		if err != nil {
-			log.Println(eventName, "UpdateTransactionStatus", err)
-			if rbErr := tx.Rollback(); rbErr != nil {
-				log.Println(eventName, "Rollback error", rbErr)
-			}
-			b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
-			return nil, totalData, totalSuccess, err
-		}
-
-		err = tx.Commit()
-		if err != nil {
-			log.Println(eventName, "Commit error", err)
-			b.repo.Transaction.UpdateTransactionStatus(ctx, transactionInput.ID, consts.TxStatusFAILED, nil)
-			return nil, totalData, totalSuccess, err
-		}
-		totalSuccess++
+	// Every account is paid in its own transaction, the run is recorded once
+	// they are all done and fails when it cannot be.
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionINTERESTPAYOUT,
+		Target: "accounts",
+		After:  map[string]int{"accounts": len(accounts), "failed": failed},
+	}, nil)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return accounts, err
 	}
-	return accounts, totalData, totalSuccess, nil
+	return accounts, nil
 }
@@ -0,0 +1,362 @@
+package accountbusiness
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPayoutConcurrency = 4
+	defaultPayoutBatchSize   = 500
+	defaultPayoutMaxRetry    = 3
+)
+
+// payoutProgress is shared by every accountBusiness in the process so the
+// progress of a run started by the scheduler can be read from a handler.
+type payoutProgress struct {
+	mu          sync.RWMutex
+	running     bool
+	startedAt   time.Time
+	finishedAt  time.Time
+	concurrency int
+	batchSize   int
+
+	batches   int64
+	processed int64
+	succeeded int64
+	failed    int64
+	retried   int64
+}
+
+var progress = &payoutProgress{}
+
+func (p *payoutProgress) start(concurrency, batchSize int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running {
+		return false
+	}
+	p.running = true
+	p.startedAt = time.Now()
+	p.finishedAt = time.Time{}
+	p.concurrency = concurrency
+	p.batchSize = batchSize
+	atomic.StoreInt64(&p.batches, 0)
+	atomic.StoreInt64(&p.processed, 0)
+	atomic.StoreInt64(&p.succeeded, 0)
+	atomic.StoreInt64(&p.failed, 0)
+	atomic.StoreInt64(&p.retried, 0)
+	return true
+}
+
+func (p *payoutProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = false
+	p.finishedAt = time.Now()
+}
+
+func (p *payoutProgress) snapshot() entity.InterestPayoutProgress {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	result := entity.InterestPayoutProgress{
+		Running:     p.running,
+		Concurrency: p.concurrency,
+		BatchSize:   p.batchSize,
+		Batches:     atomic.LoadInt64(&p.batches),
+		Processed:   atomic.LoadInt64(&p.processed),
+		Succeeded:   atomic.LoadInt64(&p.succeeded),
+		Failed:      atomic.LoadInt64(&p.failed),
+		Retried:     atomic.LoadInt64(&p.retried),
+	}
+	if !p.startedAt.IsZero() {
+		startedAt := p.startedAt
+		result.StartedAt = &startedAt
+	}
+	if !p.finishedAt.IsZero() {
+		finishedAt := p.finishedAt
+		result.FinishedAt = &finishedAt
+	}
+	return result
+}
+
+func envInt(key string, defaultValue int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return defaultValue
+	}
+	return v
+}
+
+func payoutMaxRetry() int {
+	return envInt("PAYOUT_MAX_RETRY", defaultPayoutMaxRetry)
+}
+
+// payoutFunc pays the interest of one account in its own transaction.
+type payoutFunc func(ctx context.Context, accountID uuid.UUID) (*entity.Account, error)
+
+// InterestPayoutWorker streams accounts in keyset-paginated batches and pays
+// their interest with a bounded pool of workers, one serializable tx per account.
+func (b *accountBusiness) InterestPayoutWorker(ctx context.Context) (*entity.InterestPayoutProgress, error) {
+	return b.interestPayoutWorker(ctx, b.payoutAccountInterestOnce)
+}
+
+func (b *accountBusiness) interestPayoutWorker(ctx context.Context, payout payoutFunc) (*entity.InterestPayoutProgress, error) {
+	var (
+		eventName   = "business.account.interest_payout_worker"
+		concurrency = envInt("PAYOUT_CONCURRENCY", defaultPayoutConcurrency)
+		batchSize   = envInt("PAYOUT_BATCH_SIZE", defaultPayoutBatchSize)
+		maxRetry    = payoutMaxRetry()
+		jobs        = make(chan uuid.UUID, batchSize)
+		wg          sync.WaitGroup
+	)
+
+	if !progress.start(concurrency, batchSize) {
+		return nil, errbank.NewErrConflict("interest payout is already running")
+	}
+	defer progress.finish()
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for accountID := range jobs {
+				_, err := payoutAccountInterest(ctx, accountID, maxRetry, payout)
+				atomic.AddInt64(&progress.processed, 1)
+				if err != nil {
+					log.Println(eventName, "payoutAccountInterest", accountID, err)
+					atomic.AddInt64(&progress.failed, 1)
+					continue
+				}
+				atomic.AddInt64(&progress.succeeded, 1)
+			}
+		}()
+	}
+
+	var (
+		lastID   = uuid.Nil
+		fetchErr error
+	)
+	for {
+		accounts, err := b.repo.Account.GetAccountBatch(ctx, lastID, batchSize)
+		if err != nil {
+			log.Println(eventName, "GetAccountBatch", err)
+			fetchErr = err
+			break
+		}
+		if len(accounts) == 0 {
+			break
+		}
+		atomic.AddInt64(&progress.batches, 1)
+		for i := range accounts {
+			jobs <- accounts[i].ID
+		}
+		lastID = accounts[len(accounts)-1].ID
+
+		p := progress.snapshot()
+		log.Printf("%s [WORKER] batch %d queued, processed %d (success %d, failed %d, retried %d)", eventName, p.Batches, p.Processed, p.Succeeded, p.Failed, p.Retried)
+
+		if len(accounts) < batchSize || ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := progress.snapshot()
+	return &result, fetchErr
+}
+
+func (b *accountBusiness) GetInterestPayoutProgress(ctx context.Context) entity.InterestPayoutProgress {
+	return progress.snapshot()
+}
+
+// payoutAccountInterest pays interest for a single account, retrying the whole
+// transaction when it loses a serialization conflict.
+func payoutAccountInterest(ctx context.Context, accountID uuid.UUID, maxRetry int, payout payoutFunc) (*entity.Account, error) {
+	for attempt := 0; ; attempt++ {
+		account, err := payout(ctx, accountID)
+		if err == nil || attempt >= maxRetry || !errbank.IsSerializationFailure(errors.Cause(err)) {
+			return account, err
+		}
+		atomic.AddInt64(&progress.retried, 1)
+		backoff := time.Duration(attempt+1)*50*time.Millisecond + time.Duration(rand.Intn(50))*time.Millisecond
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (b *accountBusiness) payoutAccountInterestOnce(ctx context.Context, accountID uuid.UUID) (*entity.Account, error) {
+	var (
+		eventName    = "business.account.payout_account_interest"
+		transactions []entity.Transaction
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, "BeginTx", err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Println(eventName, "Rollback error", rbErr)
+			}
+			b.failTransactions(ctx, transactions)
+		}
+	}()
+
+	account, transactions, err := b.payInterest(ctx, accountID, time.Now(), tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "Commit error", err)
+		return nil, err
+	}
+	return account, nil
+}
+
+// payInterest credits the interest earned since the last payout, less the tax withheld,
+// or charges overdraft interest as a debit when the account is overdrawn.
+func (b *accountBusiness) payInterest(ctx context.Context, accountID uuid.UUID, now time.Time, tx *sql.Tx) (*entity.Account, []entity.Transaction, error) {
+	var (
+		eventName      = "business.account.pay_interest"
+		interestAmount float64
+		taxAmount      float64
+		transactions   []entity.Transaction
+	)
+
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, accountID, tx)
+	if err != nil {
+		log.Println(eventName, "FindByIDForUpdate", err)
+		return nil, nil, err
+	}
+
+	user, err := b.repo.Users.FindByID(ctx, account.UserID)
+	if err != nil {
+		log.Println(eventName, "FindByID", err)
+		return nil, nil, err
+	}
+
+	if account.Balance < 0 {
+		// Overdrawn accounts are charged debit interest on the negative balance instead.
+		overdraftInterest := roundAmount(calculateInterest(-account.Balance, account.OverdraftInterestRate, account.LastInterestPayout, now))
+		if overdraftInterest > 0 {
+			transactions = append(transactions, newTransaction(account.ID, consts.TxTypeDEBIT, consts.TxActionINTEREST, overdraftInterest))
+		}
+		account.Balance -= overdraftInterest
+	} else {
+		interestAmount = roundAmount(calculateInterest(account.Balance, account.InterestRate, account.LastInterestPayout, now))
+		taxAmount = roundAmount(interestAmount * withholdingTaxRate(*user))
+		transactions = append(transactions, newTransaction(account.ID, consts.TxTypeCREDIT, consts.TxActionINTEREST, interestAmount))
+		if taxAmount > 0 {
+			transactions = append(transactions, newTransaction(account.ID, consts.TxTypeDEBIT, consts.TxActionTAX, taxAmount))
+		}
+		account.Balance += interestAmount - taxAmount
+	}
+
+	err = b.repo.Account.PayoutInterest(ctx, *account, tx)
+	if err != nil {
+		log.Println(eventName, "PayoutInterest", err)
+		return nil, nil, err
+	}
+
+	if interestAmount > 0 {
+		err = b.repo.Tax.AccumulateWithholding(ctx, entity.TaxWithholding{
+			UserID:        user.ID,
+			Year:          now.Year(),
+			GrossInterest: interestAmount,
+			TaxWithheld:   taxAmount,
+		}, tx)
+		if err != nil {
+			log.Println(eventName, "AccumulateWithholding", err)
+			return nil, nil, err
+		}
+	}
+
+	err = b.postTransactions(ctx, transactions, tx)
+	if err != nil {
+		log.Println(eventName, "postTransactions", err)
+		return nil, transactions, err
+	}
+	for i := range transactions {
+		err = b.stream.Notify(ctx, transactionStreamEvent(transactions[i], consts.TxStatusCOMPLETED), tx)
+		if err != nil {
+			log.Println(eventName, "Notify", err)
+			return nil, transactions, err
+		}
+	}
+
+	if interestAmount > 0 {
+		err = b.outbox.Enqueue(ctx, consts.EventInterestPaid, account.ID, entity.InterestPaidEvent{
+			AccountID:     account.ID,
+			TransactionID: transactions[0].ID,
+			GrossAmount:   interestAmount,
+			TaxAmount:     taxAmount,
+			NetAmount:     roundAmount(interestAmount - taxAmount),
+		}, tx)
+		if err != nil {
+			log.Println(eventName, "Enqueue", err)
+			return nil, transactions, err
+		}
+	}
+
+	return account, transactions, nil
+}
+
+// withholdingTaxRate returns the tax rate withheld on interest for the user's tax status,
+// configured as WITHHOLDING_TAX_RATES=resident:0.20,non_resident:0.20,corporate:0.15.
+// Exempt users and unconfigured statuses are not taxed.
+func withholdingTaxRate(user entity.User) float64 {
+	if user.TaxExempt {
+		return 0
+	}
+	for _, pair := range strings.Split(os.Getenv("WITHHOLDING_TAX_RATES"), ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(kv) != 2 || !strings.EqualFold(kv[0], user.TaxStatus) {
+			continue
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return 0
+		}
+		return rate
+	}
+	return 0
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// calculateInterest prorates the yearly interest rate over the days elapsed since the last payout.
+func calculateInterest(amount, rate float64, lastPayout, now time.Time) float64 {
+	durationSinceLastPayout := now.Sub(lastPayout.Local())
+	payoutPeriodDays := durationSinceLastPayout.Hours() / 24
+	return amount * (rate * (payoutPeriodDays / 365))
+}
@@ -0,0 +1,143 @@
+package accountbusiness
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+const (
+	defaultKYCUnverifiedMaxDeposit        = 10000000
+	defaultKYCUnverifiedMaxWithdrawal     = 5000000
+	defaultKYCUnverifiedDailyDeposit      = 20000000
+	defaultKYCUnverifiedMonthlyDeposit    = 50000000
+	defaultKYCUnverifiedDailyWithdrawal   = 10000000
+	defaultKYCUnverifiedMonthlyWithdrawal = 20000000
+	kycVerifiedMaxAmount                  = 1000000000000
+	kycVerifiedDailyAmount                = 5000000000000
+	kycVerifiedMonthlyAmount              = 50000000000000
+	kycDailyWindow                        = 24 * time.Hour
+	kycMonthlyWindow                      = 30 * 24 * time.Hour
+)
+
+// kycTier is what a user may do at their KYC status. Pending and rejected users
+// stay on the unverified tier until a reviewer verifies them. The daily and
+// monthly amounts cap the totals over a rolling window; incoming transfers count
+// as deposits and outgoing transfers as withdrawals.
+type kycTier struct {
+	MaxDeposit        float64
+	MaxWithdrawal     float64
+	DailyDeposit      float64
+	MonthlyDeposit    float64
+	DailyWithdrawal   float64
+	MonthlyWithdrawal float64
+	Transfers         bool
+}
+
+func envAmount(key string, defaultValue float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || v < 0 {
+		return defaultValue
+	}
+	return v
+}
+
+func kycTierOf(status string) kycTier {
+	if status == consts.KYCStatusVERIFIED {
+		return kycTier{
+			MaxDeposit:        kycVerifiedMaxAmount,
+			MaxWithdrawal:     kycVerifiedMaxAmount,
+			DailyDeposit:      kycVerifiedDailyAmount,
+			MonthlyDeposit:    kycVerifiedMonthlyAmount,
+			DailyWithdrawal:   kycVerifiedDailyAmount,
+			MonthlyWithdrawal: kycVerifiedMonthlyAmount,
+			Transfers:         true,
+		}
+	}
+	return kycTier{
+		MaxDeposit:        envAmount("KYC_UNVERIFIED_MAX_DEPOSIT", defaultKYCUnverifiedMaxDeposit),
+		MaxWithdrawal:     envAmount("KYC_UNVERIFIED_MAX_WITHDRAWAL", defaultKYCUnverifiedMaxWithdrawal),
+		DailyDeposit:      envAmount("KYC_UNVERIFIED_DAILY_DEPOSIT", defaultKYCUnverifiedDailyDeposit),
+		MonthlyDeposit:    envAmount("KYC_UNVERIFIED_MONTHLY_DEPOSIT", defaultKYCUnverifiedMonthlyDeposit),
+		DailyWithdrawal:   envAmount("KYC_UNVERIFIED_DAILY_WITHDRAWAL", defaultKYCUnverifiedDailyWithdrawal),
+		MonthlyWithdrawal: envAmount("KYC_UNVERIFIED_MONTHLY_WITHDRAWAL", defaultKYCUnverifiedMonthlyWithdrawal),
+	}
+}
+
+// checkKYC returns an ErrForbidden when the KYC tier of user does not allow
+// action for amount on accountID, alone or added to the totals of the last day
+// and month.
+func (b *accountBusiness) checkKYC(ctx context.Context, user entity.User, accountID uuid.UUID, action string, amount float64) error {
+	var (
+		eventName = "business.account.check_kyc"
+		tier      = kycTierOf(user.KYCStatus)
+		now       = time.Now()
+	)
+
+	switch action {
+	case consts.TxActionDEPOSIT:
+		if amount > tier.MaxDeposit {
+			return errbank.NewErrForbidden(fmt.Sprintf("deposits above %.0f need a verified kyc", tier.MaxDeposit))
+		}
+		err := b.checkKYCTotal(ctx, accountID, consts.TxTypeCREDIT, amount, tier.DailyDeposit, now.Add(-kycDailyWindow), "daily deposit")
+		if err != nil {
+			log.Println(eventName, err)
+			return err
+		}
+		err = b.checkKYCTotal(ctx, accountID, consts.TxTypeCREDIT, amount, tier.MonthlyDeposit, now.Add(-kycMonthlyWindow), "monthly deposit")
+		if err != nil {
+			log.Println(eventName, err)
+			return err
+		}
+	case consts.TxActionWITHDRAWAL, consts.TxActionTRANSFER:
+		if action == consts.TxActionTRANSFER && !tier.Transfers {
+			return errbank.NewErrForbidden("transfers need a verified kyc")
+		}
+		if action == consts.TxActionWITHDRAWAL && amount > tier.MaxWithdrawal {
+			return errbank.NewErrForbidden(fmt.Sprintf("withdrawals above %.0f need a verified kyc", tier.MaxWithdrawal))
+		}
+		err := b.checkKYCTotal(ctx, accountID, consts.TxTypeDEBIT, amount, tier.DailyWithdrawal, now.Add(-kycDailyWindow), "daily withdrawal")
+		if err != nil {
+			log.Println(eventName, err)
+			return err
+		}
+		err = b.checkKYCTotal(ctx, accountID, consts.TxTypeDEBIT, amount, tier.MonthlyWithdrawal, now.Add(-kycMonthlyWindow), "monthly withdrawal")
+		if err != nil {
+			log.Println(eventName, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// checkKYCTotal returns an ErrForbidden when amount added to the total of the
+// txType transactions counted by kycActions on accountID since the given time
+// exceeds limit.
+func (b *accountBusiness) checkKYCTotal(ctx context.Context, accountID uuid.UUID, txType string, amount, limit float64, since time.Time, name string) error {
+	stats, err := b.repo.Transaction.GetStatsByAccountID(ctx, accountID, txType, kycActions(txType), since)
+	if err != nil {
+		return err
+	}
+	if stats.Total+amount > limit {
+		return errbank.NewErrForbidden(fmt.Sprintf("the %s limit of %.0f is reached", name, limit))
+	}
+	return nil
+}
+
+// kycActions returns the actions counted in the totals of txType: cash and
+// transfers, but not payroll, fees or interest.
+func kycActions(txType string) []string {
+	if txType == consts.TxTypeCREDIT {
+		return []string{consts.TxActionDEPOSIT, consts.TxActionTRANSFER}
+	}
+	return []string{consts.TxActionWITHDRAWAL, consts.TxActionTRANSFER}
+}
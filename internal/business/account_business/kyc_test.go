@@ -0,0 +1,102 @@
+package accountbusiness
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	transactionrepo "github.com/hanselacn/banking-transaction/internal/repo/transaction_repo"
+	"github.com/pkg/errors"
+)
+
+type fakeTransactionRepo struct {
+	transactionrepo.TransactionRepo
+	// daily and monthly are the totals by transaction type of the last day and month.
+	daily, monthly map[string]float64
+	transactions   []entity.Transaction
+	completed      []uuid.UUID
+}
+
+func (f *fakeTransactionRepo) CreateTransaction(ctx context.Context, input entity.Transaction, tx *sql.Tx) error {
+	f.transactions = append(f.transactions, input)
+	return nil
+}
+
+func (f *fakeTransactionRepo) UpdateTransactionStatus(ctx context.Context, trID uuid.UUID, status string, tx *sql.Tx) error {
+	if status == consts.TxStatusCOMPLETED {
+		f.completed = append(f.completed, trID)
+	}
+	return nil
+}
+
+func (f *fakeTransactionRepo) GetStatsByAccountID(ctx context.Context, accountID uuid.UUID, txType string, actions []string, since time.Time) (*entity.TransactionStats, error) {
+	total := f.daily[txType]
+	if time.Since(since) > kycDailyWindow+time.Hour {
+		total = f.monthly[txType]
+	}
+	return &entity.TransactionStats{Total: total}, nil
+}
+
+func TestCheckKYC(t *testing.T) {
+	t.Setenv("KYC_UNVERIFIED_MAX_DEPOSIT", "1000")
+	t.Setenv("KYC_UNVERIFIED_MAX_WITHDRAWAL", "500")
+	t.Setenv("KYC_UNVERIFIED_DAILY_DEPOSIT", "2000")
+	t.Setenv("KYC_UNVERIFIED_MONTHLY_DEPOSIT", "5000")
+	t.Setenv("KYC_UNVERIFIED_DAILY_WITHDRAWAL", "1000")
+	t.Setenv("KYC_UNVERIFIED_MONTHLY_WITHDRAWAL", "2000")
+
+	cases := []struct {
+		name    string
+		status  string
+		action  string
+		amount  float64
+		daily   map[string]float64
+		monthly map[string]float64
+		allowed bool
+	}{
+		{"deposit at the limit", consts.KYCStatusUNVERIFIED, consts.TxActionDEPOSIT, 1000, nil, nil, true},
+		{"deposit above the limit", consts.KYCStatusUNVERIFIED, consts.TxActionDEPOSIT, 1001, nil, nil, false},
+		{"withdrawal at the limit", consts.KYCStatusPENDING, consts.TxActionWITHDRAWAL, 500, nil, nil, true},
+		{"withdrawal above the limit", consts.KYCStatusREJECTED, consts.TxActionWITHDRAWAL, 501, nil, nil, false},
+		{"unverified transfer", consts.KYCStatusPENDING, consts.TxActionTRANSFER, 1, nil, nil, false},
+		{"deposit up to the daily total", consts.KYCStatusUNVERIFIED, consts.TxActionDEPOSIT, 1000,
+			map[string]float64{consts.TxTypeCREDIT: 1000}, map[string]float64{consts.TxTypeCREDIT: 1000}, true},
+		{"deposit over the daily total", consts.KYCStatusUNVERIFIED, consts.TxActionDEPOSIT, 1000,
+			map[string]float64{consts.TxTypeCREDIT: 1500}, map[string]float64{consts.TxTypeCREDIT: 1500}, false},
+		{"deposit over the monthly total", consts.KYCStatusUNVERIFIED, consts.TxActionDEPOSIT, 1000,
+			nil, map[string]float64{consts.TxTypeCREDIT: 4500}, false},
+		{"debits do not count against deposits", consts.KYCStatusUNVERIFIED, consts.TxActionDEPOSIT, 1000,
+			map[string]float64{consts.TxTypeDEBIT: 5000}, map[string]float64{consts.TxTypeDEBIT: 5000}, true},
+		{"withdrawal over the daily total", consts.KYCStatusUNVERIFIED, consts.TxActionWITHDRAWAL, 500,
+			map[string]float64{consts.TxTypeDEBIT: 600}, map[string]float64{consts.TxTypeDEBIT: 600}, false},
+		{"withdrawal over the monthly total", consts.KYCStatusUNVERIFIED, consts.TxActionWITHDRAWAL, 500,
+			nil, map[string]float64{consts.TxTypeDEBIT: 1600}, false},
+		{"verified deposit", consts.KYCStatusVERIFIED, consts.TxActionDEPOSIT, 1000000000000, nil, nil, true},
+		{"verified withdrawal", consts.KYCStatusVERIFIED, consts.TxActionWITHDRAWAL, 1000000, nil, nil, true},
+		{"verified transfer", consts.KYCStatusVERIFIED, consts.TxActionTRANSFER, 1000000, nil, nil, true},
+		{"verified transfer over the daily total", consts.KYCStatusVERIFIED, consts.TxActionTRANSFER, 1000000,
+			map[string]float64{consts.TxTypeDEBIT: kycVerifiedDailyAmount}, nil, false},
+	}
+	for _, c := range cases {
+		b := &accountBusiness{
+			repo: repo.Repo{
+				Transaction: &fakeTransactionRepo{daily: c.daily, monthly: c.monthly},
+			},
+		}
+		err := b.checkKYC(context.Background(), entity.User{KYCStatus: c.status}, uuid.New(), c.action, c.amount)
+		if c.allowed && err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if !c.allowed {
+			if _, ok := errors.Cause(err).(errbank.ErrForbidden); !ok {
+				t.Errorf("%s: expected ErrForbidden, got %v", c.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package accountbusiness
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+const (
+	sanctionsReleaseBatch      = 100
+	sanctionsReleaseMaxMessage = 255
+)
+
+// holdTransfer screens the name of the recipient against the watchlist. On a hit
+// the transfer is kept with the hit instead of running and an ErrAccepted is
+// returned, the release worker runs it once an admin clears the hit.
+func (b *accountBusiness) holdTransfer(ctx context.Context, user, recipient entity.User, input entity.Transfer) error {
+	var eventName = "business.account.hold_transfer"
+
+	match, err := b.sanctions.Screen(ctx, recipient.Fullname)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	if match == nil {
+		return nil
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	hit, err := b.sanctions.OpenHit(ctx, entity.SanctionsHit{
+		Subject:      consts.SanctionsSubjectTRANSFER,
+		UserID:       user.ID,
+		ScreenedName: recipient.Fullname,
+		EntryUID:     match.Entry.UID,
+		EntryName:    match.Entry.Name,
+		Program:      match.Entry.Program,
+		Score:        match.Score,
+		Payload:      payload,
+	}, nil)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	log.Println(eventName, "transfer held for sanctions review", hit.ID)
+	return errbank.NewErrAccepted("transfer held for sanctions review, hit " + hit.ID.String())
+}
+
+// SanctionsReleaseWorker runs the held transfers whose hit was cleared. A
+// transfer runs at most once, a failure is kept as the message of its hit.
+func (b *accountBusiness) SanctionsReleaseWorker(ctx context.Context) (int, int, error) {
+	var (
+		eventName    = "business.account.sanctions_release_worker"
+		totalSuccess int
+	)
+
+	hits, err := b.repo.Sanctions.GetListReleasableHit(ctx, sanctionsReleaseBatch)
+	if err != nil {
+		log.Println(eventName, err)
+		return 0, 0, err
+	}
+	for _, hit := range hits {
+		now := time.Now()
+		claimed, err := b.repo.Sanctions.ClaimRelease(ctx, hit.ID, now)
+		if err != nil {
+			log.Println(eventName, "ClaimRelease", err)
+			return len(hits), totalSuccess, err
+		}
+		if !claimed {
+			continue
+		}
+
+		var input entity.Transfer
+		execErr := json.Unmarshal(hit.Payload, &input)
+		if execErr == nil {
+			execErr = b.transfer(ctx, input, false)
+		}
+		if execErr == nil {
+			totalSuccess++
+			continue
+		}
+		log.Println(eventName, "transfer", hit.ID, execErr)
+		hit.ExecutedAt = &now
+		hit.Message = execErr.Error()
+		if len(hit.Message) > sanctionsReleaseMaxMessage {
+			hit.Message = hit.Message[:sanctionsReleaseMaxMessage]
+		}
+		if err := b.repo.Sanctions.UpdateHit(ctx, hit, nil); err != nil {
+			log.Println(eventName, "UpdateHit", err)
+		}
+	}
+	return len(hits), totalSuccess, nil
+}
@@ -0,0 +1,576 @@
+package accountbusiness
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	sanctionsbusiness "github.com/hanselacn/banking-transaction/internal/business/sanctions_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+const (
+	defaultTermDepositRates       = "1:0.03,3:0.035,6:0.04,12:0.045,24:0.05"
+	defaultTermDepositPenaltyRate = 0.01
+	termDepositBatchSize          = 500
+)
+
+// termDepositRate returns the fixed yearly rate offered for a term, configured as
+// TERM_DEPOSIT_RATES=1:0.03,3:0.035,6:0.04,12:0.045 (months:rate).
+func termDepositRate(termMonths int) (float64, bool) {
+	rates := os.Getenv("TERM_DEPOSIT_RATES")
+	if rates == "" {
+		rates = defaultTermDepositRates
+	}
+	for _, pair := range strings.Split(rates, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		months, err := strconv.Atoi(strings.TrimSpace(kv[0]))
+		if err != nil || months != termMonths {
+			continue
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return 0, false
+		}
+		return rate, true
+	}
+	return 0, false
+}
+
+// termDepositPenaltyRate is the share of the principal kept on early withdrawal,
+// on top of the accrued interest which is forfeited.
+func termDepositPenaltyRate() float64 {
+	rate, err := strconv.ParseFloat(os.Getenv("TERM_DEPOSIT_PENALTY_RATE"), 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return defaultTermDepositPenaltyRate
+	}
+	return rate
+}
+
+// postTransactions records the transactions and completes them within tx.
+// Callers mark them FAILED when tx is not committed.
+func (b *accountBusiness) postTransactions(ctx context.Context, transactions []entity.Transaction, tx *sql.Tx) error {
+	for i := range transactions {
+		if err := b.repo.Transaction.CreateTransaction(ctx, transactions[i], nil); err != nil {
+			return err
+		}
+	}
+	for i := range transactions {
+		if err := b.repo.Transaction.UpdateTransactionStatus(ctx, transactions[i].ID, consts.TxStatusCOMPLETED, tx); err != nil {
+			return err
+		}
+		if err := b.ledger.PostTransaction(ctx, transactions[i], tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *accountBusiness) failTransactions(ctx context.Context, transactions []entity.Transaction) {
+	for i := range transactions {
+		b.repo.Transaction.UpdateTransactionStatus(ctx, transactions[i].ID, consts.TxStatusFAILED, nil)
+	}
+}
+
+func newTransaction(accountID uuid.UUID, txType, action string, amount float64) entity.Transaction {
+	return entity.Transaction{
+		ID:        uuid.New(),
+		AccountID: accountID,
+		Type:      txType,
+		Amount:    amount,
+		Action:    action,
+		Status:    consts.TxStatusINPROGRESS,
+	}
+}
+
+// OpenTermDeposit moves the amount from the customer's account into a new term
+// deposit locked at the rate currently offered for the term.
+func (b *accountBusiness) OpenTermDeposit(ctx context.Context, input entity.OpenTermDeposit) (*entity.TermDeposit, error) {
+	var (
+		eventName    = "business.account.open_term_deposit"
+		transactions []entity.Transaction
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+			b.failTransactions(ctx, transactions)
+		}
+	}()
+
+	deposit, transactions, err := b.openTermDeposit(ctx, input, time.Now(), tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return deposit, nil
+}
+
+// openTermDeposit moves the principal from the balance of the user into a new deposit
+// within tx. It returns the transactions it posted.
+func (b *accountBusiness) openTermDeposit(ctx context.Context, input entity.OpenTermDeposit, now time.Time, tx *sql.Tx) (*entity.TermDeposit, []entity.Transaction, error) {
+	var (
+		eventName    = "business.account.open_term_deposit"
+		transactions []entity.Transaction
+	)
+
+	rate, ok := termDepositRate(input.TermMonths)
+	if !ok {
+		return nil, nil, errbank.NewErrUnprocessableEntity("term_months: term is not offered")
+	}
+
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, nil, err
+	}
+	err = sanctionsbusiness.CheckStatus(*user)
+	if err != nil {
+		return nil, nil, err
+	}
+	accountRef, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, nil, err
+	}
+
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, accountRef.ID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, nil, err
+	}
+	// Term deposits are funded from the balance only, never from the overdraft.
+	if input.Amount > account.Balance {
+		return nil, nil, errbank.NewErrUnprocessableEntity("insufficient balance")
+	}
+
+	deposit := entity.TermDeposit{
+		ID:                  uuid.New(),
+		AccountID:           account.ID,
+		Principal:           roundAmount(input.Amount),
+		InterestRate:        rate,
+		TermMonths:          input.TermMonths,
+		MaturityInstruction: input.MaturityInstruction,
+		Status:              consts.TermDepositStatusACTIVE,
+		StartDate:           now,
+		MaturityDate:        now.AddDate(0, input.TermMonths, 0),
+	}
+	err = b.repo.TermDeposit.Create(ctx, deposit, tx)
+	if err != nil {
+		log.Println(eventName, "Create", err)
+		return nil, nil, err
+	}
+
+	account.Balance -= deposit.Principal
+	err = b.repo.Account.UpdateBalance(ctx, *account, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateBalance", err)
+		return nil, nil, err
+	}
+
+	transactions = append(transactions, newTransaction(account.ID, consts.TxTypeDEBIT, consts.TxActionTERMDEPOSIT, deposit.Principal))
+	err = b.postTransactions(ctx, transactions, tx)
+	if err != nil {
+		log.Println(eventName, "postTransactions", err)
+		return nil, transactions, err
+	}
+	return &deposit, transactions, nil
+}
+
+func (b *accountBusiness) GetTermDeposits(ctx context.Context, username string) ([]entity.TermDeposit, error) {
+	var (
+		eventName = "business.account.get_term_deposits"
+	)
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	deposits, err := b.repo.TermDeposit.GetListByAccountID(ctx, account.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return deposits, nil
+}
+
+// ownedAccount returns the account of username, used to check a term deposit belongs to the caller.
+func (b *accountBusiness) ownedAccount(ctx context.Context, username string) (*entity.Account, error) {
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return b.repo.Account.FindByUserID(ctx, user.ID)
+}
+
+func (b *accountBusiness) UpdateTermDepositInstruction(ctx context.Context, input entity.UpdateTermDepositInstruction) error {
+	var (
+		eventName = "business.account.update_term_deposit_instruction"
+	)
+
+	account, err := b.ownedAccount(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	deposits, err := b.repo.TermDeposit.GetListByAccountID(ctx, account.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	for _, deposit := range deposits {
+		if deposit.ID != input.ID {
+			continue
+		}
+		deposit.MaturityInstruction = input.MaturityInstruction
+		if err := b.repo.TermDeposit.UpdateInstruction(ctx, deposit); err != nil {
+			log.Println(eventName, err)
+			return err
+		}
+		return nil
+	}
+	return errbank.NewErrNotFound("term deposit not found!")
+}
+
+// WithdrawTermDeposit breaks an active deposit before maturity. The accrued interest is
+// forfeited and a penalty of TERM_DEPOSIT_PENALTY_RATE of the principal is charged.
+func (b *accountBusiness) WithdrawTermDeposit(ctx context.Context, input entity.WithdrawTermDeposit) (*entity.TermDeposit, error) {
+	var (
+		eventName    = "business.account.withdraw_term_deposit"
+		transactions []entity.Transaction
+	)
+
+	owner, err := b.ownedAccount(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+			b.failTransactions(ctx, transactions)
+		}
+	}()
+
+	deposit, transactions, err := b.withdrawTermDeposit(ctx, input.ID, owner.ID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return deposit, nil
+}
+
+// withdrawTermDeposit closes the active deposit of ownerID before maturity and pays the
+// principal net of the penalty back within tx. It returns the transactions it posted.
+func (b *accountBusiness) withdrawTermDeposit(ctx context.Context, depositID, ownerID uuid.UUID, tx *sql.Tx) (*entity.TermDeposit, []entity.Transaction, error) {
+	var (
+		eventName    = "business.account.withdraw_term_deposit"
+		transactions []entity.Transaction
+	)
+
+	deposit, err := b.repo.TermDeposit.FindByIDForUpdate(ctx, depositID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, nil, err
+	}
+	if deposit.AccountID != ownerID {
+		return nil, nil, errbank.NewErrNotFound("term deposit not found!")
+	}
+	if deposit.Status != consts.TermDepositStatusACTIVE {
+		return nil, nil, errbank.NewErrConflict("term deposit is already closed")
+	}
+	if !time.Now().Before(deposit.MaturityDate) {
+		return nil, nil, errbank.NewErrConflict("term deposit has matured and will be settled by the maturity job")
+	}
+
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, deposit.AccountID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, nil, err
+	}
+	user, err := b.repo.Users.FindByID(ctx, account.UserID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, nil, err
+	}
+	err = sanctionsbusiness.CheckStatus(*user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deposit.Penalty = roundAmount(math.Min(deposit.Principal*termDepositPenaltyRate(), deposit.Principal))
+	deposit.Status = consts.TermDepositStatusWITHDRAWN
+	transactions = append(transactions, newTransaction(account.ID, consts.TxTypeCREDIT, consts.TxActionTERMDEPOSIT, deposit.Principal))
+	if deposit.Penalty > 0 {
+		transactions = append(transactions, newTransaction(account.ID, consts.TxTypeDEBIT, consts.TxActionPENALTY, deposit.Penalty))
+	}
+	account.Balance += deposit.Principal - deposit.Penalty
+
+	err = b.repo.Account.UpdateBalance(ctx, *account, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateBalance", err)
+		return nil, transactions, err
+	}
+	err = b.repo.TermDeposit.Close(ctx, *deposit, tx)
+	if err != nil {
+		log.Println(eventName, "Close", err)
+		return nil, transactions, err
+	}
+	err = b.postTransactions(ctx, transactions, tx)
+	if err != nil {
+		log.Println(eventName, "postTransactions", err)
+		return nil, transactions, err
+	}
+	return deposit, transactions, nil
+}
+
+// TermDepositMaturityWorker settles every active deposit whose maturity date has passed.
+// A deposit closed in the meantime is skipped and not counted.
+func (b *accountBusiness) TermDepositMaturityWorker(ctx context.Context) (int, int, error) {
+	var (
+		eventName    = "business.account.term_deposit_maturity_worker"
+		now          = time.Now()
+		lastID       = uuid.Nil
+		totalData    = 0
+		totalSuccess = 0
+	)
+
+	for {
+		deposits, err := b.repo.TermDeposit.GetMaturedBatch(ctx, now, lastID, termDepositBatchSize)
+		if err != nil {
+			log.Println(eventName, err)
+			return totalData, totalSuccess, err
+		}
+		for i := range deposits {
+			settled, err := b.matureTermDeposit(ctx, deposits[i].ID, now)
+			if err != nil {
+				totalData++
+				log.Println(eventName, deposits[i].ID, err)
+				continue
+			}
+			// Settled or withdrawn by someone else since the batch was read.
+			if !settled {
+				log.Println(eventName, deposits[i].ID, "already closed, skipped")
+				continue
+			}
+			totalData++
+			totalSuccess++
+		}
+		if len(deposits) < termDepositBatchSize {
+			return totalData, totalSuccess, nil
+		}
+		lastID = deposits[len(deposits)-1].ID
+	}
+}
+
+// matureTermDeposit settles a matured deposit in its own database transaction. It
+// returns false when the deposit is no longer active or not matured yet.
+func (b *accountBusiness) matureTermDeposit(ctx context.Context, depositID uuid.UUID, now time.Time) (bool, error) {
+	var (
+		eventName    = "business.account.mature_term_deposit"
+		transactions []entity.Transaction
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return false, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+			b.failTransactions(ctx, transactions)
+		}
+	}()
+
+	settled, transactions, err := b.settleTermDeposit(ctx, depositID, now, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, err
+	}
+	if !settled {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Println(eventName, "Rollback", rollbackErr)
+		}
+		return false, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return false, err
+	}
+	return true, nil
+}
+
+// settleTermDeposit pays the interest of a matured deposit, net of withholding tax, and
+// either pays the principal back or rolls it over following the maturity instruction,
+// within tx. It returns false, with nothing written, when the deposit is no longer active
+// or not matured yet, and the transactions it posted.
+func (b *accountBusiness) settleTermDeposit(ctx context.Context, depositID uuid.UUID, now time.Time, tx *sql.Tx) (bool, []entity.Transaction, error) {
+	var (
+		eventName    = "business.account.settle_term_deposit"
+		transactions []entity.Transaction
+	)
+
+	deposit, err := b.repo.TermDeposit.FindByIDForUpdate(ctx, depositID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, nil, err
+	}
+	// Another run or an early withdrawal may have closed it in the meantime.
+	if deposit.Status != consts.TermDepositStatusACTIVE || deposit.MaturityDate.After(now) {
+		return false, nil, nil
+	}
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, deposit.AccountID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, transactions, err
+	}
+	user, err := b.repo.Users.FindByID(ctx, account.UserID)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, transactions, err
+	}
+	// A held or blocked customer is not paid, the deposit stays ACTIVE until the review ends.
+	err = sanctionsbusiness.CheckStatus(*user)
+	if err != nil {
+		return false, transactions, err
+	}
+
+	interest := roundAmount(calculateInterest(deposit.Principal, deposit.InterestRate, deposit.StartDate, deposit.MaturityDate))
+	tax := roundAmount(interest * withholdingTaxRate(*user))
+	deposit.InterestPaid = interest
+	deposit.TaxWithheld = tax
+	if interest > 0 {
+		transactions = append(transactions, newTransaction(account.ID, consts.TxTypeCREDIT, consts.TxActionINTEREST, interest))
+	}
+	if tax > 0 {
+		transactions = append(transactions, newTransaction(account.ID, consts.TxTypeDEBIT, consts.TxActionTAX, tax))
+	}
+
+	var renewal *entity.TermDeposit
+	switch deposit.MaturityInstruction {
+	case consts.TermDepositInstructionROLLOVER, consts.TermDepositInstructionROLLOVERALL:
+		rate, ok := termDepositRate(deposit.TermMonths)
+		if !ok {
+			rate = deposit.InterestRate
+		}
+		renewal = &entity.TermDeposit{
+			ID:                  uuid.New(),
+			AccountID:           deposit.AccountID,
+			Principal:           deposit.Principal,
+			InterestRate:        rate,
+			TermMonths:          deposit.TermMonths,
+			MaturityInstruction: deposit.MaturityInstruction,
+			Status:              consts.TermDepositStatusACTIVE,
+			StartDate:           deposit.MaturityDate,
+			MaturityDate:        deposit.MaturityDate.AddDate(0, deposit.TermMonths, 0),
+		}
+		account.Balance += interest - tax
+		if deposit.MaturityInstruction == consts.TermDepositInstructionROLLOVERALL && interest-tax > 0 {
+			renewal.Principal = roundAmount(deposit.Principal + interest - tax)
+			transactions = append(transactions, newTransaction(account.ID, consts.TxTypeDEBIT, consts.TxActionTERMDEPOSIT, roundAmount(interest-tax)))
+			account.Balance -= interest - tax
+		}
+		deposit.Status = consts.TermDepositStatusROLLEDOVER
+		deposit.RolledOverTo = &renewal.ID
+	default:
+		transactions = append(transactions, newTransaction(account.ID, consts.TxTypeCREDIT, consts.TxActionTERMDEPOSIT, deposit.Principal))
+		account.Balance += deposit.Principal + interest - tax
+		deposit.Status = consts.TermDepositStatusMATURED
+	}
+
+	if renewal != nil {
+		err = b.repo.TermDeposit.Create(ctx, *renewal, tx)
+		if err != nil {
+			log.Println(eventName, "Create", err)
+			return false, transactions, err
+		}
+	}
+	err = b.repo.TermDeposit.Close(ctx, *deposit, tx)
+	if err != nil {
+		log.Println(eventName, "Close", err)
+		return false, transactions, err
+	}
+	err = b.repo.Account.UpdateBalance(ctx, *account, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateBalance", err)
+		return false, transactions, err
+	}
+	if interest > 0 {
+		err = b.repo.Tax.AccumulateWithholding(ctx, entity.TaxWithholding{
+			UserID:        user.ID,
+			Year:          now.Year(),
+			GrossInterest: interest,
+			TaxWithheld:   tax,
+		}, tx)
+		if err != nil {
+			log.Println(eventName, "AccumulateWithholding", err)
+			return false, transactions, err
+		}
+	}
+	err = b.postTransactions(ctx, transactions, tx)
+	if err != nil {
+		log.Println(eventName, "postTransactions", err)
+		return false, transactions, err
+	}
+	return true, transactions, nil
+}
@@ -0,0 +1,330 @@
+package accountbusiness
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	accountrepo "github.com/hanselacn/banking-transaction/internal/repo/account_repo"
+	taxrepo "github.com/hanselacn/banking-transaction/internal/repo/tax_repo"
+	termdepositrepo "github.com/hanselacn/banking-transaction/internal/repo/term_deposit_repo"
+	usersrepo "github.com/hanselacn/banking-transaction/internal/repo/users_repo"
+	"github.com/pkg/errors"
+)
+
+type fakeAccountRepo struct {
+	accountrepo.AccountRepositories
+	account entity.Account
+}
+
+func (f *fakeAccountRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Account, error) {
+	account := f.account
+	return &account, nil
+}
+
+func (f *fakeAccountRepo) FindByUserID(ctx context.Context, id uuid.UUID) (*entity.Account, error) {
+	account := f.account
+	return &account, nil
+}
+
+func (f *fakeAccountRepo) UpdateBalance(ctx context.Context, account entity.Account, tx *sql.Tx) error {
+	f.account.Balance = account.Balance
+	return nil
+}
+
+type fakeUsersRepo struct {
+	usersrepo.UsersRepositories
+	user entity.User
+}
+
+func (f *fakeUsersRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	user := f.user
+	return &user, nil
+}
+
+func (f *fakeUsersRepo) FindByUserName(ctx context.Context, username string) (*entity.User, error) {
+	user := f.user
+	return &user, nil
+}
+
+type fakeTermDepositRepo struct {
+	termdepositrepo.TermDepositRepositories
+	deposit entity.TermDeposit
+	created []entity.TermDeposit
+	closed  []entity.TermDeposit
+}
+
+func (f *fakeTermDepositRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.TermDeposit, error) {
+	deposit := f.deposit
+	return &deposit, nil
+}
+
+func (f *fakeTermDepositRepo) Create(ctx context.Context, deposit entity.TermDeposit, tx *sql.Tx) error {
+	f.created = append(f.created, deposit)
+	return nil
+}
+
+func (f *fakeTermDepositRepo) Close(ctx context.Context, deposit entity.TermDeposit, tx *sql.Tx) error {
+	f.closed = append(f.closed, deposit)
+	return nil
+}
+
+type fakeTaxRepo struct {
+	taxrepo.TaxRepositories
+	withholdings []entity.TaxWithholding
+}
+
+func (f *fakeTaxRepo) AccumulateWithholding(ctx context.Context, withholding entity.TaxWithholding, tx *sql.Tx) error {
+	f.withholdings = append(f.withholdings, withholding)
+	return nil
+}
+
+type fakeLedger struct {
+	ledgerbusiness.LedgerBusiness
+	posted []entity.Transaction
+}
+
+func (f *fakeLedger) PostTransaction(ctx context.Context, transaction entity.Transaction, tx *sql.Tx) error {
+	f.posted = append(f.posted, transaction)
+	return nil
+}
+
+type termDepositFixture struct {
+	accounts     *fakeAccountRepo
+	users        *fakeUsersRepo
+	deposits     *fakeTermDepositRepo
+	transactions *fakeTransactionRepo
+	tax          *fakeTaxRepo
+	ledger       *fakeLedger
+	b            *accountBusiness
+}
+
+func newTermDepositFixture(balance float64, deposit entity.TermDeposit) termDepositFixture {
+	f := termDepositFixture{
+		accounts:     &fakeAccountRepo{account: entity.Account{ID: deposit.AccountID, UserID: uuid.New(), Balance: balance}},
+		deposits:     &fakeTermDepositRepo{deposit: deposit},
+		transactions: &fakeTransactionRepo{},
+		tax:          &fakeTaxRepo{},
+		ledger:       &fakeLedger{},
+	}
+	f.users = &fakeUsersRepo{user: entity.User{ID: f.accounts.account.UserID, TaxStatus: "RESIDENT"}}
+	f.b = &accountBusiness{
+		repo: repo.Repo{
+			Account:     f.accounts,
+			Users:       f.users,
+			TermDeposit: f.deposits,
+			Transaction: f.transactions,
+			Tax:         f.tax,
+		},
+		ledger: f.ledger,
+	}
+	return f
+}
+
+// amounts sums the posted transactions by action and type, e.g. "INTEREST C".
+func amounts(transactions []entity.Transaction) map[string]float64 {
+	result := map[string]float64{}
+	for _, transaction := range transactions {
+		result[transaction.Action+" "+transaction.Type] += transaction.Amount
+	}
+	return result
+}
+
+func TestWithdrawTermDeposit(t *testing.T) {
+	t.Setenv("TERM_DEPOSIT_PENALTY_RATE", "0.01")
+	deposit := entity.TermDeposit{
+		ID:           uuid.New(),
+		AccountID:    uuid.New(),
+		Principal:    1000000,
+		InterestRate: 0.05,
+		TermMonths:   12,
+		Status:       consts.TermDepositStatusACTIVE,
+		StartDate:    time.Now().AddDate(0, -6, 0),
+		MaturityDate: time.Now().AddDate(0, 6, 0),
+	}
+	f := newTermDepositFixture(50000, deposit)
+
+	withdrawn, _, err := f.b.withdrawTermDeposit(context.Background(), deposit.ID, deposit.AccountID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withdrawn.Status != consts.TermDepositStatusWITHDRAWN || withdrawn.Penalty != 10000 {
+		t.Errorf("deposit = %+v, want WITHDRAWN with a penalty of 10000", withdrawn)
+	}
+	if f.accounts.account.Balance != 1040000 {
+		t.Errorf("balance = %v, want 1040000 (principal less penalty, no interest)", f.accounts.account.Balance)
+	}
+	got := amounts(f.transactions.transactions)
+	if len(got) != 2 || got["TERM_DEPOSIT C"] != 1000000 || got["PENALTY D"] != 10000 {
+		t.Errorf("transactions = %v, want the principal credited and the penalty debited", got)
+	}
+	if len(f.transactions.completed) != 2 || len(f.ledger.posted) != 2 {
+		t.Errorf("completed = %d, posted = %d, want 2", len(f.transactions.completed), len(f.ledger.posted))
+	}
+
+	f = newTermDepositFixture(50000, deposit)
+	if _, _, err := f.b.withdrawTermDeposit(context.Background(), deposit.ID, uuid.New(), nil); err == nil {
+		t.Error("withdrawing another customer's deposit succeeded")
+	}
+}
+
+func TestSettleTermDeposit(t *testing.T) {
+	t.Setenv("WITHHOLDING_TAX_RATES", "RESIDENT:0.2")
+	t.Setenv("TERM_DEPOSIT_RATES", "12:0.04")
+
+	var (
+		start    = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.Local)
+		maturity = start.AddDate(1, 0, 0)
+		now      = maturity.Add(time.Hour)
+	)
+
+	cases := []struct {
+		name          string
+		instruction   string
+		status        string
+		maturity      time.Time
+		wantSettled   bool
+		wantStatus    string
+		wantBalance   float64
+		wantRenewal   float64
+		wantRenewRate float64
+		want          map[string]float64
+	}{
+		{name: "payout", instruction: consts.TermDepositInstructionPAYOUT, status: consts.TermDepositStatusACTIVE, maturity: maturity,
+			wantSettled: true, wantStatus: consts.TermDepositStatusMATURED, wantBalance: 1040000 + 10000,
+			want: map[string]float64{"INTEREST C": 50000, "TAX D": 10000, "TERM_DEPOSIT C": 1000000}},
+		{name: "rollover pays the interest out", instruction: consts.TermDepositInstructionROLLOVER, status: consts.TermDepositStatusACTIVE, maturity: maturity,
+			wantSettled: true, wantStatus: consts.TermDepositStatusROLLEDOVER, wantBalance: 10000 + 40000, wantRenewal: 1000000, wantRenewRate: 0.04,
+			want: map[string]float64{"INTEREST C": 50000, "TAX D": 10000}},
+		{name: "rollover with interest", instruction: consts.TermDepositInstructionROLLOVERALL, status: consts.TermDepositStatusACTIVE, maturity: maturity,
+			wantSettled: true, wantStatus: consts.TermDepositStatusROLLEDOVER, wantBalance: 10000, wantRenewal: 1040000, wantRenewRate: 0.04,
+			want: map[string]float64{"INTEREST C": 50000, "TAX D": 10000, "TERM_DEPOSIT D": 40000}},
+		{name: "already closed", instruction: consts.TermDepositInstructionPAYOUT, status: consts.TermDepositStatusWITHDRAWN, maturity: maturity,
+			wantBalance: 10000, want: map[string]float64{}},
+		{name: "not matured yet", instruction: consts.TermDepositInstructionPAYOUT, status: consts.TermDepositStatusACTIVE, maturity: now.Add(time.Hour),
+			wantBalance: 10000, want: map[string]float64{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			deposit := entity.TermDeposit{
+				ID:                  uuid.New(),
+				AccountID:           uuid.New(),
+				Principal:           1000000,
+				InterestRate:        0.05,
+				TermMonths:          12,
+				MaturityInstruction: c.instruction,
+				Status:              c.status,
+				StartDate:           start,
+				MaturityDate:        c.maturity,
+			}
+			f := newTermDepositFixture(10000, deposit)
+
+			settled, _, err := f.b.settleTermDeposit(context.Background(), deposit.ID, now, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if settled != c.wantSettled {
+				t.Fatalf("settled = %v, want %v", settled, c.wantSettled)
+			}
+			if f.accounts.account.Balance != c.wantBalance {
+				t.Errorf("balance = %v, want %v", f.accounts.account.Balance, c.wantBalance)
+			}
+			got := amounts(f.transactions.transactions)
+			if len(got) != len(c.want) {
+				t.Errorf("transactions = %v, want %v", got, c.want)
+			}
+			for key, amount := range c.want {
+				if got[key] != amount {
+					t.Errorf("%s = %v, want %v", key, got[key], amount)
+				}
+			}
+			if !c.wantSettled {
+				if len(f.deposits.closed) != 0 || len(f.tax.withholdings) != 0 {
+					t.Errorf("closed = %+v, withholdings = %+v, want nothing written", f.deposits.closed, f.tax.withholdings)
+				}
+				return
+			}
+
+			if len(f.deposits.closed) != 1 || f.deposits.closed[0].Status != c.wantStatus || f.deposits.closed[0].InterestPaid != 50000 || f.deposits.closed[0].TaxWithheld != 10000 {
+				t.Errorf("closed = %+v, want one %s paying 50000 less 10000 tax", f.deposits.closed, c.wantStatus)
+			}
+			if len(f.tax.withholdings) != 1 || f.tax.withholdings[0].GrossInterest != 50000 || f.tax.withholdings[0].TaxWithheld != 10000 || f.tax.withholdings[0].Year != now.Year() {
+				t.Errorf("withholdings = %+v, want 50000 gross and 10000 withheld in %d", f.tax.withholdings, now.Year())
+			}
+			if c.wantRenewal == 0 {
+				if len(f.deposits.created) != 0 {
+					t.Errorf("created = %+v, want no renewal", f.deposits.created)
+				}
+				return
+			}
+			if len(f.deposits.created) != 1 {
+				t.Fatalf("created = %+v, want one renewal", f.deposits.created)
+			}
+			renewal := f.deposits.created[0]
+			if renewal.Principal != c.wantRenewal || renewal.InterestRate != c.wantRenewRate || !renewal.StartDate.Equal(c.maturity) || *f.deposits.closed[0].RolledOverTo != renewal.ID {
+				t.Errorf("renewal = %+v, want %v at %v from the maturity date", renewal, c.wantRenewal, c.wantRenewRate)
+			}
+		})
+	}
+}
+
+func TestTermDepositSanctions(t *testing.T) {
+	var (
+		now     = time.Now()
+		deposit = entity.TermDeposit{
+			ID:                  uuid.New(),
+			AccountID:           uuid.New(),
+			Principal:           1000000,
+			InterestRate:        0.05,
+			TermMonths:          12,
+			MaturityInstruction: consts.TermDepositInstructionPAYOUT,
+			Status:              consts.TermDepositStatusACTIVE,
+			StartDate:           now.AddDate(-1, 0, 0),
+			MaturityDate:        now.Add(-time.Hour),
+		}
+		open = entity.OpenTermDeposit{Username: "alice", Amount: 20000, TermMonths: 12, MaturityInstruction: consts.TermDepositInstructionPAYOUT}
+	)
+
+	for _, status := range []string{consts.SanctionsStatusHOLD, consts.SanctionsStatusBLOCKED} {
+		f := newTermDepositFixture(50000, deposit)
+		f.users.user.SanctionsStatus = status
+
+		if _, _, err := f.b.openTermDeposit(context.Background(), open, now, nil); !isForbidden(err) {
+			t.Errorf("%s: openTermDeposit() error = %v, want ErrForbidden", status, err)
+		}
+		if _, _, err := f.b.settleTermDeposit(context.Background(), deposit.ID, now, nil); !isForbidden(err) {
+			t.Errorf("%s: settleTermDeposit() error = %v, want ErrForbidden", status, err)
+		}
+		early := deposit
+		early.MaturityDate = now.AddDate(0, 6, 0)
+		f.deposits.deposit = early
+		if _, _, err := f.b.withdrawTermDeposit(context.Background(), deposit.ID, deposit.AccountID, nil); !isForbidden(err) {
+			t.Errorf("%s: withdrawTermDeposit() error = %v, want ErrForbidden", status, err)
+		}
+		if f.accounts.account.Balance != 50000 || len(f.deposits.created) != 0 || len(f.deposits.closed) != 0 || len(f.transactions.transactions) != 0 {
+			t.Errorf("%s: balance = %v, created = %d, closed = %d, transactions = %d, want nothing moved", status,
+				f.accounts.account.Balance, len(f.deposits.created), len(f.deposits.closed), len(f.transactions.transactions))
+		}
+	}
+
+	f := newTermDepositFixture(50000, deposit)
+	opened, _, err := f.b.openTermDeposit(context.Background(), open, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.accounts.account.Balance != 30000 || len(f.deposits.created) != 1 || opened.Principal != 20000 || !opened.MaturityDate.Equal(now.AddDate(1, 0, 0)) {
+		t.Errorf("deposit = %+v, balance = %v, want 20000 moved into a 12 month deposit", opened, f.accounts.account.Balance)
+	}
+}
+
+func isForbidden(err error) bool {
+	_, ok := errors.Cause(err).(errbank.ErrForbidden)
+	return ok
+}
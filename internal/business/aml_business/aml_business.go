@@ -0,0 +1,280 @@
+package amlbusiness
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+// amlExportPageSize is how many alerts are read at a time while exporting.
+const amlExportPageSize = 500
+
+type AMLBusiness interface {
+	DetectionWorker(ctx context.Context) (*entity.AMLScanResult, error)
+	GetAlerts(ctx context.Context, status, alertType string, m meta.Metadata) ([]entity.AMLAlert, error)
+	GetAlert(ctx context.Context, id uuid.UUID) (*entity.AMLAlert, error)
+	ReviewAlert(ctx context.Context, input entity.ReviewAMLAlert) (*entity.AMLAlert, error)
+	ExportAlerts(ctx context.Context, status, alertType string, dr *meta.DateRange) ([]byte, error)
+}
+
+type amlBusiness struct {
+	repo  repo.Repo
+	db    *sql.DB
+	audit auditbusiness.AuditBusiness
+}
+
+func NewAMLBusiness(db *sql.DB) AMLBusiness {
+	return &amlBusiness{
+		repo:  repo.NewRepositories(db),
+		db:    db,
+		audit: auditbusiness.NewAuditBusiness(db),
+	}
+}
+
+// DetectionWorker scans the recent cash transactions for single deposits or
+// withdrawals at or above the cash threshold, and for series of transactions just
+// below it that add up to the threshold within the structuring window. Alerts are
+// deduplicated so the job can be rerun over overlapping periods.
+func (b *amlBusiness) DetectionWorker(ctx context.Context) (*entity.AMLScanResult, error) {
+	var (
+		eventName = "business.aml.detection_worker"
+		now       = time.Now()
+		since     = now.Add(-amlScanLookback())
+		threshold = amlCashThreshold()
+		result    = entity.AMLScanResult{}
+	)
+
+	large, err := b.repo.AML.GetCashTransactions(ctx, threshold, 0, since, consts.AMLAlertTypeLARGECASH)
+	if err != nil {
+		log.Println(eventName, "GetCashTransactions", err)
+		return nil, err
+	}
+	result.Scanned += len(large)
+	for _, transaction := range large {
+		created, err := b.createAlert(ctx, consts.AMLAlertTypeLARGECASH, "LARGE_CASH:"+transaction.ID.String(), []entity.AMLTransaction{transaction}, now)
+		if err != nil {
+			log.Println(eventName, "createAlert", err)
+			return nil, err
+		}
+		if created {
+			result.LargeCash++
+		}
+	}
+
+	nearby, err := b.repo.AML.GetCashTransactions(ctx, threshold*amlStructuringRatio(), threshold, since, consts.AMLAlertTypeSTRUCTURING)
+	if err != nil {
+		log.Println(eventName, "GetCashTransactions", err)
+		return nil, err
+	}
+	result.Scanned += len(nearby)
+	for _, group := range detectStructuring(nearby, amlStructuringWindow(), amlStructuringCount(), threshold) {
+		dedupeKey := "STRUCTURING:" + group[0].AccountID.String() + ":" + group[0].ID.String()
+		created, err := b.createAlert(ctx, consts.AMLAlertTypeSTRUCTURING, dedupeKey, group, now)
+		if err != nil {
+			log.Println(eventName, "createAlert", err)
+			return nil, err
+		}
+		if created {
+			result.Structuring++
+		}
+	}
+	return &result, nil
+}
+
+func (b *amlBusiness) createAlert(ctx context.Context, alertType, dedupeKey string, txs []entity.AMLTransaction, now time.Time) (bool, error) {
+	alert := entity.AMLAlert{
+		ID:               uuid.New(),
+		Type:             alertType,
+		AccountID:        txs[0].AccountID,
+		UserID:           txs[0].UserID,
+		TransactionCount: len(txs),
+		WindowStart:      txs[0].CreatedAt,
+		WindowEnd:        txs[len(txs)-1].CreatedAt,
+		Status:           consts.AMLAlertStatusOPEN,
+		DedupeKey:        dedupeKey,
+		CreatedAt:        now,
+	}
+	for _, transaction := range txs {
+		alert.TransactionIDs = append(alert.TransactionIDs, transaction.ID)
+		alert.TotalAmount += transaction.Amount
+	}
+	return b.repo.AML.CreateAlert(ctx, alert)
+}
+
+func (b *amlBusiness) GetAlerts(ctx context.Context, status, alertType string, m meta.Metadata) ([]entity.AMLAlert, error) {
+	var eventName = "business.aml.get_alerts"
+
+	alerts, err := b.repo.AML.GetListAlert(ctx, status, alertType, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (b *amlBusiness) GetAlert(ctx context.Context, id uuid.UUID) (*entity.AMLAlert, error) {
+	var eventName = "business.aml.get_alert"
+
+	alert, err := b.repo.AML.FindAlertByID(ctx, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return alert, nil
+}
+
+// ReviewAlert closes an open alert as DISMISSED or as REPORTED to the regulator.
+func (b *amlBusiness) ReviewAlert(ctx context.Context, input entity.ReviewAMLAlert) (*entity.AMLAlert, error) {
+	var eventName = "business.aml.review_alert"
+
+	alert, err := b.repo.AML.FindAlertByID(ctx, input.AlertID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if alert.Status != consts.AMLAlertStatusOPEN {
+		return nil, errbank.NewErrConflict("aml alert is already " + alert.Status)
+	}
+
+	alert.Status = input.Status
+	alert.ReviewedBy = input.ReviewedBy
+	alert.Note = input.Note
+	alert.UpdatedAt = time.Now()
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.AML.UpdateAlertStatus(ctx, *alert, consts.AMLAlertStatusOPEN, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateAlertStatus", err)
+		return nil, err
+	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionREVIEWAMLALERT,
+		Target: alert.ID.String(),
+		Before: map[string]string{"status": consts.AMLAlertStatusOPEN},
+		After:  alert,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return alert, nil
+}
+
+// ExportAlerts renders every alert matching the filters as CSV for filing.
+func (b *amlBusiness) ExportAlerts(ctx context.Context, status, alertType string, dr *meta.DateRange) ([]byte, error) {
+	var (
+		eventName = "business.aml.export_alerts"
+		alerts    []entity.AMLAlert
+		m         = meta.Metadata{
+			Pagination: meta.Pagination{Page: 1, PerPage: amlExportPageSize},
+			DateRange:  dr,
+		}
+	)
+
+	for {
+		page, err := b.repo.AML.GetListAlert(ctx, status, alertType, m)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, err
+		}
+		alerts = append(alerts, page...)
+		if len(page) < amlExportPageSize {
+			break
+		}
+		m.Page++
+	}
+
+	report, err := renderAlerts(alerts)
+	if err != nil {
+		log.Println(eventName, "renderAlerts", err)
+		return nil, err
+	}
+
+	filters := map[string]interface{}{"status": status, "type": alertType, "alerts": len(alerts)}
+	if dr != nil {
+		filters["start"] = dr.Start.Format("2006-01-02")
+		filters["end"] = dr.End.Format("2006-01-02")
+	}
+	// The report is not handed out unless the export is recorded.
+	if err := b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionEXPORTAMLREPORT,
+		Target: "aml_alerts",
+		After:  filters,
+	}, nil); err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+func renderAlerts(alerts []entity.AMLAlert) ([]byte, error) {
+	var (
+		buf    bytes.Buffer
+		writer = csv.NewWriter(&buf)
+		rows   = [][]string{
+			{"Alert ID", "Type", "Status", "Account Number", "User Name", "Full Name", "Transactions", "Total Amount", "Window Start", "Window End", "Transaction IDs", "Reviewed By", "Note", "Created At"},
+		}
+	)
+	for _, alert := range alerts {
+		transactionIDs := make([]string, len(alert.TransactionIDs))
+		for i, id := range alert.TransactionIDs {
+			transactionIDs[i] = id.String()
+		}
+		rows = append(rows, []string{
+			alert.ID.String(),
+			alert.Type,
+			alert.Status,
+			alert.AccountNumber,
+			alert.Username,
+			alert.Fullname,
+			strconv.Itoa(alert.TransactionCount),
+			fmt.Sprintf("%.2f", alert.TotalAmount),
+			alert.WindowStart.Format(time.RFC3339),
+			alert.WindowEnd.Format(time.RFC3339),
+			strings.Join(transactionIDs, " "),
+			alert.ReviewedBy,
+			alert.Note,
+			alert.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package amlbusiness
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+const (
+	defaultAMLCashThreshold     = 100000000
+	defaultAMLStructuringRatio  = 0.9
+	defaultAMLStructuringCount  = 3
+	defaultAMLStructuringWindow = 72 * time.Hour
+	defaultAMLScanLookback      = 7 * 24 * time.Hour
+)
+
+// amlCashThreshold is the amount from which a single cash transaction is reported.
+func amlCashThreshold() float64 {
+	v, err := strconv.ParseFloat(os.Getenv("AML_CASH_THRESHOLD"), 64)
+	if err != nil || v <= 0 {
+		return defaultAMLCashThreshold
+	}
+	return v
+}
+
+// amlStructuringRatio is the share of the cash threshold from which a
+// transaction counts as just below it.
+func amlStructuringRatio() float64 {
+	v, err := strconv.ParseFloat(os.Getenv("AML_STRUCTURING_RATIO"), 64)
+	if err != nil || v <= 0 || v >= 1 {
+		return defaultAMLStructuringRatio
+	}
+	return v
+}
+
+func amlStructuringCount() int {
+	v, err := strconv.Atoi(os.Getenv("AML_STRUCTURING_COUNT"))
+	if err != nil || v < 2 {
+		return defaultAMLStructuringCount
+	}
+	return v
+}
+
+func amlStructuringWindow() time.Duration {
+	v, err := time.ParseDuration(os.Getenv("AML_STRUCTURING_WINDOW"))
+	if err != nil || v <= 0 {
+		return defaultAMLStructuringWindow
+	}
+	return v
+}
+
+// amlScanLookback is how far back the detection job reads transactions, it must
+// cover the structuring window for groups spanning two runs to be found.
+func amlScanLookback() time.Duration {
+	v, err := time.ParseDuration(os.Getenv("AML_SCAN_LOOKBACK"))
+	if err != nil || v <= 0 {
+		return defaultAMLScanLookback
+	}
+	return v
+}
+
+// detectStructuring groups the sub-threshold transactions of an account that fall
+// within the window of each other. A group is reported when it holds at least
+// minCount transactions adding up to the threshold, its transactions are then not
+// reused by a later group. txs must be ordered by account and time.
+func detectStructuring(txs []entity.AMLTransaction, window time.Duration, minCount int, threshold float64) [][]entity.AMLTransaction {
+	var groups [][]entity.AMLTransaction
+
+	for start := 0; start < len(txs); {
+		end := start
+		for end < len(txs) && txs[end].AccountID == txs[start].AccountID {
+			end++
+		}
+
+		account := txs[start:end]
+		for first := 0; first < len(account); {
+			var (
+				last  = first
+				total float64
+			)
+			for last < len(account) && account[last].CreatedAt.Sub(account[first].CreatedAt) <= window {
+				total += account[last].Amount
+				last++
+			}
+			if last-first >= minCount && total >= threshold {
+				group := make([]entity.AMLTransaction, last-first)
+				copy(group, account[first:last])
+				groups = append(groups, group)
+				first = last
+				continue
+			}
+			first++
+		}
+		start = end
+	}
+	return groups
+}
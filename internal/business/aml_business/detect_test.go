@@ -0,0 +1,71 @@
+package amlbusiness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+func TestDetectStructuring(t *testing.T) {
+	var (
+		base     = time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+		accountA = uuid.New()
+		accountB = uuid.New()
+		tx       = func(account uuid.UUID, hours int, amount float64) entity.AMLTransaction {
+			return entity.AMLTransaction{ID: uuid.New(), AccountID: account, Amount: amount, CreatedAt: base.Add(time.Duration(hours) * time.Hour)}
+		}
+	)
+
+	tests := []struct {
+		name   string
+		txs    []entity.AMLTransaction
+		groups []int
+	}{
+		{
+			name:   "three deposits just below the threshold within the window",
+			txs:    []entity.AMLTransaction{tx(accountA, 0, 95), tx(accountA, 10, 92), tx(accountA, 30, 98)},
+			groups: []int{3},
+		},
+		{
+			name:   "too few transactions",
+			txs:    []entity.AMLTransaction{tx(accountA, 0, 95), tx(accountA, 10, 92)},
+			groups: nil,
+		},
+		{
+			name:   "spread beyond the window",
+			txs:    []entity.AMLTransaction{tx(accountA, 0, 95), tx(accountA, 50, 92), tx(accountA, 100, 98)},
+			groups: nil,
+		},
+		{
+			name:   "transactions of different accounts are not combined",
+			txs:    []entity.AMLTransaction{tx(accountA, 0, 95), tx(accountA, 1, 92), tx(accountB, 2, 98), tx(accountB, 3, 98)},
+			groups: nil,
+		},
+		{
+			name:   "a group consumes its transactions",
+			txs:    []entity.AMLTransaction{tx(accountA, 0, 95), tx(accountA, 1, 92), tx(accountA, 2, 98), tx(accountA, 3, 91), tx(accountA, 4, 93), tx(accountA, 60, 99), tx(accountA, 61, 99), tx(accountA, 62, 99)},
+			groups: []int{5, 3},
+		},
+		{
+			name:   "window starts later",
+			txs:    []entity.AMLTransaction{tx(accountA, 0, 95), tx(accountA, 50, 92), tx(accountA, 51, 98), tx(accountA, 52, 91)},
+			groups: []int{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := detectStructuring(tt.txs, 48*time.Hour, 3, 100)
+			if len(groups) != len(tt.groups) {
+				t.Fatalf("got %d groups, want %d", len(groups), len(tt.groups))
+			}
+			for i, group := range groups {
+				if len(group) != tt.groups[i] {
+					t.Errorf("group %d has %d transactions, want %d", i, len(group), tt.groups[i])
+				}
+			}
+		})
+	}
+}
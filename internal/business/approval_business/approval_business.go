@@ -0,0 +1,352 @@
+package approvalbusiness
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	accountbusiness "github.com/hanselacn/banking-transaction/internal/business/account_business"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	usersbusiness "github.com/hanselacn/banking-transaction/internal/business/users_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+const (
+	defaultApprovalOperations = "UPDATE_ROLE,UPDATE_INTEREST_RATE,INTEREST_PAYOUT,WITHDRAWAL:10000000"
+	defaultApprovalExpiry     = 72 * time.Hour
+	approvalMaxMessage        = 255
+)
+
+// checkerRoles lists the roles allowed to approve an operation. Role changes
+// are checked by super admins only.
+var checkerRoles = map[string][]string{
+	consts.ApprovalOperationUPDATEROLE:         {consts.RoleSuperAdmin},
+	consts.ApprovalOperationUPDATEINTERESTRATE: {consts.RoleSuperAdmin, consts.RoleAdmin},
+	consts.ApprovalOperationINTERESTPAYOUT:     {consts.RoleSuperAdmin, consts.RoleAdmin},
+	consts.ApprovalOperationWITHDRAWAL:         {consts.RoleSuperAdmin, consts.RoleAdmin},
+}
+
+type ApprovalBusiness interface {
+	RequiresApproval(operation string, amount float64) bool
+	Submit(ctx context.Context, input entity.SubmitApproval) (*entity.ApprovalRequest, error)
+	GetRequests(ctx context.Context, status, operation string, m meta.Metadata) ([]entity.ApprovalRequest, error)
+	GetRequest(ctx context.Context, id uuid.UUID) (*entity.ApprovalRequest, error)
+	Approve(ctx context.Context, input entity.ReviewApproval) (*entity.ApprovalRequest, error)
+	Reject(ctx context.Context, input entity.ReviewApproval) (*entity.ApprovalRequest, error)
+}
+
+type approvalBusiness struct {
+	repo    repo.Repo
+	db      *sql.DB
+	account accountbusiness.AccountBusiness
+	users   usersbusiness.UsersBusiness
+	audit   auditbusiness.AuditBusiness
+}
+
+func NewApprovalBusiness(db *sql.DB) ApprovalBusiness {
+	return &approvalBusiness{
+		repo:    repo.NewRepositories(db),
+		db:      db,
+		account: accountbusiness.NewAccountBusiness(db),
+		users:   usersbusiness.NewUsersBusiness(db),
+		audit:   auditbusiness.NewAuditBusiness(db),
+	}
+}
+
+// approvalThreshold returns whether operation needs a checker and from which amount,
+// configured as APPROVAL_OPERATIONS=UPDATE_ROLE,INTEREST_PAYOUT,WITHDRAWAL:10000000
+// (operation[:minimum amount]). NONE disables the approvals.
+func approvalThreshold(operation string) (float64, bool) {
+	operations := os.Getenv("APPROVAL_OPERATIONS")
+	if operations == "" {
+		operations = defaultApprovalOperations
+	}
+	for _, pair := range strings.Split(operations, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if !strings.EqualFold(strings.TrimSpace(kv[0]), operation) {
+			continue
+		}
+		if len(kv) == 1 {
+			return 0, true
+		}
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil || threshold < 0 {
+			return 0, true
+		}
+		return threshold, true
+	}
+	return 0, false
+}
+
+// approvalExpiry is how long a request waits for a checker before it expires.
+func approvalExpiry() time.Duration {
+	expiry, err := time.ParseDuration(os.Getenv("APPROVAL_EXPIRY"))
+	if err != nil || expiry <= 0 {
+		return defaultApprovalExpiry
+	}
+	return expiry
+}
+
+func canCheck(operation, role string) bool {
+	for _, allowed := range checkerRoles[operation] {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func (b *approvalBusiness) RequiresApproval(operation string, amount float64) bool {
+	threshold, ok := approvalThreshold(operation)
+	return ok && amount >= threshold
+}
+
+// Submit stores the operation with its payload until a checker decides on it.
+func (b *approvalBusiness) Submit(ctx context.Context, input entity.SubmitApproval) (*entity.ApprovalRequest, error) {
+	var (
+		eventName = "business.approval.submit"
+	)
+
+	if _, ok := checkerRoles[input.Operation]; !ok {
+		return nil, errbank.NewErrUnprocessableEntity("operation " + input.Operation + " does not support approvals")
+	}
+	payload, err := json.Marshal(input.Payload)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	request := entity.ApprovalRequest{
+		ID:        uuid.New(),
+		Operation: input.Operation,
+		Payload:   payload,
+		Amount:    input.Amount,
+		Maker:     input.Maker,
+		Status:    consts.ApprovalStatusPENDING,
+		CreatedAt: time.Now(),
+	}
+	request.UpdatedAt = request.CreatedAt
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.Approval.Create(ctx, request, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionSUBMITAPPROVAL,
+		Target: request.ID.String(),
+		After:  request,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (b *approvalBusiness) GetRequests(ctx context.Context, status, operation string, m meta.Metadata) ([]entity.ApprovalRequest, error) {
+	var eventName = "business.approval.get_requests"
+
+	requests, err := b.repo.Approval.GetList(ctx, status, operation, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (b *approvalBusiness) GetRequest(ctx context.Context, id uuid.UUID) (*entity.ApprovalRequest, error) {
+	var eventName = "business.approval.get_request"
+
+	request, err := b.repo.Approval.FindByID(ctx, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return request, nil
+}
+
+// decide moves a pending request to status on behalf of the checker, who must
+// not be its maker and must hold a role allowed to check the operation. A request
+// past its expiry is closed as EXPIRED instead.
+func (b *approvalBusiness) decide(ctx context.Context, input entity.ReviewApproval, status string) (*entity.ApprovalRequest, error) {
+	var (
+		eventName = "business.approval.decide"
+		now       = time.Now()
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	request, err := b.repo.Approval.FindByIDForUpdate(ctx, input.RequestID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if request.Status != consts.ApprovalStatusPENDING {
+		err = errbank.NewErrConflict("approval request is already " + request.Status)
+		return nil, err
+	}
+	if request.Maker == input.Checker {
+		err = errbank.NewErrForbidden("an approval request must be checked by another user than its maker")
+		return nil, err
+	}
+	if !canCheck(request.Operation, input.CheckerRole) {
+		err = errbank.NewErrForbidden("role " + input.CheckerRole + " cannot check " + request.Operation)
+		return nil, err
+	}
+
+	request.Checker = input.Checker
+	request.Note = input.Note
+	request.DecidedAt = &now
+	request.Status = status
+	expired := now.Sub(request.CreatedAt) > approvalExpiry()
+	if expired {
+		request.Status = consts.ApprovalStatusEXPIRED
+	}
+	err = b.repo.Approval.Update(ctx, *request, tx)
+	if err != nil {
+		log.Println(eventName, "Update", err)
+		return nil, err
+	}
+
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionDECIDEAPPROVAL,
+		Target: request.ID.String(),
+		Before: map[string]string{"status": consts.ApprovalStatusPENDING},
+		After:  request,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	if expired {
+		return request, errbank.NewErrConflict("approval request expired, the maker has to submit it again")
+	}
+	return request, nil
+}
+
+func (b *approvalBusiness) Reject(ctx context.Context, input entity.ReviewApproval) (*entity.ApprovalRequest, error) {
+	return b.decide(ctx, input, consts.ApprovalStatusREJECTED)
+}
+
+// Approve approves a pending request and invokes the business method it holds.
+// The outcome is recorded as EXECUTED or FAILED; a failed execution returns its
+// error along with the request.
+func (b *approvalBusiness) Approve(ctx context.Context, input entity.ReviewApproval) (*entity.ApprovalRequest, error) {
+	var (
+		eventName = "business.approval.approve"
+	)
+
+	request, err := b.decide(ctx, input, consts.ApprovalStatusAPPROVED)
+	if err != nil {
+		log.Println(eventName, err)
+		return request, err
+	}
+
+	// The request is approved, record the outcome even when the checker disconnects.
+	ctx = context.WithoutCancel(ctx)
+	result, execErr := b.execute(ctx, *request)
+	now := time.Now()
+	request.ExecutedAt = &now
+	request.Status = consts.ApprovalStatusEXECUTED
+	request.Result = result
+	if execErr != nil {
+		log.Println(eventName, "execute", request.ID, execErr)
+		request.Status = consts.ApprovalStatusFAILED
+		request.Message = truncate(execErr.Error(), approvalMaxMessage)
+	}
+	err = b.repo.Approval.Update(ctx, *request, nil)
+	if err != nil {
+		log.Println(eventName, "Update", err)
+		return request, err
+	}
+	return request, execErr
+}
+
+// execute invokes the business method held by an approved request.
+func (b *approvalBusiness) execute(ctx context.Context, request entity.ApprovalRequest) (interface{}, error) {
+	switch request.Operation {
+	case consts.ApprovalOperationUPDATEROLE:
+		var input entity.User
+		if err := json.Unmarshal(request.Payload, &input); err != nil {
+			return nil, err
+		}
+		return nil, b.users.UpdateRoleByUserName(ctx, input)
+	case consts.ApprovalOperationUPDATEINTERESTRATE:
+		var input entity.UpdateInterestRate
+		if err := json.Unmarshal(request.Payload, &input); err != nil {
+			return nil, err
+		}
+		return nil, b.account.UpdateInterestRate(ctx, input)
+	case consts.ApprovalOperationINTERESTPAYOUT:
+		return b.account.InterestPayout(ctx)
+	case consts.ApprovalOperationWITHDRAWAL:
+		var input entity.Withdrawal
+		if err := json.Unmarshal(request.Payload, &input); err != nil {
+			return nil, err
+		}
+		return nil, b.account.Withdrawal(ctx, input)
+	}
+	return nil, errbank.NewErrUnprocessableEntity("operation " + request.Operation + " does not support approvals")
+}
@@ -0,0 +1,59 @@
+package approvalbusiness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+)
+
+func TestRequiresApproval(t *testing.T) {
+	cases := []struct {
+		env       string
+		operation string
+		amount    float64
+		want      bool
+	}{
+		{"", consts.ApprovalOperationUPDATEROLE, 0, true},
+		{"", consts.ApprovalOperationWITHDRAWAL, 9999999.99, false},
+		{"", consts.ApprovalOperationWITHDRAWAL, 10000000, true},
+		{"withdrawal:500, interest_payout", consts.ApprovalOperationWITHDRAWAL, 500, true},
+		{"withdrawal:500, interest_payout", consts.ApprovalOperationINTERESTPAYOUT, 0, true},
+		{"withdrawal:500, interest_payout", consts.ApprovalOperationUPDATEROLE, 0, false},
+		{"WITHDRAWAL:abc", consts.ApprovalOperationWITHDRAWAL, 1, true},
+		{"NONE", consts.ApprovalOperationUPDATEROLE, 0, false},
+	}
+	b := &approvalBusiness{}
+	for _, c := range cases {
+		t.Setenv("APPROVAL_OPERATIONS", c.env)
+		if got := b.RequiresApproval(c.operation, c.amount); got != c.want {
+			t.Errorf("APPROVAL_OPERATIONS=%q RequiresApproval(%s, %v) = %v, want %v", c.env, c.operation, c.amount, got, c.want)
+		}
+	}
+}
+
+func TestCanCheck(t *testing.T) {
+	if canCheck(consts.ApprovalOperationUPDATEROLE, consts.RoleAdmin) {
+		t.Error("admin must not check role changes")
+	}
+	if !canCheck(consts.ApprovalOperationUPDATEROLE, consts.RoleSuperAdmin) {
+		t.Error("super admin must check role changes")
+	}
+	if canCheck(consts.ApprovalOperationWITHDRAWAL, consts.RoleCustomer) {
+		t.Error("customer must not check withdrawals")
+	}
+	if canCheck("UNKNOWN", consts.RoleSuperAdmin) {
+		t.Error("unknown operations cannot be checked")
+	}
+}
+
+func TestApprovalExpiry(t *testing.T) {
+	t.Setenv("APPROVAL_EXPIRY", "")
+	if got := approvalExpiry(); got != defaultApprovalExpiry {
+		t.Errorf("default expiry = %v", got)
+	}
+	t.Setenv("APPROVAL_EXPIRY", "30m")
+	if got := approvalExpiry(); got != 30*time.Minute {
+		t.Errorf("expiry = %v, want 30m", got)
+	}
+}
@@ -0,0 +1,255 @@
+package auditbusiness
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	"github.com/pkg/errors"
+)
+
+const (
+	auditBatchSize = 1000
+	auditMaxBreaks = 100
+)
+
+type AuditBusiness interface {
+	// Record appends within tx, the entry is only kept when tx commits. A nil tx
+	// appends on its own.
+	Record(ctx context.Context, record entity.AuditRecord, tx *sql.Tx) error
+	GetLogs(ctx context.Context, m meta.Metadata) ([]entity.AuditLog, error)
+	Verify(ctx context.Context) (*entity.AuditVerification, error)
+}
+
+type auditBusiness struct {
+	repo repo.Repo
+	db   *sql.DB
+}
+
+func NewAuditBusiness(db *sql.DB) AuditBusiness {
+	return &auditBusiness{
+		repo: repo.NewRepositories(db),
+		db:   db,
+	}
+}
+
+// hashEntry is the sha256 of the entry content and the hash of the entry
+// before it, so changing, removing or reordering an entry breaks every later link.
+func hashEntry(entry entity.AuditLog) (string, error) {
+	content, err := json.Marshal(struct {
+		Sequence  int64           `json:"sequence"`
+		PrevHash  string          `json:"prev_hash"`
+		Actor     string          `json:"actor"`
+		Action    string          `json:"action"`
+		Target    string          `json:"target"`
+		Before    json.RawMessage `json:"before"`
+		After     json.RawMessage `json:"after"`
+		IP        string          `json:"ip"`
+		CreatedAt string          `json:"created_at"`
+	}{
+		Sequence:  entry.Sequence,
+		PrevHash:  entry.PrevHash,
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Target:    entry.Target,
+		Before:    entry.Before,
+		After:     entry.After,
+		IP:        entry.IP,
+		CreatedAt: entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Record appends a privileged action to the audit log on behalf of the
+// authenticated user, or of the system when there is none.
+func (b *auditBusiness) Record(ctx context.Context, record entity.AuditRecord, tx *sql.Tx) error {
+	var (
+		eventName = "business.audit.record"
+		entry     = entity.AuditLog{
+			Actor:  consts.AuditActorSYSTEM,
+			Action: record.Action,
+			Target: record.Target,
+			// Postgres keeps microseconds, hash what is read back.
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		}
+	)
+
+	if actor, _ := ctx.Value(middleware.CtxValueUserName).(string); actor != "" {
+		entry.Actor = actor
+	}
+	entry.IP, _ = ctx.Value(middleware.CtxValueIP).(string)
+
+	before, err := json.Marshal(record.Before)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	after, err := json.Marshal(record.After)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	entry.Before = before
+	entry.After = after
+
+	if tx != nil {
+		return b.append(ctx, entry, tx)
+	}
+
+	tx, err = b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.append(ctx, entry, tx)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	return nil
+}
+
+// append links entry to the last one and stores it within tx. The chain stays
+// locked until tx ends.
+func (b *auditBusiness) append(ctx context.Context, entry entity.AuditLog, tx *sql.Tx) error {
+	var (
+		eventName = "business.audit.append"
+	)
+
+	err := b.repo.Audit.LockChain(ctx, tx)
+	if err != nil {
+		log.Println(eventName, "LockChain", err)
+		return err
+	}
+	last, err := b.repo.Audit.FindLast(ctx, tx)
+	switch errors.Cause(err).(type) {
+	case nil:
+		entry.Sequence = last.Sequence + 1
+		entry.PrevHash = last.Hash
+	case errbank.ErrNotFound:
+		entry.Sequence = 1
+		entry.PrevHash = consts.AuditGenesisHash
+	default:
+		log.Println(eventName, "FindLast", err)
+		return err
+	}
+
+	entry.Hash, err = hashEntry(entry)
+	if err != nil {
+		log.Println(eventName, "hashEntry", err)
+		return err
+	}
+	err = b.repo.Audit.Create(ctx, entry, tx)
+	if err != nil {
+		log.Println(eventName, "Create", err)
+		return err
+	}
+	return nil
+}
+
+func (b *auditBusiness) GetLogs(ctx context.Context, m meta.Metadata) ([]entity.AuditLog, error) {
+	var eventName = "business.audit.get_logs"
+
+	logs, err := b.repo.Audit.GetList(ctx, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return logs, nil
+}
+
+// chainVerifier walks the log in sequence order and reports every entry that
+// does not follow its predecessor.
+type chainVerifier struct {
+	sequence int64
+	hash     string
+	report   entity.AuditVerification
+}
+
+func newChainVerifier() *chainVerifier {
+	return &chainVerifier{
+		hash:   consts.AuditGenesisHash,
+		report: entity.AuditVerification{Valid: true},
+	}
+}
+
+func (v *chainVerifier) fail(sequence int64, reason string) {
+	v.report.Valid = false
+	if len(v.report.Breaks) < auditMaxBreaks {
+		v.report.Breaks = append(v.report.Breaks, entity.AuditChainBreak{Sequence: sequence, Reason: reason})
+	}
+}
+
+func (v *chainVerifier) check(entry entity.AuditLog) {
+	v.report.Checked++
+	if entry.Sequence != v.sequence+1 {
+		v.fail(entry.Sequence, fmt.Sprintf("expected sequence %d, entries are missing", v.sequence+1))
+	}
+	if entry.PrevHash != v.hash {
+		v.fail(entry.Sequence, "previous hash does not match the hash of the previous entry")
+	}
+	hash, err := hashEntry(entry)
+	if err != nil || hash != entry.Hash {
+		v.fail(entry.Sequence, "hash does not match the entry content")
+	}
+	v.sequence = entry.Sequence
+	v.hash = entry.Hash
+	v.report.LastHash = entry.Hash
+}
+
+// Verify recomputes the hash chain from the first entry and reports the breaks.
+func (b *auditBusiness) Verify(ctx context.Context) (*entity.AuditVerification, error) {
+	var (
+		eventName = "business.audit.verify"
+		verifier  = newChainVerifier()
+	)
+
+	for {
+		logs, err := b.repo.Audit.GetBatch(ctx, verifier.sequence, auditBatchSize)
+		if err != nil {
+			log.Println(eventName, "GetBatch", err)
+			return nil, err
+		}
+		if len(logs) == 0 {
+			break
+		}
+		for _, entry := range logs {
+			verifier.check(entry)
+		}
+	}
+	if !verifier.report.Valid {
+		log.Printf("%s AUDIT CHAIN BROKEN %d breaks in %d entries", eventName, len(verifier.report.Breaks), verifier.report.Checked)
+	}
+	return &verifier.report, nil
+}
@@ -0,0 +1,90 @@
+package auditbusiness
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+func testChain(t *testing.T) []entity.AuditLog {
+	var (
+		prev  = consts.AuditGenesisHash
+		start = time.Date(2024, 3, 1, 8, 0, 0, 123456000, time.UTC)
+		chain []entity.AuditLog
+	)
+	for i, action := range []string{consts.AuditActionCREATEUSER, consts.AuditActionUPDATEROLE, consts.AuditActionUPDATEINTERESTRATE} {
+		entry := entity.AuditLog{
+			Sequence:  int64(i + 1),
+			Actor:     "root_admin",
+			Action:    action,
+			Target:    "user:jane_doe",
+			Before:    json.RawMessage(`{"role":"customer"}`),
+			After:     json.RawMessage(`{"role":"admin"}`),
+			IP:        "10.0.0.1",
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+			PrevHash:  prev,
+		}
+		hash, err := hashEntry(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Hash = hash
+		prev = hash
+		chain = append(chain, entry)
+	}
+	return chain
+}
+
+func verify(chain []entity.AuditLog) entity.AuditVerification {
+	verifier := newChainVerifier()
+	for _, entry := range chain {
+		verifier.check(entry)
+	}
+	return verifier.report
+}
+
+func TestHashEntryTimeZone(t *testing.T) {
+	entry := testChain(t)[0]
+	local := entry
+	local.CreatedAt = entry.CreatedAt.In(time.FixedZone("WIB", 7*3600))
+	a, _ := hashEntry(entry)
+	b, _ := hashEntry(local)
+	if a != b {
+		t.Error("hash depends on the time zone the entry is read in")
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	chain := testChain(t)
+	report := verify(chain)
+	if !report.Valid || report.Checked != 3 || len(report.Breaks) != 0 || report.LastHash != chain[2].Hash {
+		t.Fatalf("unexpected report for an intact chain %+v", report)
+	}
+
+	cases := map[string]struct {
+		tamper   func([]entity.AuditLog) []entity.AuditLog
+		sequence int64
+	}{
+		"changed content": {func(c []entity.AuditLog) []entity.AuditLog {
+			c[1].After = json.RawMessage(`{"role":"super_admin"}`)
+			return c
+		}, 2},
+		"removed entry": {func(c []entity.AuditLog) []entity.AuditLog {
+			return append(c[:1], c[2:]...)
+		}, 3},
+		"rewritten entry": {func(c []entity.AuditLog) []entity.AuditLog {
+			c[0].Actor = "someone_else"
+			c[0].Hash, _ = hashEntry(c[0])
+			return c
+		}, 2},
+	}
+	for name, c := range cases {
+		report := verify(c.tamper(testChain(t)))
+		if report.Valid || len(report.Breaks) == 0 || report.Breaks[0].Sequence != c.sequence {
+			t.Errorf("%s: expected a break at %d, got %+v", name, c.sequence, report)
+		}
+	}
+}
@@ -2,25 +2,101 @@ package authorizationbusiness
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"log"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	notificationbusiness "github.com/hanselacn/banking-transaction/internal/business/notification_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
 	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/hashx"
 	"github.com/hanselacn/banking-transaction/internal/repo"
+	"github.com/pkg/errors"
 )
 
+const (
+	defaultPasswordHistorySize = 5
+	defaultPasswordResetTTL    = 30 * time.Minute
+	passwordResetTokenBytes    = 32
+)
+
+// passwordHistorySize is how many of the latest passwords, the current one
+// included, cannot be set again.
+func passwordHistorySize() int {
+	v, err := strconv.Atoi(os.Getenv("PASSWORD_HISTORY_SIZE"))
+	if err != nil || v < 1 {
+		return defaultPasswordHistorySize
+	}
+	return v
+}
+
+func passwordResetTTL() time.Duration {
+	v, err := time.ParseDuration(os.Getenv("PASSWORD_RESET_TOKEN_TTL"))
+	if err != nil || v <= 0 {
+		return defaultPasswordResetTTL
+	}
+	return v
+}
+
+// newResetToken returns a random url safe token and the sha256 stored for it.
+func newResetToken() (string, string, error) {
+	b := make([]byte, passwordResetTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashResetToken(token), nil
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// reused returns whether password matches one of the password hashes.
+func reused(password string, hashes []string) bool {
+	for _, hash := range hashes {
+		if hashx.CheckPasswordHash(password, hash) {
+			return true
+		}
+	}
+	return false
+}
+
 type AuthorizationBusiness interface {
 	ChangePassword(ctx context.Context, input entity.ChangePasswordInput) error
+	ForgotPassword(ctx context.Context, input entity.RequestPasswordReset) error
+	AdminResetPassword(ctx context.Context, input entity.RequestPasswordReset) (*entity.PasswordResetToken, error)
+	ResetPassword(ctx context.Context, input entity.ResetPasswordInput) error
 }
 
 type authorizationBusiness struct {
-	repo repo.Repo
+	repo         repo.Repo
+	db           *sql.DB
+	audit        auditbusiness.AuditBusiness
+	notification notificationbusiness.NotificationBusiness
 }
 
 func NewAuthorizationBusiness(db *sql.DB) AuthorizationBusiness {
-	return &authorizationBusiness{repo: repo.NewRepositories(db)}
+	return &authorizationBusiness{
+		repo:         repo.NewRepositories(db),
+		db:           db,
+		audit:        auditbusiness.NewAuditBusiness(db),
+		notification: notificationbusiness.NewNotificationBusiness(db),
+	}
 }
 
+// ChangePassword sets a new password for a user who knows the current one.
 func (b *authorizationBusiness) ChangePassword(ctx context.Context, input entity.ChangePasswordInput) error {
 	var (
 		eventName = "business.authorization.change_password"
@@ -30,12 +106,250 @@ func (b *authorizationBusiness) ChangePassword(ctx context.Context, input entity
 		log.Println(eventName, err)
 		return err
 	}
+	auth, err := b.repo.Authorization.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	if !hashx.CheckPasswordHash(input.OldPassword, auth.Password) {
+		return errbank.NewErrForbidden("old password is wrong")
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.setPassword(ctx, *auth, input.NewPassword, tx)
+	if err != nil {
+		log.Println(eventName, "setPassword", err)
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+
+	b.notification.Notify(ctx, entity.NotificationInput{
+		UserID: user.ID,
+		Type:   consts.NotificationTypePASSWORDCHANGED,
+	})
+	return nil
+}
+
+// setPassword checks password against the history of the user, then stores its
+// hash and spends the pending reset tokens, within tx.
+func (b *authorizationBusiness) setPassword(ctx context.Context, auth entity.Authorization, password string, tx *sql.Tx) error {
+	size := passwordHistorySize()
+	history, err := b.repo.Authorization.GetPasswordHistory(ctx, auth.UserID, size)
+	if err != nil {
+		return err
+	}
+	if reused(password, append([]string{auth.Password}, history...)) {
+		return errbank.NewErrUnprocessableEntity("new_password: cannot be one of the last " + strconv.Itoa(size) + " passwords")
+	}
+
+	hash, err := hashx.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	auth.Password = hash
+	if err := b.repo.Authorization.UpdatePassword(ctx, auth, tx); err != nil {
+		return err
+	}
+	if err := b.repo.Authorization.CreatePasswordHistory(ctx, auth.UserID, hash, tx); err != nil {
+		return err
+	}
+	return b.repo.Authorization.RevokeResetTokens(ctx, auth.UserID, tx)
+}
+
+// issueResetToken replaces the pending reset tokens of user with a new one and
+// sends it to the user on the notification channels. The token is audited
+// with it when audited is set.
+func (b *authorizationBusiness) issueResetToken(ctx context.Context, user entity.User, requestedBy string, audited bool) (*entity.PasswordResetToken, error) {
+	var (
+		eventName = "business.authorization.issue_reset_token"
+		now       = time.Now()
+	)
+
+	token, tokenHash, err := newResetToken()
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	resetToken := entity.PasswordResetToken{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		TokenHash:   tokenHash,
+		RequestedBy: requestedBy,
+		ExpiresAt:   now.Add(passwordResetTTL()),
+		CreatedAt:   now,
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.Authorization.RevokeResetTokens(ctx, user.ID, tx)
+	if err != nil {
+		log.Println(eventName, "RevokeResetTokens", err)
+		return nil, err
+	}
+	err = b.repo.Authorization.CreateResetToken(ctx, resetToken, tx)
+	if err != nil {
+		log.Println(eventName, "CreateResetToken", err)
+		return nil, err
+	}
+	if audited {
+		err = b.audit.Record(ctx, entity.AuditRecord{
+			Action: consts.AuditActionRESETPASSWORD,
+			Target: user.Username,
+			After:  resetToken,
+		}, tx)
+		if err != nil {
+			log.Println(eventName, "audit", err)
+			return nil, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+
+	b.notification.Notify(ctx, entity.NotificationInput{
+		UserID: user.ID,
+		Type:   consts.NotificationTypePASSWORDRESET,
+		Data: map[string]string{
+			"Token":     token,
+			"ExpiresAt": resetToken.ExpiresAt.Format("2006-01-02 15:04"),
+		},
+	})
+	return &resetToken, nil
+}
+
+// ForgotPassword sends a reset token to the user. An unknown user name is not
+// reported, so the answer does not tell which users exist.
+func (b *authorizationBusiness) ForgotPassword(ctx context.Context, input entity.RequestPasswordReset) error {
+	var (
+		eventName = "business.authorization.forgot_password"
+	)
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		if _, ok := errors.Cause(err).(errbank.ErrNotFound); ok {
+			return nil
+		}
+		log.Println(eventName, err)
+		return err
+	}
+	if _, err := b.issueResetToken(ctx, *user, input.Username, false); err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	return nil
+}
 
-	if err := b.repo.Authorization.UpdatePassword(ctx, entity.Authorization{
-		UserID:   user.ID,
-		Password: input.Password,
-	}); err != nil {
+// AdminResetPassword sends a reset token to a user on behalf of an admin.
+// Admins only reset the password of customers, super admins of anyone.
+func (b *authorizationBusiness) AdminResetPassword(ctx context.Context, input entity.RequestPasswordReset) (*entity.PasswordResetToken, error) {
+	var (
+		eventName = "business.authorization.admin_reset_password"
+	)
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	role, _ := ctx.Value(middleware.CtxValueRole).(string)
+	if role != consts.RoleSuperAdmin && user.Role != consts.RoleCustomer {
+		return nil, errbank.NewErrForbidden("only a super admin resets the password of " + user.Role)
+	}
+
+	resetToken, err := b.issueResetToken(ctx, *user, input.RequestedBy, true)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return resetToken, nil
+}
+
+// ResetPassword sets a new password with a reset token. The token is spent
+// only when the password is set.
+func (b *authorizationBusiness) ResetPassword(ctx context.Context, input entity.ResetPasswordInput) error {
+	var (
+		eventName = "business.authorization.reset_password"
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	resetToken, err := b.repo.Authorization.UseResetToken(ctx, hashResetToken(input.Token), tx)
+	if err != nil {
+		log.Println(eventName, "UseResetToken", err)
+		if _, ok := errors.Cause(err).(errbank.ErrNotFound); ok {
+			err = errbank.NewErrForbidden("reset token is invalid or expired")
+		}
+		return err
+	}
+	auth, err := b.repo.Authorization.FindByUserID(ctx, resetToken.UserID)
+	if err != nil {
+		log.Println(eventName, err)
 		return err
 	}
+	err = b.setPassword(ctx, *auth, input.NewPassword, tx)
+	if err != nil {
+		log.Println(eventName, "setPassword", err)
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+
+	b.notification.Notify(ctx, entity.NotificationInput{
+		UserID: resetToken.UserID,
+		Type:   consts.NotificationTypePASSWORDCHANGED,
+	})
 	return nil
 }
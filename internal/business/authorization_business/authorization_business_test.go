@@ -0,0 +1,45 @@
+package authorizationbusiness
+
+import (
+	"testing"
+
+	"github.com/hanselacn/banking-transaction/internal/pkg/hashx"
+)
+
+func TestReused(t *testing.T) {
+	var hashes []string
+	for _, password := range []string{"Old#Pass1", "Older#Pass2"} {
+		hash, err := hashx.HashPassword(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	if !reused("Older#Pass2", hashes) {
+		t.Error("a password of the history is not reported as reused")
+	}
+	if reused("New#Pass3", hashes) {
+		t.Error("a new password is reported as reused")
+	}
+	if reused("Old#Pass1", nil) {
+		t.Error("a password is reported as reused without history")
+	}
+}
+
+func TestNewResetToken(t *testing.T) {
+	token, hash, err := newResetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != hashResetToken(token) || hash == token {
+		t.Errorf("hash %q does not match token %q", hash, token)
+	}
+	other, _, err := newResetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == token {
+		t.Error("two reset tokens are equal")
+	}
+}
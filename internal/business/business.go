@@ -4,14 +4,50 @@ import (
 	"database/sql"
 
 	accountbusiness "github.com/hanselacn/banking-transaction/internal/business/account_business"
+	amlbusiness "github.com/hanselacn/banking-transaction/internal/business/aml_business"
+	approvalbusiness "github.com/hanselacn/banking-transaction/internal/business/approval_business"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
 	authorizationbusiness "github.com/hanselacn/banking-transaction/internal/business/authorization_business"
+	feebusiness "github.com/hanselacn/banking-transaction/internal/business/fee_business"
+	fraudbusiness "github.com/hanselacn/banking-transaction/internal/business/fraud_business"
+	integritybusiness "github.com/hanselacn/banking-transaction/internal/business/integrity_business"
+	kycbusiness "github.com/hanselacn/banking-transaction/internal/business/kyc_business"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	loanbusiness "github.com/hanselacn/banking-transaction/internal/business/loan_business"
+	notificationbusiness "github.com/hanselacn/banking-transaction/internal/business/notification_business"
+	outboxbusiness "github.com/hanselacn/banking-transaction/internal/business/outbox_business"
+	payrollbusiness "github.com/hanselacn/banking-transaction/internal/business/payroll_business"
+	sanctionsbusiness "github.com/hanselacn/banking-transaction/internal/business/sanctions_business"
+	standingorderbusiness "github.com/hanselacn/banking-transaction/internal/business/standing_order_business"
+	statementbusiness "github.com/hanselacn/banking-transaction/internal/business/statement_business"
+	streambusiness "github.com/hanselacn/banking-transaction/internal/business/stream_business"
+	taxbusiness "github.com/hanselacn/banking-transaction/internal/business/tax_business"
 	usersbusiness "github.com/hanselacn/banking-transaction/internal/business/users_business"
+	webhookbusiness "github.com/hanselacn/banking-transaction/internal/business/webhook_business"
 )
 
 type Business struct {
 	AccountBusiness       accountbusiness.AccountBusiness
 	UserBusiness          usersbusiness.UsersBusiness
 	AuthorizationBusiness authorizationbusiness.AuthorizationBusiness
+	TaxBusiness           taxbusiness.TaxBusiness
+	FeeBusiness           feebusiness.FeeBusiness
+	LoanBusiness          loanbusiness.LoanBusiness
+	StandingOrderBusiness standingorderbusiness.StandingOrderBusiness
+	LedgerBusiness        ledgerbusiness.LedgerBusiness
+	IntegrityBusiness     integritybusiness.IntegrityBusiness
+	StatementBusiness     statementbusiness.StatementBusiness
+	PayrollBusiness       payrollbusiness.PayrollBusiness
+	ApprovalBusiness      approvalbusiness.ApprovalBusiness
+	AuditBusiness         auditbusiness.AuditBusiness
+	OutboxBusiness        outboxbusiness.OutboxBusiness
+	WebhookBusiness       webhookbusiness.WebhookBusiness
+	StreamBusiness        streambusiness.StreamBusiness
+	NotificationBusiness  notificationbusiness.NotificationBusiness
+	KYCBusiness           kycbusiness.KYCBusiness
+	FraudBusiness         fraudbusiness.FraudBusiness
+	AMLBusiness           amlbusiness.AMLBusiness
+	SanctionsBusiness     sanctionsbusiness.SanctionsBusiness
 }
 
 func NewBusiness(db *sql.DB) Business {
@@ -19,5 +55,23 @@ func NewBusiness(db *sql.DB) Business {
 		AccountBusiness:       accountbusiness.NewAccountBusiness(db),
 		UserBusiness:          usersbusiness.NewUsersBusiness(db),
 		AuthorizationBusiness: authorizationbusiness.NewAuthorizationBusiness(db),
+		TaxBusiness:           taxbusiness.NewTaxBusiness(db),
+		FeeBusiness:           feebusiness.NewFeeBusiness(db),
+		LoanBusiness:          loanbusiness.NewLoanBusiness(db),
+		StandingOrderBusiness: standingorderbusiness.NewStandingOrderBusiness(db),
+		LedgerBusiness:        ledgerbusiness.NewLedgerBusiness(db),
+		IntegrityBusiness:     integritybusiness.NewIntegrityBusiness(db),
+		StatementBusiness:     statementbusiness.NewStatementBusiness(db),
+		PayrollBusiness:       payrollbusiness.NewPayrollBusiness(db),
+		ApprovalBusiness:      approvalbusiness.NewApprovalBusiness(db),
+		AuditBusiness:         auditbusiness.NewAuditBusiness(db),
+		OutboxBusiness:        outboxbusiness.NewOutboxBusiness(db),
+		WebhookBusiness:       webhookbusiness.NewWebhookBusiness(db),
+		StreamBusiness:        streambusiness.NewStreamBusiness(db),
+		NotificationBusiness:  notificationbusiness.NewNotificationBusiness(db),
+		KYCBusiness:           kycbusiness.NewKYCBusiness(db),
+		FraudBusiness:         fraudbusiness.NewFraudBusiness(db),
+		AMLBusiness:           amlbusiness.NewAMLBusiness(db),
+		SanctionsBusiness:     sanctionsbusiness.NewSanctionsBusiness(db),
 	}
 }
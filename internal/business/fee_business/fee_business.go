@@ -0,0 +1,455 @@
+package feebusiness
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+const feeBatchSize = 500
+
+type FeeBusiness interface {
+	ChargeTransactionFees(ctx context.Context, account *entity.Account, action string, tx *sql.Tx) ([]entity.Fee, error)
+	MonthlyFeeWorker(ctx context.Context) (int, int, error)
+	GetFeeSchedules(ctx context.Context) ([]entity.FeeSchedule, error)
+	UpdateFeeSchedule(ctx context.Context, input entity.FeeSchedule) error
+	GetAccountFees(ctx context.Context, username string, m meta.Metadata) ([]entity.Fee, error)
+	WaiveFee(ctx context.Context, input entity.WaiveFee) error
+}
+
+type feeBusiness struct {
+	repo   repo.Repo
+	db     *sql.DB
+	ledger ledgerbusiness.LedgerBusiness
+	audit  auditbusiness.AuditBusiness
+}
+
+func NewFeeBusiness(db *sql.DB) FeeBusiness {
+	return &feeBusiness{
+		repo:   repo.NewRepositories(db),
+		db:     db,
+		ledger: ledgerbusiness.NewLedgerBusiness(db),
+		audit:  auditbusiness.NewAuditBusiness(db),
+	}
+}
+
+func monthRange(t time.Time) meta.DateRange {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return meta.DateRange{
+		Field: "created_at",
+		Start: start,
+		End:   start.AddDate(0, 1, 0),
+	}
+}
+
+// ChargeTransactionFees charges the fees triggered by a posting of action on the account
+// and deducts them from account.Balance. The caller persists the balance within tx.
+func (b *feeBusiness) ChargeTransactionFees(ctx context.Context, account *entity.Account, action string, tx *sql.Tx) ([]entity.Fee, error) {
+	var (
+		eventName = "business.fee.charge_transaction_fees"
+		now       = time.Now()
+		fees      []entity.Fee
+	)
+
+	if action == consts.TxActionWITHDRAWAL {
+		schedule, err := b.repo.Fee.FindScheduleByCode(ctx, consts.FeeCodeWITHDRAWAL)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, err
+		}
+		if schedule.Active && schedule.Amount > 0 {
+			total, err := b.repo.Transaction.CountByAccountIDAndAction(ctx, account.ID, consts.TxActionWITHDRAWAL, monthRange(now), tx)
+			if err != nil {
+				log.Println(eventName, err)
+				return nil, err
+			}
+			if total >= schedule.FreeCount {
+				fee, err := b.chargeFee(ctx, account, *schedule, now, tx)
+				if err != nil {
+					log.Println(eventName, err)
+					return nil, err
+				}
+				if fee != nil {
+					fees = append(fees, *fee)
+				}
+			}
+		}
+	}
+
+	// A fee never blocks incoming funds: an account left below the minimum by a
+	// credit is charged by the monthly fee job instead.
+	if isCredit(action) {
+		return fees, nil
+	}
+	fee, err := b.chargeBelowMinBalanceFee(ctx, account, now, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if fee != nil {
+		fees = append(fees, *fee)
+	}
+	return fees, nil
+}
+
+// isCredit returns whether a posting of action adds funds to the account.
+func isCredit(action string) bool {
+	return action == consts.TxActionDEPOSIT || action == consts.TxActionPAYROLL
+}
+
+func (b *feeBusiness) chargeBelowMinBalanceFee(ctx context.Context, account *entity.Account, now time.Time, tx *sql.Tx) (*entity.Fee, error) {
+	schedule, err := b.repo.Fee.FindScheduleByCode(ctx, consts.FeeCodeBELOWMINBALANCE)
+	if err != nil {
+		return nil, err
+	}
+	if !schedule.Active || schedule.Amount <= 0 || account.Balance >= schedule.MinBalance {
+		return nil, nil
+	}
+	return b.chargeFee(ctx, account, *schedule, now, tx)
+}
+
+// chargeFee debits the schedule amount from the account. It returns nil fee when
+// a monthly fee was already charged for the current period.
+func (b *feeBusiness) chargeFee(ctx context.Context, account *entity.Account, schedule entity.FeeSchedule, now time.Time, tx *sql.Tx) (*entity.Fee, error) {
+	if schedule.Amount > account.AvailableBalance() {
+		return nil, errbank.NewErrUnprocessableEntity("insufficient balance to cover " + schedule.Code + " fee")
+	}
+
+	fee := entity.Fee{
+		ID:            uuid.New(),
+		AccountID:     account.ID,
+		TransactionID: uuid.New(),
+		Code:          schedule.Code,
+		Amount:        schedule.Amount,
+		Period:        now.Format("2006-01"),
+		Status:        consts.FeeStatusCHARGED,
+	}
+	created, err := b.repo.Fee.Create(ctx, fee, tx)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, nil
+	}
+
+	// The fee transaction lives in the caller's tx, so a rolled back operation
+	// leaves no fee behind.
+	transaction := entity.Transaction{
+		ID:        fee.TransactionID,
+		AccountID: account.ID,
+		Type:      consts.TxTypeDEBIT,
+		Amount:    fee.Amount,
+		Action:    consts.TxActionFEE,
+		Status:    consts.TxStatusCOMPLETED,
+	}
+	err = b.repo.Transaction.CreateTransaction(ctx, transaction, tx)
+	if err != nil {
+		return nil, err
+	}
+	err = b.ledger.PostTransaction(ctx, transaction, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Balance -= fee.Amount
+	return &fee, nil
+}
+
+// MonthlyFeeWorker charges the maintenance and below-minimum-balance fees of the
+// current month to every account, one transaction per account.
+func (b *feeBusiness) MonthlyFeeWorker(ctx context.Context) (int, int, error) {
+	var (
+		eventName    = "business.fee.monthly_fee_worker"
+		lastID       = uuid.Nil
+		totalData    = 0
+		totalSuccess = 0
+	)
+
+	for {
+		accounts, err := b.repo.Account.GetAccountBatch(ctx, lastID, feeBatchSize)
+		if err != nil {
+			log.Println(eventName, err)
+			return totalData, totalSuccess, err
+		}
+		for i := range accounts {
+			totalData++
+			if err := b.chargeMonthlyFees(ctx, accounts[i].ID); err != nil {
+				log.Println(eventName, accounts[i].ID, err)
+				continue
+			}
+			totalSuccess++
+		}
+		if len(accounts) < feeBatchSize {
+			return totalData, totalSuccess, nil
+		}
+		lastID = accounts[len(accounts)-1].ID
+	}
+}
+
+func (b *feeBusiness) chargeMonthlyFees(ctx context.Context, accountID uuid.UUID) error {
+	var (
+		eventName = "business.fee.charge_monthly_fees"
+		now       = time.Now()
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, accountID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	balance := account.Balance
+
+	_, err = b.chargeBelowMinBalanceFee(ctx, account, now, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	schedule, err := b.repo.Fee.FindScheduleByCode(ctx, consts.FeeCodeMAINTENANCE)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	if schedule.Active && schedule.Amount > 0 {
+		_, err = b.chargeFee(ctx, account, *schedule, now, tx)
+		if err != nil {
+			log.Println(eventName, err)
+			return err
+		}
+	}
+
+	if account.Balance != balance {
+		err = b.repo.Account.UpdateBalance(ctx, *account, tx)
+		if err != nil {
+			log.Println(eventName, err)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	return nil
+}
+
+func (b *feeBusiness) GetFeeSchedules(ctx context.Context) ([]entity.FeeSchedule, error) {
+	var (
+		eventName = "business.fee.get_fee_schedules"
+	)
+	schedules, err := b.repo.Fee.GetListSchedule(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (b *feeBusiness) UpdateFeeSchedule(ctx context.Context, input entity.FeeSchedule) error {
+	var (
+		eventName = "business.fee.update_fee_schedule"
+	)
+	before, err := b.repo.Fee.FindScheduleByCode(ctx, input.Code)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.Fee.UpdateSchedule(ctx, input, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionUPDATEFEESCHEDULE,
+		Target: input.Code,
+		Before: before,
+		After:  input,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	return nil
+}
+
+func (b *feeBusiness) GetAccountFees(ctx context.Context, username string, m meta.Metadata) ([]entity.Fee, error) {
+	var (
+		eventName = "business.fee.get_account_fees"
+	)
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	fees, err := b.repo.Fee.GetListByAccountID(ctx, account.ID, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return fees, nil
+}
+
+// WaiveFee refunds a charged fee to the account with a credit FEE transaction.
+func (b *feeBusiness) WaiveFee(ctx context.Context, input entity.WaiveFee) error {
+	var (
+		eventName = "business.fee.waive_fee"
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	fee, err := b.waiveFee(ctx, input, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionWAIVEFEE,
+		Target: fee.ID.String(),
+		Before: map[string]string{"status": consts.FeeStatusCHARGED},
+		After:  fee,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	return nil
+}
+
+// waiveFee marks the fee waived and credits it back to the account within tx.
+func (b *feeBusiness) waiveFee(ctx context.Context, input entity.WaiveFee, tx *sql.Tx) (*entity.Fee, error) {
+	var (
+		eventName = "business.fee.waive_fee"
+	)
+
+	fee, err := b.repo.Fee.FindByIDForUpdate(ctx, input.FeeID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if fee.Status == consts.FeeStatusWAIVED {
+		return nil, errbank.NewErrConflict("fee already waived")
+	}
+
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, fee.AccountID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	refund := entity.Transaction{
+		ID:        uuid.New(),
+		AccountID: account.ID,
+		Type:      consts.TxTypeCREDIT,
+		Amount:    fee.Amount,
+		Action:    consts.TxActionFEE,
+		Status:    consts.TxStatusCOMPLETED,
+	}
+	err = b.repo.Transaction.CreateTransaction(ctx, refund, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	account.Balance += fee.Amount
+	err = b.repo.Account.UpdateBalance(ctx, *account, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateBalance", err)
+		return nil, err
+	}
+
+	fee.Status = consts.FeeStatusWAIVED
+	fee.WaivedBy = input.WaivedBy
+	fee.WaiveReason = input.Reason
+	fee.WaiveTransactionID = &refund.ID
+	err = b.repo.Fee.UpdateWaived(ctx, *fee, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateWaived", err)
+		return nil, err
+	}
+
+	err = b.ledger.PostTransaction(ctx, refund, tx)
+	if err != nil {
+		log.Println(eventName, "PostTransaction", err)
+		return nil, err
+	}
+	return fee, nil
+}
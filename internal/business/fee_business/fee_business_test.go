@@ -0,0 +1,217 @@
+package feebusiness
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	accountrepo "github.com/hanselacn/banking-transaction/internal/repo/account_repo"
+	feerepo "github.com/hanselacn/banking-transaction/internal/repo/fee_repo"
+	transactionrepo "github.com/hanselacn/banking-transaction/internal/repo/transaction_repo"
+	"github.com/pkg/errors"
+)
+
+type fakeFeeRepo struct {
+	feerepo.FeeRepositories
+	schedules map[string]entity.FeeSchedule
+	fees      map[uuid.UUID]entity.Fee
+	created   []entity.Fee
+}
+
+func (f *fakeFeeRepo) FindScheduleByCode(ctx context.Context, code string) (*entity.FeeSchedule, error) {
+	schedule := f.schedules[code]
+	return &schedule, nil
+}
+
+func (f *fakeFeeRepo) Create(ctx context.Context, fee entity.Fee, tx *sql.Tx) (bool, error) {
+	f.created = append(f.created, fee)
+	return true, nil
+}
+
+func (f *fakeFeeRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Fee, error) {
+	fee, ok := f.fees[id]
+	if !ok {
+		return nil, errbank.NewErrNotFound("fee not found")
+	}
+	return &fee, nil
+}
+
+func (f *fakeFeeRepo) UpdateWaived(ctx context.Context, fee entity.Fee, tx *sql.Tx) error {
+	f.fees[fee.ID] = fee
+	return nil
+}
+
+type fakeTransactionRepo struct {
+	transactionrepo.TransactionRepo
+	withdrawals  int
+	transactions []entity.Transaction
+}
+
+func (f *fakeTransactionRepo) CountByAccountIDAndAction(ctx context.Context, accountID uuid.UUID, action string, dr meta.DateRange, tx *sql.Tx) (int, error) {
+	return f.withdrawals, nil
+}
+
+func (f *fakeTransactionRepo) CreateTransaction(ctx context.Context, input entity.Transaction, tx *sql.Tx) error {
+	f.transactions = append(f.transactions, input)
+	return nil
+}
+
+type fakeAccountRepo struct {
+	accountrepo.AccountRepositories
+	account entity.Account
+}
+
+func (f *fakeAccountRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Account, error) {
+	account := f.account
+	return &account, nil
+}
+
+func (f *fakeAccountRepo) UpdateBalance(ctx context.Context, account entity.Account, tx *sql.Tx) error {
+	f.account = account
+	return nil
+}
+
+type fakeLedger struct {
+	ledgerbusiness.LedgerBusiness
+	posted []entity.Transaction
+}
+
+func (f *fakeLedger) PostTransaction(ctx context.Context, transaction entity.Transaction, tx *sql.Tx) error {
+	f.posted = append(f.posted, transaction)
+	return nil
+}
+
+func newTestBusiness(fees *fakeFeeRepo, transactions *fakeTransactionRepo, accounts *fakeAccountRepo) *feeBusiness {
+	return &feeBusiness{
+		repo:   repo.Repo{Fee: fees, Transaction: transactions, Account: accounts},
+		ledger: &fakeLedger{},
+	}
+}
+
+func TestChargeTransactionFees(t *testing.T) {
+	schedules := map[string]entity.FeeSchedule{
+		consts.FeeCodeWITHDRAWAL:      {Code: consts.FeeCodeWITHDRAWAL, Amount: 5000, FreeCount: 3, Active: true},
+		consts.FeeCodeBELOWMINBALANCE: {Code: consts.FeeCodeBELOWMINBALANCE, Amount: 10000, MinBalance: 50000, Active: true},
+	}
+
+	cases := []struct {
+		name        string
+		action      string
+		balance     float64
+		withdrawals int
+		want        []string
+		wantBalance float64
+		wantErr     bool
+	}{
+		{name: "free withdrawal", action: consts.TxActionWITHDRAWAL, balance: 100000, withdrawals: 2, wantBalance: 100000},
+		{name: "withdrawal over the free count", action: consts.TxActionWITHDRAWAL, balance: 100000, withdrawals: 3, want: []string{consts.FeeCodeWITHDRAWAL}, wantBalance: 95000},
+		{name: "withdrawal fee and below minimum", action: consts.TxActionWITHDRAWAL, balance: 52000, withdrawals: 5, want: []string{consts.FeeCodeWITHDRAWAL, consts.FeeCodeBELOWMINBALANCE}, wantBalance: 37000},
+		{name: "transfer left below minimum", action: consts.TxActionTRANSFER, balance: 20000, want: []string{consts.FeeCodeBELOWMINBALANCE}, wantBalance: 10000},
+		{name: "transfer left above minimum", action: consts.TxActionTRANSFER, balance: 60000, wantBalance: 60000},
+		{name: "debit cannot cover the fee", action: consts.TxActionTRANSFER, balance: 5000, wantErr: true},
+		{name: "deposit left below minimum", action: consts.TxActionDEPOSIT, balance: 5000, wantBalance: 5000},
+		{name: "payroll left below minimum", action: consts.TxActionPAYROLL, balance: 20000, wantBalance: 20000},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				fees         = &fakeFeeRepo{schedules: schedules}
+				transactions = &fakeTransactionRepo{withdrawals: c.withdrawals}
+				b            = newTestBusiness(fees, transactions, &fakeAccountRepo{})
+				account      = &entity.Account{ID: uuid.New(), Balance: c.balance}
+			)
+
+			charged, err := b.ChargeTransactionFees(context.Background(), account, c.action, nil)
+			if c.wantErr {
+				if _, ok := errors.Cause(err).(errbank.ErrUnprocessableEntity); !ok {
+					t.Fatalf("ChargeTransactionFees() error = %v, want unprocessable entity", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var codes []string
+			for _, fee := range charged {
+				codes = append(codes, fee.Code)
+			}
+			if len(codes) != len(c.want) {
+				t.Fatalf("charged %v, want %v", codes, c.want)
+			}
+			for i := range codes {
+				if codes[i] != c.want[i] {
+					t.Fatalf("charged %v, want %v", codes, c.want)
+				}
+			}
+			if account.Balance != c.wantBalance {
+				t.Errorf("balance = %v, want %v", account.Balance, c.wantBalance)
+			}
+		})
+	}
+}
+
+func TestWaiveFee(t *testing.T) {
+	var (
+		accountID = uuid.New()
+		charged   = entity.Fee{ID: uuid.New(), AccountID: accountID, Code: consts.FeeCodeMAINTENANCE, Amount: 7500, Status: consts.FeeStatusCHARGED}
+		waived    = entity.Fee{ID: uuid.New(), AccountID: accountID, Code: consts.FeeCodeWITHDRAWAL, Amount: 5000, Status: consts.FeeStatusWAIVED}
+	)
+
+	cases := []struct {
+		name        string
+		feeID       uuid.UUID
+		wantBalance float64
+		wantErr     error
+	}{
+		{name: "charged fee is refunded", feeID: charged.ID, wantBalance: 17500},
+		{name: "waived fee is not refunded twice", feeID: waived.ID, wantBalance: 10000, wantErr: errbank.NewErrConflict("")},
+		{name: "unknown fee", feeID: uuid.New(), wantBalance: 10000, wantErr: errbank.NewErrNotFound("")},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				fees         = &fakeFeeRepo{fees: map[uuid.UUID]entity.Fee{charged.ID: charged, waived.ID: waived}}
+				transactions = &fakeTransactionRepo{}
+				accounts     = &fakeAccountRepo{account: entity.Account{ID: accountID, Balance: 10000}}
+				b            = newTestBusiness(fees, transactions, accounts)
+			)
+
+			fee, err := b.waiveFee(context.Background(), entity.WaiveFee{FeeID: c.feeID, Reason: "goodwill", WaivedBy: "admin"}, nil)
+			if c.wantErr != nil {
+				if fmt.Sprintf("%T", errors.Cause(err)) != fmt.Sprintf("%T", c.wantErr) {
+					t.Fatalf("waiveFee() error = %v, want %T", err, c.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if accounts.account.Balance != c.wantBalance {
+				t.Errorf("balance = %v, want %v", accounts.account.Balance, c.wantBalance)
+			}
+			if c.wantErr != nil {
+				return
+			}
+
+			if fee.Status != consts.FeeStatusWAIVED || fee.WaivedBy != "admin" || fees.fees[fee.ID].Status != consts.FeeStatusWAIVED {
+				t.Errorf("fee = %+v, want it waived by admin", fee)
+			}
+			if len(transactions.transactions) != 1 {
+				t.Fatalf("got %d refunds, want 1", len(transactions.transactions))
+			}
+			refund := transactions.transactions[0]
+			if refund.Type != consts.TxTypeCREDIT || refund.Amount != charged.Amount || *fee.WaiveTransactionID != refund.ID {
+				t.Errorf("refund = %+v, want a credit of %v", refund, charged.Amount)
+			}
+			if refund.Status != consts.TxStatusCOMPLETED {
+				t.Errorf("refund status = %s, want COMPLETED", refund.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package fraudbusiness
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+type FraudBusiness interface {
+	Screen(ctx context.Context, check entity.FraudCheck) (*entity.FraudDecision, error)
+	OpenCase(ctx context.Context, check entity.FraudCheck, decision entity.FraudDecision, transactionID *uuid.UUID, tx *sql.Tx) (*entity.FraudCase, error)
+	RememberDevice(ctx context.Context, check entity.FraudCheck)
+	GetRules(ctx context.Context) ([]entity.FraudRule, error)
+	UpdateRule(ctx context.Context, input entity.FraudRule) error
+	GetCases(ctx context.Context, status string, m meta.Metadata) ([]entity.FraudCase, error)
+	GetCase(ctx context.Context, id uuid.UUID) (*entity.FraudCase, error)
+	ResolveCase(ctx context.Context, input entity.ResolveFraudCase) (*entity.FraudCase, error)
+}
+
+type fraudBusiness struct {
+	repo  repo.Repo
+	db    *sql.DB
+	audit auditbusiness.AuditBusiness
+}
+
+func NewFraudBusiness(db *sql.DB) FraudBusiness {
+	return &fraudBusiness{
+		repo:  repo.NewRepositories(db),
+		db:    db,
+		audit: auditbusiness.NewAuditBusiness(db),
+	}
+}
+
+// Device returns the device of the authenticated user when it is the owner,
+// transactions run by the scheduler or by a checker carry no device.
+func Device(ctx context.Context, owner string) string {
+	if username, _ := ctx.Value(middleware.CtxValueUserName).(string); username != owner {
+		return ""
+	}
+	device, _ := ctx.Value(middleware.CtxValueDevice).(string)
+	return device
+}
+
+// Screen evaluates the active rules on a transaction about to be written.
+func (b *fraudBusiness) Screen(ctx context.Context, check entity.FraudCheck) (*entity.FraudDecision, error) {
+	var (
+		eventName = "business.fraud.screen"
+		now       = time.Now()
+		decision  = entity.FraudDecision{Hits: []entity.FraudHit{}}
+	)
+
+	rules, err := b.repo.Fraud.GetListRule(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	for _, rule := range rules {
+		run, ok := ruleChecks[rule.Code]
+		if !rule.Active || !ok {
+			continue
+		}
+		reason, err := run(ctx, b.repo, rule, check, now)
+		if err != nil {
+			log.Println(eventName, rule.Code, err)
+			return nil, err
+		}
+		if reason != "" {
+			decision.Hits = append(decision.Hits, entity.FraudHit{Code: rule.Code, Action: rule.Action, Reason: reason})
+		}
+	}
+	decision.Decision = decide(decision.Hits)
+	return &decision, nil
+}
+
+// OpenCase puts a blocked or reviewed transaction in the case queue. A reviewed
+// transaction opens its case within tx, so the case exists only if it commits.
+func (b *fraudBusiness) OpenCase(ctx context.Context, check entity.FraudCheck, decision entity.FraudDecision, transactionID *uuid.UUID, tx *sql.Tx) (*entity.FraudCase, error) {
+	var (
+		eventName = "business.fraud.open_case"
+		fraudCase = entity.FraudCase{
+			ID:            uuid.New(),
+			AccountID:     check.Account.ID,
+			UserID:        check.User.ID,
+			TransactionID: transactionID,
+			Action:        check.Action,
+			Amount:        check.Amount,
+			Decision:      decision.Decision,
+			Hits:          decision.Hits,
+			Device:        check.Device,
+			Status:        consts.FraudCaseStatusOPEN,
+			CreatedAt:     time.Now(),
+		}
+	)
+
+	if err := b.repo.Fraud.CreateCase(ctx, fraudCase, tx); err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	log.Println(eventName, fraudCase.Decision, fraudCase.ID, check.User.Username, check.Action, check.Amount)
+	return &fraudCase, nil
+}
+
+// RememberDevice records the device of a committed transaction as known.
+func (b *fraudBusiness) RememberDevice(ctx context.Context, check entity.FraudCheck) {
+	var eventName = "business.fraud.remember_device"
+
+	if check.Device == "" {
+		return
+	}
+	if err := b.repo.Fraud.TouchDevice(ctx, check.User.ID, check.Device, nil); err != nil {
+		log.Println(eventName, err)
+	}
+}
+
+func (b *fraudBusiness) GetRules(ctx context.Context) ([]entity.FraudRule, error) {
+	var eventName = "business.fraud.get_rules"
+
+	rules, err := b.repo.Fraud.GetListRule(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (b *fraudBusiness) UpdateRule(ctx context.Context, input entity.FraudRule) error {
+	var eventName = "business.fraud.update_rule"
+
+	rules, err := b.repo.Fraud.GetListRule(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	var before *entity.FraudRule
+	for i := range rules {
+		if rules[i].Code == input.Code {
+			before = &rules[i]
+		}
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.Fraud.UpdateRule(ctx, input, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionUPDATEFRAUDRULE,
+		Target: input.Code,
+		Before: before,
+		After:  input,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	return nil
+}
+
+func (b *fraudBusiness) GetCases(ctx context.Context, status string, m meta.Metadata) ([]entity.FraudCase, error) {
+	var eventName = "business.fraud.get_cases"
+
+	cases, err := b.repo.Fraud.GetListCase(ctx, status, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return cases, nil
+}
+
+func (b *fraudBusiness) GetCase(ctx context.Context, id uuid.UUID) (*entity.FraudCase, error) {
+	var eventName = "business.fraud.get_case"
+
+	fraudCase, err := b.repo.Fraud.FindCaseByID(ctx, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return fraudCase, nil
+}
+
+// ResolveCase closes an open case as CLEARED (a false positive) or CONFIRMED.
+// Clearing a case trusts its device from then on.
+func (b *fraudBusiness) ResolveCase(ctx context.Context, input entity.ResolveFraudCase) (*entity.FraudCase, error) {
+	var (
+		eventName = "business.fraud.resolve_case"
+		now       = time.Now()
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	fraudCase, err := b.repo.Fraud.FindCaseByIDForUpdate(ctx, input.CaseID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if fraudCase.Status != consts.FraudCaseStatusOPEN {
+		err = errbank.NewErrConflict("fraud case is already " + fraudCase.Status)
+		return nil, err
+	}
+
+	fraudCase.Status = input.Status
+	fraudCase.ResolvedBy = input.ResolvedBy
+	fraudCase.Note = input.Note
+	fraudCase.ResolvedAt = &now
+	err = b.repo.Fraud.ResolveCase(ctx, *fraudCase, tx)
+	if err != nil {
+		log.Println(eventName, "ResolveCase", err)
+		return nil, err
+	}
+	if fraudCase.Status == consts.FraudCaseStatusCLEARED && fraudCase.Device != "" {
+		err = b.repo.Fraud.TouchDevice(ctx, fraudCase.UserID, fraudCase.Device, tx)
+		if err != nil {
+			log.Println(eventName, "TouchDevice", err)
+			return nil, err
+		}
+	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionRESOLVEFRAUDCASE,
+		Target: fraudCase.ID.String(),
+		Before: map[string]string{"status": consts.FraudCaseStatusOPEN},
+		After:  fraudCase,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return fraudCase, nil
+}
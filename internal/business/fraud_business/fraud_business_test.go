@@ -0,0 +1,210 @@
+package fraudbusiness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	authorizationrepo "github.com/hanselacn/banking-transaction/internal/repo/authorization_repo"
+	fraudrepo "github.com/hanselacn/banking-transaction/internal/repo/fraud_repo"
+	transactionrepo "github.com/hanselacn/banking-transaction/internal/repo/transaction_repo"
+)
+
+type fakeTransactionRepo struct {
+	transactionrepo.TransactionRepo
+	// stats returns the stats of a query by its type and first action.
+	stats func(txType string, actions []string, since time.Time) entity.TransactionStats
+}
+
+func (f *fakeTransactionRepo) GetStatsByAccountID(ctx context.Context, accountID uuid.UUID, txType string, actions []string, since time.Time) (*entity.TransactionStats, error) {
+	stats := f.stats(txType, actions, since)
+	return &stats, nil
+}
+
+type fakeAuthorizationRepo struct {
+	authorizationrepo.AuthorizationRepositories
+	changedAt *time.Time
+}
+
+func (f *fakeAuthorizationRepo) FindByUserID(ctx context.Context, userID uuid.UUID) (*entity.Authorization, error) {
+	return &entity.Authorization{UserID: userID, PasswordChangedAt: f.changedAt}, nil
+}
+
+type fakeFraudRepo struct {
+	fraudrepo.FraudRepositories
+	rules   []entity.FraudRule
+	devices []string
+}
+
+func (f *fakeFraudRepo) GetListRule(ctx context.Context) ([]entity.FraudRule, error) {
+	return f.rules, nil
+}
+
+func (f *fakeFraudRepo) HasDevices(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return len(f.devices) > 0, nil
+}
+
+func (f *fakeFraudRepo) FindDevice(ctx context.Context, userID uuid.UUID, device string) (bool, error) {
+	for _, known := range f.devices {
+		if known == device {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func defaultRules() []entity.FraudRule {
+	return []entity.FraudRule{
+		{Code: consts.FraudRuleVELOCITY, Action: consts.FraudDecisionBLOCK, Threshold: 3, WindowMinutes: 10, Active: true},
+		{Code: consts.FraudRuleAMOUNTSPIKE, Action: consts.FraudDecisionREVIEW, Threshold: 5, WindowMinutes: 129600, Active: true},
+		{Code: consts.FraudRulePASSWORDCHANGEWITHDRAWAL, Action: consts.FraudDecisionREVIEW, WindowMinutes: 1440, Active: true},
+		{Code: consts.FraudRuleNEWDEVICE, Action: consts.FraudDecisionREVIEW, Threshold: 1000, Active: true},
+	}
+}
+
+func TestScreen(t *testing.T) {
+	var (
+		recently = time.Now().Add(-time.Hour)
+		longAgo  = time.Now().Add(-48 * time.Hour)
+	)
+
+	cases := []struct {
+		name      string
+		check     entity.FraudCheck
+		recent    int
+		history   entity.TransactionStats
+		outgoing  int
+		changedAt *time.Time
+		devices   []string
+		decision  string
+		hits      []string
+	}{
+		{
+			name:     "usual withdrawal is allowed",
+			check:    entity.FraudCheck{Action: consts.TxActionWITHDRAWAL, Amount: 100, Device: "phone"},
+			recent:   1,
+			history:  entity.TransactionStats{Count: 10, Average: 100},
+			outgoing: 3,
+			devices:  []string{"phone"},
+			decision: consts.FraudDecisionALLOW,
+		},
+		{
+			name:     "velocity blocks",
+			check:    entity.FraudCheck{Action: consts.TxActionDEPOSIT, Amount: 100},
+			recent:   3,
+			decision: consts.FraudDecisionBLOCK,
+			hits:     []string{consts.FraudRuleVELOCITY},
+		},
+		{
+			name:     "amount spike against the history",
+			check:    entity.FraudCheck{Action: consts.TxActionWITHDRAWAL, Amount: 600},
+			history:  entity.TransactionStats{Count: 4, Average: 100},
+			outgoing: 4,
+			decision: consts.FraudDecisionREVIEW,
+			hits:     []string{consts.FraudRuleAMOUNTSPIKE},
+		},
+		{
+			name:     "no spike without enough history",
+			check:    entity.FraudCheck{Action: consts.TxActionWITHDRAWAL, Amount: 600},
+			history:  entity.TransactionStats{Count: 2, Average: 100},
+			outgoing: 2,
+			decision: consts.FraudDecisionALLOW,
+		},
+		{
+			name:      "first withdrawal after a password change",
+			check:     entity.FraudCheck{Action: consts.TxActionTRANSFER, Amount: 100},
+			changedAt: &recently,
+			decision:  consts.FraudDecisionREVIEW,
+			hits:      []string{consts.FraudRulePASSWORDCHANGEWITHDRAWAL},
+		},
+		{
+			name:      "password changed before the window",
+			check:     entity.FraudCheck{Action: consts.TxActionWITHDRAWAL, Amount: 100},
+			changedAt: &longAgo,
+			decision:  consts.FraudDecisionALLOW,
+		},
+		{
+			name:      "deposits after a password change are allowed",
+			check:     entity.FraudCheck{Action: consts.TxActionDEPOSIT, Amount: 100},
+			changedAt: &recently,
+			decision:  consts.FraudDecisionALLOW,
+		},
+		{
+			name:     "new device above the threshold",
+			check:    entity.FraudCheck{Action: consts.TxActionWITHDRAWAL, Amount: 1000, Device: "laptop"},
+			outgoing: 1,
+			devices:  []string{"phone"},
+			decision: consts.FraudDecisionREVIEW,
+			hits:     []string{consts.FraudRuleNEWDEVICE},
+		},
+		{
+			name:     "first device of the user",
+			check:    entity.FraudCheck{Action: consts.TxActionWITHDRAWAL, Amount: 1000, Device: "phone"},
+			decision: consts.FraudDecisionALLOW,
+		},
+		{
+			name:     "the strictest hit decides",
+			check:    entity.FraudCheck{Action: consts.TxActionWITHDRAWAL, Amount: 5000, Device: "laptop"},
+			recent:   5,
+			history:  entity.TransactionStats{Count: 5, Average: 100},
+			outgoing: 5,
+			devices:  []string{"phone"},
+			decision: consts.FraudDecisionBLOCK,
+			hits:     []string{consts.FraudRuleVELOCITY, consts.FraudRuleAMOUNTSPIKE, consts.FraudRuleNEWDEVICE},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			transactions := &fakeTransactionRepo{stats: func(txType string, actions []string, since time.Time) entity.TransactionStats {
+				switch {
+				case len(actions) == len(customerActions):
+					return entity.TransactionStats{Count: c.recent}
+				case len(actions) == 2:
+					return entity.TransactionStats{Count: c.outgoing}
+				}
+				return c.history
+			}}
+			b := &fraudBusiness{repo: repo.Repo{
+				Transaction:   transactions,
+				Authorization: &fakeAuthorizationRepo{changedAt: c.changedAt},
+				Fraud:         &fakeFraudRepo{rules: defaultRules(), devices: c.devices},
+			}}
+
+			decision, err := b.Screen(context.Background(), c.check)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision.Decision != c.decision {
+				t.Errorf("expected %s, got %s %+v", c.decision, decision.Decision, decision.Hits)
+			}
+			if len(decision.Hits) != len(c.hits) {
+				t.Fatalf("expected hits %v, got %+v", c.hits, decision.Hits)
+			}
+			for i, hit := range decision.Hits {
+				if hit.Code != c.hits[i] {
+					t.Errorf("expected hit %s, got %s", c.hits[i], hit.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestScreenSkipsInactiveRules(t *testing.T) {
+	rules := defaultRules()
+	for i := range rules {
+		rules[i].Active = false
+	}
+	b := &fraudBusiness{repo: repo.Repo{Fraud: &fakeFraudRepo{rules: rules}}}
+
+	decision, err := b.Screen(context.Background(), entity.FraudCheck{Action: consts.TxActionWITHDRAWAL, Amount: 1000000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Decision != consts.FraudDecisionALLOW || len(decision.Hits) != 0 {
+		t.Fatalf("inactive rules must not be evaluated: %+v", decision)
+	}
+}
@@ -0,0 +1,128 @@
+package fraudbusiness
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+// spikeMinHistory is the number of past transactions needed before an amount
+// is compared with the average.
+const spikeMinHistory = 3
+
+// ruleCheck returns the reason rule is triggered by check, or an empty reason.
+type ruleCheck func(ctx context.Context, r repo.Repo, rule entity.FraudRule, check entity.FraudCheck, now time.Time) (string, error)
+
+var ruleChecks = map[string]ruleCheck{
+	consts.FraudRuleVELOCITY:                 checkVelocity,
+	consts.FraudRuleAMOUNTSPIKE:              checkAmountSpike,
+	consts.FraudRulePASSWORDCHANGEWITHDRAWAL: checkPasswordChangeWithdrawal,
+	consts.FraudRuleNEWDEVICE:                checkNewDevice,
+}
+
+var customerActions = []string{consts.TxActionWITHDRAWAL, consts.TxActionDEPOSIT, consts.TxActionTRANSFER}
+
+func isMoneyOut(action string) bool {
+	return action == consts.TxActionWITHDRAWAL || action == consts.TxActionTRANSFER
+}
+
+func window(rule entity.FraudRule, now time.Time) time.Time {
+	return now.Add(-time.Duration(rule.WindowMinutes) * time.Minute)
+}
+
+// checkVelocity triggers on more than threshold transactions within the window,
+// the screened one included.
+func checkVelocity(ctx context.Context, r repo.Repo, rule entity.FraudRule, check entity.FraudCheck, now time.Time) (string, error) {
+	if rule.Threshold <= 0 || rule.WindowMinutes <= 0 {
+		return "", nil
+	}
+	stats, err := r.Transaction.GetStatsByAccountID(ctx, check.Account.ID, "", customerActions, window(rule, now))
+	if err != nil {
+		return "", err
+	}
+	if float64(stats.Count+1) > rule.Threshold {
+		return fmt.Sprintf("%d transactions within %d minutes", stats.Count+1, rule.WindowMinutes), nil
+	}
+	return "", nil
+}
+
+// checkAmountSpike triggers on an amount above threshold times the average of
+// the same action within the window.
+func checkAmountSpike(ctx context.Context, r repo.Repo, rule entity.FraudRule, check entity.FraudCheck, now time.Time) (string, error) {
+	if rule.Threshold <= 0 || rule.WindowMinutes <= 0 {
+		return "", nil
+	}
+	txType := consts.TxTypeCREDIT
+	if isMoneyOut(check.Action) {
+		txType = consts.TxTypeDEBIT
+	}
+	stats, err := r.Transaction.GetStatsByAccountID(ctx, check.Account.ID, txType, []string{check.Action}, window(rule, now))
+	if err != nil {
+		return "", err
+	}
+	if stats.Count < spikeMinHistory || stats.Average <= 0 {
+		return "", nil
+	}
+	if check.Amount > stats.Average*rule.Threshold {
+		return fmt.Sprintf("amount is %.1f times the average %s of %.2f", check.Amount/stats.Average, check.Action, stats.Average), nil
+	}
+	return "", nil
+}
+
+// checkPasswordChangeWithdrawal triggers on the first money out within the
+// window after a password change.
+func checkPasswordChangeWithdrawal(ctx context.Context, r repo.Repo, rule entity.FraudRule, check entity.FraudCheck, now time.Time) (string, error) {
+	if !isMoneyOut(check.Action) || rule.WindowMinutes <= 0 {
+		return "", nil
+	}
+	auth, err := r.Authorization.FindByUserID(ctx, check.User.ID)
+	if err != nil {
+		return "", err
+	}
+	if auth.PasswordChangedAt == nil || auth.PasswordChangedAt.Before(window(rule, now)) {
+		return "", nil
+	}
+	stats, err := r.Transaction.GetStatsByAccountID(ctx, check.Account.ID, consts.TxTypeDEBIT, []string{consts.TxActionWITHDRAWAL, consts.TxActionTRANSFER}, *auth.PasswordChangedAt)
+	if err != nil {
+		return "", err
+	}
+	if stats.Count == 0 {
+		return "first money out since the password change at " + auth.PasswordChangedAt.Format(time.RFC3339), nil
+	}
+	return "", nil
+}
+
+// checkNewDevice triggers on an amount of at least threshold from a device the
+// user never used before. The first device of a user is not suspicious.
+func checkNewDevice(ctx context.Context, r repo.Repo, rule entity.FraudRule, check entity.FraudCheck, now time.Time) (string, error) {
+	if check.Device == "" || check.Amount < rule.Threshold {
+		return "", nil
+	}
+	known, err := r.Fraud.FindDevice(ctx, check.User.ID, check.Device)
+	if err != nil || known {
+		return "", err
+	}
+	hasDevices, err := r.Fraud.HasDevices(ctx, check.User.ID)
+	if err != nil || !hasDevices {
+		return "", err
+	}
+	return "transaction from a new device", nil
+}
+
+// decide returns the strictest action of the hits.
+func decide(hits []entity.FraudHit) string {
+	decision := consts.FraudDecisionALLOW
+	for _, hit := range hits {
+		switch hit.Action {
+		case consts.FraudDecisionBLOCK:
+			return consts.FraudDecisionBLOCK
+		case consts.FraudDecisionREVIEW:
+			decision = consts.FraudDecisionREVIEW
+		}
+	}
+	return decision
+}
@@ -0,0 +1,372 @@
+package integritybusiness
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	"github.com/pkg/errors"
+)
+
+const (
+	integrityBatchSize = 500
+	integrityMaxRetry  = 3
+)
+
+type IntegrityBusiness interface {
+	CheckBalances(ctx context.Context) (*entity.IntegrityReport, error)
+	GetDiscrepancies(ctx context.Context, status string, m meta.Metadata) ([]entity.BalanceDiscrepancy, error)
+	CorrectDiscrepancy(ctx context.Context, input entity.CorrectDiscrepancy) (*entity.BalanceDiscrepancy, error)
+}
+
+type integrityBusiness struct {
+	repo   repo.Repo
+	db     *sql.DB
+	ledger ledgerbusiness.LedgerBusiness
+	audit  auditbusiness.AuditBusiness
+}
+
+func NewIntegrityBusiness(db *sql.DB) IntegrityBusiness {
+	return &integrityBusiness{
+		repo:   repo.NewRepositories(db),
+		db:     db,
+		ledger: ledgerbusiness.NewLedgerBusiness(db),
+		audit:  auditbusiness.NewAuditBusiness(db),
+	}
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// CheckBalances compares the decrypted balance of every account with the sum of
+// its completed transactions. Mismatches are recorded as open discrepancies and
+// returned; open discrepancies of accounts that match again are cleared.
+func (b *integrityBusiness) CheckBalances(ctx context.Context) (*entity.IntegrityReport, error) {
+	var (
+		eventName = "business.integrity.check_balances"
+		lastID    = uuid.Nil
+		report    = entity.IntegrityReport{}
+	)
+
+	for {
+		accounts, err := b.repo.Account.GetAccountBatch(ctx, lastID, integrityBatchSize)
+		if err != nil {
+			log.Println(eventName, "GetAccountBatch", err)
+			return &report, err
+		}
+		if len(accounts) == 0 {
+			break
+		}
+		for _, account := range accounts {
+			report.Checked++
+			discrepancy, err := b.checkAccount(ctx, account.ID)
+			if err != nil {
+				log.Println(eventName, "checkAccount", account.ID, err)
+				report.Failed++
+				continue
+			}
+			if discrepancy != nil {
+				log.Printf("%s BALANCE MISMATCH account %s stored %.2f computed %.2f", eventName, account.ID, discrepancy.StoredBalance, discrepancy.ComputedBalance)
+				report.Mismatched++
+				report.Discrepancies = append(report.Discrepancies, *discrepancy)
+			}
+		}
+		lastID = accounts[len(accounts)-1].ID
+	}
+	return &report, nil
+}
+
+// checkAccount retries checkAccountOnce when it loses a serialization conflict
+// against a concurrent posting on the same account.
+func (b *integrityBusiness) checkAccount(ctx context.Context, accountID uuid.UUID) (*entity.BalanceDiscrepancy, error) {
+	for attempt := 0; ; attempt++ {
+		discrepancy, err := b.checkAccountOnce(ctx, accountID)
+		if err == nil || attempt >= integrityMaxRetry || !errbank.IsSerializationFailure(errors.Cause(err)) {
+			return discrepancy, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * 50 * time.Millisecond):
+		}
+	}
+}
+
+// checkAccountOnce reads the balance and the transaction history from the same
+// snapshot, so a posting committed in between cannot be reported as a mismatch.
+func (b *integrityBusiness) checkAccountOnce(ctx context.Context, accountID uuid.UUID) (*entity.BalanceDiscrepancy, error) {
+	var (
+		eventName = "business.integrity.check_account"
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	discrepancy, err := b.compareBalance(ctx, accountID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return discrepancy, nil
+}
+
+// compareBalance records an open discrepancy when the balance of the account
+// differs from its transaction history, or clears it when they match again. It
+// returns nil when they match.
+func (b *integrityBusiness) compareBalance(ctx context.Context, accountID uuid.UUID, tx *sql.Tx) (*entity.BalanceDiscrepancy, error) {
+	var (
+		eventName = "business.integrity.compare_balance"
+	)
+
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, accountID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	computed, err := b.repo.Transaction.SumCompletedByAccountID(ctx, accountID, tx)
+	if err != nil {
+		log.Println(eventName, "SumCompletedByAccountID", err)
+		return nil, err
+	}
+
+	if cents(account.Balance) == cents(computed) {
+		err = b.repo.Integrity.ClearOpenByAccountID(ctx, accountID, tx)
+		if err != nil {
+			log.Println(eventName, "ClearOpenByAccountID", err)
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	// History from before transactions carried their account cannot be summed,
+	// the balance of such an account is carried over once as its opening balance.
+	legacy, err := b.repo.Integrity.NeedsOpeningBalance(ctx, accountID, tx)
+	if err != nil {
+		log.Println(eventName, "NeedsOpeningBalance", err)
+		return nil, err
+	}
+	if legacy {
+		err = b.postOpeningBalance(ctx, accountID, cents(account.Balance)-cents(computed), tx)
+		if err != nil {
+			log.Println(eventName, "postOpeningBalance", err)
+			return nil, err
+		}
+		err = b.repo.Integrity.ClearOpenByAccountID(ctx, accountID, tx)
+		if err != nil {
+			log.Println(eventName, "ClearOpenByAccountID", err)
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	discrepancy, err := b.repo.Integrity.UpsertOpen(ctx, entity.BalanceDiscrepancy{
+		ID:              uuid.New(),
+		AccountID:       accountID,
+		StoredBalance:   account.Balance,
+		ComputedBalance: computed,
+		Difference:      float64(cents(account.Balance)-cents(computed)) / 100,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "UpsertOpen", err)
+		return nil, err
+	}
+	return discrepancy, nil
+}
+
+// postOpeningBalance completes the history of accountID with an OPENING_BALANCE
+// transaction of difference cents, a credit when positive.
+func (b *integrityBusiness) postOpeningBalance(ctx context.Context, accountID uuid.UUID, difference int64, tx *sql.Tx) error {
+	transaction := entity.Transaction{
+		ID:        uuid.New(),
+		AccountID: accountID,
+		Type:      consts.TxTypeCREDIT,
+		Amount:    float64(difference) / 100,
+		Action:    consts.TxActionOPENINGBALANCE,
+		Status:    consts.TxStatusCOMPLETED,
+	}
+	if difference < 0 {
+		transaction.Type = consts.TxTypeDEBIT
+		transaction.Amount = float64(-difference) / 100
+	}
+	err := b.repo.Transaction.CreateTransaction(ctx, transaction, tx)
+	if err != nil {
+		return err
+	}
+	return b.ledger.PostTransaction(ctx, transaction, tx)
+}
+
+func (b *integrityBusiness) GetDiscrepancies(ctx context.Context, status string, m meta.Metadata) ([]entity.BalanceDiscrepancy, error) {
+	var eventName = "business.integrity.get_discrepancies"
+
+	discrepancies, err := b.repo.Integrity.GetList(ctx, status, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return discrepancies, nil
+}
+
+// CorrectDiscrepancy resolves an open discrepancy approved by an admin. The
+// amounts are recomputed under lock first, so the correction applies to the
+// current mismatch rather than the one seen by the last check.
+func (b *integrityBusiness) CorrectDiscrepancy(ctx context.Context, input entity.CorrectDiscrepancy) (*entity.BalanceDiscrepancy, error) {
+	var (
+		eventName = "business.integrity.correct_discrepancy"
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	discrepancy, err := b.correctDiscrepancy(ctx, input, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return discrepancy, nil
+}
+
+// correctDiscrepancy applies the resolution and records it in the audit log
+// within tx. A POST_ADJUSTMENT leaves the balance as is and completes the history
+// with an ADJUSTMENT transaction; a RESET_BALANCE sets the balance to the history.
+func (b *integrityBusiness) correctDiscrepancy(ctx context.Context, input entity.CorrectDiscrepancy, tx *sql.Tx) (*entity.BalanceDiscrepancy, error) {
+	var (
+		eventName = "business.integrity.correct_discrepancy"
+	)
+
+	discrepancy, err := b.repo.Integrity.FindByIDForUpdate(ctx, input.DiscrepancyID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if discrepancy.Status != consts.DiscrepancyStatusOPEN {
+		return nil, errbank.NewErrConflict("discrepancy is already " + discrepancy.Status)
+	}
+
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, discrepancy.AccountID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	computed, err := b.repo.Transaction.SumCompletedByAccountID(ctx, account.ID, tx)
+	if err != nil {
+		log.Println(eventName, "SumCompletedByAccountID", err)
+		return nil, err
+	}
+	difference := cents(account.Balance) - cents(computed)
+	if difference == 0 {
+		return nil, errbank.NewErrConflict("balance matches the transaction history, nothing to correct")
+	}
+
+	before := account.Balance
+	discrepancy.StoredBalance = account.Balance
+	discrepancy.ComputedBalance = computed
+	discrepancy.Difference = float64(difference) / 100
+	discrepancy.Status = consts.DiscrepancyStatusCORRECTED
+	discrepancy.Resolution = input.Resolution
+	discrepancy.ResolvedBy = input.ResolvedBy
+
+	switch input.Resolution {
+	case consts.DiscrepancyResolutionRESETBALANCE:
+		account.Balance = float64(cents(computed)) / 100
+		err = b.repo.Account.UpdateBalance(ctx, *account, tx)
+		if err != nil {
+			log.Println(eventName, "UpdateBalance", err)
+			return nil, err
+		}
+	case consts.DiscrepancyResolutionPOSTADJUSTMENT:
+		transaction := entity.Transaction{
+			ID:        uuid.New(),
+			AccountID: account.ID,
+			Type:      consts.TxTypeCREDIT,
+			Amount:    float64(difference) / 100,
+			Action:    consts.TxActionADJUSTMENT,
+			Status:    consts.TxStatusCOMPLETED,
+		}
+		if difference < 0 {
+			transaction.Type = consts.TxTypeDEBIT
+			transaction.Amount = float64(-difference) / 100
+		}
+		err = b.repo.Transaction.CreateTransaction(ctx, transaction, tx)
+		if err != nil {
+			log.Println(eventName, "CreateTransaction", err)
+			return nil, err
+		}
+		err = b.ledger.PostTransaction(ctx, transaction, tx)
+		if err != nil {
+			log.Println(eventName, "PostTransaction", err)
+			return nil, err
+		}
+		discrepancy.ResolveTransactionID = &transaction.ID
+	default:
+		return nil, errbank.NewErrUnprocessableEntity("invalid resolution")
+	}
+
+	err = b.repo.Integrity.Resolve(ctx, *discrepancy, tx)
+	if err != nil {
+		log.Println(eventName, "Resolve", err)
+		return nil, err
+	}
+
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionCORRECTDISCREPANCY,
+		Target: discrepancy.ID.String(),
+		Before: map[string]float64{"balance": before, "computed_balance": computed},
+		After:  map[string]interface{}{"balance": account.Balance, "discrepancy": discrepancy},
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+	return discrepancy, nil
+}
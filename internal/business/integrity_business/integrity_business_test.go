@@ -0,0 +1,255 @@
+package integritybusiness
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	accountrepo "github.com/hanselacn/banking-transaction/internal/repo/account_repo"
+	integrityrepo "github.com/hanselacn/banking-transaction/internal/repo/integrity_repo"
+	transactionrepo "github.com/hanselacn/banking-transaction/internal/repo/transaction_repo"
+	"github.com/pkg/errors"
+)
+
+type fakeAccountRepo struct {
+	accountrepo.AccountRepositories
+	account entity.Account
+}
+
+func (f *fakeAccountRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Account, error) {
+	account := f.account
+	return &account, nil
+}
+
+func (f *fakeAccountRepo) UpdateBalance(ctx context.Context, account entity.Account, tx *sql.Tx) error {
+	f.account.Balance = account.Balance
+	return nil
+}
+
+type fakeTransactionRepo struct {
+	transactionrepo.TransactionRepo
+	// history is the net amount of the completed transactions of the account.
+	history      float64
+	transactions []entity.Transaction
+}
+
+func (f *fakeTransactionRepo) SumCompletedByAccountID(ctx context.Context, accountID uuid.UUID, tx *sql.Tx) (float64, error) {
+	return f.history, nil
+}
+
+func (f *fakeTransactionRepo) CreateTransaction(ctx context.Context, input entity.Transaction, tx *sql.Tx) error {
+	f.transactions = append(f.transactions, input)
+	return nil
+}
+
+type fakeIntegrityRepo struct {
+	integrityrepo.IntegrityRepositories
+	open     *entity.BalanceDiscrepancy
+	cleared  bool
+	resolved *entity.BalanceDiscrepancy
+	// legacy tells whether the account still needs an opening balance.
+	legacy bool
+}
+
+func (f *fakeIntegrityRepo) NeedsOpeningBalance(ctx context.Context, accountID uuid.UUID, tx *sql.Tx) (bool, error) {
+	return f.legacy, nil
+}
+
+func (f *fakeIntegrityRepo) UpsertOpen(ctx context.Context, discrepancy entity.BalanceDiscrepancy, tx *sql.Tx) (*entity.BalanceDiscrepancy, error) {
+	discrepancy.Status = consts.DiscrepancyStatusOPEN
+	f.open = &discrepancy
+	return &discrepancy, nil
+}
+
+func (f *fakeIntegrityRepo) ClearOpenByAccountID(ctx context.Context, accountID uuid.UUID, tx *sql.Tx) error {
+	f.cleared = true
+	return nil
+}
+
+func (f *fakeIntegrityRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.BalanceDiscrepancy, error) {
+	discrepancy := *f.open
+	return &discrepancy, nil
+}
+
+func (f *fakeIntegrityRepo) Resolve(ctx context.Context, discrepancy entity.BalanceDiscrepancy, tx *sql.Tx) error {
+	f.resolved = &discrepancy
+	return nil
+}
+
+type fakeLedger struct {
+	ledgerbusiness.LedgerBusiness
+	posted []entity.Transaction
+}
+
+func (f *fakeLedger) PostTransaction(ctx context.Context, transaction entity.Transaction, tx *sql.Tx) error {
+	f.posted = append(f.posted, transaction)
+	return nil
+}
+
+type fakeAudit struct {
+	auditbusiness.AuditBusiness
+	records []entity.AuditRecord
+}
+
+func (f *fakeAudit) Record(ctx context.Context, record entity.AuditRecord, tx *sql.Tx) error {
+	f.records = append(f.records, record)
+	return nil
+}
+
+func TestCompareBalance(t *testing.T) {
+	cases := []struct {
+		name           string
+		balance        float64
+		history        float64
+		legacy         bool
+		wantDifference float64
+		wantCleared    bool
+		wantOpening    *entity.Transaction
+	}{
+		{name: "match", balance: 150000, history: 150000, wantCleared: true},
+		{name: "match within a cent", balance: 150000.001, history: 150000, wantCleared: true},
+		{name: "balance above the history", balance: 150000, history: 100000, wantDifference: 50000},
+		{name: "balance below the history", balance: 90000, history: 100000.5, wantDifference: -10000.5},
+		{name: "legacy history credits the opening balance", balance: 150000, history: 20000, legacy: true, wantCleared: true,
+			wantOpening: &entity.Transaction{Type: consts.TxTypeCREDIT, Amount: 130000}},
+		{name: "legacy history debits the opening balance", balance: 5000, history: 20000, legacy: true, wantCleared: true,
+			wantOpening: &entity.Transaction{Type: consts.TxTypeDEBIT, Amount: 15000}},
+		{name: "legacy account that matches", balance: 20000, history: 20000, legacy: true, wantCleared: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				accounts     = &fakeAccountRepo{account: entity.Account{ID: uuid.New(), Balance: c.balance}}
+				transactions = &fakeTransactionRepo{history: c.history}
+				integrity    = &fakeIntegrityRepo{legacy: c.legacy}
+				ledger       = &fakeLedger{}
+				audit        = &fakeAudit{}
+				b            = &integrityBusiness{
+					repo:   repo.Repo{Account: accounts, Transaction: transactions, Integrity: integrity},
+					ledger: ledger,
+					audit:  audit,
+				}
+			)
+
+			discrepancy, err := b.compareBalance(context.Background(), accounts.account.ID, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.wantDifference == 0 {
+				if discrepancy != nil || integrity.open != nil {
+					t.Errorf("discrepancy = %+v, want none", discrepancy)
+				}
+			} else if discrepancy == nil || discrepancy.Difference != c.wantDifference || integrity.open == nil {
+				t.Errorf("discrepancy = %+v, want a difference of %v", discrepancy, c.wantDifference)
+			}
+			if integrity.cleared != c.wantCleared {
+				t.Errorf("cleared = %v, want %v", integrity.cleared, c.wantCleared)
+			}
+			if c.wantOpening == nil {
+				if len(transactions.transactions) != 0 {
+					t.Errorf("transactions = %+v, want none", transactions.transactions)
+				}
+			} else {
+				if len(transactions.transactions) != 1 || len(ledger.posted) != 1 {
+					t.Fatalf("transactions = %+v, posted = %d, want one opening balance", transactions.transactions, len(ledger.posted))
+				}
+				opening := transactions.transactions[0]
+				if opening.Action != consts.TxActionOPENINGBALANCE || opening.Type != c.wantOpening.Type || opening.Amount != c.wantOpening.Amount || opening.Status != consts.TxStatusCOMPLETED {
+					t.Errorf("opening = %+v, want a completed %s of %v", opening, c.wantOpening.Type, c.wantOpening.Amount)
+				}
+			}
+			if len(audit.records) != 0 {
+				t.Errorf("got %d audit records, want none for a check", len(audit.records))
+			}
+		})
+	}
+}
+
+func TestCorrectDiscrepancy(t *testing.T) {
+	cases := []struct {
+		name        string
+		status      string
+		resolution  string
+		balance     float64
+		history     float64
+		wantBalance float64
+		wantAdjust  *entity.Transaction
+		wantErr     bool
+	}{
+		{name: "reset the balance", status: consts.DiscrepancyStatusOPEN, resolution: consts.DiscrepancyResolutionRESETBALANCE,
+			balance: 120000, history: 100000, wantBalance: 100000},
+		{name: "post a credit adjustment", status: consts.DiscrepancyStatusOPEN, resolution: consts.DiscrepancyResolutionPOSTADJUSTMENT,
+			balance: 120000, history: 100000, wantBalance: 120000, wantAdjust: &entity.Transaction{Type: consts.TxTypeCREDIT, Amount: 20000}},
+		{name: "post a debit adjustment", status: consts.DiscrepancyStatusOPEN, resolution: consts.DiscrepancyResolutionPOSTADJUSTMENT,
+			balance: 90000, history: 100000, wantBalance: 90000, wantAdjust: &entity.Transaction{Type: consts.TxTypeDEBIT, Amount: 10000}},
+		{name: "already corrected", status: consts.DiscrepancyStatusCORRECTED, resolution: consts.DiscrepancyResolutionRESETBALANCE,
+			balance: 120000, history: 100000, wantBalance: 120000, wantErr: true},
+		{name: "matches again", status: consts.DiscrepancyStatusOPEN, resolution: consts.DiscrepancyResolutionRESETBALANCE,
+			balance: 100000, history: 100000, wantBalance: 100000, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				accountID    = uuid.New()
+				accounts     = &fakeAccountRepo{account: entity.Account{ID: accountID, Balance: c.balance}}
+				transactions = &fakeTransactionRepo{history: c.history}
+				integrity    = &fakeIntegrityRepo{open: &entity.BalanceDiscrepancy{ID: uuid.New(), AccountID: accountID, Status: c.status}}
+				audit        = &fakeAudit{}
+				b            = &integrityBusiness{
+					repo:   repo.Repo{Account: accounts, Transaction: transactions, Integrity: integrity},
+					ledger: &fakeLedger{},
+					audit:  audit,
+				}
+			)
+
+			discrepancy, err := b.correctDiscrepancy(context.Background(), entity.CorrectDiscrepancy{
+				DiscrepancyID: integrity.open.ID,
+				Resolution:    c.resolution,
+				ResolvedBy:    "admin",
+			}, nil)
+			if c.wantErr {
+				if _, ok := errors.Cause(err).(errbank.ErrConflict); !ok {
+					t.Fatalf("correctDiscrepancy() error = %v, want ErrConflict", err)
+				}
+				if integrity.resolved != nil || len(audit.records) != 0 {
+					t.Errorf("resolved = %+v, audit = %+v, want nothing recorded", integrity.resolved, audit.records)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if accounts.account.Balance != c.wantBalance {
+				t.Errorf("balance = %v, want %v", accounts.account.Balance, c.wantBalance)
+			}
+			if discrepancy.Status != consts.DiscrepancyStatusCORRECTED || integrity.resolved == nil {
+				t.Errorf("discrepancy = %+v, want it resolved as CORRECTED", discrepancy)
+			}
+			if c.wantAdjust != nil {
+				if len(transactions.transactions) != 1 {
+					t.Fatalf("transactions = %+v, want one adjustment", transactions.transactions)
+				}
+				adjust := transactions.transactions[0]
+				if adjust.Action != consts.TxActionADJUSTMENT || adjust.Type != c.wantAdjust.Type || adjust.Amount != c.wantAdjust.Amount || *discrepancy.ResolveTransactionID != adjust.ID {
+					t.Errorf("adjustment = %+v, want a %s of %v", adjust, c.wantAdjust.Type, c.wantAdjust.Amount)
+				}
+			}
+
+			if len(audit.records) != 1 || audit.records[0].Action != consts.AuditActionCORRECTDISCREPANCY {
+				t.Fatalf("audit records = %+v, want one %s", audit.records, consts.AuditActionCORRECTDISCREPANCY)
+			}
+			before := audit.records[0].Before.(map[string]float64)
+			after := audit.records[0].After.(map[string]interface{})
+			if before["balance"] != c.balance || after["balance"] != c.wantBalance {
+				t.Errorf("audit balance before = %v after = %v, want %v and %v", before["balance"], after["balance"], c.balance, c.wantBalance)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package kycbusiness
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+type KYCBusiness interface {
+	SubmitDocument(ctx context.Context, input entity.SubmitKYCDocument) (*entity.KYCDocument, error)
+	GetKYC(ctx context.Context, username string) (*entity.KYCDetail, error)
+	GetDocument(ctx context.Context, username string, id uuid.UUID) (*entity.KYCDocument, error)
+	GetApplicants(ctx context.Context, status string, m meta.Metadata) ([]entity.User, error)
+	Review(ctx context.Context, input entity.ReviewKYC) (*entity.KYCDetail, error)
+}
+
+type kycBusiness struct {
+	repo  repo.Repo
+	db    *sql.DB
+	audit auditbusiness.AuditBusiness
+}
+
+func NewKYCBusiness(db *sql.DB) KYCBusiness {
+	return &kycBusiness{
+		repo:  repo.NewRepositories(db),
+		db:    db,
+		audit: auditbusiness.NewAuditBusiness(db),
+	}
+}
+
+// SubmitDocument stores an identity document and puts the user in the review
+// queue. A rejected user submits new documents to be reviewed again.
+func (b *kycBusiness) SubmitDocument(ctx context.Context, input entity.SubmitKYCDocument) (*entity.KYCDocument, error) {
+	var (
+		eventName = "business.kyc.submit_document"
+	)
+
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if user.KYCStatus == consts.KYCStatusVERIFIED {
+		return nil, errbank.NewErrConflict("user is already verified")
+	}
+
+	document := entity.KYCDocument{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		DocumentType: input.DocumentType,
+		FileName:     input.FileName,
+		ContentType:  input.ContentType,
+		Content:      input.Content,
+		CreatedAt:    time.Now(),
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.KYC.CreateDocument(ctx, document, tx)
+	if err != nil {
+		log.Println(eventName, "CreateDocument", err)
+		return nil, err
+	}
+	if user.KYCStatus != consts.KYCStatusPENDING {
+		user.KYCStatus = consts.KYCStatusPENDING
+		user.KYCNote = ""
+		err = b.repo.Users.UpdateKYCStatus(ctx, *user, tx)
+		if err != nil {
+			log.Println(eventName, "UpdateKYCStatus", err)
+			return nil, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (b *kycBusiness) GetKYC(ctx context.Context, username string) (*entity.KYCDetail, error) {
+	var (
+		eventName = "business.kyc.get_kyc"
+	)
+
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	documents, err := b.repo.KYC.GetDocuments(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return &entity.KYCDetail{
+		Username:  user.Username,
+		Status:    user.KYCStatus,
+		Note:      user.KYCNote,
+		Documents: documents,
+	}, nil
+}
+
+func (b *kycBusiness) GetDocument(ctx context.Context, username string, id uuid.UUID) (*entity.KYCDocument, error) {
+	var (
+		eventName = "business.kyc.get_document"
+	)
+
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	document, err := b.repo.KYC.FindDocument(ctx, user.ID, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return document, nil
+}
+
+func (b *kycBusiness) GetApplicants(ctx context.Context, status string, m meta.Metadata) ([]entity.User, error) {
+	var eventName = "business.kyc.get_applicants"
+
+	users, err := b.repo.Users.GetListByKYCStatus(ctx, status, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return users, nil
+}
+
+// Review verifies or rejects a pending user. Nobody reviews their own documents.
+func (b *kycBusiness) Review(ctx context.Context, input entity.ReviewKYC) (*entity.KYCDetail, error) {
+	var (
+		eventName = "business.kyc.review"
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	detail, err := b.review(ctx, input, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return detail, nil
+}
+
+// review updates the kyc status of the user and audits it within tx.
+func (b *kycBusiness) review(ctx context.Context, input entity.ReviewKYC, tx *sql.Tx) (*entity.KYCDetail, error) {
+	var (
+		eventName = "business.kyc.review"
+	)
+
+	if input.Username == input.ReviewedBy {
+		return nil, errbank.NewErrForbidden("a user cannot review their own kyc")
+	}
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if user.KYCStatus != consts.KYCStatusPENDING {
+		return nil, errbank.NewErrConflict("kyc is " + user.KYCStatus + ", only a pending kyc can be reviewed")
+	}
+
+	before := entity.KYCDetail{Username: user.Username, Status: user.KYCStatus, Note: user.KYCNote}
+	user.KYCStatus = input.Status
+	user.KYCNote = input.Note
+	err = b.repo.Users.UpdateKYCStatus(ctx, *user, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	after := entity.KYCDetail{Username: user.Username, Status: user.KYCStatus, Note: user.KYCNote}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionREVIEWKYC,
+		Target: user.Username,
+		Before: before,
+		After:  after,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+	return &after, nil
+}
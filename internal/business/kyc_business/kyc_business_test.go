@@ -0,0 +1,99 @@
+package kycbusiness
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	usersrepo "github.com/hanselacn/banking-transaction/internal/repo/users_repo"
+	"github.com/pkg/errors"
+)
+
+type fakeUsersRepo struct {
+	usersrepo.UsersRepositories
+	user    entity.User
+	updated []entity.User
+}
+
+func (f *fakeUsersRepo) FindByUserName(ctx context.Context, username string) (*entity.User, error) {
+	user := f.user
+	return &user, nil
+}
+
+func (f *fakeUsersRepo) UpdateKYCStatus(ctx context.Context, user entity.User, tx *sql.Tx) error {
+	f.updated = append(f.updated, user)
+	return nil
+}
+
+type fakeAudit struct {
+	auditbusiness.AuditBusiness
+	records []entity.AuditRecord
+}
+
+func (f *fakeAudit) Record(ctx context.Context, record entity.AuditRecord, tx *sql.Tx) error {
+	f.records = append(f.records, record)
+	return nil
+}
+
+func TestReview(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   string
+		input    entity.ReviewKYC
+		expected error
+	}{
+		{
+			name:   "verifies a pending user",
+			status: consts.KYCStatusPENDING,
+			input:  entity.ReviewKYC{Username: "alice", Status: consts.KYCStatusVERIFIED, ReviewedBy: "admin"},
+		},
+		{
+			name:     "only a pending kyc is reviewed",
+			status:   consts.KYCStatusUNVERIFIED,
+			input:    entity.ReviewKYC{Username: "alice", Status: consts.KYCStatusVERIFIED, ReviewedBy: "admin"},
+			expected: errbank.ErrConflict(""),
+		},
+		{
+			name:     "nobody reviews their own kyc",
+			status:   consts.KYCStatusPENDING,
+			input:    entity.ReviewKYC{Username: "alice", Status: consts.KYCStatusVERIFIED, ReviewedBy: "alice"},
+			expected: errbank.ErrForbidden(""),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				users = &fakeUsersRepo{user: entity.User{ID: uuid.New(), Username: "alice", KYCStatus: c.status}}
+				audit = &fakeAudit{}
+				b     = &kycBusiness{repo: repo.Repo{Users: users}, audit: audit}
+			)
+
+			detail, err := b.review(context.Background(), c.input, nil)
+			if c.expected != nil {
+				if reflect.TypeOf(errors.Cause(err)) != reflect.TypeOf(c.expected) {
+					t.Fatalf("expected %T, got %v", c.expected, err)
+				}
+				if len(users.updated) != 0 || len(audit.records) != 0 {
+					t.Fatalf("a refused review must not change the user")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if detail.Status != consts.KYCStatusVERIFIED || len(users.updated) != 1 || users.updated[0].KYCStatus != consts.KYCStatusVERIFIED {
+				t.Fatalf("user is not verified: %+v", users.updated)
+			}
+			if len(audit.records) != 1 || audit.records[0].Action != consts.AuditActionREVIEWKYC {
+				t.Fatalf("review is not audited: %+v", audit.records)
+			}
+		})
+	}
+}
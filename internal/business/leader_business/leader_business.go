@@ -0,0 +1,178 @@
+package leaderbusiness
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	cfg "github.com/hanselacn/banking-transaction/config"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+const defaultLeaseTTL = 30 * time.Second
+
+type LeaderBusiness interface {
+	Campaign(ctx context.Context) error
+	IsLeader(ctx context.Context) error
+	Resign(ctx context.Context) error
+	GetLeaseStatus(ctx context.Context) (*entity.LeaseStatus, error)
+}
+
+type leaderBusiness struct {
+	repo       repo.Repo
+	leaseName  string
+	instanceID string
+	ttl        time.Duration
+
+	mu        sync.RWMutex
+	expiresAt time.Time
+}
+
+// NewLeaderBusiness holds the scheduler lease as worker.InstanceID, falling back to
+// hostname, pid and a random suffix, for worker.LeaseTTL (default: 30s).
+func NewLeaderBusiness(db *sql.DB, worker cfg.Worker) LeaderBusiness {
+	return newLeaderBusiness(repo.NewRepositories(db), worker)
+}
+
+func newLeaderBusiness(r repo.Repo, worker cfg.Worker) *leaderBusiness {
+	ttl, err := time.ParseDuration(worker.LeaseTTL)
+	if err != nil || ttl <= 0 {
+		ttl = defaultLeaseTTL
+	}
+	instanceID := worker.InstanceID
+	if instanceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "unknown"
+		}
+		instanceID = fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8])
+	}
+	return &leaderBusiness{
+		repo:       r,
+		leaseName:  consts.LeaseNameScheduler,
+		instanceID: instanceID,
+		ttl:        ttl,
+	}
+}
+
+// Campaign makes a first attempt to take the lease and keeps renewing or
+// re-acquiring it in the background until ctx is done.
+func (b *leaderBusiness) Campaign(ctx context.Context) error {
+	var (
+		eventName = "business.leader.campaign"
+	)
+
+	if err := b.tryAcquire(ctx); err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(b.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := b.tryAcquire(ctx); err != nil {
+					log.Println(eventName, err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *leaderBusiness) tryAcquire(ctx context.Context) error {
+	var (
+		eventName = "business.leader.try_acquire"
+		wasLeader = b.IsLeader(ctx) == nil
+	)
+
+	lease, err := b.repo.Lease.TryAcquire(ctx, b.leaseName, b.instanceID, b.ttl)
+	if err != nil {
+		b.setExpiresAt(time.Time{})
+		return err
+	}
+
+	if lease == nil {
+		b.setExpiresAt(time.Time{})
+		if wasLeader {
+			log.Println(eventName, "[WORKER] Lost scheduler lease", b.instanceID)
+		}
+		return nil
+	}
+
+	// Keep a local safety margin so this instance stops acting as leader
+	// slightly before the lease can be taken over by another one.
+	b.setExpiresAt(time.Now().Add(b.ttl - b.ttl/10))
+	if !wasLeader {
+		log.Println(eventName, "[WORKER] Acquired scheduler lease", b.instanceID)
+	}
+	return nil
+}
+
+func (b *leaderBusiness) setExpiresAt(t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expiresAt = t
+}
+
+// IsLeader implements gocron.Elector, returning nil only while this instance holds a live lease.
+func (b *leaderBusiness) IsLeader(ctx context.Context) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.expiresAt.IsZero() || time.Now().After(b.expiresAt) {
+		return errbank.NewErrConflict("this instance is not the scheduler leader")
+	}
+	return nil
+}
+
+// Resign gives the lease up so another instance can take over without waiting for it to expire.
+func (b *leaderBusiness) Resign(ctx context.Context) error {
+	var (
+		eventName = "business.leader.resign"
+	)
+
+	if b.IsLeader(ctx) != nil {
+		return nil
+	}
+	b.setExpiresAt(time.Time{})
+	if err := b.repo.Lease.Release(ctx, b.leaseName, b.instanceID); err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	log.Println(eventName, "[WORKER] Released scheduler lease", b.instanceID)
+	return nil
+}
+
+func (b *leaderBusiness) GetLeaseStatus(ctx context.Context) (*entity.LeaseStatus, error) {
+	var (
+		eventName = "business.leader.get_lease_status"
+		status    = entity.LeaseStatus{
+			InstanceID: b.instanceID,
+		}
+	)
+
+	lease, err := b.repo.Lease.FindByName(ctx, b.leaseName)
+	if err != nil {
+		if _, ok := err.(errbank.ErrNotFound); ok {
+			return &status, nil
+		}
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	status.Lease = lease
+	status.IsLeader = lease.Holder == status.InstanceID && lease.ExpiresAt.After(time.Now())
+	return &status, nil
+}
@@ -0,0 +1,129 @@
+package leaderbusiness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cfg "github.com/hanselacn/banking-transaction/config"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	leaserepo "github.com/hanselacn/banking-transaction/internal/repo/lease_repo"
+)
+
+// fakeLeaseRepo hands the lease to whoever asks while it is free or already theirs.
+type fakeLeaseRepo struct {
+	leaserepo.LeaseRepositories
+	lease    *entity.Lease
+	ttl      time.Duration
+	released []string
+}
+
+func (f *fakeLeaseRepo) TryAcquire(ctx context.Context, name, holder string, ttl time.Duration) (*entity.Lease, error) {
+	now := time.Now()
+	if f.lease != nil && f.lease.Holder != holder && f.lease.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	if f.lease == nil || f.lease.Holder != holder {
+		f.lease = &entity.Lease{Name: name, Holder: holder, AcquiredAt: now}
+	}
+	f.lease.RenewedAt = now
+	f.lease.ExpiresAt = now.Add(ttl)
+	f.ttl = ttl
+	lease := *f.lease
+	return &lease, nil
+}
+
+func (f *fakeLeaseRepo) Release(ctx context.Context, name, holder string) error {
+	if f.lease != nil && f.lease.Holder == holder {
+		f.lease = nil
+	}
+	f.released = append(f.released, holder)
+	return nil
+}
+
+func (f *fakeLeaseRepo) FindByName(ctx context.Context, name string) (*entity.Lease, error) {
+	lease := *f.lease
+	return &lease, nil
+}
+
+func TestNewLeaderBusinessConfig(t *testing.T) {
+	b := newLeaderBusiness(repo.Repo{}, cfg.Worker{InstanceID: "worker-1", LeaseTTL: "45s"})
+	if b.instanceID != "worker-1" || b.ttl != 45*time.Second {
+		t.Errorf("instance = %q, ttl = %v, want worker-1 and 45s", b.instanceID, b.ttl)
+	}
+
+	b = newLeaderBusiness(repo.Repo{}, cfg.Worker{LeaseTTL: "soon"})
+	if b.instanceID == "" || b.ttl != defaultLeaseTTL {
+		t.Errorf("instance = %q, ttl = %v, want a generated id and %v", b.instanceID, b.ttl, defaultLeaseTTL)
+	}
+}
+
+func TestLeaderLease(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		leases = &fakeLeaseRepo{}
+		worker = newLeaderBusiness(repo.Repo{Lease: leases}, cfg.Worker{InstanceID: "worker-1", LeaseTTL: "30s"})
+		other  = newLeaderBusiness(repo.Repo{Lease: leases}, cfg.Worker{InstanceID: "worker-2", LeaseTTL: "30s"})
+	)
+
+	// acquire
+	if err := worker.tryAcquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if worker.IsLeader(ctx) != nil || leases.lease.Holder != "worker-1" || leases.ttl != 30*time.Second {
+		t.Fatalf("lease = %+v, want worker-1 leading for 30s", leases.lease)
+	}
+	if err := other.tryAcquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if other.IsLeader(ctx) == nil {
+		t.Error("worker-2 leads while worker-1 holds the lease")
+	}
+
+	// renew
+	acquiredAt := leases.lease.AcquiredAt
+	if err := worker.tryAcquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if worker.IsLeader(ctx) != nil || !leases.lease.AcquiredAt.Equal(acquiredAt) {
+		t.Errorf("lease = %+v, want it renewed by worker-1", leases.lease)
+	}
+	status, err := worker.GetLeaseStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.IsLeader || status.InstanceID != "worker-1" {
+		t.Errorf("status = %+v, want worker-1 leading", status)
+	}
+
+	// lose
+	leases.lease.Holder, leases.lease.ExpiresAt = "worker-2", time.Now().Add(time.Minute)
+	if err := worker.tryAcquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if worker.IsLeader(ctx) == nil {
+		t.Error("worker-1 still leads after losing the lease")
+	}
+	if err := worker.Resign(ctx); err != nil || len(leases.released) != 0 {
+		t.Errorf("Resign() = %v, released = %v, want nothing released by a follower", err, leases.released)
+	}
+
+	// resign
+	leases.lease = nil
+	if err := worker.tryAcquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := worker.Resign(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if worker.IsLeader(ctx) == nil || leases.lease != nil || len(leases.released) != 1 || leases.released[0] != "worker-1" {
+		t.Errorf("lease = %+v, released = %v, want it released by worker-1", leases.lease, leases.released)
+	}
+	if err := other.tryAcquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if other.IsLeader(ctx) != nil {
+		t.Error("worker-2 cannot take the lease once worker-1 resigned")
+	}
+}
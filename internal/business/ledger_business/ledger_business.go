@@ -0,0 +1,211 @@
+package ledgerbusiness
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+type LedgerBusiness interface {
+	PostTransaction(ctx context.Context, transaction entity.Transaction, tx *sql.Tx) error
+	PostTransactionSplit(ctx context.Context, transaction entity.Transaction, contra []entity.JournalLine, tx *sql.Tx) error
+	GetTrialBalance(ctx context.Context) (*entity.TrialBalance, error)
+	CheckJournals(ctx context.Context) ([]entity.UnbalancedJournal, error)
+}
+
+type ledgerBusiness struct {
+	repo repo.Repo
+	db   *sql.DB
+}
+
+func NewLedgerBusiness(db *sql.DB) LedgerBusiness {
+	return &ledgerBusiness{
+		repo: repo.NewRepositories(db),
+		db:   db,
+	}
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// contraAccount returns the ledger account on the other side of a customer
+// posting. The customer side is always CUSTOMER_DEPOSITS.
+func contraAccount(action, txType string) (string, error) {
+	switch action {
+	case consts.TxActionDEPOSIT, consts.TxActionWITHDRAWAL, consts.TxActionPURCHASE, consts.TxActionPAYROLL:
+		return consts.LedgerCASH, nil
+	case consts.TxActionTRANSFER:
+		return consts.LedgerTRANSFERCLEARING, nil
+	case consts.TxActionINTEREST:
+		if txType == consts.TxTypeCREDIT {
+			return consts.LedgerINTERESTEXPENSE, nil
+		}
+		return consts.LedgerINTERESTINCOME, nil
+	case consts.TxActionTAX:
+		return consts.LedgerTAXPAYABLE, nil
+	case consts.TxActionFEE:
+		return consts.LedgerFEEINCOME, nil
+	case consts.TxActionTERMDEPOSIT:
+		return consts.LedgerTERMDEPOSITS, nil
+	case consts.TxActionPENALTY:
+		return consts.LedgerPENALTYINCOME, nil
+	case consts.TxActionLOAN:
+		return consts.LedgerLOANSRECEIVABLE, nil
+	case consts.TxActionADJUSTMENT:
+		return consts.LedgerRECONCILIATION, nil
+	case consts.TxActionOPENINGBALANCE:
+		return consts.LedgerOPENINGBALANCES, nil
+	}
+	return "", fmt.Errorf("no ledger account mapped for transaction action %s", action)
+}
+
+// ContraLine builds a contra line for amount against ledger account code,
+// on the opposite side of a customer posting of txType.
+func ContraLine(code, txType string, amount float64) entity.JournalLine {
+	line := entity.JournalLine{
+		ID:                uuid.New(),
+		LedgerAccountCode: code,
+	}
+	if txType == consts.TxTypeCREDIT {
+		line.Debit = amount
+	} else {
+		line.Credit = amount
+	}
+	return line
+}
+
+// buildEntry pairs the customer deposits line of transaction with the contra
+// lines and rejects the entry unless debits equal credits.
+func buildEntry(transaction entity.Transaction, contra []entity.JournalLine) (entity.JournalEntry, error) {
+	var (
+		transactionID = transaction.ID
+		accountID     = transaction.AccountID
+		customer      = entity.JournalLine{
+			ID:                uuid.New(),
+			LedgerAccountCode: consts.LedgerCUSTOMERDEPOSITS,
+			AccountID:         &accountID,
+		}
+		entry = entity.JournalEntry{
+			ID:            uuid.New(),
+			TransactionID: &transactionID,
+			Description:   fmt.Sprintf("%s %s", transaction.Action, transaction.Type),
+		}
+		debit, credit int64
+	)
+
+	if transaction.Type == consts.TxTypeCREDIT {
+		customer.Credit = transaction.Amount
+	} else {
+		customer.Debit = transaction.Amount
+	}
+	entry.Lines = []entity.JournalLine{customer}
+	for _, line := range contra {
+		// A split may leave one side empty, e.g. an instalment of interest only.
+		if cents(line.Debit) == 0 && cents(line.Credit) == 0 {
+			continue
+		}
+		entry.Lines = append(entry.Lines, line)
+	}
+	for _, line := range entry.Lines {
+		if line.Debit < 0 || line.Credit < 0 || (line.Debit == 0) == (line.Credit == 0) {
+			return entry, fmt.Errorf("journal for transaction %s has an invalid line on %s", transaction.ID, line.LedgerAccountCode)
+		}
+		debit += cents(line.Debit)
+		credit += cents(line.Credit)
+	}
+	if debit != credit {
+		return entry, fmt.Errorf("journal for transaction %s is unbalanced: debit %.2f credit %.2f", transaction.ID, float64(debit)/100, float64(credit)/100)
+	}
+	return entry, nil
+}
+
+// PostTransaction writes the balanced journal of a completed customer posting
+// within tx. An error means the books would not balance and tx must roll back.
+func (b *ledgerBusiness) PostTransaction(ctx context.Context, transaction entity.Transaction, tx *sql.Tx) error {
+	code, err := contraAccount(transaction.Action, transaction.Type)
+	if err != nil {
+		log.Println("business.ledger.post_transaction", err)
+		return err
+	}
+	return b.PostTransactionSplit(ctx, transaction, []entity.JournalLine{ContraLine(code, transaction.Type, transaction.Amount)}, tx)
+}
+
+// PostTransactionSplit is PostTransaction for postings whose contra side spans
+// several ledger accounts, such as a loan instalment of principal and interest.
+func (b *ledgerBusiness) PostTransactionSplit(ctx context.Context, transaction entity.Transaction, contra []entity.JournalLine, tx *sql.Tx) error {
+	var eventName = "business.ledger.post_transaction_split"
+
+	if cents(transaction.Amount) == 0 {
+		return nil
+	}
+	entry, err := buildEntry(transaction, contra)
+	if err != nil {
+		log.Println(eventName, "UNBALANCED JOURNAL", err)
+		return err
+	}
+	err = b.repo.Ledger.CreateEntry(ctx, entry, tx)
+	if err != nil {
+		log.Println(eventName, "CreateEntry", err)
+		return err
+	}
+	return nil
+}
+
+func (b *ledgerBusiness) GetTrialBalance(ctx context.Context) (*entity.TrialBalance, error) {
+	var eventName = "business.ledger.get_trial_balance"
+
+	lines, err := b.repo.Ledger.GetTrialBalance(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	var (
+		result = entity.TrialBalance{GeneratedAt: time.Now()}
+		debit  int64
+		credit int64
+	)
+	for _, line := range lines {
+		// Assets and expenses carry a debit balance, everything else a credit balance.
+		switch line.Type {
+		case consts.LedgerTypeASSET, consts.LedgerTypeEXPENSE:
+			line.Balance = float64(cents(line.Debit)-cents(line.Credit)) / 100
+		default:
+			line.Balance = float64(cents(line.Credit)-cents(line.Debit)) / 100
+		}
+		debit += cents(line.Debit)
+		credit += cents(line.Credit)
+		result.Lines = append(result.Lines, line)
+	}
+	result.TotalDebit = float64(debit) / 100
+	result.TotalCredit = float64(credit) / 100
+	result.Balanced = debit == credit
+	return &result, nil
+}
+
+// CheckJournals returns every journal entry whose debits and credits differ.
+// It fails with ErrRequestISE when any is found so callers cannot ignore it.
+func (b *ledgerBusiness) CheckJournals(ctx context.Context) ([]entity.UnbalancedJournal, error) {
+	var eventName = "business.ledger.check_journals"
+
+	journals, err := b.repo.Ledger.GetUnbalancedEntries(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if len(journals) > 0 {
+		log.Printf("%s UNBALANCED LEDGER: %d journal entries do not balance", eventName, len(journals))
+		return journals, errbank.NewErrRequestISE(fmt.Sprintf("ledger is unbalanced: %d journal entries do not balance", len(journals)))
+	}
+	return nil, nil
+}
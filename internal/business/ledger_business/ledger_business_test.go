@@ -0,0 +1,79 @@
+package ledgerbusiness
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+func TestBuildEntryDeposit(t *testing.T) {
+	transaction := entity.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: consts.TxTypeCREDIT, Amount: 150.25, Action: consts.TxActionDEPOSIT}
+	code, err := contraAccount(transaction.Action, transaction.Type)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := buildEntry(transaction, []entity.JournalLine{ContraLine(code, transaction.Type, transaction.Amount)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(entry.Lines))
+	}
+	customer, cash := entry.Lines[0], entry.Lines[1]
+	if customer.LedgerAccountCode != consts.LedgerCUSTOMERDEPOSITS || customer.Credit != 150.25 || *customer.AccountID != transaction.AccountID {
+		t.Errorf("unexpected customer line %+v", customer)
+	}
+	if cash.LedgerAccountCode != consts.LedgerCASH || cash.Debit != 150.25 {
+		t.Errorf("unexpected cash line %+v", cash)
+	}
+}
+
+func TestContraAccount(t *testing.T) {
+	cases := []struct {
+		action, txType, want string
+	}{
+		{consts.TxActionWITHDRAWAL, consts.TxTypeDEBIT, consts.LedgerCASH},
+		{consts.TxActionINTEREST, consts.TxTypeCREDIT, consts.LedgerINTERESTEXPENSE},
+		{consts.TxActionINTEREST, consts.TxTypeDEBIT, consts.LedgerINTERESTINCOME},
+		{consts.TxActionTAX, consts.TxTypeDEBIT, consts.LedgerTAXPAYABLE},
+		{consts.TxActionFEE, consts.TxTypeCREDIT, consts.LedgerFEEINCOME},
+		{consts.TxActionTRANSFER, consts.TxTypeDEBIT, consts.LedgerTRANSFERCLEARING},
+	}
+	for _, c := range cases {
+		got, err := contraAccount(c.action, c.txType)
+		if err != nil || got != c.want {
+			t.Errorf("contraAccount(%s, %s) = %s, %v; want %s", c.action, c.txType, got, err, c.want)
+		}
+	}
+	if _, err := contraAccount("UNKNOWN", consts.TxTypeDEBIT); err == nil {
+		t.Error("expected error for unmapped action")
+	}
+}
+
+func TestBuildEntrySplitSkipsEmptyLines(t *testing.T) {
+	transaction := entity.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: consts.TxTypeDEBIT, Amount: 100.10, Action: consts.TxActionLOAN}
+	entry, err := buildEntry(transaction, []entity.JournalLine{
+		ContraLine(consts.LedgerLOANSRECEIVABLE, transaction.Type, 0),
+		ContraLine(consts.LedgerINTERESTINCOME, transaction.Type, 100.10),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Lines) != 2 || entry.Lines[1].LedgerAccountCode != consts.LedgerINTERESTINCOME {
+		t.Fatalf("unexpected lines %+v", entry.Lines)
+	}
+}
+
+func TestBuildEntryUnbalanced(t *testing.T) {
+	transaction := entity.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: consts.TxTypeDEBIT, Amount: 100, Action: consts.TxActionLOAN}
+	_, err := buildEntry(transaction, []entity.JournalLine{
+		ContraLine(consts.LedgerLOANSRECEIVABLE, transaction.Type, 90),
+		ContraLine(consts.LedgerINTERESTINCOME, transaction.Type, 9.99),
+	})
+	if err == nil {
+		t.Fatal("expected unbalanced journal to be rejected")
+	}
+}
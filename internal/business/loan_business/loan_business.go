@@ -0,0 +1,492 @@
+package loanbusiness
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	sanctionsbusiness "github.com/hanselacn/banking-transaction/internal/business/sanctions_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+const loanBatchSize = 500
+
+type LoanBusiness interface {
+	DisburseLoan(ctx context.Context, input entity.DisburseLoan) (*entity.LoanSchedule, error)
+	GetLoans(ctx context.Context, username string) ([]entity.Loan, error)
+	GetLoanSchedule(ctx context.Context, username string, loanID uuid.UUID) (*entity.LoanSchedule, error)
+	GetLoanPayments(ctx context.Context, username string, loanID uuid.UUID) ([]entity.LoanPayment, error)
+	LoanCollectionWorker(ctx context.Context) (int, int, error)
+}
+
+type loanBusiness struct {
+	repo   repo.Repo
+	db     *sql.DB
+	ledger ledgerbusiness.LedgerBusiness
+	audit  auditbusiness.AuditBusiness
+}
+
+func NewLoanBusiness(db *sql.DB) LoanBusiness {
+	return &loanBusiness{
+		repo:   repo.NewRepositories(db),
+		db:     db,
+		ledger: ledgerbusiness.NewLedgerBusiness(db),
+		audit:  auditbusiness.NewAuditBusiness(db),
+	}
+}
+
+// DisburseLoan credits the principal to the customer's account and stores the loan
+// with its amortization schedule.
+func (b *loanBusiness) DisburseLoan(ctx context.Context, input entity.DisburseLoan) (*entity.LoanSchedule, error) {
+	var (
+		eventName = "business.loan.disburse_loan"
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	var transaction *entity.Transaction
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+			if transaction != nil {
+				b.repo.Transaction.UpdateTransactionStatus(ctx, transaction.ID, consts.TxStatusFAILED, nil)
+			}
+		}
+	}()
+
+	schedule, transaction, err := b.disburseLoan(ctx, input, time.Now(), tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// disburseLoan opens the loan with its schedule and credits the principal to the
+// account of the user within tx. The transaction is returned once it is created.
+func (b *loanBusiness) disburseLoan(ctx context.Context, input entity.DisburseLoan, now time.Time, tx *sql.Tx) (*entity.LoanSchedule, *entity.Transaction, error) {
+	var (
+		eventName = "business.loan.disburse_loan"
+	)
+
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, nil, err
+	}
+	err = sanctionsbusiness.CheckStatus(*user)
+	if err != nil {
+		return nil, nil, err
+	}
+	accountRef, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, nil, err
+	}
+
+	loan := entity.Loan{
+		ID:                   uuid.New(),
+		AccountID:            accountRef.ID,
+		Principal:            roundAmount(input.Principal),
+		InterestRate:         input.InterestRate,
+		PenaltyRate:          input.PenaltyRate,
+		TermMonths:           input.TermMonths,
+		Method:               input.Method,
+		OutstandingPrincipal: roundAmount(input.Principal),
+		Status:               consts.LoanStatusACTIVE,
+		DisbursedBy:          input.DisbursedBy,
+		DisbursedAt:          now,
+	}
+	installments := buildSchedule(loan, now)
+	transaction := entity.Transaction{
+		ID:        uuid.New(),
+		AccountID: accountRef.ID,
+		Type:      consts.TxTypeCREDIT,
+		Amount:    loan.Principal,
+		Action:    consts.TxActionLOAN,
+		Status:    consts.TxStatusINPROGRESS,
+	}
+
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, accountRef.ID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, nil, err
+	}
+
+	err = b.repo.Loan.Create(ctx, loan, tx)
+	if err != nil {
+		log.Println(eventName, "Create", err)
+		return nil, nil, err
+	}
+	for i := range installments {
+		err = b.repo.Loan.CreateInstallment(ctx, installments[i], tx)
+		if err != nil {
+			log.Println(eventName, "CreateInstallment", err)
+			return nil, nil, err
+		}
+	}
+
+	err = b.repo.Transaction.CreateTransaction(ctx, transaction, nil)
+	if err != nil {
+		log.Println(eventName, "CreateTransaction", err)
+		return nil, nil, err
+	}
+
+	account.Balance += loan.Principal
+	err = b.repo.Account.UpdateBalance(ctx, *account, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateBalance", err)
+		return nil, &transaction, err
+	}
+	err = b.repo.Transaction.UpdateTransactionStatus(ctx, transaction.ID, consts.TxStatusCOMPLETED, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateTransactionStatus", err)
+		return nil, &transaction, err
+	}
+	err = b.ledger.PostTransaction(ctx, transaction, tx)
+	if err != nil {
+		log.Println(eventName, "PostTransaction", err)
+		return nil, &transaction, err
+	}
+
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionDISBURSELOAN,
+		Target: loan.ID.String(),
+		After:  loan,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, &transaction, err
+	}
+
+	return &entity.LoanSchedule{
+		Loan:                 loan,
+		OutstandingPrincipal: loan.OutstandingPrincipal,
+		Installments:         installments,
+	}, &transaction, nil
+}
+
+func (b *loanBusiness) GetLoans(ctx context.Context, username string) ([]entity.Loan, error) {
+	var (
+		eventName = "business.loan.get_loans"
+	)
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	loans, err := b.repo.Loan.GetListByAccountID(ctx, account.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return loans, nil
+}
+
+// findOwnedLoan returns the loan only when it was disbursed to username's account.
+func (b *loanBusiness) findOwnedLoan(ctx context.Context, username string, loanID uuid.UUID) (*entity.Loan, error) {
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	loan, err := b.repo.Loan.FindByID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if loan.AccountID != account.ID {
+		return nil, errbank.NewErrNotFound("loan not found!")
+	}
+	return loan, nil
+}
+
+func (b *loanBusiness) GetLoanSchedule(ctx context.Context, username string, loanID uuid.UUID) (*entity.LoanSchedule, error) {
+	var (
+		eventName = "business.loan.get_loan_schedule"
+		now       = time.Now()
+	)
+
+	loan, err := b.findOwnedLoan(ctx, username, loanID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	installments, err := b.repo.Loan.GetInstallments(ctx, loan.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	schedule := entity.LoanSchedule{
+		Loan:                 *loan,
+		OutstandingPrincipal: loan.OutstandingPrincipal,
+		Installments:         installments,
+	}
+	for _, installment := range installments {
+		if installment.Status != consts.InstallmentStatusPAID && !installment.DueDate.After(now) {
+			schedule.OverdueAmount += installment.Remaining()
+		}
+	}
+	schedule.OverdueAmount = roundAmount(schedule.OverdueAmount)
+	return &schedule, nil
+}
+
+func (b *loanBusiness) GetLoanPayments(ctx context.Context, username string, loanID uuid.UUID) ([]entity.LoanPayment, error) {
+	var (
+		eventName = "business.loan.get_loan_payments"
+	)
+
+	loan, err := b.findOwnedLoan(ctx, username, loanID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	payments, err := b.repo.Loan.GetPayments(ctx, loan.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return payments, nil
+}
+
+// LoanCollectionWorker collects every due instalment from the linked accounts.
+func (b *loanBusiness) LoanCollectionWorker(ctx context.Context) (int, int, error) {
+	var (
+		eventName    = "business.loan.loan_collection_worker"
+		now          = time.Now()
+		lastID       = uuid.Nil
+		totalData    = 0
+		totalSuccess = 0
+	)
+
+	for {
+		loanIDs, err := b.repo.Loan.GetDueLoanBatch(ctx, now, lastID, loanBatchSize)
+		if err != nil {
+			log.Println(eventName, err)
+			return totalData, totalSuccess, err
+		}
+		for _, loanID := range loanIDs {
+			totalData++
+			if err := b.collectLoan(ctx, loanID, now); err != nil {
+				log.Println(eventName, loanID, err)
+				continue
+			}
+			totalSuccess++
+		}
+		if len(loanIDs) < loanBatchSize {
+			return totalData, totalSuccess, nil
+		}
+		lastID = loanIDs[len(loanIDs)-1]
+	}
+}
+
+// collectLoan accrues penalty interest on overdue instalments and pays the due
+// instalments, oldest first, from the positive balance of the linked account.
+// Instalments left unpaid are marked LATE.
+func (b *loanBusiness) collectLoan(ctx context.Context, loanID uuid.UUID, now time.Time) error {
+	var (
+		eventName    = "business.loan.collect_loan"
+		payments     []entity.LoanPayment
+		transactions []entity.Transaction
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+			for i := range transactions {
+				b.repo.Transaction.UpdateTransactionStatus(ctx, transactions[i].ID, consts.TxStatusFAILED, nil)
+			}
+		}
+	}()
+
+	loan, err := b.repo.Loan.FindByIDForUpdate(ctx, loanID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, loan.AccountID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	installments, err := b.repo.Loan.GetDueInstallmentsForUpdate(ctx, loan.ID, now, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	var (
+		available    = math.Max(account.Balance, 0)
+		totalDue     float64
+		totalPenalty float64
+	)
+	for i := range installments {
+		installment := &installments[i]
+		if installment.Status == consts.InstallmentStatusLATE {
+			accruePenalty(installment, loan.PenaltyRate, now)
+		}
+
+		pay := roundAmount(math.Min(installment.Remaining(), available))
+		if pay > 0 {
+			principal, interest, penalty := allocatePayment(installment, pay)
+			available -= principal + interest + penalty
+			totalDue += principal + interest
+			totalPenalty += penalty
+			loan.OutstandingPrincipal = roundAmount(loan.OutstandingPrincipal - principal)
+			payments = append(payments, entity.LoanPayment{
+				ID:            uuid.New(),
+				LoanID:        loan.ID,
+				InstallmentID: installment.ID,
+				Amount:        roundAmount(principal + interest + penalty),
+				Principal:     principal,
+				Interest:      interest,
+				Penalty:       penalty,
+			})
+		}
+
+		if roundAmount(installment.Remaining()) <= 0 {
+			paidAt := now
+			installment.Status = consts.InstallmentStatusPAID
+			installment.PaidAt = &paidAt
+		} else if installment.Status != consts.InstallmentStatusLATE {
+			// Penalty starts accruing from the due date on the next run.
+			installment.Status = consts.InstallmentStatusLATE
+		}
+
+		err = b.repo.Loan.UpdateInstallment(ctx, *installment, tx)
+		if err != nil {
+			log.Println(eventName, "UpdateInstallment", err)
+			return err
+		}
+	}
+
+	var totalPrincipal, totalInterest float64
+	for i := range payments {
+		totalPrincipal += payments[i].Principal
+		totalInterest += payments[i].Interest
+	}
+	if totalDue > 0 {
+		transactions = append(transactions, entity.Transaction{
+			ID:        uuid.New(),
+			AccountID: account.ID,
+			Type:      consts.TxTypeDEBIT,
+			Amount:    roundAmount(totalDue),
+			Action:    consts.TxActionLOAN,
+			Status:    consts.TxStatusINPROGRESS,
+		})
+	}
+	if totalPenalty > 0 {
+		transactions = append(transactions, entity.Transaction{
+			ID:        uuid.New(),
+			AccountID: account.ID,
+			Type:      consts.TxTypeDEBIT,
+			Amount:    roundAmount(totalPenalty),
+			Action:    consts.TxActionPENALTY,
+			Status:    consts.TxStatusINPROGRESS,
+		})
+	}
+	for i := range transactions {
+		err = b.repo.Transaction.CreateTransaction(ctx, transactions[i], nil)
+		if err != nil {
+			log.Println(eventName, "CreateTransaction", err)
+			return err
+		}
+	}
+
+	if len(payments) > 0 {
+		account.Balance = roundAmount(account.Balance - totalDue - totalPenalty)
+		err = b.repo.Account.UpdateBalance(ctx, *account, tx)
+		if err != nil {
+			log.Println(eventName, "UpdateBalance", err)
+			return err
+		}
+		for i := range payments {
+			payments[i].TransactionID = transactions[0].ID
+			err = b.repo.Loan.CreatePayment(ctx, payments[i], tx)
+			if err != nil {
+				log.Println(eventName, "CreatePayment", err)
+				return err
+			}
+		}
+	}
+
+	if loan.OutstandingPrincipal <= 0 {
+		loan.OutstandingPrincipal = 0
+		loan.Status = consts.LoanStatusPAIDOFF
+	}
+	err = b.repo.Loan.UpdateOutstanding(ctx, *loan, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateOutstanding", err)
+		return err
+	}
+
+	for i := range transactions {
+		err = b.repo.Transaction.UpdateTransactionStatus(ctx, transactions[i].ID, consts.TxStatusCOMPLETED, tx)
+		if err != nil {
+			log.Println(eventName, "UpdateTransactionStatus", err)
+			return err
+		}
+		if transactions[i].Action == consts.TxActionLOAN {
+			// The instalment repays principal and earns interest income.
+			err = b.ledger.PostTransactionSplit(ctx, transactions[i], []entity.JournalLine{
+				ledgerbusiness.ContraLine(consts.LedgerLOANSRECEIVABLE, consts.TxTypeDEBIT, roundAmount(totalPrincipal)),
+				ledgerbusiness.ContraLine(consts.LedgerINTERESTINCOME, consts.TxTypeDEBIT, roundAmount(totalInterest)),
+			}, tx)
+		} else {
+			err = b.ledger.PostTransaction(ctx, transactions[i], tx)
+		}
+		if err != nil {
+			log.Println(eventName, "PostTransaction", err)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package loanbusiness
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	accountrepo "github.com/hanselacn/banking-transaction/internal/repo/account_repo"
+	loanrepo "github.com/hanselacn/banking-transaction/internal/repo/loan_repo"
+	transactionrepo "github.com/hanselacn/banking-transaction/internal/repo/transaction_repo"
+	usersrepo "github.com/hanselacn/banking-transaction/internal/repo/users_repo"
+	"github.com/pkg/errors"
+)
+
+type fakeUsersRepo struct {
+	usersrepo.UsersRepositories
+	user entity.User
+}
+
+func (f *fakeUsersRepo) FindByUserName(ctx context.Context, username string) (*entity.User, error) {
+	user := f.user
+	return &user, nil
+}
+
+type fakeAccountRepo struct {
+	accountrepo.AccountRepositories
+	account entity.Account
+}
+
+func (f *fakeAccountRepo) FindByUserID(ctx context.Context, id uuid.UUID) (*entity.Account, error) {
+	account := f.account
+	return &account, nil
+}
+
+func (f *fakeAccountRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Account, error) {
+	account := f.account
+	return &account, nil
+}
+
+func (f *fakeAccountRepo) UpdateBalance(ctx context.Context, account entity.Account, tx *sql.Tx) error {
+	f.account.Balance = account.Balance
+	return nil
+}
+
+type fakeLoanRepo struct {
+	loanrepo.LoanRepositories
+	loans        []entity.Loan
+	installments []entity.LoanInstallment
+}
+
+func (f *fakeLoanRepo) Create(ctx context.Context, loan entity.Loan, tx *sql.Tx) error {
+	f.loans = append(f.loans, loan)
+	return nil
+}
+
+func (f *fakeLoanRepo) CreateInstallment(ctx context.Context, installment entity.LoanInstallment, tx *sql.Tx) error {
+	f.installments = append(f.installments, installment)
+	return nil
+}
+
+type fakeTransactionRepo struct {
+	transactionrepo.TransactionRepo
+	transactions []entity.Transaction
+	completed    []uuid.UUID
+}
+
+func (f *fakeTransactionRepo) CreateTransaction(ctx context.Context, input entity.Transaction, tx *sql.Tx) error {
+	f.transactions = append(f.transactions, input)
+	return nil
+}
+
+func (f *fakeTransactionRepo) UpdateTransactionStatus(ctx context.Context, trID uuid.UUID, status string, tx *sql.Tx) error {
+	if status == consts.TxStatusCOMPLETED {
+		f.completed = append(f.completed, trID)
+	}
+	return nil
+}
+
+type fakeLedger struct {
+	ledgerbusiness.LedgerBusiness
+	posted []entity.Transaction
+}
+
+func (f *fakeLedger) PostTransaction(ctx context.Context, transaction entity.Transaction, tx *sql.Tx) error {
+	f.posted = append(f.posted, transaction)
+	return nil
+}
+
+type fakeAudit struct {
+	auditbusiness.AuditBusiness
+	records []entity.AuditRecord
+}
+
+func (f *fakeAudit) Record(ctx context.Context, record entity.AuditRecord, tx *sql.Tx) error {
+	f.records = append(f.records, record)
+	return nil
+}
+
+func TestDisburseLoanSanctions(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  string
+		allowed bool
+	}{
+		{"clear", "", true},
+		{"on hold", consts.SanctionsStatusHOLD, false},
+		{"blocked", consts.SanctionsStatusBLOCKED, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				user         = entity.User{ID: uuid.New(), Username: "alice", SanctionsStatus: c.status}
+				accounts     = &fakeAccountRepo{account: entity.Account{ID: uuid.New(), UserID: user.ID, Balance: 1000}}
+				loans        = &fakeLoanRepo{}
+				transactions = &fakeTransactionRepo{}
+				ledger       = &fakeLedger{}
+				b            = &loanBusiness{
+					repo: repo.Repo{
+						Users:       &fakeUsersRepo{user: user},
+						Account:     accounts,
+						Loan:        loans,
+						Transaction: transactions,
+					},
+					ledger: ledger,
+					audit:  &fakeAudit{},
+				}
+			)
+
+			schedule, transaction, err := b.disburseLoan(context.Background(), entity.DisburseLoan{
+				Username:     user.Username,
+				Principal:    12000,
+				InterestRate: 0.12,
+				TermMonths:   12,
+				Method:       consts.LoanMethodFLAT,
+				DisbursedBy:  "admin",
+			}, time.Now(), nil)
+			if !c.allowed {
+				if _, ok := errors.Cause(err).(errbank.ErrForbidden); !ok {
+					t.Fatalf("disburseLoan() error = %v, want ErrForbidden", err)
+				}
+				if transaction != nil || len(loans.loans) != 0 || len(transactions.transactions) != 0 || accounts.account.Balance != 1000 {
+					t.Errorf("loans = %+v, transactions = %+v, balance = %v, want nothing disbursed", loans.loans, transactions.transactions, accounts.account.Balance)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if accounts.account.Balance != 13000 || len(schedule.Installments) != 12 || len(loans.installments) != 12 {
+				t.Errorf("balance = %v, installments = %d, want 13000 and 12", accounts.account.Balance, len(loans.installments))
+			}
+			if len(transactions.completed) != 1 || len(ledger.posted) != 1 || transactions.completed[0] != transaction.ID {
+				t.Errorf("completed = %v, posted = %d, want the loan credit completed and posted", transactions.completed, len(ledger.posted))
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package loanbusiness
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// buildSchedule splits a loan into monthly installments starting one month after start.
+// ANNUITY keeps every installment equal with interest charged on the declining balance,
+// FLAT charges interest on the original principal for the whole term. The last
+// installment absorbs rounding so the principal always adds up to the loan amount.
+func buildSchedule(loan entity.Loan, start time.Time) []entity.LoanInstallment {
+	var (
+		installments = make([]entity.LoanInstallment, 0, loan.TermMonths)
+		monthlyRate  = loan.InterestRate / 12
+		remaining    = loan.Principal
+		payment      float64
+	)
+
+	switch {
+	case loan.Method == consts.LoanMethodFLAT:
+		payment = roundAmount(loan.Principal / float64(loan.TermMonths))
+	case monthlyRate == 0:
+		payment = roundAmount(loan.Principal / float64(loan.TermMonths))
+	default:
+		payment = roundAmount(loan.Principal * monthlyRate / (1 - math.Pow(1+monthlyRate, -float64(loan.TermMonths))))
+	}
+
+	for seq := 1; seq <= loan.TermMonths; seq++ {
+		var principal, interest float64
+		if loan.Method == consts.LoanMethodFLAT {
+			interest = roundAmount(loan.Principal * monthlyRate)
+			principal = payment
+		} else {
+			interest = roundAmount(remaining * monthlyRate)
+			principal = roundAmount(payment - interest)
+		}
+		if seq == loan.TermMonths || principal > remaining {
+			principal = roundAmount(remaining)
+		}
+		remaining = roundAmount(remaining - principal)
+
+		installments = append(installments, entity.LoanInstallment{
+			ID:           uuid.New(),
+			LoanID:       loan.ID,
+			Seq:          seq,
+			DueDate:      start.AddDate(0, seq, 0),
+			PrincipalDue: principal,
+			InterestDue:  interest,
+			Status:       consts.InstallmentStatusPENDING,
+		})
+	}
+	return installments
+}
+
+// accruePenalty adds penalty interest on the overdue principal and interest of an
+// installment for the days elapsed since its due date or the previous accrual.
+func accruePenalty(installment *entity.LoanInstallment, penaltyRate float64, now time.Time) {
+	from := installment.DueDate
+	if installment.PenaltyAccruedAt != nil && installment.PenaltyAccruedAt.After(from) {
+		from = *installment.PenaltyAccruedAt
+	}
+	if !now.After(from) {
+		return
+	}
+	overdue := (installment.PrincipalDue - installment.PrincipalPaid) + (installment.InterestDue - installment.InterestPaid)
+	days := now.Sub(from).Hours() / 24
+	installment.PenaltyDue = roundAmount(installment.PenaltyDue + overdue*penaltyRate*days/365)
+	accruedAt := now
+	installment.PenaltyAccruedAt = &accruedAt
+}
+
+// allocatePayment applies up to amount to the installment, settling penalty first,
+// then interest, then principal, and returns the split actually paid.
+func allocatePayment(installment *entity.LoanInstallment, amount float64) (principal, interest, penalty float64) {
+	penalty = roundAmount(math.Min(amount, installment.PenaltyDue-installment.PenaltyPaid))
+	amount -= penalty
+	interest = roundAmount(math.Min(amount, installment.InterestDue-installment.InterestPaid))
+	amount -= interest
+	principal = roundAmount(math.Min(amount, installment.PrincipalDue-installment.PrincipalPaid))
+
+	installment.PenaltyPaid = roundAmount(installment.PenaltyPaid + penalty)
+	installment.InterestPaid = roundAmount(installment.InterestPaid + interest)
+	installment.PrincipalPaid = roundAmount(installment.PrincipalPaid + principal)
+	return principal, interest, penalty
+}
@@ -0,0 +1,80 @@
+package loanbusiness
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+func TestBuildScheduleAnnuity(t *testing.T) {
+	loan := entity.Loan{Principal: 10000, InterestRate: 0.12, TermMonths: 12, Method: consts.LoanMethodANNUITY}
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	installments := buildSchedule(loan, start)
+	if len(installments) != 12 {
+		t.Fatalf("expected 12 installments, got %d", len(installments))
+	}
+
+	var principal float64
+	for i, installment := range installments {
+		principal += installment.PrincipalDue
+		if want := start.AddDate(0, i+1, 0); !installment.DueDate.Equal(want) {
+			t.Errorf("installment %d due %v, want %v", installment.Seq, installment.DueDate, want)
+		}
+		if i < len(installments)-1 {
+			if total := installment.PrincipalDue + installment.InterestDue; math.Abs(total-888.49) > 0.011 {
+				t.Errorf("installment %d total %.2f, want 888.49", installment.Seq, total)
+			}
+		}
+	}
+	if math.Abs(principal-loan.Principal) > 0.001 {
+		t.Errorf("principal sums to %.2f, want %.2f", principal, loan.Principal)
+	}
+	if installments[0].InterestDue != 100 {
+		t.Errorf("first interest %.2f, want 100.00", installments[0].InterestDue)
+	}
+}
+
+func TestBuildScheduleFlat(t *testing.T) {
+	loan := entity.Loan{Principal: 1000, InterestRate: 0.12, TermMonths: 3, Method: consts.LoanMethodFLAT}
+
+	installments := buildSchedule(loan, time.Now())
+	want := []float64{333.33, 333.33, 333.34}
+	for i, installment := range installments {
+		if installment.PrincipalDue != want[i] {
+			t.Errorf("installment %d principal %.2f, want %.2f", installment.Seq, installment.PrincipalDue, want[i])
+		}
+		if installment.InterestDue != 10 {
+			t.Errorf("installment %d interest %.2f, want 10.00", installment.Seq, installment.InterestDue)
+		}
+	}
+}
+
+func TestAllocatePayment(t *testing.T) {
+	installment := entity.LoanInstallment{PrincipalDue: 100, InterestDue: 10, PenaltyDue: 2}
+
+	principal, interest, penalty := allocatePayment(&installment, 50)
+	if penalty != 2 || interest != 10 || principal != 38 {
+		t.Fatalf("got principal %.2f interest %.2f penalty %.2f", principal, interest, penalty)
+	}
+	if remaining := installment.Remaining(); math.Abs(remaining-62) > 0.001 {
+		t.Fatalf("remaining %.2f, want 62.00", remaining)
+	}
+}
+
+func TestAccruePenalty(t *testing.T) {
+	due := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	installment := entity.LoanInstallment{DueDate: due, PrincipalDue: 3650, InterestDue: 0}
+
+	accruePenalty(&installment, 0.1, due.AddDate(0, 0, 10))
+	if installment.PenaltyDue != 10 {
+		t.Fatalf("penalty %.2f, want 10.00", installment.PenaltyDue)
+	}
+	accruePenalty(&installment, 0.1, due.AddDate(0, 0, 10))
+	if installment.PenaltyDue != 10 {
+		t.Fatalf("penalty accrued twice for the same day: %.2f", installment.PenaltyDue)
+	}
+}
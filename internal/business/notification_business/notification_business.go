@@ -0,0 +1,265 @@
+package notificationbusiness
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/notifyx"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+const (
+	defaultNotificationFile = "notifications.log"
+	notificationTimeout     = 30 * time.Second
+	notificationMaxMessage  = 255
+	notificationTimeLayout  = "02 Jan 2006 15:04 MST"
+)
+
+type NotificationBusiness interface {
+	// Notify sends the notification on every channel the user enabled, in the
+	// background: a failing channel never fails the operation that triggered it.
+	Notify(ctx context.Context, input entity.NotificationInput)
+	GetPreferences(ctx context.Context, username string) ([]entity.NotificationPreference, error)
+	UpdatePreference(ctx context.Context, input entity.UpdateNotificationPreference) (*entity.NotificationPreference, error)
+	GetNotifications(ctx context.Context, username string, m meta.Metadata) ([]entity.Notification, error)
+}
+
+type notificationBusiness struct {
+	repo      repo.Repo
+	db        *sql.DB
+	notifiers map[string]notifyx.Notifier
+}
+
+func NewNotificationBusiness(db *sql.DB) NotificationBusiness {
+	return &notificationBusiness{
+		repo:      repo.NewRepositories(db),
+		db:        db,
+		notifiers: newNotifiers(),
+	}
+}
+
+// newNotifiers sends emails through SMTP_HOST when it is set. Without it, and
+// for SMS which has no provider yet, the messages go to NOTIFICATION_FILE.
+func newNotifiers() map[string]notifyx.Notifier {
+	path := os.Getenv("NOTIFICATION_FILE")
+	if path == "" {
+		path = defaultNotificationFile
+	}
+	var (
+		logNotifier                    = notifyx.NewLogNotifier(path)
+		emailNotifier notifyx.Notifier = logNotifier
+	)
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		emailNotifier = notifyx.NewSMTPNotifier(notifyx.SMTPConfig{
+			Host:     host,
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+	}
+	return map[string]notifyx.Notifier{
+		consts.NotificationChannelEMAIL: emailNotifier,
+		consts.NotificationChannelSMS:   logNotifier,
+	}
+}
+
+// TransactionNotice is the notification of a completed deposit or withdrawal.
+func TransactionNotice(notificationType string, userID uuid.UUID, account entity.Account, transaction entity.Transaction) entity.NotificationInput {
+	ending := account.AccountNumber
+	if len(ending) > 4 {
+		ending = ending[len(ending)-4:]
+	}
+	return entity.NotificationInput{
+		UserID: userID,
+		Type:   notificationType,
+		Data: map[string]string{
+			"Amount":        fmt.Sprintf("%.2f", transaction.Amount),
+			"AccountEnding": ending,
+			"TransactionID": transaction.ID.String(),
+		},
+	}
+}
+
+// StandingOrderNotice is the notification of an occurrence of order due on scheduledFor
+// that was made, or failed for reason.
+func StandingOrderNotice(notificationType string, userID uuid.UUID, account entity.Account, order entity.StandingOrder, scheduledFor time.Time, reason string) entity.NotificationInput {
+	ending := account.AccountNumber
+	if len(ending) > 4 {
+		ending = ending[len(ending)-4:]
+	}
+	return entity.NotificationInput{
+		UserID: userID,
+		Type:   notificationType,
+		Data: map[string]string{
+			"OrderType":       strings.ToLower(order.Type),
+			"Amount":          fmt.Sprintf("%.2f", order.Amount),
+			"AccountEnding":   ending,
+			"ScheduledFor":    scheduledFor.Format("2006-01-02"),
+			"StandingOrderID": order.ID.String(),
+			"Reason":          reason,
+		},
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func (b *notificationBusiness) Notify(ctx context.Context, input entity.NotificationInput) {
+	var eventName = "business.notification.notify"
+
+	// The triggering request may be over before the channels answer.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notificationTimeout)
+	go func() {
+		defer cancel()
+		if _, err := b.send(ctx, input); err != nil {
+			log.Println(eventName, input.Type, input.UserID, err)
+		}
+	}()
+}
+
+// send renders and sends the notification on each enabled channel and records
+// every attempt, sent or failed.
+func (b *notificationBusiness) send(ctx context.Context, input entity.NotificationInput) ([]entity.Notification, error) {
+	var (
+		eventName     = "business.notification.send"
+		notifications []entity.Notification
+	)
+
+	user, err := b.repo.Users.FindByID(ctx, input.UserID)
+	if err != nil {
+		log.Println(eventName, "FindByID", err)
+		return nil, err
+	}
+	preferences, err := b.repo.Notification.GetPreferences(ctx, input.UserID)
+	if err != nil {
+		log.Println(eventName, "GetPreferences", err)
+		return nil, err
+	}
+
+	data := map[string]string{
+		"FullName": user.Fullname,
+		"Username": user.Username,
+		"Time":     time.Now().Format(notificationTimeLayout),
+	}
+	if data["FullName"] == "" {
+		data["FullName"] = user.Username
+	}
+	for k, v := range input.Data {
+		data[k] = v
+	}
+
+	for _, preference := range preferences {
+		notifier, ok := b.notifiers[preference.Channel]
+		if !preference.Enabled || !ok {
+			continue
+		}
+		subject, body, err := render(input.Type, preference.Channel, data)
+		if err != nil {
+			log.Println(eventName, "render", err)
+			return notifications, err
+		}
+
+		_, recorded, err := render(input.Type, preference.Channel, redact(data))
+		if err != nil {
+			log.Println(eventName, "render", err)
+			return notifications, err
+		}
+
+		notification := entity.Notification{
+			ID:        uuid.New(),
+			UserID:    input.UserID,
+			Type:      input.Type,
+			Channel:   preference.Channel,
+			Recipient: preference.Address,
+			Subject:   subject,
+			Body:      recorded,
+			Status:    consts.NotificationStatusSENT,
+			CreatedAt: time.Now(),
+		}
+		err = notifier.Send(ctx, notifyx.Message{
+			Channel: preference.Channel,
+			To:      preference.Address,
+			Subject: subject,
+			Body:    body,
+		})
+		if err != nil {
+			log.Println(eventName, "Send", preference.Channel, err)
+			notification.Status = consts.NotificationStatusFAILED
+			notification.Error = truncate(err.Error(), notificationMaxMessage)
+		}
+		if err := b.repo.Notification.Create(ctx, notification); err != nil {
+			log.Println(eventName, "Create", err)
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
+
+func (b *notificationBusiness) GetPreferences(ctx context.Context, username string) ([]entity.NotificationPreference, error) {
+	var eventName = "business.notification.get_preferences"
+
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	preferences, err := b.repo.Notification.GetPreferences(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func (b *notificationBusiness) UpdatePreference(ctx context.Context, input entity.UpdateNotificationPreference) (*entity.NotificationPreference, error) {
+	var eventName = "business.notification.update_preference"
+
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	preference := entity.NotificationPreference{
+		UserID:    user.ID,
+		Channel:   input.Channel,
+		Address:   input.Address,
+		Enabled:   input.Enabled,
+		UpdatedAt: time.Now(),
+	}
+	err = b.repo.Notification.UpsertPreference(ctx, preference)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return &preference, nil
+}
+
+func (b *notificationBusiness) GetNotifications(ctx context.Context, username string, m meta.Metadata) ([]entity.Notification, error) {
+	var eventName = "business.notification.get_notifications"
+
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	notifications, err := b.repo.Notification.GetList(ctx, user.ID, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return notifications, nil
+}
@@ -0,0 +1,161 @@
+package notificationbusiness
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/notifyx"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	notificationrepo "github.com/hanselacn/banking-transaction/internal/repo/notification_repo"
+	usersrepo "github.com/hanselacn/banking-transaction/internal/repo/users_repo"
+)
+
+type fakeUsersRepo struct {
+	usersrepo.UsersRepositories
+	user entity.User
+}
+
+func (f *fakeUsersRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	return &f.user, nil
+}
+
+type fakeNotificationRepo struct {
+	notificationrepo.NotificationRepositories
+	preferences []entity.NotificationPreference
+	created     []entity.Notification
+}
+
+func (f *fakeNotificationRepo) GetPreferences(ctx context.Context, userID uuid.UUID) ([]entity.NotificationPreference, error) {
+	return f.preferences, nil
+}
+
+func (f *fakeNotificationRepo) Create(ctx context.Context, notification entity.Notification) error {
+	f.created = append(f.created, notification)
+	return nil
+}
+
+type recordingNotifier struct {
+	err  error
+	sent []notifyx.Message
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, message notifyx.Message) error {
+	n.sent = append(n.sent, message)
+	return n.err
+}
+
+func TestSendUsesPreferencesAndRecords(t *testing.T) {
+	var (
+		user          = entity.User{ID: uuid.New(), Username: "alice", Fullname: "Alice Liddell"}
+		email         = &recordingNotifier{}
+		sms           = &recordingNotifier{err: errors.New("gateway down")}
+		notifications = &fakeNotificationRepo{preferences: []entity.NotificationPreference{
+			{UserID: user.ID, Channel: consts.NotificationChannelEMAIL, Address: "alice@example.com", Enabled: true},
+			{UserID: user.ID, Channel: consts.NotificationChannelSMS, Address: "+6281234567890", Enabled: true},
+		}}
+		b = &notificationBusiness{
+			repo: repo.Repo{Users: &fakeUsersRepo{user: user}, Notification: notifications},
+			notifiers: map[string]notifyx.Notifier{
+				consts.NotificationChannelEMAIL: email,
+				consts.NotificationChannelSMS:   sms,
+			},
+		}
+		account     = entity.Account{AccountNumber: "000000000000000000000000000000001234"}
+		transaction = entity.Transaction{ID: uuid.New(), Amount: 150000}
+	)
+
+	sent, err := b.send(context.Background(), TransactionNotice(consts.NotificationTypeDEPOSIT, user.ID, account, transaction))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || len(notifications.created) != 2 {
+		t.Fatalf("got %d sent and %d recorded, want 2 and 2", len(sent), len(notifications.created))
+	}
+
+	if len(email.sent) != 1 {
+		t.Fatalf("got %d emails, want 1", len(email.sent))
+	}
+	message := email.sent[0]
+	if message.To != "alice@example.com" || message.Subject != "Deposit received" {
+		t.Errorf("email to %q with subject %q", message.To, message.Subject)
+	}
+	for _, want := range []string{"Hello Alice Liddell", "150000.00", "ending 1234", transaction.ID.String()} {
+		if !strings.Contains(message.Body, want) {
+			t.Errorf("email body does not contain %q:\n%s", want, message.Body)
+		}
+	}
+	if strings.Contains(message.Body, account.AccountNumber) {
+		t.Errorf("email body shows the full account number")
+	}
+
+	if notifications.created[0].Status != consts.NotificationStatusSENT {
+		t.Errorf("email recorded as %s, want SENT", notifications.created[0].Status)
+	}
+	if record := notifications.created[1]; record.Status != consts.NotificationStatusFAILED || record.Error != "gateway down" || record.Subject != "" {
+		t.Errorf("sms recorded as %+v, want FAILED without subject", record)
+	}
+
+	// A disabled channel is skipped.
+	notifications.preferences[0].Enabled = false
+	sent, err = b.send(context.Background(), entity.NotificationInput{UserID: user.ID, Type: consts.NotificationTypePASSWORDCHANGED})
+	if err != nil || len(sent) != 1 || sent[0].Channel != consts.NotificationChannelSMS {
+		t.Errorf("send() = %+v, %v, want the sms only", sent, err)
+	}
+
+	// A reset token reaches the user but is not kept in the record.
+	sms.err = nil
+	sent, err = b.send(context.Background(), entity.NotificationInput{
+		UserID: user.ID,
+		Type:   consts.NotificationTypePASSWORDRESET,
+		Data:   map[string]string{"Token": "s3cr3t-token", "ExpiresAt": "2026-01-01 10:30"},
+	})
+	if err != nil || len(sent) != 1 {
+		t.Fatalf("send() = %+v, %v, want the sms", sent, err)
+	}
+	if last := sms.sent[len(sms.sent)-1]; !strings.Contains(last.Body, "s3cr3t-token") {
+		t.Errorf("sms body does not contain the token:\n%s", last.Body)
+	}
+	if strings.Contains(sent[0].Body, "s3cr3t-token") {
+		t.Errorf("recorded body shows the token:\n%s", sent[0].Body)
+	}
+}
+
+func TestTemplatesRenderWithTheirTriggerData(t *testing.T) {
+	data := map[string]string{"FullName": "Alice", "Username": "alice", "Time": "01 Jan 2026 10:00 UTC"}
+	triggers := map[string]map[string]string{
+		consts.NotificationTypeDEPOSIT:         TransactionNotice(consts.NotificationTypeDEPOSIT, uuid.Nil, entity.Account{}, entity.Transaction{}).Data,
+		consts.NotificationTypeWITHDRAWAL:      TransactionNotice(consts.NotificationTypeWITHDRAWAL, uuid.Nil, entity.Account{}, entity.Transaction{}).Data,
+		consts.NotificationTypePASSWORDCHANGED: nil,
+		consts.NotificationTypePASSWORDRESET:   {"Token": "token", "ExpiresAt": "2026-01-01 10:30"},
+		consts.NotificationTypeROLECHANGED:     {"OldRole": "customer", "NewRole": "admin"},
+		consts.NotificationTypeSTANDINGORDEREXECUTED: StandingOrderNotice(consts.NotificationTypeSTANDINGORDEREXECUTED, uuid.Nil, entity.Account{},
+			entity.StandingOrder{}, time.Time{}, "").Data,
+		consts.NotificationTypeSTANDINGORDERFAILED: StandingOrderNotice(consts.NotificationTypeSTANDINGORDERFAILED, uuid.Nil, entity.Account{},
+			entity.StandingOrder{}, time.Time{}, "insufficient balance").Data,
+	}
+	if len(triggers) != len(templates) {
+		t.Fatalf("%d templates for %d triggers", len(templates), len(triggers))
+	}
+	for notificationType, extra := range triggers {
+		merged := map[string]string{}
+		for _, m := range []map[string]string{data, extra} {
+			for k, v := range m {
+				merged[k] = v
+			}
+		}
+		for _, channel := range []string{consts.NotificationChannelEMAIL, consts.NotificationChannelSMS} {
+			if _, body, err := render(notificationType, channel, merged); err != nil || body == "" {
+				t.Errorf("render(%s, %s) = %q, %v", notificationType, channel, body, err)
+			}
+		}
+	}
+	if _, _, err := render("UNKNOWN", consts.NotificationChannelEMAIL, data); err == nil {
+		t.Error("expected an error for a type without template")
+	}
+}
@@ -0,0 +1,129 @@
+package notificationbusiness
+
+import (
+	"errors"
+	"strings"
+	"text/template"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+)
+
+type notificationTemplate struct {
+	subject string
+	email   string
+	sms     string
+}
+
+// templates are filled with the notification data plus FullName, Username and
+// Time. A missing key fails the rendering instead of printing "<no value>".
+var templates = map[string]notificationTemplate{
+	consts.NotificationTypeDEPOSIT: {
+		subject: "Deposit received",
+		email: `Hello {{.FullName}},
+
+{{.Amount}} was deposited to your account ending {{.AccountEnding}} on {{.Time}}.
+Reference: {{.TransactionID}}
+
+If you did not expect this deposit, please contact us.`,
+		sms: `Deposit of {{.Amount}} to your account ending {{.AccountEnding}} on {{.Time}}. Ref {{.TransactionID}}`,
+	},
+	consts.NotificationTypeWITHDRAWAL: {
+		subject: "Withdrawal made",
+		email: `Hello {{.FullName}},
+
+{{.Amount}} was withdrawn from your account ending {{.AccountEnding}} on {{.Time}}.
+Reference: {{.TransactionID}}
+
+If you did not make this withdrawal, contact us right away.`,
+		sms: `Withdrawal of {{.Amount}} from your account ending {{.AccountEnding}} on {{.Time}}. Not you? Contact us now. Ref {{.TransactionID}}`,
+	},
+	consts.NotificationTypePASSWORDCHANGED: {
+		subject: "Your password was changed",
+		email: `Hello {{.FullName}},
+
+The password of your user {{.Username}} was changed on {{.Time}}.
+
+If you did not change it, contact us right away.`,
+		sms: `The password of your user {{.Username}} was changed on {{.Time}}. Not you? Contact us now.`,
+	},
+	consts.NotificationTypePASSWORDRESET: {
+		subject: "Reset your password",
+		email: `Hello {{.FullName}},
+
+A password reset was requested for your user {{.Username}} on {{.Time}}.
+Use this token to set a new password before {{.ExpiresAt}}:
+
+{{.Token}}
+
+If you did not ask for it, ignore this message; your password stays the same.`,
+		sms: `Password reset token for {{.Username}}: {{.Token}} valid until {{.ExpiresAt}}. Not you? Ignore this message.`,
+	},
+	consts.NotificationTypeROLECHANGED: {
+		subject: "Your access was changed",
+		email: `Hello {{.FullName}},
+
+The role of your user {{.Username}} was changed from {{.OldRole}} to {{.NewRole}} on {{.Time}}.
+
+If you did not expect this change, please contact us.`,
+		sms: `The role of your user {{.Username}} was changed from {{.OldRole}} to {{.NewRole}} on {{.Time}}.`,
+	},
+	consts.NotificationTypeSTANDINGORDEREXECUTED: {
+		subject: "Standing order executed",
+		email: `Hello {{.FullName}},
+
+Your standing {{.OrderType}} of {{.Amount}} from your account ending {{.AccountEnding}} due on {{.ScheduledFor}} was made on {{.Time}}.
+Standing order: {{.StandingOrderID}}`,
+		sms: `Standing {{.OrderType}} of {{.Amount}} from your account ending {{.AccountEnding}} due {{.ScheduledFor}} was made.`,
+	},
+	consts.NotificationTypeSTANDINGORDERFAILED: {
+		subject: "Standing order failed",
+		email: `Hello {{.FullName}},
+
+Your standing {{.OrderType}} of {{.Amount}} from your account ending {{.AccountEnding}} due on {{.ScheduledFor}} could not be made: {{.Reason}}.
+Standing order: {{.StandingOrderID}}
+
+The next occurrence will be attempted as scheduled.`,
+		sms: `Standing {{.OrderType}} of {{.Amount}} from your account ending {{.AccountEnding}} due {{.ScheduledFor}} failed: {{.Reason}}`,
+	},
+}
+
+// secretKeys are data keys sent to the user but never kept in the recorded
+// notifications, which admins can read.
+var secretKeys = []string{"Token"}
+
+// redact returns a copy of data with the secret keys masked.
+func redact(data map[string]string) map[string]string {
+	redacted := make(map[string]string, len(data))
+	for k, v := range data {
+		redacted[k] = v
+	}
+	for _, k := range secretKeys {
+		if _, ok := redacted[k]; ok {
+			redacted[k] = "[REDACTED]"
+		}
+	}
+	return redacted
+}
+
+// render returns the subject and body of the notification type on channel.
+// SMS have no subject.
+func render(notificationType, channel string, data map[string]string) (string, string, error) {
+	tmpl, ok := templates[notificationType]
+	if !ok {
+		return "", "", errors.New("no template for notification type " + notificationType)
+	}
+	subject, text := tmpl.subject, tmpl.email
+	if channel == consts.NotificationChannelSMS {
+		subject, text = "", tmpl.sms
+	}
+
+	parsed, err := template.New(notificationType).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", "", err
+	}
+	var body strings.Builder
+	if err := parsed.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+	return subject, body.String(), nil
+}
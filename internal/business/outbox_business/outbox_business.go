@@ -0,0 +1,185 @@
+package outboxbusiness
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	webhookbusiness "github.com/hanselacn/banking-transaction/internal/business/webhook_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/eventx"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+const (
+	defaultOutboxFile = "outbox_events.jsonl"
+	outboxBatchSize   = 100
+	outboxMaxMessage  = 255
+)
+
+// memoryPublisher is shared by every outboxBusiness in the process so the
+// events relayed by the scheduler can be read elsewhere in the process.
+var memoryPublisher = eventx.NewMemoryPublisher(0)
+
+type OutboxBusiness interface {
+	Enqueue(ctx context.Context, eventType string, accountID uuid.UUID, payload interface{}, tx *sql.Tx) error
+	Relay(ctx context.Context) (*entity.OutboxRelayResult, error)
+}
+
+type outboxBusiness struct {
+	repo      repo.Repo
+	db        *sql.DB
+	publisher eventx.Publisher
+}
+
+func NewOutboxBusiness(db *sql.DB) OutboxBusiness {
+	return &outboxBusiness{
+		repo:      repo.NewRepositories(db),
+		db:        db,
+		publisher: newPublisher(db),
+	}
+}
+
+// newPublisher picks the publisher from OUTBOX_PUBLISHER: jsonl (default)
+// appends to OUTBOX_FILE, memory keeps the events in the process. Every event
+// is also queued for the webhook subscriptions of its account.
+func newPublisher(db *sql.DB) eventx.Publisher {
+	var publisher eventx.Publisher
+	switch os.Getenv("OUTBOX_PUBLISHER") {
+	case consts.OutboxPublisherMEMORY:
+		publisher = memoryPublisher
+	default:
+		path := os.Getenv("OUTBOX_FILE")
+		if path == "" {
+			path = defaultOutboxFile
+		}
+		publisher = eventx.NewJSONLPublisher(path)
+	}
+	return eventx.Fanout{publisher, webhookbusiness.NewWebhookBusiness(db)}
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// Enqueue writes the event in tx, the database transaction of the change it
+// describes. Callers enqueue after updating the account row, so the sequence is
+// drawn under the row lock and orders the events of an account.
+func (b *outboxBusiness) Enqueue(ctx context.Context, eventType string, accountID uuid.UUID, payload interface{}, tx *sql.Tx) error {
+	var (
+		eventName = "business.outbox.enqueue"
+	)
+
+	content, err := json.Marshal(payload)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	err = b.repo.Outbox.Create(ctx, entity.OutboxEvent{
+		ID:        uuid.New(),
+		AccountID: accountID,
+		Type:      eventType,
+		Payload:   content,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	return nil
+}
+
+// Relay publishes the pending events in sequence order and marks them published,
+// a crash in between publishes them again. When an event fails, the later events
+// of its account wait for the next run so an account's events stay in order.
+// Only one relay runs at a time across the replicas.
+func (b *outboxBusiness) Relay(ctx context.Context) (*entity.OutboxRelayResult, error) {
+	var (
+		eventName = "business.outbox.relay"
+		result    = entity.OutboxRelayResult{}
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	// The transaction only holds the relay lock.
+	defer tx.Rollback()
+
+	locked, err := b.repo.Outbox.TryLockRelay(ctx, tx)
+	if err != nil {
+		log.Println(eventName, "TryLockRelay", err)
+		return nil, err
+	}
+	if !locked {
+		return &result, nil
+	}
+
+	err = b.relayPending(ctx, &result)
+	if err != nil {
+		log.Println(eventName, err)
+		return &result, err
+	}
+	return &result, nil
+}
+
+// relayPending publishes the pending events batch by batch until none is left
+// or one fails.
+func (b *outboxBusiness) relayPending(ctx context.Context, result *entity.OutboxRelayResult) error {
+	var (
+		eventName = "business.outbox.relay_pending"
+	)
+
+	for {
+		events, err := b.repo.Outbox.GetPending(ctx, outboxBatchSize)
+		if err != nil {
+			log.Println(eventName, "GetPending", err)
+			return err
+		}
+		blocked := map[uuid.UUID]bool{}
+		for _, event := range events {
+			if blocked[event.AccountID] {
+				result.Deferred++
+				continue
+			}
+			if err := b.publish(ctx, event); err != nil {
+				log.Println(eventName, "publish", event.ID, err)
+				blocked[event.AccountID] = true
+				result.Failed++
+				continue
+			}
+			result.Published++
+		}
+		// Failed events come back first in the next batch, leave them to the next run.
+		if len(events) < outboxBatchSize || len(blocked) > 0 {
+			return nil
+		}
+	}
+}
+
+func (b *outboxBusiness) publish(ctx context.Context, event entity.OutboxEvent) error {
+	err := b.publisher.Publish(ctx, eventx.Message{
+		ID:        event.ID.String(),
+		Key:       event.AccountID.String(),
+		Type:      event.Type,
+		Payload:   event.Payload,
+		CreatedAt: event.CreatedAt,
+	})
+	if err != nil {
+		if markErr := b.repo.Outbox.MarkFailed(ctx, event.ID, truncate(err.Error(), outboxMaxMessage)); markErr != nil {
+			log.Println("business.outbox.publish", "MarkFailed", markErr)
+		}
+		return err
+	}
+	return b.repo.Outbox.MarkPublished(ctx, event.ID)
+}
@@ -0,0 +1,131 @@
+package outboxbusiness
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/eventx"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+// fakeOutboxRepo keeps the events in memory, in sequence order.
+type fakeOutboxRepo struct {
+	events []entity.OutboxEvent
+}
+
+func (f *fakeOutboxRepo) Create(ctx context.Context, event entity.OutboxEvent, tx *sql.Tx) error {
+	event.Sequence = int64(len(f.events) + 1)
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeOutboxRepo) TryLockRelay(ctx context.Context, tx *sql.Tx) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeOutboxRepo) GetPending(ctx context.Context, size int) ([]entity.OutboxEvent, error) {
+	var pending []entity.OutboxEvent
+	for _, event := range f.events {
+		if event.PublishedAt == nil && len(pending) < size {
+			pending = append(pending, event)
+		}
+	}
+	return pending, nil
+}
+
+func (f *fakeOutboxRepo) find(id uuid.UUID) *entity.OutboxEvent {
+	for i := range f.events {
+		if f.events[i].ID == id {
+			return &f.events[i]
+		}
+	}
+	return nil
+}
+
+func (f *fakeOutboxRepo) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	event := f.find(id)
+	event.Attempts++
+	event.PublishedAt = &event.CreatedAt
+	return nil
+}
+
+func (f *fakeOutboxRepo) MarkFailed(ctx context.Context, id uuid.UUID, message string) error {
+	event := f.find(id)
+	event.Attempts++
+	event.LastError = message
+	return nil
+}
+
+// flakyPublisher fails the messages of the keys in failing.
+type flakyPublisher struct {
+	failing   map[string]bool
+	published []eventx.Message
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, message eventx.Message) error {
+	if p.failing[message.Key] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, message)
+	return nil
+}
+
+func TestRelayKeepsAccountOrder(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		outbox    = &fakeOutboxRepo{}
+		publisher = &flakyPublisher{failing: map[string]bool{}}
+		b         = &outboxBusiness{repo: repo.Repo{Outbox: outbox}, publisher: publisher}
+		accountA  = uuid.New()
+		accountB  = uuid.New()
+	)
+
+	for _, account := range []uuid.UUID{accountA, accountB, accountA, accountB, accountA} {
+		if err := b.Enqueue(ctx, "DepositCompleted", account, map[string]float64{"amount": 10}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	publisher.failing[accountA.String()] = true
+	var result entity.OutboxRelayResult
+	if err := b.relayPending(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result != (entity.OutboxRelayResult{Published: 2, Failed: 1, Deferred: 2}) {
+		t.Errorf("unexpected first run %+v", result)
+	}
+	for _, message := range publisher.published {
+		if message.Key != accountB.String() {
+			t.Errorf("published %s of a blocked account", message.ID)
+		}
+	}
+	if first := outbox.events[0]; first.Attempts != 1 || first.LastError != "broker unavailable" || first.PublishedAt != nil {
+		t.Errorf("failure not recorded %+v", first)
+	}
+	if third := outbox.events[2]; third.Attempts != 0 {
+		t.Errorf("deferred event was attempted %+v", third)
+	}
+
+	delete(publisher.failing, accountA.String())
+	result = entity.OutboxRelayResult{}
+	if err := b.relayPending(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result != (entity.OutboxRelayResult{Published: 3}) {
+		t.Errorf("unexpected second run %+v", result)
+	}
+	var order []uuid.UUID
+	for _, message := range publisher.published[2:] {
+		order = append(order, uuid.MustParse(message.ID))
+	}
+	want := []uuid.UUID{outbox.events[0].ID, outbox.events[2].ID, outbox.events[4].ID}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("account events out of order: got %v, want %v", order, want)
+		}
+	}
+}
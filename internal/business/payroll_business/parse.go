@@ -0,0 +1,181 @@
+package payrollbusiness
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+const defaultPayrollCurrency = "IDR"
+
+// payrollCurrency is the currency of the accounts, the one the statements are
+// exported in. pain.001 instructions in any other currency are refused.
+func payrollCurrency() string {
+	if v := strings.ToUpper(strings.TrimSpace(os.Getenv("STATEMENT_CURRENCY"))); len(v) == 3 {
+		return v
+	}
+	return defaultPayrollCurrency
+}
+
+// detectFormat picks the parser from the file extension, falling back to the
+// content for uploads without one.
+func detectFormat(fileName string, content []byte) string {
+	name := strings.ToLower(fileName)
+	switch {
+	case strings.HasSuffix(name, ".csv"):
+		return consts.PayrollFormatCSV
+	case strings.HasSuffix(name, ".xml"):
+		return consts.PayrollFormatPAIN001
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("<")) {
+		return consts.PayrollFormatPAIN001
+	}
+	return consts.PayrollFormatCSV
+}
+
+func parseRecords(format string, content []byte) ([]entity.PayrollRecord, error) {
+	switch format {
+	case consts.PayrollFormatCSV:
+		return parseCSV(content)
+	case consts.PayrollFormatPAIN001:
+		return parsePAIN001(content)
+	}
+	return nil, errbank.NewErrUnprocessableEntity("unsupported payroll format " + format)
+}
+
+// parseCSV reads "account_number,amount,reference" rows. The reference column is
+// optional and a header row is skipped. LineNo is the line of the row in the file.
+func parseCSV(content []byte) ([]entity.PayrollRecord, error) {
+	var (
+		reader  = csv.NewReader(bytes.NewReader(content))
+		records []entity.PayrollRecord
+	)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errbank.NewErrUnprocessableEntity("malformed csv: " + err.Error())
+		}
+		lineNo, _ := reader.FieldPos(0)
+		if len(records) == 0 && isCSVHeader(row) {
+			continue
+		}
+		if len(row) < 2 || len(row) > 3 {
+			return nil, errbank.NewErrUnprocessableEntity(fmt.Sprintf("line %d: expected account_number,amount,reference", lineNo))
+		}
+		record := entity.PayrollRecord{
+			LineNo:        lineNo,
+			AccountNumber: strings.TrimSpace(row[0]),
+			Amount:        strings.TrimSpace(row[1]),
+		}
+		if len(row) == 3 {
+			record.Reference = strings.TrimSpace(row[2])
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func isCSVHeader(row []string) bool {
+	if len(row) < 2 {
+		return false
+	}
+	_, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+	return err != nil && strings.Contains(strings.ToLower(row[0]), "account")
+}
+
+type painDocument struct {
+	XMLName      xml.Name          `xml:"Document"`
+	NbOfTxs      string            `xml:"CstmrCdtTrfInitn>GrpHdr>NbOfTxs"`
+	CtrlSum      string            `xml:"CstmrCdtTrfInitn>GrpHdr>CtrlSum"`
+	PaymentInfos []painPaymentInfo `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type painPaymentInfo struct {
+	Transactions []painTransaction `xml:"CdtTrfTxInf"`
+}
+
+type painTransaction struct {
+	EndToEndID   string     `xml:"PmtId>EndToEndId"`
+	Amount       painAmount `xml:"Amt>InstdAmt"`
+	IBAN         string     `xml:"CdtrAcct>Id>IBAN"`
+	OtherID      string     `xml:"CdtrAcct>Id>Othr>Id"`
+	Unstructured []string   `xml:"RmtInf>Ustrd"`
+}
+
+type painAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// parsePAIN001 reads the credit transfers of an ISO 20022 customer credit transfer
+// initiation (pain.001, any version). The creditor account is taken from the
+// proprietary id, or the IBAN, and LineNo is the position of the transfer in the file.
+// The group header counts are checked so a truncated file is refused as a whole.
+func parsePAIN001(content []byte) ([]entity.PayrollRecord, error) {
+	var (
+		document painDocument
+		records  []entity.PayrollRecord
+		sum      int64
+		currency = payrollCurrency()
+	)
+
+	err := xml.Unmarshal(content, &document)
+	if err != nil {
+		return nil, errbank.NewErrUnprocessableEntity("malformed pain.001: " + err.Error())
+	}
+	if !strings.Contains(document.XMLName.Space, "pain.001") {
+		return nil, errbank.NewErrUnprocessableEntity("not a pain.001 document")
+	}
+
+	for _, info := range document.PaymentInfos {
+		for _, transfer := range info.Transactions {
+			lineNo := len(records) + 1
+			if transfer.Amount.Currency != "" && !strings.EqualFold(transfer.Amount.Currency, currency) {
+				return nil, errbank.NewErrUnprocessableEntity(fmt.Sprintf("transaction %d: currency %s is not supported, expected %s", lineNo, transfer.Amount.Currency, currency))
+			}
+			record := entity.PayrollRecord{
+				LineNo:        lineNo,
+				AccountNumber: strings.TrimSpace(transfer.OtherID),
+				Amount:        strings.TrimSpace(transfer.Amount.Value),
+				Reference:     strings.TrimSpace(strings.Join(transfer.Unstructured, " ")),
+			}
+			if record.AccountNumber == "" {
+				record.AccountNumber = strings.TrimSpace(transfer.IBAN)
+			}
+			if endToEndID := strings.TrimSpace(transfer.EndToEndID); record.Reference == "" && endToEndID != "NOTPROVIDED" {
+				record.Reference = endToEndID
+			}
+			if amount, err := strconv.ParseFloat(record.Amount, 64); err == nil {
+				sum += int64(math.Round(amount * 100))
+			}
+			records = append(records, record)
+		}
+	}
+
+	if v := strings.TrimSpace(document.NbOfTxs); v != "" && v != strconv.Itoa(len(records)) {
+		return nil, errbank.NewErrUnprocessableEntity(fmt.Sprintf("NbOfTxs %s does not match the %d transactions in the file", v, len(records)))
+	}
+	if v := strings.TrimSpace(document.CtrlSum); v != "" {
+		ctrlSum, err := strconv.ParseFloat(v, 64)
+		if err != nil || int64(math.Round(ctrlSum*100)) != sum {
+			return nil, errbank.NewErrUnprocessableEntity(fmt.Sprintf("CtrlSum %s does not match the sum of the transactions", v))
+		}
+	}
+	return records, nil
+}
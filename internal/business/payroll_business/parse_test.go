@@ -0,0 +1,174 @@
+package payrollbusiness
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/pkg/errors"
+)
+
+const testPAIN001 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>PAYROLL-2024-03</MsgId>
+      <NbOfTxs>2</NbOfTxs>
+      <CtrlSum>%s</CtrlSum>
+    </GrpHdr>
+    <PmtInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>EMP-001</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="IDR">1500000.00</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>1234567890</Id></Othr></Id></CdtrAcct>
+        <RmtInf><Ustrd>Salary March</Ustrd></RmtInf>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>EMP-002</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="IDR">2500000.50</InstdAmt></Amt>
+        <CdtrAcct><Id><IBAN>ID0987654321</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+func pain001(ctrlSum string) []byte {
+	return []byte(fmt.Sprintf(testPAIN001, ctrlSum))
+}
+
+func isUnprocessable(err error) bool {
+	_, ok := errors.Cause(err).(errbank.ErrUnprocessableEntity)
+	return ok
+}
+
+func TestParseCSV(t *testing.T) {
+	content := []byte("account_number,amount,reference\n1234567890, 1500000.00 ,Salary March\n\n0987654321,2500000.5\n")
+	records, err := parseCSV(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []entity.PayrollRecord{
+		{LineNo: 2, AccountNumber: "1234567890", Amount: "1500000.00", Reference: "Salary March"},
+		{LineNo: 4, AccountNumber: "0987654321", Amount: "2500000.5"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestParseCSVColumns(t *testing.T) {
+	_, err := parseCSV([]byte("1234567890,100,ref,extra\n"))
+	if !isUnprocessable(err) {
+		t.Errorf("expected unprocessable entity, got %v", err)
+	}
+}
+
+func TestParsePAIN001(t *testing.T) {
+	t.Setenv("STATEMENT_CURRENCY", "")
+	records, err := parsePAIN001(pain001("4000000.50"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []entity.PayrollRecord{
+		{LineNo: 1, AccountNumber: "1234567890", Amount: "1500000.00", Reference: "Salary March"},
+		{LineNo: 2, AccountNumber: "ID0987654321", Amount: "2500000.50", Reference: "EMP-002"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestParsePAIN001Rejected(t *testing.T) {
+	t.Setenv("STATEMENT_CURRENCY", "")
+	cases := map[string][]byte{
+		"control sum":  pain001("4000000.00"),
+		"currency":     bytes.Replace(pain001("4000000.50"), []byte(`Ccy="IDR"`), []byte(`Ccy="USD"`), 1),
+		"not pain.001": []byte(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"></Document>`),
+		"malformed":    []byte(`<Document`),
+	}
+	for name, content := range cases {
+		if _, err := parsePAIN001(content); !isUnprocessable(err) {
+			t.Errorf("%s: expected unprocessable entity, got %v", name, err)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	cases := []struct {
+		fileName string
+		content  string
+		want     string
+	}{
+		{"payroll.csv", "<x>", consts.PayrollFormatCSV},
+		{"payroll.XML", "a,b", consts.PayrollFormatPAIN001},
+		{"upload", "  <?xml", consts.PayrollFormatPAIN001},
+		{"upload", "1234567890,100", consts.PayrollFormatCSV},
+	}
+	for _, c := range cases {
+		if got := detectFormat(c.fileName, []byte(c.content)); got != c.want {
+			t.Errorf("detectFormat(%q) = %s, want %s", c.fileName, got, c.want)
+		}
+	}
+}
+
+func TestValidateRecord(t *testing.T) {
+	cases := []struct {
+		record  entity.PayrollRecord
+		amount  float64
+		invalid bool
+	}{
+		{entity.PayrollRecord{AccountNumber: "1234567890", Amount: "1500000.29", Reference: "Salary March"}, 1500000.29, false},
+		{entity.PayrollRecord{AccountNumber: "1234567890", Amount: "0.50"}, 0, true},
+		{entity.PayrollRecord{AccountNumber: "1234567890", Amount: "10.505"}, 0, true},
+		{entity.PayrollRecord{AccountNumber: "1234567890", Amount: "-10"}, 0, true},
+		{entity.PayrollRecord{AccountNumber: "1234567890", Amount: "10", Reference: "<script>"}, 0, true},
+		{entity.PayrollRecord{AccountNumber: "", Amount: "10"}, 0, true},
+	}
+	for _, c := range cases {
+		amount, reason := validateRecord(c.record)
+		if (reason != "") != c.invalid || amount != c.amount {
+			t.Errorf("validateRecord(%+v) = %v, %q", c.record, amount, reason)
+		}
+	}
+}
+
+func TestRenderReport(t *testing.T) {
+	transactionID := uuid.New()
+	batch := entity.PayrollBatch{ID: uuid.New(), FileName: "payroll.csv", Status: consts.PayrollBatchStatusPARTIALLYCOMPLETED, LineCount: 2}
+	lines := []entity.PayrollLine{
+		{LineNo: 2, AccountNumber: "1234567890", Amount: 1500000, Reference: "Salary, March", Status: consts.PayrollLineStatusCOMPLETED, TransactionID: &transactionID},
+		{LineNo: 3, AccountNumber: "0000000000", Status: consts.PayrollLineStatusINVALID, Message: "account not found"},
+	}
+	out, err := renderReport(batch, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := csv.NewReader(bytes.NewReader(out))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := rows[len(rows)-2:]
+	if got := last[0]; got[3] != "Salary, March" || got[4] != consts.PayrollLineStatusCOMPLETED || got[6] != transactionID.String() {
+		t.Errorf("unexpected completed row %q", got)
+	}
+	if got := last[1]; got[0] != "3" || got[5] != "account not found" || got[6] != "" {
+		t.Errorf("unexpected invalid row %q", got)
+	}
+}
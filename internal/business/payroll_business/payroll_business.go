@@ -0,0 +1,511 @@
+package payrollbusiness
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	feebusiness "github.com/hanselacn/banking-transaction/internal/business/fee_business"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	sanctionsbusiness "github.com/hanselacn/banking-transaction/internal/business/sanctions_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	"github.com/pkg/errors"
+)
+
+const (
+	payrollMaxLines    = 10000
+	payrollMinAmount   = 1
+	payrollMaxMessage  = 255
+	payrollWorkerBatch = 10
+)
+
+type PayrollBusiness interface {
+	UploadBatch(ctx context.Context, input entity.PayrollUpload) (*entity.PayrollBatch, error)
+	GetBatches(ctx context.Context, status string, m meta.Metadata) ([]entity.PayrollBatch, error)
+	GetBatch(ctx context.Context, id uuid.UUID) (*entity.PayrollBatch, error)
+	ApproveBatch(ctx context.Context, input entity.ReviewPayrollBatch) (*entity.PayrollBatch, error)
+	RejectBatch(ctx context.Context, input entity.ReviewPayrollBatch) (*entity.PayrollBatch, error)
+	GetReport(ctx context.Context, id uuid.UUID) (*entity.PayrollBatch, []byte, error)
+	PayrollWorker(ctx context.Context) (int, int, error)
+}
+
+type payrollBusiness struct {
+	repo   repo.Repo
+	db     *sql.DB
+	fee    feebusiness.FeeBusiness
+	ledger ledgerbusiness.LedgerBusiness
+	audit  auditbusiness.AuditBusiness
+}
+
+func NewPayrollBusiness(db *sql.DB) PayrollBusiness {
+	return &payrollBusiness{
+		repo:   repo.NewRepositories(db),
+		db:     db,
+		fee:    feebusiness.NewFeeBusiness(db),
+		ledger: ledgerbusiness.NewLedgerBusiness(db),
+		audit:  auditbusiness.NewAuditBusiness(db),
+	}
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// validateRecord checks a line of the file and returns the amount to credit,
+// or the reason the line is invalid.
+func validateRecord(record entity.PayrollRecord) (float64, string) {
+	if err := record.Validate(); err != nil {
+		return 0, err.Error()
+	}
+	amount, err := strconv.ParseFloat(record.Amount, 64)
+	if err != nil {
+		return 0, "amount: invalid amount"
+	}
+	if amount < payrollMinAmount {
+		return 0, fmt.Sprintf("amount: must be at least %d", payrollMinAmount)
+	}
+	if i := strings.IndexByte(record.Amount, '.'); i >= 0 && len(record.Amount)-i-1 > 2 {
+		return 0, "amount: at most 2 decimals are allowed"
+	}
+	return amount, ""
+}
+
+// UploadBatch parses and validates a payroll file and stores it for approval.
+// Invalid lines (malformed values, unknown account) are kept with their reason
+// and skipped on execution; a file that cannot be read is refused as a whole.
+func (b *payrollBusiness) UploadBatch(ctx context.Context, input entity.PayrollUpload) (*entity.PayrollBatch, error) {
+	var (
+		eventName = "business.payroll.upload_batch"
+		checksum  = sha256.Sum256(input.Content)
+		batch     = entity.PayrollBatch{
+			ID:         uuid.New(),
+			FileName:   input.FileName,
+			Format:     input.Format,
+			Checksum:   hex.EncodeToString(checksum[:]),
+			Status:     consts.PayrollBatchStatusPENDINGAPPROVAL,
+			UploadedBy: input.UploadedBy,
+		}
+		total int64
+	)
+
+	if batch.Format == "" {
+		batch.Format = detectFormat(input.FileName, input.Content)
+	}
+	records, err := parseRecords(batch.Format, input.Content)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errbank.NewErrUnprocessableEntity("payroll file has no line")
+	}
+	if len(records) > payrollMaxLines {
+		return nil, errbank.NewErrUnprocessableEntity(fmt.Sprintf("payroll file has more than %d lines", payrollMaxLines))
+	}
+
+	for _, record := range records {
+		line := entity.PayrollLine{
+			ID:            uuid.New(),
+			BatchID:       batch.ID,
+			LineNo:        record.LineNo,
+			AccountNumber: truncate(record.AccountNumber, 255),
+			Reference:     truncate(record.Reference, 255),
+			Status:        consts.PayrollLineStatusINVALID,
+		}
+		amount, reason := validateRecord(record)
+		if reason == "" {
+			account, err := b.repo.Account.FindByAccountNumber(ctx, record.AccountNumber)
+			switch errors.Cause(err).(type) {
+			case nil:
+				line.AccountID = &account.ID
+				line.Amount = amount
+				line.Status = consts.PayrollLineStatusVALID
+			case errbank.ErrNotFound:
+				reason = "account not found"
+			default:
+				log.Println(eventName, "FindByAccountNumber", err)
+				return nil, err
+			}
+		}
+		line.Message = truncate(reason, payrollMaxMessage)
+		if line.Status == consts.PayrollLineStatusVALID {
+			batch.ValidCount++
+			total += cents(line.Amount)
+		}
+		batch.Lines = append(batch.Lines, line)
+	}
+	batch.LineCount = len(batch.Lines)
+	batch.TotalAmount = float64(total) / 100
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.Payroll.CreateBatch(ctx, batch, tx)
+	if err != nil {
+		log.Println(eventName, "CreateBatch", err)
+		if _, ok := errors.Cause(err).(errbank.ErrConflict); ok {
+			err = errbank.NewErrConflict("this payroll file was already uploaded")
+		}
+		return nil, err
+	}
+	for _, line := range batch.Lines {
+		err = b.repo.Payroll.CreateLine(ctx, line, tx)
+		if err != nil {
+			log.Println(eventName, "CreateLine", err)
+			return nil, err
+		}
+	}
+
+	summary := batch
+	summary.Lines = nil
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionUPLOADPAYROLL,
+		Target: batch.ID.String(),
+		After:  summary,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (b *payrollBusiness) GetBatches(ctx context.Context, status string, m meta.Metadata) ([]entity.PayrollBatch, error) {
+	var eventName = "business.payroll.get_batches"
+
+	batches, err := b.repo.Payroll.GetList(ctx, status, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return batches, nil
+}
+
+func (b *payrollBusiness) GetBatch(ctx context.Context, id uuid.UUID) (*entity.PayrollBatch, error) {
+	var eventName = "business.payroll.get_batch"
+
+	batch, err := b.repo.Payroll.FindByID(ctx, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	batch.Lines, err = b.repo.Payroll.GetLines(ctx, id)
+	if err != nil {
+		log.Println(eventName, "GetLines", err)
+		return nil, err
+	}
+	return batch, nil
+}
+
+// review moves a pending batch to status on behalf of the reviewer. The
+// uploader of a batch cannot review it.
+func (b *payrollBusiness) review(ctx context.Context, input entity.ReviewPayrollBatch, status string) (*entity.PayrollBatch, error) {
+	var (
+		eventName = "business.payroll.review"
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	batch, err := b.repo.Payroll.FindByIDForUpdate(ctx, input.BatchID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if batch.Status != consts.PayrollBatchStatusPENDINGAPPROVAL {
+		err = errbank.NewErrConflict("payroll batch is already " + batch.Status)
+		return nil, err
+	}
+	if batch.UploadedBy == input.ReviewedBy {
+		err = errbank.NewErrForbidden("a payroll batch must be reviewed by another admin than the uploader")
+		return nil, err
+	}
+	if status == consts.PayrollBatchStatusPROCESSING && batch.ValidCount == 0 {
+		err = errbank.NewErrUnprocessableEntity("payroll batch has no valid line to execute")
+		return nil, err
+	}
+
+	batch.Status = status
+	batch.ReviewedBy = input.ReviewedBy
+	batch.ReviewNote = input.Note
+	err = b.repo.Payroll.Review(ctx, *batch, tx)
+	if err != nil {
+		log.Println(eventName, "Review", err)
+		return nil, err
+	}
+
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionREVIEWPAYROLL,
+		Target: batch.ID.String(),
+		Before: map[string]string{"status": consts.PayrollBatchStatusPENDINGAPPROVAL},
+		After:  batch,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (b *payrollBusiness) RejectBatch(ctx context.Context, input entity.ReviewPayrollBatch) (*entity.PayrollBatch, error) {
+	return b.review(ctx, input, consts.PayrollBatchStatusREJECTED)
+}
+
+// ApproveBatch approves a pending batch and leaves it PROCESSING. The batch is
+// executed by PayrollWorker, which also resumes a batch left half done by a crash.
+func (b *payrollBusiness) ApproveBatch(ctx context.Context, input entity.ReviewPayrollBatch) (*entity.PayrollBatch, error) {
+	var (
+		eventName = "business.payroll.approve_batch"
+	)
+
+	batch, err := b.review(ctx, input, consts.PayrollBatchStatusPROCESSING)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return batch, nil
+}
+
+// PayrollWorker executes the PROCESSING batches, crediting every line still
+// VALID in its own database transaction. A failing line does not stop the batch;
+// its reason is recorded and the batch ends PARTIALLY_COMPLETED.
+func (b *payrollBusiness) PayrollWorker(ctx context.Context) (int, int, error) {
+	var (
+		eventName    = "business.payroll.payroll_worker"
+		totalSuccess int
+		m            = meta.Metadata{
+			Pagination: meta.Pagination{Page: 1, PerPage: payrollWorkerBatch},
+		}
+	)
+
+	batches, err := b.repo.Payroll.GetList(ctx, consts.PayrollBatchStatusPROCESSING, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return 0, 0, err
+	}
+	for _, batch := range batches {
+		err := b.executeBatch(ctx, batch.ID)
+		if err != nil {
+			log.Println(eventName, "executeBatch", batch.ID, err)
+			continue
+		}
+		totalSuccess++
+	}
+	return len(batches), totalSuccess, nil
+}
+
+// executeBatch credits the lines of the batch still VALID and finishes it.
+// CompleteLine claims a line once, so a batch can safely be executed again.
+func (b *payrollBusiness) executeBatch(ctx context.Context, batchID uuid.UUID) error {
+	var (
+		eventName = "business.payroll.execute_batch"
+	)
+
+	lines, err := b.repo.Payroll.GetLines(ctx, batchID)
+	if err != nil {
+		log.Println(eventName, "GetLines", err)
+		return err
+	}
+	for _, line := range lines {
+		if line.Status != consts.PayrollLineStatusVALID {
+			continue
+		}
+		err := b.creditLine(ctx, line)
+		if err != nil {
+			log.Println(eventName, "creditLine", line.ID, err)
+		}
+	}
+
+	_, err = b.repo.Payroll.Finish(ctx, batchID)
+	if err != nil {
+		log.Println(eventName, "Finish", err)
+		return err
+	}
+	return nil
+}
+
+// creditLine posts a PAYROLL credit for the line and marks it COMPLETED in the
+// same database transaction, or FAILED with the reason.
+func (b *payrollBusiness) creditLine(ctx context.Context, line entity.PayrollLine) error {
+	var (
+		eventName   = "business.payroll.credit_line"
+		transaction = entity.Transaction{
+			ID:        uuid.New(),
+			AccountID: *line.AccountID,
+			Type:      consts.TxTypeCREDIT,
+			Amount:    line.Amount,
+			Action:    consts.TxActionPAYROLL,
+			Status:    consts.TxStatusINPROGRESS,
+		}
+	)
+
+	err := b.repo.Transaction.CreateTransaction(ctx, transaction, nil)
+	if err != nil {
+		log.Println(eventName, "CreateTransaction", err)
+		b.repo.Payroll.FailLine(ctx, line.ID, nil, truncate(err.Error(), payrollMaxMessage))
+		return err
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		b.repo.Transaction.UpdateTransactionStatus(ctx, transaction.ID, consts.TxStatusFAILED, nil)
+		b.repo.Payroll.FailLine(ctx, line.ID, &transaction.ID, truncate(err.Error(), payrollMaxMessage))
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+			b.repo.Transaction.UpdateTransactionStatus(ctx, transaction.ID, consts.TxStatusFAILED, nil)
+			b.repo.Payroll.FailLine(ctx, line.ID, &transaction.ID, truncate(err.Error(), payrollMaxMessage))
+		}
+	}()
+
+	err = b.postLine(ctx, line, transaction, tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	return nil
+}
+
+// postLine completes the line and credits its amount to the employee account within tx.
+func (b *payrollBusiness) postLine(ctx context.Context, line entity.PayrollLine, transaction entity.Transaction, tx *sql.Tx) error {
+	var eventName = "business.payroll.post_line"
+
+	claimed, err := b.repo.Payroll.CompleteLine(ctx, line.ID, transaction.ID, tx)
+	if err != nil {
+		log.Println(eventName, "CompleteLine", err)
+		return err
+	}
+	if !claimed {
+		return errbank.NewErrConflict("payroll line is already processed")
+	}
+
+	account, err := b.repo.Account.FindByIDForUpdate(ctx, transaction.AccountID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	user, err := b.repo.Users.FindByID(ctx, account.UserID)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	err = sanctionsbusiness.CheckStatus(*user)
+	if err != nil {
+		return err
+	}
+
+	account.Balance += line.Amount
+	_, err = b.fee.ChargeTransactionFees(ctx, account, consts.TxActionPAYROLL, tx)
+	if err != nil {
+		log.Println(eventName, "ChargeTransactionFees", err)
+		return err
+	}
+	err = b.repo.Account.UpdateBalance(ctx, entity.Account{
+		UserID:  account.UserID,
+		Balance: account.Balance,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateBalance", err)
+		return err
+	}
+	err = b.repo.Transaction.UpdateTransactionStatus(ctx, transaction.ID, consts.TxStatusCOMPLETED, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateTransactionStatus", err)
+		return err
+	}
+	err = b.ledger.PostTransaction(ctx, transaction, tx)
+	if err != nil {
+		log.Println(eventName, "PostTransaction", err)
+		return err
+	}
+	return nil
+}
+
+func (b *payrollBusiness) GetReport(ctx context.Context, id uuid.UUID) (*entity.PayrollBatch, []byte, error) {
+	var eventName = "business.payroll.get_report"
+
+	batch, err := b.GetBatch(ctx, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, nil, err
+	}
+	report, err := renderReport(*batch, batch.Lines)
+	if err != nil {
+		log.Println(eventName, "renderReport", err)
+		return nil, nil, err
+	}
+	return batch, report, nil
+}
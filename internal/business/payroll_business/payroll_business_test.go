@@ -0,0 +1,136 @@
+package payrollbusiness
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	feebusiness "github.com/hanselacn/banking-transaction/internal/business/fee_business"
+	ledgerbusiness "github.com/hanselacn/banking-transaction/internal/business/ledger_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	accountrepo "github.com/hanselacn/banking-transaction/internal/repo/account_repo"
+	payrollrepo "github.com/hanselacn/banking-transaction/internal/repo/payroll_repo"
+	transactionrepo "github.com/hanselacn/banking-transaction/internal/repo/transaction_repo"
+	usersrepo "github.com/hanselacn/banking-transaction/internal/repo/users_repo"
+	"github.com/pkg/errors"
+)
+
+type fakePayrollRepo struct {
+	payrollrepo.PayrollRepositories
+	completed []uuid.UUID
+}
+
+func (f *fakePayrollRepo) CompleteLine(ctx context.Context, lineID uuid.UUID, transactionID uuid.UUID, tx *sql.Tx) (bool, error) {
+	f.completed = append(f.completed, lineID)
+	return true, nil
+}
+
+type fakeAccountRepo struct {
+	accountrepo.AccountRepositories
+	account entity.Account
+}
+
+func (f *fakeAccountRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Account, error) {
+	account := f.account
+	return &account, nil
+}
+
+func (f *fakeAccountRepo) UpdateBalance(ctx context.Context, account entity.Account, tx *sql.Tx) error {
+	f.account.Balance = account.Balance
+	return nil
+}
+
+type fakeUsersRepo struct {
+	usersrepo.UsersRepositories
+	user entity.User
+}
+
+func (f *fakeUsersRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	user := f.user
+	return &user, nil
+}
+
+type fakeTransactionRepo struct {
+	transactionrepo.TransactionRepo
+	completed []uuid.UUID
+}
+
+func (f *fakeTransactionRepo) UpdateTransactionStatus(ctx context.Context, trID uuid.UUID, status string, tx *sql.Tx) error {
+	if status == consts.TxStatusCOMPLETED {
+		f.completed = append(f.completed, trID)
+	}
+	return nil
+}
+
+type fakeFee struct {
+	feebusiness.FeeBusiness
+}
+
+func (f *fakeFee) ChargeTransactionFees(ctx context.Context, account *entity.Account, action string, tx *sql.Tx) ([]entity.Fee, error) {
+	return nil, nil
+}
+
+type fakeLedger struct {
+	ledgerbusiness.LedgerBusiness
+	posted []entity.Transaction
+}
+
+func (f *fakeLedger) PostTransaction(ctx context.Context, transaction entity.Transaction, tx *sql.Tx) error {
+	f.posted = append(f.posted, transaction)
+	return nil
+}
+
+func TestPostLineSanctions(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  string
+		allowed bool
+	}{
+		{"clear", "", true},
+		{"on hold", consts.SanctionsStatusHOLD, false},
+		{"blocked", consts.SanctionsStatusBLOCKED, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				user         = entity.User{ID: uuid.New(), SanctionsStatus: c.status}
+				accounts     = &fakeAccountRepo{account: entity.Account{ID: uuid.New(), UserID: user.ID, Balance: 100}}
+				transactions = &fakeTransactionRepo{}
+				ledger       = &fakeLedger{}
+				b            = &payrollBusiness{
+					repo: repo.Repo{
+						Payroll:     &fakePayrollRepo{},
+						Account:     accounts,
+						Users:       &fakeUsersRepo{user: user},
+						Transaction: transactions,
+					},
+					fee:    &fakeFee{},
+					ledger: ledger,
+				}
+				line        = entity.PayrollLine{ID: uuid.New(), AccountID: &accounts.account.ID, Amount: 5000}
+				transaction = entity.Transaction{ID: uuid.New(), AccountID: accounts.account.ID, Type: consts.TxTypeCREDIT, Amount: line.Amount, Action: consts.TxActionPAYROLL}
+			)
+
+			err := b.postLine(context.Background(), line, transaction, nil)
+			if !c.allowed {
+				if _, ok := errors.Cause(err).(errbank.ErrForbidden); !ok {
+					t.Fatalf("postLine() error = %v, want ErrForbidden", err)
+				}
+				if accounts.account.Balance != 100 || len(transactions.completed) != 0 || len(ledger.posted) != 0 {
+					t.Errorf("balance = %v, completed = %v, want nothing credited", accounts.account.Balance, transactions.completed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if accounts.account.Balance != 5100 || len(transactions.completed) != 1 || len(ledger.posted) != 1 {
+				t.Errorf("balance = %v, completed = %v, posted = %d, want the line credited", accounts.account.Balance, transactions.completed, len(ledger.posted))
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package payrollbusiness
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// renderReport lays out the outcome of every line of the batch, invalid lines
+// included, after a summary of the batch.
+func renderReport(batch entity.PayrollBatch, lines []entity.PayrollLine) ([]byte, error) {
+	var (
+		buf    bytes.Buffer
+		writer = csv.NewWriter(&buf)
+		rows   = [][]string{
+			{"Batch ID", batch.ID.String()},
+			{"File Name", batch.FileName},
+			{"Format", batch.Format},
+			{"Status", batch.Status},
+			{"Uploaded By", batch.UploadedBy},
+			{"Reviewed By", batch.ReviewedBy},
+			{"Executed At", formatTime(batch.ExecutedAt)},
+			{"Lines", strconv.Itoa(batch.LineCount)},
+			{"Valid Lines", strconv.Itoa(batch.ValidCount)},
+			{"Total Amount", fmt.Sprintf("%.2f", batch.TotalAmount)},
+			{"Completed", strconv.Itoa(batch.CompletedCount)},
+			{"Failed", strconv.Itoa(batch.FailedCount)},
+			{"Line", "Account Number", "Amount", "Reference", "Status", "Message", "Transaction ID"},
+		}
+	)
+	for _, line := range lines {
+		transactionID := ""
+		if line.TransactionID != nil {
+			transactionID = line.TransactionID.String()
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(line.LineNo),
+			line.AccountNumber,
+			fmt.Sprintf("%.2f", line.Amount),
+			line.Reference,
+			line.Status,
+			line.Message,
+			transactionID,
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,179 @@
+package sanctionsbusiness
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/fuzzyx"
+)
+
+const (
+	defaultSanctionsListFile       = "sdn.csv"
+	defaultSanctionsMatchThreshold = 0.92
+	// sdnNull is how the OFAC CSV files write an empty column.
+	sdnNull = "-0-"
+)
+
+// skippedEntryTypes are listed entries that cannot be a customer or a counterparty.
+var skippedEntryTypes = map[string]bool{
+	"vessel":   true,
+	"aircraft": true,
+}
+
+func sanctionsListFile() string {
+	if v := strings.TrimSpace(os.Getenv("SANCTIONS_LIST_FILE")); v != "" {
+		return v
+	}
+	return defaultSanctionsListFile
+}
+
+// sanctionsMatchThreshold is the name similarity, between 0 and 1, from which a
+// name is a hit.
+func sanctionsMatchThreshold() float64 {
+	v, err := strconv.ParseFloat(os.Getenv("SANCTIONS_MATCH_THRESHOLD"), 64)
+	if err != nil || v <= 0 || v > 1 {
+		return defaultSanctionsMatchThreshold
+	}
+	return v
+}
+
+// parseList reads a watchlist in the OFAC SDN format, as XML (sdn.xml) or as CSV
+// (sdn.csv: ent_num, name, type, program, ...).
+func parseList(path string) ([]entity.SanctionsEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		return parseSDNXML(file)
+	}
+	return parseSDNCSV(file)
+}
+
+func sdnValue(s string) string {
+	s = strings.TrimSpace(s)
+	if s == sdnNull {
+		return ""
+	}
+	return s
+}
+
+func parseSDNCSV(r io.Reader) ([]entity.SanctionsEntry, error) {
+	var (
+		reader  = csv.NewReader(r)
+		entries []entity.SanctionsEntry
+	)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 || sdnValue(record[1]) == "" {
+			continue
+		}
+		entry := entity.SanctionsEntry{
+			UID:  sdnValue(record[0]),
+			Name: sdnValue(record[1]),
+		}
+		if len(record) > 2 {
+			entry.Type = sdnValue(record[2])
+		}
+		if len(record) > 3 {
+			entry.Program = sdnValue(record[3])
+		}
+		if skippedEntryTypes[strings.ToLower(entry.Type)] {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+type sdnName struct {
+	FirstName string `xml:"firstName"`
+	LastName  string `xml:"lastName"`
+}
+
+func (n sdnName) String() string {
+	return strings.TrimSpace(strings.TrimSpace(n.FirstName) + " " + strings.TrimSpace(n.LastName))
+}
+
+type sdnEntry struct {
+	sdnName
+	UID      string    `xml:"uid"`
+	SDNType  string    `xml:"sdnType"`
+	Programs []string  `xml:"programList>program"`
+	AKAs     []sdnName `xml:"akaList>aka"`
+}
+
+// parseSDNXML streams the sdnEntry elements, every alias becomes an entry with
+// the uid of its entry.
+func parseSDNXML(r io.Reader) ([]entity.SanctionsEntry, error) {
+	var (
+		decoder = xml.NewDecoder(r)
+		entries []entity.SanctionsEntry
+	)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "sdnEntry" {
+			continue
+		}
+		var sdn sdnEntry
+		if err := decoder.DecodeElement(&sdn, &start); err != nil {
+			return nil, err
+		}
+		if skippedEntryTypes[strings.ToLower(sdn.SDNType)] {
+			continue
+		}
+		program := strings.Join(sdn.Programs, " ")
+		for _, name := range append([]sdnName{sdn.sdnName}, sdn.AKAs...) {
+			if name.String() == "" {
+				continue
+			}
+			entries = append(entries, entity.SanctionsEntry{
+				UID:     strings.TrimSpace(sdn.UID),
+				Name:    name.String(),
+				Type:    strings.TrimSpace(sdn.SDNType),
+				Program: program,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// matchName returns the entries similar to name from threshold on, best first.
+func matchName(name string, entries []entity.SanctionsEntry, threshold float64) []entity.SanctionsMatch {
+	var matches []entity.SanctionsMatch
+	for _, entry := range entries {
+		if score := fuzzyx.NameSimilarity(name, entry.Name); score >= threshold {
+			matches = append(matches, entity.SanctionsMatch{Entry: entry, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
@@ -0,0 +1,101 @@
+package sanctionsbusiness
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+const sdnCSV = `36,"AEROCARIBBEAN AIRLINES",-0- ,"CUBA",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- 
+173,"ANGLO-CARIBBEAN CO., LTD.",-0- ,"CUBA",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- 
+2674,"HASSAN, Mohammed Al",individual,"SDGT",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,"DOB 1970"
+15036,"ZAFAR","vessel","IRAN",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- 
+`
+
+const sdnXML = `<?xml version="1.0" standalone="yes"?>
+<sdnList xmlns="http://tempuri.org/sdnList.xsd">
+  <publshInformation><Publish_Date>10/01/2026</Publish_Date></publshInformation>
+  <sdnEntry>
+    <uid>2674</uid>
+    <firstName>Mohammed</firstName>
+    <lastName>AL HASSAN</lastName>
+    <sdnType>Individual</sdnType>
+    <programList><program>SDGT</program></programList>
+    <akaList>
+      <aka><uid>101</uid><type>a.k.a.</type><category>strong</category><lastName>ABU YUSUF</lastName></aka>
+    </akaList>
+  </sdnEntry>
+  <sdnEntry>
+    <uid>15036</uid>
+    <lastName>ZAFAR</lastName>
+    <sdnType>Vessel</sdnType>
+    <programList><program>IRAN</program></programList>
+  </sdnEntry>
+</sdnList>`
+
+func TestParseSDNCSV(t *testing.T) {
+	entries, err := parseSDNCSV(strings.NewReader(sdnCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3 without the vessel", len(entries))
+	}
+	want := entity.SanctionsEntry{UID: "2674", Name: "HASSAN, Mohammed Al", Type: "individual", Program: "SDGT"}
+	if entries[2] != want {
+		t.Errorf("got %+v, want %+v", entries[2], want)
+	}
+	if entries[0].Type != "" {
+		t.Errorf("-0- should read as empty, got %q", entries[0].Type)
+	}
+}
+
+func TestParseSDNXML(t *testing.T) {
+	entries, err := parseSDNXML(strings.NewReader(sdnXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []entity.SanctionsEntry{
+		{UID: "2674", Name: "Mohammed AL HASSAN", Type: "Individual", Program: "SDGT"},
+		{UID: "2674", Name: "ABU YUSUF", Type: "Individual", Program: "SDGT"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d: got %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestMatchName(t *testing.T) {
+	entries, err := parseSDNCSV(strings.NewReader(sdnCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		uid  string
+	}{
+		{"Muhammad Al Hasan", "2674"},
+		{"mohammed al-hassan", "2674"},
+		{"Aero Caribbean Airlines", "36"},
+		{"John Smith", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		matches := matchName(tt.name, entries, defaultSanctionsMatchThreshold)
+		if tt.uid == "" {
+			if len(matches) != 0 {
+				t.Errorf("%q: got %+v, want no match", tt.name, matches)
+			}
+			continue
+		}
+		if len(matches) == 0 || matches[0].Entry.UID != tt.uid {
+			t.Errorf("%q: got %+v, want entry %s first", tt.name, matches, tt.uid)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package sanctionsbusiness
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+type SanctionsBusiness interface {
+	ReloadList(ctx context.Context, path string) (*entity.SanctionsReloadResult, error)
+	Screen(ctx context.Context, name string) (*entity.SanctionsMatch, error)
+	OpenHit(ctx context.Context, hit entity.SanctionsHit, tx *sql.Tx) (*entity.SanctionsHit, error)
+	GetHits(ctx context.Context, status, subject string, m meta.Metadata) ([]entity.SanctionsHit, error)
+	GetHit(ctx context.Context, id uuid.UUID) (*entity.SanctionsHit, error)
+	ReviewHit(ctx context.Context, input entity.ReviewSanctionsHit) (*entity.SanctionsHit, error)
+}
+
+type sanctionsBusiness struct {
+	repo  repo.Repo
+	db    *sql.DB
+	audit auditbusiness.AuditBusiness
+}
+
+func NewSanctionsBusiness(db *sql.DB) SanctionsBusiness {
+	return &sanctionsBusiness{
+		repo:  repo.NewRepositories(db),
+		db:    db,
+		audit: auditbusiness.NewAuditBusiness(db),
+	}
+}
+
+// CheckStatus returns an ErrForbidden while user waits for a sanctions review
+// or once the hit is confirmed. Every operation that moves money in or out of
+// the user's account runs it inside its tx.
+func CheckStatus(user entity.User) error {
+	switch user.SanctionsStatus {
+	case consts.SanctionsStatusHOLD:
+		return errbank.NewErrForbidden("account is on hold pending a sanctions review")
+	case consts.SanctionsStatusBLOCKED:
+		return errbank.NewErrForbidden("account is blocked by sanctions screening")
+	}
+	return nil
+}
+
+// watchlist caches the loaded list per process, it is read again from the
+// database once a reload changes its version.
+var watchlist struct {
+	sync.Mutex
+	version time.Time
+	entries []entity.SanctionsEntry
+}
+
+func (b *sanctionsBusiness) entries(ctx context.Context) ([]entity.SanctionsEntry, error) {
+	version, err := b.repo.Sanctions.GetListVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	watchlist.Lock()
+	defer watchlist.Unlock()
+	if watchlist.entries != nil && watchlist.version.Equal(version) {
+		return watchlist.entries, nil
+	}
+	entries, err := b.repo.Sanctions.GetEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []entity.SanctionsEntry{}
+	}
+	watchlist.version, watchlist.entries = version, entries
+	return entries, nil
+}
+
+// ReloadList replaces the watchlist with the file at path, or at
+// SANCTIONS_LIST_FILE when path is empty.
+func (b *sanctionsBusiness) ReloadList(ctx context.Context, path string) (*entity.SanctionsReloadResult, error) {
+	var (
+		eventName = "business.sanctions.reload_list"
+		result    = entity.SanctionsReloadResult{File: path, LoadedAt: time.Now()}
+	)
+
+	if result.File == "" {
+		result.File = sanctionsListFile()
+	}
+	entries, err := parseList(result.File)
+	if err != nil {
+		log.Println(eventName, "parseList", err)
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errbank.NewErrUnprocessableEntity("sanctions list " + result.File + " has no entries")
+	}
+	result.Entries = len(entries)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.Sanctions.ReplaceEntries(ctx, entries, result.LoadedAt, tx)
+	if err != nil {
+		log.Println(eventName, "ReplaceEntries", err)
+		return nil, err
+	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionRELOADSANCTIONS,
+		Target: result.File,
+		After:  result,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Screen returns the best watchlist match of name, leaving out entries a hit on
+// the same name was already cleared for, or nil when there is none.
+func (b *sanctionsBusiness) Screen(ctx context.Context, name string) (*entity.SanctionsMatch, error) {
+	var eventName = "business.sanctions.screen"
+
+	entries, err := b.entries(ctx)
+	if err != nil {
+		log.Println(eventName, "entries", err)
+		return nil, err
+	}
+	for _, match := range matchName(name, entries, sanctionsMatchThreshold()) {
+		cleared, err := b.repo.Sanctions.IsCleared(ctx, name, match.Entry.UID)
+		if err != nil {
+			log.Println(eventName, "IsCleared", err)
+			return nil, err
+		}
+		if !cleared {
+			return &match, nil
+		}
+	}
+	return nil, nil
+}
+
+// OpenHit stores a hit waiting for an admin review.
+func (b *sanctionsBusiness) OpenHit(ctx context.Context, hit entity.SanctionsHit, tx *sql.Tx) (*entity.SanctionsHit, error) {
+	var eventName = "business.sanctions.open_hit"
+
+	hit.ID = uuid.New()
+	hit.Status = consts.SanctionsHitStatusOPEN
+	hit.CreatedAt = time.Now()
+	if err := b.repo.Sanctions.CreateHit(ctx, hit, tx); err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return &hit, nil
+}
+
+func (b *sanctionsBusiness) GetHits(ctx context.Context, status, subject string, m meta.Metadata) ([]entity.SanctionsHit, error) {
+	var eventName = "business.sanctions.get_hits"
+
+	hits, err := b.repo.Sanctions.GetListHit(ctx, status, subject, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return hits, nil
+}
+
+func (b *sanctionsBusiness) GetHit(ctx context.Context, id uuid.UUID) (*entity.SanctionsHit, error) {
+	var eventName = "business.sanctions.get_hit"
+
+	hit, err := b.repo.Sanctions.FindHitByID(ctx, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return hit, nil
+}
+
+// ReviewHit closes an open hit. A confirmed user hit blocks the user, a cleared
+// one lifts the hold once no other hit of the user is open. A cleared transfer
+// is run by the release worker, a confirmed one never runs.
+func (b *sanctionsBusiness) ReviewHit(ctx context.Context, input entity.ReviewSanctionsHit) (*entity.SanctionsHit, error) {
+	var (
+		eventName = "business.sanctions.review_hit"
+		now       = time.Now()
+	)
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	hit, err := b.repo.Sanctions.FindHitByIDForUpdate(ctx, input.HitID, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if hit.Status != consts.SanctionsHitStatusOPEN {
+		err = errbank.NewErrConflict("sanctions hit is already " + hit.Status)
+		return nil, err
+	}
+	if hit.Username == input.ReviewedBy {
+		err = errbank.NewErrForbidden("a sanctions hit must be reviewed by another user")
+		return nil, err
+	}
+
+	hit.Status = input.Status
+	hit.ReviewedBy = input.ReviewedBy
+	hit.Note = input.Note
+	hit.ReviewedAt = &now
+	err = b.repo.Sanctions.UpdateHit(ctx, *hit, tx)
+	if err != nil {
+		log.Println(eventName, "UpdateHit", err)
+		return nil, err
+	}
+	if hit.Subject == consts.SanctionsSubjectUSER {
+		err = b.settleUser(ctx, *hit, tx)
+		if err != nil {
+			log.Println(eventName, "settleUser", err)
+			return nil, err
+		}
+	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionREVIEWSANCTIONSHIT,
+		Target: hit.ID.String(),
+		Before: map[string]string{"status": consts.SanctionsHitStatusOPEN},
+		After:  hit,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return nil, err
+	}
+	return hit, nil
+}
+
+// settleUser updates the sanctions status of the user of a reviewed user hit.
+func (b *sanctionsBusiness) settleUser(ctx context.Context, hit entity.SanctionsHit, tx *sql.Tx) error {
+	user, err := b.repo.Users.FindByID(ctx, hit.UserID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case hit.Status == consts.SanctionsHitStatusCONFIRMED:
+		user.SanctionsStatus = consts.SanctionsStatusBLOCKED
+	case user.SanctionsStatus == consts.SanctionsStatusHOLD:
+		open, err := b.repo.Sanctions.CountOpenHits(ctx, user.ID, consts.SanctionsSubjectUSER, tx)
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return nil
+		}
+		user.SanctionsStatus = consts.SanctionsStatusCLEAR
+	default:
+		return nil
+	}
+	return b.repo.Users.UpdateSanctionsStatus(ctx, *user, tx)
+}
@@ -0,0 +1,49 @@
+package standingorderbusiness
+
+import (
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+// monthDay returns the given day of a month, clamped to the last day of shorter months.
+func monthDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// firstOccurrence returns the first execution date of an order starting on start.
+// Monthly orders run on the first dayOfMonth on or after start.
+func firstOccurrence(frequency string, start time.Time, dayOfMonth int) time.Time {
+	if frequency != consts.StandingOrderFrequencyMONTHLY {
+		return start
+	}
+	candidate := monthDay(start.Year(), start.Month(), dayOfMonth, start.Location())
+	if candidate.Before(start) {
+		candidate = monthDay(start.Year(), start.Month()+1, dayOfMonth, start.Location())
+	}
+	return candidate
+}
+
+// nextOccurrence returns the execution date following order.ScheduledFor, or false
+// when the order has no further occurrence.
+func nextOccurrence(order entity.StandingOrder) (time.Time, bool) {
+	var next time.Time
+	switch order.Frequency {
+	case consts.StandingOrderFrequencyWEEKLY:
+		next = order.ScheduledFor.AddDate(0, 0, 7)
+	case consts.StandingOrderFrequencyMONTHLY:
+		next = monthDay(order.ScheduledFor.Year(), order.ScheduledFor.Month()+1, order.DayOfMonth, order.ScheduledFor.Location())
+	default:
+		return time.Time{}, false
+	}
+	if order.EndDate != nil && next.After(*order.EndDate) {
+		return time.Time{}, false
+	}
+	return next, true
+}
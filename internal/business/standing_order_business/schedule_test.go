@@ -0,0 +1,56 @@
+package standingorderbusiness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestFirstOccurrence(t *testing.T) {
+	cases := []struct {
+		name       string
+		frequency  string
+		start      time.Time
+		dayOfMonth int
+		want       time.Time
+	}{
+		{"once", consts.StandingOrderFrequencyONCE, date(2024, 3, 10), 0, date(2024, 3, 10)},
+		{"weekly", consts.StandingOrderFrequencyWEEKLY, date(2024, 3, 10), 0, date(2024, 3, 10)},
+		{"monthly later this month", consts.StandingOrderFrequencyMONTHLY, date(2024, 3, 10), 25, date(2024, 3, 25)},
+		{"monthly next month", consts.StandingOrderFrequencyMONTHLY, date(2024, 3, 10), 5, date(2024, 4, 5)},
+		{"monthly clamped", consts.StandingOrderFrequencyMONTHLY, date(2024, 2, 10), 31, date(2024, 2, 29)},
+	}
+	for _, c := range cases {
+		if got := firstOccurrence(c.frequency, c.start, c.dayOfMonth); !got.Equal(c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	end := date(2024, 4, 15)
+	cases := []struct {
+		name   string
+		order  entity.StandingOrder
+		want   time.Time
+		wantOK bool
+	}{
+		{"once", entity.StandingOrder{Frequency: consts.StandingOrderFrequencyONCE, ScheduledFor: date(2024, 1, 31)}, time.Time{}, false},
+		{"weekly", entity.StandingOrder{Frequency: consts.StandingOrderFrequencyWEEKLY, ScheduledFor: date(2024, 1, 29)}, date(2024, 2, 5), true},
+		{"monthly end of month", entity.StandingOrder{Frequency: consts.StandingOrderFrequencyMONTHLY, DayOfMonth: 31, ScheduledFor: date(2024, 1, 31)}, date(2024, 2, 29), true},
+		{"monthly back to day", entity.StandingOrder{Frequency: consts.StandingOrderFrequencyMONTHLY, DayOfMonth: 31, ScheduledFor: date(2024, 2, 29)}, date(2024, 3, 31), true},
+		{"past end date", entity.StandingOrder{Frequency: consts.StandingOrderFrequencyMONTHLY, DayOfMonth: 20, ScheduledFor: date(2024, 3, 20), EndDate: &end}, time.Time{}, false},
+	}
+	for _, c := range cases {
+		got, ok := nextOccurrence(c.order)
+		if ok != c.wantOK || !got.Equal(c.want) {
+			t.Errorf("%s: got %v %v, want %v %v", c.name, got, ok, c.want, c.wantOK)
+		}
+	}
+}
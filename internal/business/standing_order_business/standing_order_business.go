@@ -0,0 +1,464 @@
+package standingorderbusiness
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	accountbusiness "github.com/hanselacn/banking-transaction/internal/business/account_business"
+	approvalbusiness "github.com/hanselacn/banking-transaction/internal/business/approval_business"
+	notificationbusiness "github.com/hanselacn/banking-transaction/internal/business/notification_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	"github.com/pkg/errors"
+)
+
+const (
+	standingOrderBatchSize      = 500
+	defaultStandingOrderRetry   = 3
+	defaultStandingOrderBackoff = 6 * time.Hour
+)
+
+type StandingOrderBusiness interface {
+	CreateStandingOrder(ctx context.Context, input entity.CreateStandingOrder) (*entity.StandingOrder, error)
+	GetStandingOrders(ctx context.Context, username string) ([]entity.StandingOrder, error)
+	UpdateStandingOrder(ctx context.Context, input entity.UpdateStandingOrder) error
+	CancelStandingOrder(ctx context.Context, username string, id uuid.UUID) error
+	GetStandingOrderExecutions(ctx context.Context, username string, id uuid.UUID) ([]entity.StandingOrderExecution, error)
+	StandingOrderWorker(ctx context.Context) (int, int, error)
+}
+
+type standingOrderBusiness struct {
+	repo         repo.Repo
+	account      accountbusiness.AccountBusiness
+	approval     approvalbusiness.ApprovalBusiness
+	notification notificationbusiness.NotificationBusiness
+}
+
+func NewStandingOrderBusiness(db *sql.DB) StandingOrderBusiness {
+	return &standingOrderBusiness{
+		repo:         repo.NewRepositories(db),
+		account:      accountbusiness.NewAccountBusiness(db),
+		approval:     approvalbusiness.NewApprovalBusiness(db),
+		notification: notificationbusiness.NewNotificationBusiness(db),
+	}
+}
+
+// standingOrderMaxRetry is how many times a failed occurrence is retried before it is skipped.
+func standingOrderMaxRetry() int {
+	v, err := strconv.Atoi(os.Getenv("STANDING_ORDER_MAX_RETRY"))
+	if err != nil || v < 0 {
+		return defaultStandingOrderRetry
+	}
+	return v
+}
+
+func standingOrderRetryInterval() time.Duration {
+	v, err := time.ParseDuration(os.Getenv("STANDING_ORDER_RETRY_INTERVAL"))
+	if err != nil || v <= 0 {
+		return defaultStandingOrderBackoff
+	}
+	return v
+}
+
+// needsApproval tells whether an occurrence of order would need a checker when
+// made by hand. Nobody approves a scheduled run, so such an order is refused.
+func (b *standingOrderBusiness) needsApproval(order entity.StandingOrder) bool {
+	return order.Type == consts.StandingOrderTypeWITHDRAWAL && b.approval.RequiresApproval(consts.ApprovalOperationWITHDRAWAL, order.Amount)
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+func (b *standingOrderBusiness) CreateStandingOrder(ctx context.Context, input entity.CreateStandingOrder) (*entity.StandingOrder, error) {
+	var (
+		eventName = "business.standing_order.create_standing_order"
+	)
+
+	start, err := time.ParseInLocation("2006-01-02", input.StartDate, time.Local)
+	if err != nil {
+		return nil, errbank.NewErrUnprocessableEntity("start_date: must be a valid date")
+	}
+	if start.Before(today()) {
+		return nil, errbank.NewErrUnprocessableEntity("start_date: must not be in the past")
+	}
+
+	order := entity.StandingOrder{
+		ID:          uuid.New(),
+		Type:        input.Type,
+		Amount:      input.Amount,
+		Frequency:   input.Frequency,
+		Status:      consts.StandingOrderStatusACTIVE,
+		Description: input.Description,
+	}
+	if input.EndDate != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", input.EndDate, time.Local)
+		if err != nil || endDate.Before(start) {
+			return nil, errbank.NewErrUnprocessableEntity("end_date: must be a valid date after start_date")
+		}
+		order.EndDate = &endDate
+	}
+	if order.Frequency == consts.StandingOrderFrequencyMONTHLY {
+		order.DayOfMonth = input.DayOfMonth
+		if order.DayOfMonth == 0 {
+			order.DayOfMonth = start.Day()
+		}
+	}
+	if b.needsApproval(order) {
+		return nil, errbank.NewErrUnprocessableEntity("amount: a withdrawal of this amount needs an approval and cannot be scheduled")
+	}
+	order.ScheduledFor = firstOccurrence(order.Frequency, start, order.DayOfMonth)
+	order.NextRunAt = order.ScheduledFor
+
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	order.AccountID = account.ID
+
+	if order.Type == consts.StandingOrderTypeTRANSFER {
+		if input.ToAccountNumber == "" {
+			return nil, errbank.NewErrUnprocessableEntity("to_account_number: cannot be blank")
+		}
+		beneficiary, err := b.repo.Account.FindByAccountNumber(ctx, input.ToAccountNumber)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, err
+		}
+		if beneficiary.ID == account.ID {
+			return nil, errbank.NewErrUnprocessableEntity("to_account_number: cannot transfer to the same account")
+		}
+		order.ToAccountNumber = input.ToAccountNumber
+	}
+
+	if err := b.repo.StandingOrder.Create(ctx, order); err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (b *standingOrderBusiness) GetStandingOrders(ctx context.Context, username string) ([]entity.StandingOrder, error) {
+	var (
+		eventName = "business.standing_order.get_standing_orders"
+	)
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	orders, err := b.repo.StandingOrder.GetListByAccountID(ctx, account.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return orders, nil
+}
+
+// findOwnedOrder returns the order only when it debits username's account.
+func (b *standingOrderBusiness) findOwnedOrder(ctx context.Context, username string, id uuid.UUID) (*entity.StandingOrder, error) {
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	order, err := b.repo.StandingOrder.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.AccountID != account.ID {
+		return nil, errbank.NewErrNotFound("standing order not found!")
+	}
+	return order, nil
+}
+
+func (b *standingOrderBusiness) UpdateStandingOrder(ctx context.Context, input entity.UpdateStandingOrder) error {
+	var (
+		eventName = "business.standing_order.update_standing_order"
+	)
+
+	order, err := b.findOwnedOrder(ctx, input.Username, input.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	if order.Status != consts.StandingOrderStatusACTIVE && order.Status != consts.StandingOrderStatusPAUSED {
+		return errbank.NewErrConflict("standing order is already closed")
+	}
+
+	if input.Amount > 0 {
+		order.Amount = input.Amount
+		if b.needsApproval(*order) {
+			return errbank.NewErrUnprocessableEntity("amount: a withdrawal of this amount needs an approval and cannot be scheduled")
+		}
+	}
+	if input.Description != "" {
+		order.Description = input.Description
+	}
+	if input.ToAccountNumber != "" {
+		if order.Type != consts.StandingOrderTypeTRANSFER {
+			return errbank.NewErrUnprocessableEntity("to_account_number: only transfers have a beneficiary")
+		}
+		beneficiary, err := b.repo.Account.FindByAccountNumber(ctx, input.ToAccountNumber)
+		if err != nil {
+			log.Println(eventName, err)
+			return err
+		}
+		if beneficiary.ID == order.AccountID {
+			return errbank.NewErrUnprocessableEntity("to_account_number: cannot transfer to the same account")
+		}
+		order.ToAccountNumber = input.ToAccountNumber
+	}
+	if input.EndDate != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", input.EndDate, time.Local)
+		if err != nil || endDate.Before(order.ScheduledFor) {
+			return errbank.NewErrUnprocessableEntity("end_date: must be a valid date after the next occurrence")
+		}
+		order.EndDate = &endDate
+	}
+	if input.Status != "" && input.Status != order.Status {
+		if input.Status == consts.StandingOrderStatusACTIVE {
+			// Occurrences missed while paused are skipped rather than executed late.
+			for order.ScheduledFor.Before(today()) {
+				next, ok := nextOccurrence(*order)
+				if !ok {
+					return errbank.NewErrConflict("standing order has no occurrence left to resume")
+				}
+				order.ScheduledFor = next
+			}
+			order.NextRunAt = order.ScheduledFor
+			order.RetryCount = 0
+		}
+		order.Status = input.Status
+	}
+
+	if err := b.repo.StandingOrder.Update(ctx, *order); err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	return nil
+}
+
+func (b *standingOrderBusiness) CancelStandingOrder(ctx context.Context, username string, id uuid.UUID) error {
+	var (
+		eventName = "business.standing_order.cancel_standing_order"
+	)
+
+	order, err := b.findOwnedOrder(ctx, username, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	if order.Status != consts.StandingOrderStatusACTIVE && order.Status != consts.StandingOrderStatusPAUSED {
+		return errbank.NewErrConflict("standing order is already closed")
+	}
+	order.Status = consts.StandingOrderStatusCANCELLED
+	if err := b.repo.StandingOrder.Update(ctx, *order); err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	return nil
+}
+
+func (b *standingOrderBusiness) GetStandingOrderExecutions(ctx context.Context, username string, id uuid.UUID) ([]entity.StandingOrderExecution, error) {
+	var (
+		eventName = "business.standing_order.get_standing_order_executions"
+	)
+
+	order, err := b.findOwnedOrder(ctx, username, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	executions, err := b.repo.StandingOrder.GetExecutions(ctx, order.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return executions, nil
+}
+
+// StandingOrderWorker executes every active order whose next run is due.
+func (b *standingOrderBusiness) StandingOrderWorker(ctx context.Context) (int, int, error) {
+	var (
+		eventName    = "business.standing_order.standing_order_worker"
+		now          = time.Now()
+		lastID       = uuid.Nil
+		totalData    = 0
+		totalSuccess = 0
+	)
+
+	for {
+		orders, err := b.repo.StandingOrder.GetDueBatch(ctx, now, lastID, standingOrderBatchSize)
+		if err != nil {
+			log.Println(eventName, err)
+			return totalData, totalSuccess, err
+		}
+		for i := range orders {
+			totalData++
+			if err := b.executeStandingOrder(ctx, orders[i], now); err != nil {
+				log.Println(eventName, orders[i].ID, err)
+				continue
+			}
+			totalSuccess++
+		}
+		if len(orders) < standingOrderBatchSize {
+			return totalData, totalSuccess, nil
+		}
+		lastID = orders[len(orders)-1].ID
+	}
+}
+
+// executeStandingOrder runs one attempt of the order's current occurrence and records it.
+// An attempt refused for an insufficient balance or failing transiently is retried after
+// STANDING_ORDER_RETRY_INTERVAL up to STANDING_ORDER_MAX_RETRY times, any other failure
+// skips the occurrence right away. A transfer held for a sanctions review is recorded
+// HELD and not retried, it runs once the hit is cleared. The owner is notified once the
+// occurrence is made or skipped.
+func (b *standingOrderBusiness) executeStandingOrder(ctx context.Context, order entity.StandingOrder, now time.Time) error {
+	var (
+		eventName = "business.standing_order.execute_standing_order"
+		execution = entity.StandingOrderExecution{
+			ID:              uuid.New(),
+			StandingOrderID: order.ID,
+			ScheduledFor:    order.ScheduledFor,
+			Attempt:         order.RetryCount + 1,
+			Status:          consts.ExecutionStatusINPROGRESS,
+		}
+	)
+
+	claimed, err := b.repo.StandingOrder.CreateExecution(ctx, execution)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	if !claimed {
+		return errbank.NewErrConflict("standing order attempt already executed")
+	}
+
+	execErr := b.execute(ctx, order)
+	_, held := errors.Cause(execErr).(errbank.ErrAccepted)
+	switch {
+	case execErr == nil:
+		execution.Status = consts.ExecutionStatusSUCCESS
+		b.advance(&order, consts.StandingOrderStatusCOMPLETED)
+	case held:
+		execution.Status = consts.ExecutionStatusHELD
+		execution.Message = execErr.Error()
+		b.advance(&order, consts.StandingOrderStatusCOMPLETED)
+	case retryable(execErr) && order.RetryCount < standingOrderMaxRetry():
+		execution.Status = consts.ExecutionStatusRETRY
+		execution.Message = execErr.Error()
+		order.RetryCount++
+		order.NextRunAt = now.Add(standingOrderRetryInterval())
+	default:
+		execution.Status = consts.ExecutionStatusFAILED
+		execution.Message = execErr.Error()
+		b.advance(&order, consts.StandingOrderStatusFAILED)
+	}
+
+	if err := b.repo.StandingOrder.UpdateExecution(ctx, execution); err != nil {
+		log.Println(eventName, "UpdateExecution", err)
+		return err
+	}
+	if err := b.repo.StandingOrder.Update(ctx, order); err != nil {
+		log.Println(eventName, "Update", err)
+		return err
+	}
+	switch execution.Status {
+	case consts.ExecutionStatusSUCCESS:
+		b.notifyOccurrence(ctx, consts.NotificationTypeSTANDINGORDEREXECUTED, order, execution)
+	case consts.ExecutionStatusFAILED:
+		b.notifyOccurrence(ctx, consts.NotificationTypeSTANDINGORDERFAILED, order, execution)
+	}
+	return execErr
+}
+
+// retryable tells whether a failed attempt may pass later: the balance may be topped
+// up and transient failures go away. A refusal such as a KYC limit or a sanctions
+// hold fails the same way on every attempt.
+func retryable(err error) bool {
+	cause := errors.Cause(err)
+	if errbank.IsInsufficientBalance(cause) || errbank.IsSerializationFailure(cause) {
+		return true
+	}
+	switch cause.(type) {
+	case errbank.ErrForbidden, errbank.ErrNotFound, errbank.ErrConflict, errbank.ErrUnprocessableEntity,
+		errbank.ErrBadRequest, errbank.ErrUnauthorized:
+		return false
+	}
+	return true
+}
+
+// notifyOccurrence tells the owner of order how the occurrence of execution ended.
+func (b *standingOrderBusiness) notifyOccurrence(ctx context.Context, notificationType string, order entity.StandingOrder, execution entity.StandingOrderExecution) {
+	var eventName = "business.standing_order.notify_occurrence"
+
+	account, err := b.repo.Account.FindByID(ctx, order.AccountID)
+	if err != nil {
+		log.Println(eventName, err)
+		return
+	}
+	b.notification.Notify(ctx, notificationbusiness.StandingOrderNotice(notificationType, account.UserID, *account, order, execution.ScheduledFor, execution.Message))
+}
+
+func (b *standingOrderBusiness) execute(ctx context.Context, order entity.StandingOrder) error {
+	// The threshold may have been lowered since the order was scheduled.
+	if b.needsApproval(order) {
+		return errbank.NewErrForbidden("a withdrawal of this amount needs an approval")
+	}
+	account, err := b.repo.Account.FindByID(ctx, order.AccountID)
+	if err != nil {
+		return err
+	}
+	user, err := b.repo.Users.FindByID(ctx, account.UserID)
+	if err != nil {
+		return err
+	}
+	if order.Type == consts.StandingOrderTypeTRANSFER {
+		return b.account.Transfer(ctx, entity.Transfer{
+			Username:        user.Username,
+			ToAccountNumber: order.ToAccountNumber,
+			Amount:          order.Amount,
+		})
+	}
+	return b.account.Withdrawal(ctx, entity.Withdrawal{
+		Username: user.Username,
+		Amount:   order.Amount,
+	})
+}
+
+// advance moves the order to its next occurrence, or closes it with lastStatus
+// when there is none left.
+func (b *standingOrderBusiness) advance(order *entity.StandingOrder, lastStatus string) {
+	order.RetryCount = 0
+	next, ok := nextOccurrence(*order)
+	if !ok {
+		order.Status = lastStatus
+		return
+	}
+	order.ScheduledFor = next
+	order.NextRunAt = next
+}
@@ -0,0 +1,239 @@
+package standingorderbusiness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	accountbusiness "github.com/hanselacn/banking-transaction/internal/business/account_business"
+	approvalbusiness "github.com/hanselacn/banking-transaction/internal/business/approval_business"
+	notificationbusiness "github.com/hanselacn/banking-transaction/internal/business/notification_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	accountrepo "github.com/hanselacn/banking-transaction/internal/repo/account_repo"
+	standingorderrepo "github.com/hanselacn/banking-transaction/internal/repo/standing_order_repo"
+	usersrepo "github.com/hanselacn/banking-transaction/internal/repo/users_repo"
+	"github.com/pkg/errors"
+)
+
+type fakeStandingOrderRepo struct {
+	standingorderrepo.StandingOrderRepositories
+	order      entity.StandingOrder
+	executions []entity.StandingOrderExecution
+	updated    []entity.StandingOrder
+}
+
+func (f *fakeStandingOrderRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.StandingOrder, error) {
+	order := f.order
+	return &order, nil
+}
+
+func (f *fakeStandingOrderRepo) CreateExecution(ctx context.Context, execution entity.StandingOrderExecution) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeStandingOrderRepo) UpdateExecution(ctx context.Context, execution entity.StandingOrderExecution) error {
+	f.executions = append(f.executions, execution)
+	return nil
+}
+
+func (f *fakeStandingOrderRepo) Update(ctx context.Context, order entity.StandingOrder) error {
+	f.updated = append(f.updated, order)
+	return nil
+}
+
+type fakeAccountRepo struct {
+	accountrepo.AccountRepositories
+}
+
+func (f *fakeAccountRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.Account, error) {
+	return &entity.Account{ID: id, UserID: uuid.New()}, nil
+}
+
+func (f *fakeAccountRepo) FindByUserID(ctx context.Context, userID uuid.UUID) (*entity.Account, error) {
+	return &entity.Account{ID: testAccountID, UserID: userID}, nil
+}
+
+type fakeUsersRepo struct {
+	usersrepo.UsersRepositories
+}
+
+func (f *fakeUsersRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	return &entity.User{ID: id, Username: "alice"}, nil
+}
+
+func (f *fakeUsersRepo) FindByUserName(ctx context.Context, username string) (*entity.User, error) {
+	return &entity.User{ID: uuid.New(), Username: username}, nil
+}
+
+var testAccountID = uuid.New()
+
+type fakeAccountBusiness struct {
+	accountbusiness.AccountBusiness
+	err         error
+	withdrawals []entity.Withdrawal
+}
+
+func (f *fakeAccountBusiness) Transfer(ctx context.Context, input entity.Transfer) error {
+	return f.err
+}
+
+func (f *fakeAccountBusiness) Withdrawal(ctx context.Context, input entity.Withdrawal) error {
+	f.withdrawals = append(f.withdrawals, input)
+	return f.err
+}
+
+type fakeNotification struct {
+	notificationbusiness.NotificationBusiness
+	sent []entity.NotificationInput
+}
+
+func (f *fakeNotification) Notify(ctx context.Context, input entity.NotificationInput) {
+	f.sent = append(f.sent, input)
+}
+
+// fakeApproval needs a checker for withdrawals from threshold.
+type fakeApproval struct {
+	approvalbusiness.ApprovalBusiness
+	threshold float64
+}
+
+func (f *fakeApproval) RequiresApproval(operation string, amount float64) bool {
+	return operation == consts.ApprovalOperationWITHDRAWAL && amount >= f.threshold
+}
+
+func TestExecuteStandingOrder(t *testing.T) {
+	var (
+		scheduled = date(2026, time.March, 5)
+		now       = scheduled.Add(time.Hour)
+	)
+
+	cases := []struct {
+		name             string
+		err              error
+		retries          int
+		wantStatus       string
+		wantScheduled    time.Time
+		wantRetries      int
+		wantNotification string
+	}{
+		{name: "executed", wantStatus: consts.ExecutionStatusSUCCESS, wantScheduled: date(2026, time.April, 5),
+			wantNotification: consts.NotificationTypeSTANDINGORDEREXECUTED},
+		{name: "held for sanctions review", err: errbank.NewErrAccepted("transfer held for sanctions review"), wantStatus: consts.ExecutionStatusHELD, wantScheduled: date(2026, time.April, 5)},
+		{name: "insufficient balance is retried", err: errbank.NewErrUnprocessableEntity("insufficient balance"), wantStatus: consts.ExecutionStatusRETRY, wantScheduled: scheduled, wantRetries: 1},
+		{name: "insufficient balance for the fee is retried", err: errbank.NewErrUnprocessableEntity("insufficient balance to cover TRANSFER fee"), wantStatus: consts.ExecutionStatusRETRY, wantScheduled: scheduled, wantRetries: 1},
+		{name: "serialization failure is retried", err: errbank.NewErrSerializationFailure("could not serialize access"), wantStatus: consts.ExecutionStatusRETRY, wantScheduled: scheduled, wantRetries: 1},
+		{name: "connection error is retried", err: errors.New("connection refused"), wantStatus: consts.ExecutionStatusRETRY, wantScheduled: scheduled, wantRetries: 1},
+		{name: "last attempt fails", err: errbank.NewErrUnprocessableEntity("insufficient balance"), retries: defaultStandingOrderRetry, wantStatus: consts.ExecutionStatusFAILED, wantScheduled: date(2026, time.April, 5),
+			wantNotification: consts.NotificationTypeSTANDINGORDERFAILED},
+		{name: "kyc limit fails right away", err: errbank.NewErrForbidden("transfers need a verified kyc"), wantStatus: consts.ExecutionStatusFAILED, wantScheduled: date(2026, time.April, 5),
+			wantNotification: consts.NotificationTypeSTANDINGORDERFAILED},
+		{name: "sanctions hold fails right away", err: errbank.NewErrForbidden("account is on hold pending a sanctions review"), wantStatus: consts.ExecutionStatusFAILED, wantScheduled: date(2026, time.April, 5),
+			wantNotification: consts.NotificationTypeSTANDINGORDERFAILED},
+		{name: "missing recipient fails right away", err: errbank.NewErrNotFound("data not found!"), wantStatus: consts.ExecutionStatusFAILED, wantScheduled: date(2026, time.April, 5),
+			wantNotification: consts.NotificationTypeSTANDINGORDERFAILED},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				orders       = &fakeStandingOrderRepo{}
+				notification = &fakeNotification{}
+				b            = &standingOrderBusiness{
+					repo:         repo.Repo{StandingOrder: orders, Account: &fakeAccountRepo{}, Users: &fakeUsersRepo{}},
+					account:      &fakeAccountBusiness{err: c.err},
+					approval:     &fakeApproval{threshold: 10000000},
+					notification: notification,
+				}
+				order = entity.StandingOrder{
+					ID:              uuid.New(),
+					AccountID:       uuid.New(),
+					Type:            consts.StandingOrderTypeTRANSFER,
+					ToAccountNumber: "000000000000000000000000000000000001",
+					Amount:          50000,
+					Frequency:       consts.StandingOrderFrequencyMONTHLY,
+					DayOfMonth:      5,
+					ScheduledFor:    scheduled,
+					NextRunAt:       scheduled,
+					RetryCount:      c.retries,
+					Status:          consts.StandingOrderStatusACTIVE,
+				}
+			)
+
+			err := b.executeStandingOrder(context.Background(), order, now)
+			if (err == nil) != (c.err == nil) {
+				t.Fatalf("executeStandingOrder() error = %v, want %v", err, c.err)
+			}
+			if len(orders.executions) != 1 || orders.executions[0].Status != c.wantStatus {
+				t.Fatalf("executions = %+v, want one %s", orders.executions, c.wantStatus)
+			}
+			updated := orders.updated[0]
+			if !updated.ScheduledFor.Equal(c.wantScheduled) || updated.RetryCount != c.wantRetries {
+				t.Errorf("order scheduled for %s with %d retries, want %s with %d", updated.ScheduledFor, updated.RetryCount, c.wantScheduled, c.wantRetries)
+			}
+			if c.wantNotification == "" {
+				if len(notification.sent) != 0 {
+					t.Errorf("notifications = %+v, want none", notification.sent)
+				}
+				return
+			}
+			if len(notification.sent) != 1 || notification.sent[0].Type != c.wantNotification || notification.sent[0].Data["ScheduledFor"] != "2026-03-05" {
+				t.Errorf("notifications = %+v, want one %s for 2026-03-05", notification.sent, c.wantNotification)
+			}
+		})
+	}
+}
+
+func TestWithdrawalNeedingApprovalIsNotScheduled(t *testing.T) {
+	var (
+		orders  = &fakeStandingOrderRepo{}
+		account = &fakeAccountBusiness{}
+		b       = &standingOrderBusiness{
+			repo:     repo.Repo{StandingOrder: orders, Account: &fakeAccountRepo{}, Users: &fakeUsersRepo{}},
+			account:  account,
+			approval: &fakeApproval{threshold: 10000000},
+		}
+		order = entity.StandingOrder{
+			ID:           uuid.New(),
+			AccountID:    testAccountID,
+			Type:         consts.StandingOrderTypeWITHDRAWAL,
+			Amount:       5000000,
+			Frequency:    consts.StandingOrderFrequencyWEEKLY,
+			ScheduledFor: today(),
+			NextRunAt:    today(),
+			Status:       consts.StandingOrderStatusACTIVE,
+		}
+	)
+	orders.order = order
+
+	_, err := b.CreateStandingOrder(context.Background(), entity.CreateStandingOrder{
+		Username:  "alice",
+		Type:      consts.StandingOrderTypeWITHDRAWAL,
+		Amount:    10000000,
+		Frequency: consts.StandingOrderFrequencyWEEKLY,
+		StartDate: today().Format("2006-01-02"),
+	})
+	if _, ok := errors.Cause(err).(errbank.ErrUnprocessableEntity); !ok {
+		t.Errorf("CreateStandingOrder() error = %v, want ErrUnprocessableEntity", err)
+	}
+
+	err = b.UpdateStandingOrder(context.Background(), entity.UpdateStandingOrder{ID: order.ID, Username: "alice", Amount: 20000000})
+	if _, ok := errors.Cause(err).(errbank.ErrUnprocessableEntity); !ok {
+		t.Errorf("UpdateStandingOrder() error = %v, want ErrUnprocessableEntity", err)
+	}
+	if len(orders.updated) != 0 {
+		t.Errorf("updated = %+v, want the order unchanged", orders.updated)
+	}
+
+	// An order scheduled before the threshold was lowered is not executed.
+	order.Amount = 15000000
+	err = b.execute(context.Background(), order)
+	if _, ok := errors.Cause(err).(errbank.ErrForbidden); !ok {
+		t.Errorf("execute() error = %v, want ErrForbidden", err)
+	}
+	if len(account.withdrawals) != 0 {
+		t.Errorf("withdrawals = %+v, want none", account.withdrawals)
+	}
+}
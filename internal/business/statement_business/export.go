@@ -0,0 +1,350 @@
+package statementbusiness
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+const (
+	ExportFormatMT940   = "mt940"
+	ExportFormatCAMT053 = "camt053"
+	ExportFormatOFX     = "ofx"
+
+	defaultStatementCurrency = "IDR"
+	defaultStatementBankID   = "BANKTRX"
+)
+
+func statementCurrency() string {
+	if v := strings.ToUpper(strings.TrimSpace(os.Getenv("STATEMENT_CURRENCY"))); len(v) == 3 {
+		return v
+	}
+	return defaultStatementCurrency
+}
+
+func statementBankID() string {
+	if v := strings.TrimSpace(os.Getenv("STATEMENT_BANK_ID")); v != "" {
+		return v
+	}
+	return defaultStatementBankID
+}
+
+// reference shortens a transaction id to the 16 characters SWIFT fields allow.
+func reference(line entity.StatementLine) string {
+	return strings.ToUpper(strings.ReplaceAll(line.TransactionID.String(), "-", "")[:16])
+}
+
+// mt940Amount formats an amount with the comma decimal separator SWIFT requires.
+func mt940Amount(v float64) string {
+	return strings.Replace(fmt.Sprintf("%.2f", math.Abs(v)), ".", ",", 1)
+}
+
+func mt940Mark(v float64) string {
+	if v < 0 {
+		return "D"
+	}
+	return "C"
+}
+
+func mt940TypeCode(line entity.StatementLine) string {
+	switch line.Action {
+	case consts.TxActionTRANSFER:
+		return "NTRF"
+	case consts.TxActionINTEREST:
+		return "NINT"
+	case consts.TxActionTAX:
+		return "NTAX"
+	case consts.TxActionFEE, consts.TxActionPENALTY:
+		return "NCHG"
+	case consts.TxActionLOAN:
+		if line.Credit > 0 {
+			return "NLDP"
+		}
+		return "NPRN"
+	}
+	return "NMSC"
+}
+
+// mt940Text keeps the characters of the SWIFT X character set.
+func mt940Text(s string, max int) string {
+	var b strings.Builder
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || strings.ContainsRune("/-?:().,'+ ", r) {
+			b.WriteRune(r)
+		}
+	}
+	out := b.String()
+	if len(out) > max {
+		out = out[:max]
+	}
+	return out
+}
+
+// renderMT940 renders the statement as a SWIFT MT940 customer statement message.
+func renderMT940(statement entity.Statement) []byte {
+	var (
+		buf      bytes.Buffer
+		currency = statementCurrency()
+		last     = statement.PeriodEnd.AddDate(0, 0, -1)
+	)
+	writeLine := func(format string, args ...interface{}) {
+		fmt.Fprintf(&buf, format+"\r\n", args...)
+	}
+
+	writeLine(":20:%s", mt940Text("STMT"+statement.PeriodStart.Format("060102")+last.Format("0102"), 16))
+	writeLine(":25:%s", mt940Text(statement.AccountNumber, 35))
+	writeLine(":28C:%s/1", statement.PeriodStart.Format("0601"))
+	writeLine(":60F:%s%s%s%s", mt940Mark(statement.OpeningBalance), statement.PeriodStart.Format("060102"), currency, mt940Amount(statement.OpeningBalance))
+	for _, line := range statement.Lines {
+		mark, value := "C", line.Credit
+		if line.Debit > 0 {
+			mark, value = "D", line.Debit
+		}
+		writeLine(":61:%s%s%s%s%sNONREF//%s", line.Date.Format("060102"), line.Date.Format("0102"), mark, mt940Amount(value), mt940TypeCode(line), reference(line))
+		writeLine(":86:%s", mt940Text(line.Description, 65))
+	}
+	writeLine(":62F:%s%s%s%s", mt940Mark(statement.ClosingBalance), last.Format("060102"), currency, mt940Amount(statement.ClosingBalance))
+	buf.WriteString("-\r\n")
+	return buf.Bytes()
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>Dt"`
+}
+
+type camtSummary struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camtEntry struct {
+	Reference   string     `xml:"NtryRef"`
+	Amount      camtAmount `xml:"Amt"`
+	Indicator   string     `xml:"CdtDbtInd"`
+	Status      string     `xml:"Sts"`
+	BookingDate string     `xml:"BookgDt>DtTm"`
+	ValueDate   string     `xml:"ValDt>Dt"`
+	ServicerRef string     `xml:"AcctSvcrRef"`
+	Code        string     `xml:"BkTxCd>Prtry>Cd"`
+	Issuer      string     `xml:"BkTxCd>Prtry>Issr"`
+	TxReference string     `xml:"NtryDtls>TxDtls>Refs>AcctSvcrRef"`
+	Information string     `xml:"NtryDtls>TxDtls>AddtlTxInf"`
+}
+
+type camtDocument struct {
+	XMLName       xml.Name      `xml:"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02 Document"`
+	MessageID     string        `xml:"BkToCstmrStmt>GrpHdr>MsgId"`
+	CreatedAt     string        `xml:"BkToCstmrStmt>GrpHdr>CreDtTm"`
+	StatementID   string        `xml:"BkToCstmrStmt>Stmt>Id"`
+	StmtCreatedAt string        `xml:"BkToCstmrStmt>Stmt>CreDtTm"`
+	From          string        `xml:"BkToCstmrStmt>Stmt>FrToDt>FrDtTm"`
+	To            string        `xml:"BkToCstmrStmt>Stmt>FrToDt>ToDtTm"`
+	AccountID     string        `xml:"BkToCstmrStmt>Stmt>Acct>Id>Othr>Id"`
+	Currency      string        `xml:"BkToCstmrStmt>Stmt>Acct>Ccy"`
+	Owner         string        `xml:"BkToCstmrStmt>Stmt>Acct>Ownr>Nm"`
+	Servicer      string        `xml:"BkToCstmrStmt>Stmt>Acct>Svcr>FinInstnId>Othr>Id"`
+	Balances      []camtBalance `xml:"BkToCstmrStmt>Stmt>Bal"`
+	Total         camtSummary   `xml:"BkToCstmrStmt>Stmt>TxsSummry>TtlNtries"`
+	TotalCredit   camtSummary   `xml:"BkToCstmrStmt>Stmt>TxsSummry>TtlCdtNtries"`
+	TotalDebit    camtSummary   `xml:"BkToCstmrStmt>Stmt>TxsSummry>TtlDbtNtries"`
+	Entries       []camtEntry   `xml:"BkToCstmrStmt>Stmt>Ntry"`
+}
+
+func camtIndicator(v float64) string {
+	if v < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// renderCAMT053 renders the statement as an ISO 20022 camt.053.001.02 bank to customer statement.
+func renderCAMT053(statement entity.Statement) ([]byte, error) {
+	var (
+		currency = statementCurrency()
+		last     = statement.PeriodEnd.AddDate(0, 0, -1)
+		id       = fmt.Sprintf("%s-%s-%s", statement.AccountNumber, statement.PeriodStart.Format("20060102"), last.Format("20060102"))
+		credits  int
+		debits   int
+		amountOf = func(v float64) camtAmount {
+			return camtAmount{Currency: currency, Value: fmt.Sprintf("%.2f", math.Abs(v))}
+		}
+		generatedAt  = statement.GeneratedAt.Format("2006-01-02T15:04:05")
+		balanceCodes = []struct {
+			code  string
+			value float64
+			date  time.Time
+		}{
+			{"OPBD", statement.OpeningBalance, statement.PeriodStart},
+			{"CLBD", statement.ClosingBalance, last},
+		}
+	)
+
+	doc := camtDocument{
+		MessageID:     id,
+		CreatedAt:     generatedAt,
+		StatementID:   id,
+		StmtCreatedAt: generatedAt,
+		From:          statement.PeriodStart.Format("2006-01-02T15:04:05"),
+		To:            statement.PeriodEnd.Add(-time.Second).Format("2006-01-02T15:04:05"),
+		AccountID:     statement.AccountNumber,
+		Currency:      currency,
+		Owner:         statement.Fullname,
+		Servicer:      statementBankID(),
+		TotalCredit:   camtSummary{Sum: fmt.Sprintf("%.2f", statement.TotalCredit)},
+		TotalDebit:    camtSummary{Sum: fmt.Sprintf("%.2f", statement.TotalDebit)},
+		Total:         camtSummary{Sum: fmt.Sprintf("%.2f", statement.TotalCredit+statement.TotalDebit)},
+	}
+	for _, balance := range balanceCodes {
+		doc.Balances = append(doc.Balances, camtBalance{
+			Code:      balance.code,
+			Amount:    amountOf(balance.value),
+			Indicator: camtIndicator(balance.value),
+			Date:      balance.date.Format("2006-01-02"),
+		})
+	}
+	for _, line := range statement.Lines {
+		value, indicator := line.Credit, "CRDT"
+		if line.Debit > 0 {
+			value, indicator = line.Debit, "DBIT"
+			debits++
+		} else {
+			credits++
+		}
+		doc.Entries = append(doc.Entries, camtEntry{
+			Reference:   reference(line),
+			Amount:      amountOf(value),
+			Indicator:   indicator,
+			Status:      "BOOK",
+			BookingDate: line.Date.Format("2006-01-02T15:04:05"),
+			ValueDate:   line.Date.Format("2006-01-02"),
+			ServicerRef: line.TransactionID.String(),
+			Code:        line.Action,
+			Issuer:      statementBankID(),
+			TxReference: line.TransactionID.String(),
+			Information: line.Description,
+		})
+	}
+	doc.TotalCredit.Count = credits
+	doc.TotalDebit.Count = debits
+	doc.Total.Count = credits + debits
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	FITID  string `xml:"FITID"`
+	Name   string `xml:"NAME"`
+}
+
+type ofxDocument struct {
+	XMLName      xml.Name         `xml:"OFX"`
+	SignonStatus ofxStatus        `xml:"SIGNONMSGSRSV1>SONRS>STATUS"`
+	ServerDate   string           `xml:"SIGNONMSGSRSV1>SONRS>DTSERVER"`
+	Language     string           `xml:"SIGNONMSGSRSV1>SONRS>LANGUAGE"`
+	TransferID   string           `xml:"BANKMSGSRSV1>STMTTRNRS>TRNUID"`
+	Status       ofxStatus        `xml:"BANKMSGSRSV1>STMTTRNRS>STATUS"`
+	Currency     string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>CURDEF"`
+	BankID       string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKACCTFROM>BANKID"`
+	AccountID    string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKACCTFROM>ACCTID"`
+	AccountType  string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKACCTFROM>ACCTTYPE"`
+	Start        string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>DTSTART"`
+	End          string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>DTEND"`
+	Transactions []ofxTransaction `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>STMTTRN"`
+	Balance      string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>LEDGERBAL>BALAMT"`
+	BalanceAsOf  string           `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>LEDGERBAL>DTASOF"`
+}
+
+func ofxDate(t time.Time) string {
+	return t.Format("20060102150405")
+}
+
+func ofxType(line entity.StatementLine) string {
+	switch line.Action {
+	case consts.TxActionDEPOSIT:
+		return "DEP"
+	case consts.TxActionPAYROLL:
+		return "DIRECTDEP"
+	case consts.TxActionWITHDRAWAL:
+		return "CASH"
+	case consts.TxActionTRANSFER:
+		return "XFER"
+	case consts.TxActionINTEREST:
+		if line.Credit > 0 {
+			return "INT"
+		}
+	case consts.TxActionFEE, consts.TxActionPENALTY:
+		if line.Debit > 0 {
+			return "FEE"
+		}
+	}
+	if line.Credit > 0 {
+		return "CREDIT"
+	}
+	return "DEBIT"
+}
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// renderOFX renders the statement as an OFX 2.2 bank statement response.
+func renderOFX(statement entity.Statement) ([]byte, error) {
+	doc := ofxDocument{
+		SignonStatus: ofxStatus{Code: 0, Severity: "INFO"},
+		ServerDate:   ofxDate(statement.GeneratedAt),
+		Language:     "ENG",
+		TransferID:   "0",
+		Status:       ofxStatus{Code: 0, Severity: "INFO"},
+		Currency:     statementCurrency(),
+		BankID:       statementBankID(),
+		AccountID:    statement.AccountNumber,
+		AccountType:  "SAVINGS",
+		Start:        ofxDate(statement.PeriodStart),
+		End:          ofxDate(statement.PeriodEnd),
+		Balance:      fmt.Sprintf("%.2f", statement.ClosingBalance),
+		BalanceAsOf:  ofxDate(statement.PeriodEnd),
+	}
+	for _, line := range statement.Lines {
+		value := line.Credit - line.Debit
+		doc.Transactions = append(doc.Transactions, ofxTransaction{
+			Type:   ofxType(line),
+			Posted: ofxDate(line.Date),
+			Amount: fmt.Sprintf("%.2f", value),
+			FITID:  line.TransactionID.String(),
+			Name:   line.Description,
+		})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(ofxHeader), out...), nil
+}
@@ -0,0 +1,97 @@
+package statementbusiness
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+func exportStatement() entity.Statement {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	return buildStatement(entity.Statement{
+		AccountNumber:  "1234567890",
+		Fullname:       "Jane Doe",
+		PeriodStart:    start,
+		PeriodEnd:      start.AddDate(0, 1, 0),
+		OpeningBalance: 100,
+		GeneratedAt:    start.AddDate(0, 1, 0),
+	}, testTransactions(start))
+}
+
+func TestRenderMT940(t *testing.T) {
+	t.Setenv("STATEMENT_CURRENCY", "")
+	lines := strings.Split(strings.TrimSuffix(string(renderMT940(exportStatement())), "\r\n"), "\r\n")
+
+	want := map[int]string{
+		0: ":20:STMT2403010331",
+		1: ":25:1234567890",
+		3: ":60F:C240301IDR100,00",
+	}
+	for i, line := range want {
+		if lines[i] != line {
+			t.Errorf("line %d = %q, want %q", i, lines[i], line)
+		}
+	}
+	if !strings.HasPrefix(lines[4], ":61:2403010301C1000,10NMSCNONREF//") || len(lines[4]) != len(":61:2403010301C1000,10NMSCNONREF//")+16 {
+		t.Errorf("unexpected deposit entry %q", lines[4])
+	}
+	if !strings.HasPrefix(lines[8], ":61:2403040304D2,50NCHG") {
+		t.Errorf("unexpected fee entry %q", lines[8])
+	}
+	if got := lines[len(lines)-2]; got != ":62F:C240331IDR906,90" {
+		t.Errorf("closing balance %q", got)
+	}
+	if lines[len(lines)-1] != "-" {
+		t.Errorf("missing message trailer")
+	}
+}
+
+func TestRenderCAMT053(t *testing.T) {
+	out, err := renderCAMT053(exportStatement())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc camtDocument
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.XMLName.Space != "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02" {
+		t.Errorf("namespace %q", doc.XMLName.Space)
+	}
+	if len(doc.Balances) != 2 || doc.Balances[1].Code != "CLBD" || doc.Balances[1].Amount.Value != "906.90" || doc.Balances[1].Indicator != "CRDT" {
+		t.Errorf("unexpected balances %+v", doc.Balances)
+	}
+	if len(doc.Entries) != 7 || doc.Total.Count != 7 || doc.TotalDebit.Count != 4 || doc.TotalDebit.Sum != "205.70" {
+		t.Errorf("unexpected entries %d summary %+v %+v", len(doc.Entries), doc.Total, doc.TotalDebit)
+	}
+	if doc.Entries[1].Indicator != "DBIT" || doc.Entries[1].Amount.Currency != "IDR" {
+		t.Errorf("unexpected entry %+v", doc.Entries[1])
+	}
+}
+
+func TestRenderOFX(t *testing.T) {
+	out, err := renderOFX(exportStatement())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte(`<?OFX OFXHEADER="200" VERSION="220"`)) {
+		t.Errorf("missing OFX header")
+	}
+	var doc ofxDocument
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Balance != "906.90" || doc.AccountID != "1234567890" || len(doc.Transactions) != 7 {
+		t.Errorf("unexpected statement %+v", doc)
+	}
+	if tr := doc.Transactions[1]; tr.Type != "CASH" || tr.Amount != "-200.20" {
+		t.Errorf("unexpected withdrawal %+v", tr)
+	}
+	if tr := doc.Transactions[5]; tr.Type != "INT" || tr.Posted != "20240328000000" {
+		t.Errorf("unexpected interest %+v", tr)
+	}
+}
@@ -0,0 +1,234 @@
+package statementbusiness
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/pdfx"
+)
+
+const (
+	pdfLineHeight   = 12.0
+	pdfMargin       = 40.0
+	pdfTableFont    = 8.0
+	statementLayout = "02 Jan 2006"
+)
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromCents(amount int64) float64 {
+	return float64(amount) / 100
+}
+
+func describe(transaction entity.Transaction) string {
+	credit := transaction.Type == consts.TxTypeCREDIT
+	switch transaction.Action {
+	case consts.TxActionDEPOSIT:
+		return "Deposit"
+	case consts.TxActionWITHDRAWAL:
+		return "Withdrawal"
+	case consts.TxActionPURCHASE:
+		return "Purchase"
+	case consts.TxActionTRANSFER:
+		if credit {
+			return "Incoming transfer"
+		}
+		return "Outgoing transfer"
+	case consts.TxActionINTEREST:
+		if credit {
+			return "Interest"
+		}
+		return "Overdraft interest"
+	case consts.TxActionTAX:
+		return "Withholding tax"
+	case consts.TxActionFEE:
+		if credit {
+			return "Fee refund"
+		}
+		return "Fee"
+	case consts.TxActionTERMDEPOSIT:
+		if credit {
+			return "Term deposit payout"
+		}
+		return "Term deposit placement"
+	case consts.TxActionPENALTY:
+		return "Penalty"
+	case consts.TxActionLOAN:
+		if credit {
+			return "Loan disbursement"
+		}
+		return "Loan instalment"
+	case consts.TxActionADJUSTMENT:
+		return "Balance adjustment"
+	case consts.TxActionPAYROLL:
+		return "Payroll"
+	}
+	return transaction.Action
+}
+
+// buildStatement lays the completed transactions of the period out with a
+// running balance starting from the opening balance. Amounts are summed in
+// cents so the closing balance does not drift.
+func buildStatement(statement entity.Statement, transactions []entity.Transaction) entity.Statement {
+	var (
+		balance                                  = cents(statement.OpeningBalance)
+		credit, debit, earned, charged, tax, fee int64
+	)
+	statement.Lines = []entity.StatementLine{}
+	for _, transaction := range transactions {
+		if transaction.Status != consts.TxStatusCOMPLETED {
+			continue
+		}
+		amount := cents(transaction.Amount)
+		line := entity.StatementLine{
+			TransactionID: transaction.ID,
+			Date:          transaction.CreatedAt,
+			Action:        transaction.Action,
+			Description:   describe(transaction),
+		}
+		if transaction.Type == consts.TxTypeCREDIT {
+			balance += amount
+			credit += amount
+			line.Credit = fromCents(amount)
+		} else {
+			balance -= amount
+			debit += amount
+			line.Debit = fromCents(amount)
+		}
+		line.Balance = fromCents(balance)
+
+		switch {
+		case transaction.Action == consts.TxActionINTEREST && transaction.Type == consts.TxTypeCREDIT:
+			earned += amount
+		case transaction.Action == consts.TxActionINTEREST:
+			charged += amount
+		case transaction.Action == consts.TxActionTAX:
+			tax += amount
+		case transaction.Action == consts.TxActionFEE && transaction.Type == consts.TxTypeCREDIT:
+			fee -= amount
+		case transaction.Action == consts.TxActionFEE:
+			fee += amount
+		}
+		statement.Lines = append(statement.Lines, line)
+	}
+	statement.ClosingBalance = fromCents(balance)
+	statement.TotalCredit = fromCents(credit)
+	statement.TotalDebit = fromCents(debit)
+	statement.InterestEarned = fromCents(earned)
+	statement.InterestCharged = fromCents(charged)
+	statement.TaxWithheld = fromCents(tax)
+	statement.Fees = fromCents(fee)
+	return statement
+}
+
+func amount(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+func renderPDF(statement entity.Statement) ([]byte, error) {
+	var (
+		doc    = pdfx.New()
+		header = fmt.Sprintf("%-11s %-24s %14s %14s %15s", "Date", "Description", "Debit", "Credit", "Balance")
+		page   *pdfx.Page
+		y      float64
+	)
+
+	newPage := func() {
+		page = doc.AddPage()
+		y = pdfx.PageHeight - pdfMargin
+		page.Text(pdfMargin, y, pdfx.FontBold, 16, "Account Statement")
+		page.Text(pdfx.PageWidth-pdfMargin-120, y, pdfx.FontRegular, 9, fmt.Sprintf("Page %d", len(doc.Pages())))
+		y -= 2 * pdfLineHeight
+	}
+	newPage()
+
+	summary := []string{
+		fmt.Sprintf("Account Number : %s", statement.AccountNumber),
+		fmt.Sprintf("Account Holder : %s", statement.Fullname),
+		fmt.Sprintf("Period         : %s to %s", statement.PeriodStart.Format(statementLayout), statement.PeriodEnd.AddDate(0, 0, -1).Format(statementLayout)),
+		fmt.Sprintf("Opening Balance: %.2f", statement.OpeningBalance),
+		fmt.Sprintf("Total Credits  : %.2f", statement.TotalCredit),
+		fmt.Sprintf("Total Debits   : %.2f", statement.TotalDebit),
+		fmt.Sprintf("Interest Earned: %.2f   Overdraft Interest: %.2f   Tax Withheld: %.2f   Fees: %.2f", statement.InterestEarned, statement.InterestCharged, statement.TaxWithheld, statement.Fees),
+		fmt.Sprintf("Closing Balance: %.2f", statement.ClosingBalance),
+	}
+	for _, line := range summary {
+		page.Text(pdfMargin, y, pdfx.FontMono, 9, line)
+		y -= pdfLineHeight
+	}
+	y -= pdfLineHeight
+
+	tableHeader := func() {
+		page.Text(pdfMargin, y, pdfx.FontMono, pdfTableFont, header)
+		y -= 4
+		page.Line(pdfMargin, y, pdfx.PageWidth-pdfMargin, y)
+		y -= pdfLineHeight
+	}
+	tableHeader()
+	page.Text(pdfMargin, y, pdfx.FontMono, pdfTableFont, fmt.Sprintf("%-11s %-24s %14s %14s %15.2f", statement.PeriodStart.Format(statementLayout), "Opening balance", "", "", statement.OpeningBalance))
+	y -= pdfLineHeight
+
+	for _, line := range statement.Lines {
+		if y < pdfMargin {
+			newPage()
+			tableHeader()
+		}
+		page.Text(pdfMargin, y, pdfx.FontMono, pdfTableFont, fmt.Sprintf("%-11s %-24.24s %14s %14s %15.2f", line.Date.Format(statementLayout), line.Description, amount(line.Debit), amount(line.Credit), line.Balance))
+		y -= pdfLineHeight
+	}
+	if y < pdfMargin+2*pdfLineHeight {
+		newPage()
+		tableHeader()
+	}
+	page.Line(pdfMargin, y+pdfLineHeight-4, pdfx.PageWidth-pdfMargin, y+pdfLineHeight-4)
+	page.Text(pdfMargin, y, pdfx.FontMono, pdfTableFont, fmt.Sprintf("%-11s %-24s %14s %14s %15.2f", "", "Closing balance", amount(statement.TotalDebit), amount(statement.TotalCredit), statement.ClosingBalance))
+	y -= 2 * pdfLineHeight
+	page.Text(pdfMargin, y, pdfx.FontRegular, 7, fmt.Sprintf("Generated %s", statement.GeneratedAt.Format(time.RFC1123)))
+
+	return doc.Bytes()
+}
+
+func renderCSV(statement entity.Statement) ([]byte, error) {
+	var (
+		buf    bytes.Buffer
+		writer = csv.NewWriter(&buf)
+		rows   = [][]string{
+			{"Account Number", statement.AccountNumber},
+			{"Account Holder", statement.Fullname},
+			{"Period", statement.Period},
+			{"Opening Balance", fmt.Sprintf("%.2f", statement.OpeningBalance)},
+			{"Total Credits", fmt.Sprintf("%.2f", statement.TotalCredit)},
+			{"Total Debits", fmt.Sprintf("%.2f", statement.TotalDebit)},
+			{"Interest Earned", fmt.Sprintf("%.2f", statement.InterestEarned)},
+			{"Overdraft Interest", fmt.Sprintf("%.2f", statement.InterestCharged)},
+			{"Tax Withheld", fmt.Sprintf("%.2f", statement.TaxWithheld)},
+			{"Fees", fmt.Sprintf("%.2f", statement.Fees)},
+			{"Closing Balance", fmt.Sprintf("%.2f", statement.ClosingBalance)},
+			{"Date", "Transaction ID", "Description", "Debit", "Credit", "Balance"},
+		}
+	)
+	for _, line := range statement.Lines {
+		rows = append(rows, []string{
+			line.Date.Format(time.RFC3339),
+			line.TransactionID.String(),
+			line.Description,
+			amount(line.Debit),
+			amount(line.Credit),
+			fmt.Sprintf("%.2f", line.Balance),
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,89 @@
+package statementbusiness
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+)
+
+func testTransactions(start time.Time) []entity.Transaction {
+	tx := func(day int, txType, action, status string, amount float64) entity.Transaction {
+		return entity.Transaction{ID: uuid.New(), Type: txType, Action: action, Status: status, Amount: amount, CreatedAt: start.AddDate(0, 0, day)}
+	}
+	return []entity.Transaction{
+		tx(0, consts.TxTypeCREDIT, consts.TxActionDEPOSIT, consts.TxStatusCOMPLETED, 1000.10),
+		tx(1, consts.TxTypeDEBIT, consts.TxActionWITHDRAWAL, consts.TxStatusFAILED, 5000),
+		tx(2, consts.TxTypeDEBIT, consts.TxActionWITHDRAWAL, consts.TxStatusCOMPLETED, 200.20),
+		tx(3, consts.TxTypeDEBIT, consts.TxActionFEE, consts.TxStatusCOMPLETED, 2.50),
+		tx(4, consts.TxTypeCREDIT, consts.TxActionFEE, consts.TxStatusCOMPLETED, 2.50),
+		tx(5, consts.TxTypeDEBIT, consts.TxActionFEE, consts.TxStatusCOMPLETED, 1),
+		tx(27, consts.TxTypeCREDIT, consts.TxActionINTEREST, consts.TxStatusCOMPLETED, 10),
+		tx(27, consts.TxTypeDEBIT, consts.TxActionTAX, consts.TxStatusCOMPLETED, 2),
+	}
+}
+
+func TestBuildStatement(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	statement := buildStatement(entity.Statement{OpeningBalance: 100}, testTransactions(start))
+
+	if len(statement.Lines) != 7 {
+		t.Fatalf("expected 7 completed lines, got %d", len(statement.Lines))
+	}
+	if statement.ClosingBalance != 906.9 {
+		t.Errorf("closing balance %.2f, want 906.90", statement.ClosingBalance)
+	}
+	if last := statement.Lines[len(statement.Lines)-1]; last.Balance != statement.ClosingBalance {
+		t.Errorf("running balance %.2f does not end at closing balance", last.Balance)
+	}
+	if statement.TotalCredit != 1012.6 || statement.TotalDebit != 205.7 {
+		t.Errorf("totals credit %.2f debit %.2f", statement.TotalCredit, statement.TotalDebit)
+	}
+	if statement.Fees != 1 || statement.InterestEarned != 10 || statement.TaxWithheld != 2 {
+		t.Errorf("fees %.2f interest %.2f tax %.2f", statement.Fees, statement.InterestEarned, statement.TaxWithheld)
+	}
+	if statement.Lines[3].Description != "Fee refund" {
+		t.Errorf("unexpected description %q", statement.Lines[3].Description)
+	}
+}
+
+func TestRenderStatement(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	statement := buildStatement(entity.Statement{
+		AccountNumber: "1234567890",
+		Fullname:      "Jane Doe",
+		Period:        "2024-03",
+		PeriodStart:   start,
+		PeriodEnd:     start.AddDate(0, 1, 0),
+	}, testTransactions(start))
+
+	// Enough lines to spill over to a second page.
+	for len(statement.Lines) < 120 {
+		statement.Lines = append(statement.Lines, statement.Lines[0])
+	}
+	pdf, err := renderPDF(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) || !bytes.Contains(pdf, []byte("/Count 3")) {
+		t.Errorf("unexpected pdf layout")
+	}
+
+	out, err := renderCSV(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := csv.NewReader(bytes.NewReader(out))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0][1] != "1234567890" || records[11][0] != "Date" || len(records) != 12+len(statement.Lines) {
+		t.Errorf("unexpected csv layout: %v", records[:12])
+	}
+}
@@ -0,0 +1,276 @@
+package statementbusiness
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	"github.com/pkg/errors"
+)
+
+const (
+	statementBatchSize = 500
+	periodLayout       = "2006-01"
+)
+
+type StatementBusiness interface {
+	GetStatement(ctx context.Context, username string, period string) (*entity.StatementFile, error)
+	StatementWorker(ctx context.Context) (int, int, error)
+	ExportStatement(ctx context.Context, username string, dr meta.DateRange, format string) (*entity.StatementExport, error)
+}
+
+type statementBusiness struct {
+	repo repo.Repo
+	db   *sql.DB
+}
+
+func NewStatementBusiness(db *sql.DB) StatementBusiness {
+	return &statementBusiness{
+		repo: repo.NewRepositories(db),
+		db:   db,
+	}
+}
+
+// GetStatement returns the statement of a month. Statements of closed months are
+// served from the pre-generated copy, generated and stored on first request when
+// the monthly job has not produced one yet; the current month is rendered up to now.
+func (b *statementBusiness) GetStatement(ctx context.Context, username string, period string) (*entity.StatementFile, error) {
+	var (
+		eventName = "business.statement.get_statement"
+		now       = time.Now()
+	)
+
+	start, err := time.ParseInLocation(periodLayout, period, time.Local)
+	if err != nil {
+		return nil, errbank.NewErrUnprocessableEntity("period must be formatted as yyyy-mm")
+	}
+	if start.After(now) {
+		return nil, errbank.NewErrUnprocessableEntity("period is in the future")
+	}
+
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	closed := !start.AddDate(0, 1, 0).After(now)
+	if closed {
+		stored, err := b.repo.Statement.FindByAccountIDAndPeriod(ctx, account.ID, period)
+		if err == nil {
+			return stored, nil
+		}
+		if _, ok := errors.Cause(err).(errbank.ErrNotFound); !ok {
+			log.Println(eventName, "FindByAccountIDAndPeriod", err)
+			return nil, err
+		}
+	}
+
+	statement, err := b.generate(ctx, *user, *account, start, now)
+	if err != nil {
+		log.Println(eventName, "generate", err)
+		return nil, err
+	}
+	if closed {
+		if _, err := b.repo.Statement.Create(ctx, *statement); err != nil {
+			log.Println(eventName, "Create", err)
+			return nil, err
+		}
+	}
+	return statement, nil
+}
+
+// buildAccountStatement lays out the completed transactions of the account created
+// in [start, until) for the statement period [start, end).
+func (b *statementBusiness) buildAccountStatement(ctx context.Context, user entity.User, account entity.Account, start, until, end, now time.Time) (entity.Statement, error) {
+	opening, err := b.repo.Transaction.SumCompletedByAccountIDBefore(ctx, account.ID, start)
+	if err != nil {
+		return entity.Statement{}, err
+	}
+	transactions, err := b.repo.Transaction.GetListByAccountID(ctx, account.ID, meta.DateRange{
+		Field: "created_at",
+		Start: start,
+		End:   until,
+	})
+	if err != nil {
+		return entity.Statement{}, err
+	}
+
+	return buildStatement(entity.Statement{
+		AccountNumber:  account.AccountNumber,
+		Username:       user.Username,
+		Fullname:       user.Fullname,
+		Period:         start.Format(periodLayout),
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: opening,
+		GeneratedAt:    now,
+	}, transactions), nil
+}
+
+// generate renders the statement of the month starting at start, covering the
+// transactions created before now when the month is not over yet.
+func (b *statementBusiness) generate(ctx context.Context, user entity.User, account entity.Account, start, now time.Time) (*entity.StatementFile, error) {
+	end := start.AddDate(0, 1, 0)
+	until := end
+	if now.Before(until) {
+		until = now
+	}
+	statement, err := b.buildAccountStatement(ctx, user, account, start, until, end, now)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf, err := renderPDF(statement)
+	if err != nil {
+		return nil, err
+	}
+	csv, err := renderCSV(statement)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.StatementFile{
+		ID:             uuid.New(),
+		AccountID:      account.ID,
+		Period:         statement.Period,
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		PDF:            pdf,
+		CSV:            csv,
+		GeneratedAt:    now,
+	}, nil
+}
+
+// ExportStatement renders the account transactions of the days in dr, both ends
+// included, in a bank statement interchange format for accounting software.
+func (b *statementBusiness) ExportStatement(ctx context.Context, username string, dr meta.DateRange, format string) (*entity.StatementExport, error) {
+	var (
+		eventName = "business.statement.export_statement"
+		now       = time.Now()
+		start     = time.Date(dr.Start.Year(), dr.Start.Month(), dr.Start.Day(), 0, 0, 0, 0, time.Local)
+		end       = time.Date(dr.End.Year(), dr.End.Month(), dr.End.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+	)
+
+	if !start.Before(end) {
+		return nil, errbank.NewErrUnprocessableEntity("start must not be after end")
+	}
+	if start.After(now) {
+		return nil, errbank.NewErrUnprocessableEntity("period is in the future")
+	}
+	if end.After(start.AddDate(1, 0, 1)) {
+		return nil, errbank.NewErrUnprocessableEntity("period must not exceed one year")
+	}
+
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	statement, err := b.buildAccountStatement(ctx, *user, *account, start, end, end, now)
+	if err != nil {
+		log.Println(eventName, "buildAccountStatement", err)
+		return nil, err
+	}
+
+	var (
+		name   = fmt.Sprintf("statement_%s_%s_%s", account.AccountNumber, start.Format("20060102"), end.AddDate(0, 0, -1).Format("20060102"))
+		export = &entity.StatementExport{}
+	)
+	switch format {
+	case ExportFormatMT940:
+		export.FileName = name + ".sta"
+		export.ContentType = "text/plain"
+		export.Content = renderMT940(statement)
+	case ExportFormatCAMT053:
+		export.FileName = name + ".xml"
+		export.ContentType = "application/xml"
+		export.Content, err = renderCAMT053(statement)
+	case ExportFormatOFX:
+		export.FileName = name + ".ofx"
+		export.ContentType = "application/x-ofx"
+		export.Content, err = renderOFX(statement)
+	default:
+		return nil, errbank.NewErrUnprocessableEntity("format must be mt940, camt053 or ofx")
+	}
+	if err != nil {
+		log.Println(eventName, format, err)
+		return nil, err
+	}
+	return export, nil
+}
+
+// StatementWorker pre-generates the statements of the previous month for every
+// account opened before the end of that month.
+func (b *statementBusiness) StatementWorker(ctx context.Context) (int, int, error) {
+	var (
+		eventName    = "business.statement.statement_worker"
+		now          = time.Now()
+		end          = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		start        = end.AddDate(0, -1, 0)
+		period       = start.Format(periodLayout)
+		lastID       = uuid.Nil
+		totalData    = 0
+		totalSuccess = 0
+	)
+
+	for {
+		accounts, err := b.repo.Account.GetAccountBatch(ctx, lastID, statementBatchSize)
+		if err != nil {
+			log.Println(eventName, err)
+			return totalData, totalSuccess, err
+		}
+		for i := range accounts {
+			if !accounts[i].CreatedAt.Before(end) {
+				continue
+			}
+			totalData++
+			if err := b.generateStored(ctx, accounts[i], start, period, now); err != nil {
+				log.Println(eventName, accounts[i].ID, err)
+				continue
+			}
+			totalSuccess++
+		}
+		if len(accounts) < statementBatchSize {
+			return totalData, totalSuccess, nil
+		}
+		lastID = accounts[len(accounts)-1].ID
+	}
+}
+
+func (b *statementBusiness) generateStored(ctx context.Context, account entity.Account, start time.Time, period string, now time.Time) error {
+	_, err := b.repo.Statement.FindByAccountIDAndPeriod(ctx, account.ID, period)
+	if err == nil {
+		return nil
+	}
+	if _, ok := errors.Cause(err).(errbank.ErrNotFound); !ok {
+		return err
+	}
+
+	user, err := b.repo.Users.FindByID(ctx, account.UserID)
+	if err != nil {
+		return err
+	}
+	statement, err := b.generate(ctx, *user, account, start, now)
+	if err != nil {
+		return err
+	}
+	_, err = b.repo.Statement.Create(ctx, *statement)
+	return err
+}
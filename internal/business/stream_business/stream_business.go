@@ -0,0 +1,224 @@
+package streambusiness
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	"github.com/lib/pq"
+)
+
+const (
+	streamBuffer         = 16
+	listenerMinReconnect = 10 * time.Second
+	listenerMaxReconnect = time.Minute
+	listenerPingInterval = 90 * time.Second
+)
+
+// accountHub is shared by every streamBusiness in the process: the listener
+// started by the server feeds it and the open streams read from it.
+var accountHub = newHub()
+
+// hub fans the committed events out to the streams open on their account.
+type hub struct {
+	mu          sync.RWMutex
+	subscribers map[uuid.UUID]map[chan entity.AccountStreamEvent]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subscribers: map[uuid.UUID]map[chan entity.AccountStreamEvent]struct{}{}}
+}
+
+func (h *hub) subscribe(accountID uuid.UUID) (chan entity.AccountStreamEvent, func()) {
+	ch := make(chan entity.AccountStreamEvent, streamBuffer)
+	h.mu.Lock()
+	if h.subscribers[accountID] == nil {
+		h.subscribers[accountID] = map[chan entity.AccountStreamEvent]struct{}{}
+	}
+	h.subscribers[accountID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers[accountID], ch)
+		if len(h.subscribers[accountID]) == 0 {
+			delete(h.subscribers, accountID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// broadcast never waits for a stream. A stream that is that far behind misses
+// the event, its next balance message still carries the current balance.
+func (h *hub) broadcast(event entity.AccountStreamEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subscribers[event.AccountID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+type StreamBusiness interface {
+	// Notify publishes the event when tx commits, to the streams of every instance.
+	Notify(ctx context.Context, event entity.AccountStreamEvent, tx *sql.Tx) error
+	// Listen receives the notified events until ctx is done.
+	Listen(ctx context.Context, connStr string) error
+	// Subscribe streams the balance of the user's account followed by every
+	// committed transaction and the balance after it, until ctx is done.
+	Subscribe(ctx context.Context, username string) (<-chan entity.StreamMessage, error)
+}
+
+type streamBusiness struct {
+	repo repo.Repo
+	db   *sql.DB
+	hub  *hub
+}
+
+func NewStreamBusiness(db *sql.DB) StreamBusiness {
+	return &streamBusiness{
+		repo: repo.NewRepositories(db),
+		db:   db,
+		hub:  accountHub,
+	}
+}
+
+func (b *streamBusiness) Notify(ctx context.Context, event entity.AccountStreamEvent, tx *sql.Tx) error {
+	var eventName = "business.stream.notify"
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	err = b.repo.Stream.Notify(ctx, consts.StreamChannelACCOUNT, payload, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	return nil
+}
+
+func (b *streamBusiness) Listen(ctx context.Context, connStr string) error {
+	var eventName = "business.stream.listen"
+
+	listener := pq.NewListener(connStr, listenerMinReconnect, listenerMaxReconnect, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println(eventName, err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(consts.StreamChannelACCOUNT)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// nil after a reconnect, what was notified in between is lost.
+			if notification == nil {
+				continue
+			}
+			var event entity.AccountStreamEvent
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				log.Println(eventName, err)
+				continue
+			}
+			b.hub.broadcast(event)
+		case <-ticker.C:
+			go listener.Ping()
+		}
+	}
+}
+
+func (b *streamBusiness) balance(ctx context.Context, userID uuid.UUID) (*entity.StreamMessage, error) {
+	account, err := b.repo.Account.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.StreamMessage{
+		Event: consts.StreamEventBALANCE,
+		Data: entity.BalanceUpdate{
+			AccountID:        account.ID,
+			AccountNumber:    account.AccountNumber,
+			Balance:          account.Balance,
+			AvailableBalance: account.AvailableBalance(),
+			UpdatedAt:        time.Now(),
+		},
+	}, nil
+}
+
+func (b *streamBusiness) Subscribe(ctx context.Context, username string) (<-chan entity.StreamMessage, error) {
+	var eventName = "business.stream.subscribe"
+
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	// Subscribe before reading the balance so no transaction falls in between.
+	events, unsubscribe := b.hub.subscribe(account.ID)
+	current, err := b.balance(ctx, user.ID)
+	if err != nil {
+		unsubscribe()
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	messages := make(chan entity.StreamMessage)
+	go func() {
+		defer close(messages)
+		defer unsubscribe()
+
+		send := func(message entity.StreamMessage) bool {
+			select {
+			case messages <- message:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if !send(*current) {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-events:
+				if !send(entity.StreamMessage{Event: consts.StreamEventTRANSACTION, Data: event}) {
+					return
+				}
+				balance, err := b.balance(ctx, user.ID)
+				if err != nil {
+					log.Println(eventName, "balance", err)
+					continue
+				}
+				if !send(*balance) {
+					return
+				}
+			}
+		}
+	}()
+	return messages, nil
+}
@@ -0,0 +1,120 @@
+package streambusiness
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	accountrepo "github.com/hanselacn/banking-transaction/internal/repo/account_repo"
+	usersrepo "github.com/hanselacn/banking-transaction/internal/repo/users_repo"
+)
+
+type fakeUsersRepo struct {
+	usersrepo.UsersRepositories
+	user entity.User
+}
+
+func (f *fakeUsersRepo) FindByUserName(ctx context.Context, username string) (*entity.User, error) {
+	return &f.user, nil
+}
+
+type fakeAccountRepo struct {
+	accountrepo.AccountRepositories
+	mu      sync.Mutex
+	account entity.Account
+}
+
+func (f *fakeAccountRepo) FindByUserID(ctx context.Context, id uuid.UUID) (*entity.Account, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	account := f.account
+	return &account, nil
+}
+
+func (f *fakeAccountRepo) setBalance(balance float64) {
+	f.mu.Lock()
+	f.account.Balance = balance
+	f.mu.Unlock()
+}
+
+func receive(t *testing.T, messages <-chan entity.StreamMessage) entity.StreamMessage {
+	t.Helper()
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	return entity.StreamMessage{}
+}
+
+func TestSubscribeStreamsOwnAccount(t *testing.T) {
+	var (
+		user     = entity.User{ID: uuid.New(), Username: "alice"}
+		accounts = &fakeAccountRepo{account: entity.Account{ID: uuid.New(), UserID: user.ID, Balance: 1000, OverdraftLimit: 500}}
+		b        = &streamBusiness{
+			repo: repo.Repo{Users: &fakeUsersRepo{user: user}, Account: accounts},
+			hub:  newHub(),
+		}
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	messages, err := b.Subscribe(ctx, user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := receive(t, messages)
+	if balance, _ := first.Data.(entity.BalanceUpdate); first.Event != consts.StreamEventBALANCE || balance.Balance != 1000 || balance.AvailableBalance != 1500 {
+		t.Fatalf("first message = %+v, want the current balance", first)
+	}
+
+	// Another account's transaction is not streamed.
+	b.hub.broadcast(entity.AccountStreamEvent{AccountID: uuid.New(), Amount: 1})
+	accounts.setBalance(1250)
+	deposit := entity.AccountStreamEvent{AccountID: accounts.account.ID, TransactionID: uuid.New(), Amount: 250, Status: consts.TxStatusCOMPLETED}
+	b.hub.broadcast(deposit)
+
+	if message := receive(t, messages); message.Event != consts.StreamEventTRANSACTION || message.Data != deposit {
+		t.Fatalf("got %+v, want the deposit", message)
+	}
+	if message := receive(t, messages); message.Data.(entity.BalanceUpdate).Balance != 1250 {
+		t.Fatalf("got %+v, want the balance after the deposit", message)
+	}
+
+	cancel()
+	for range messages {
+	}
+	b.hub.mu.RLock()
+	defer b.hub.mu.RUnlock()
+	if len(b.hub.subscribers) != 0 {
+		t.Errorf("stream still subscribed after its context is done")
+	}
+}
+
+func TestBroadcastDoesNotWaitForSlowStreams(t *testing.T) {
+	h := newHub()
+	accountID := uuid.New()
+	events, unsubscribe := h.subscribe(accountID)
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < streamBuffer*2; i++ {
+			h.broadcast(entity.AccountStreamEvent{AccountID: accountID})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcast blocked on a stream that does not read")
+	}
+	if len(events) != streamBuffer {
+		t.Errorf("got %d buffered events, want %d", len(events), streamBuffer)
+	}
+}
@@ -0,0 +1,99 @@
+package taxbusiness
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+type TaxBusiness interface {
+	GetTaxCertificate(ctx context.Context, username string, year int) (*entity.TaxCertificate, error)
+}
+
+type taxBusiness struct {
+	repo repo.Repo
+}
+
+func NewTaxBusiness(db *sql.DB) TaxBusiness {
+	return &taxBusiness{repo: repo.NewRepositories(db)}
+}
+
+// GetTaxCertificate summarizes the interest paid and tax withheld for a customer over a calendar year.
+func (b *taxBusiness) GetTaxCertificate(ctx context.Context, username string, year int) (*entity.TaxCertificate, error) {
+	var (
+		eventName = "business.tax.get_tax_certificate"
+	)
+
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	certificate := entity.TaxCertificate{
+		Username:      user.Username,
+		Fullname:      user.Fullname,
+		AccountNumber: account.AccountNumber,
+		TaxStatus:     user.TaxStatus,
+		TaxExempt:     user.TaxExempt,
+		Year:          year,
+		Lines:         []entity.TaxCertificateLine{},
+		GeneratedAt:   time.Now(),
+	}
+
+	withholding, err := b.repo.Tax.FindByUserIDAndYear(ctx, user.ID, year)
+	if err != nil {
+		if _, ok := err.(errbank.ErrNotFound); !ok {
+			log.Println(eventName, err)
+			return nil, err
+		}
+	} else {
+		certificate.GrossInterest = withholding.GrossInterest
+		certificate.TaxWithheld = withholding.TaxWithheld
+	}
+	certificate.NetInterest = certificate.GrossInterest - certificate.TaxWithheld
+
+	transactions, err := b.repo.Transaction.GetListByAccountID(ctx, account.ID, meta.DateRange{
+		Field: "created_at",
+		Start: time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local),
+		End:   time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.Local),
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		if transaction.Status != consts.TxStatusCOMPLETED {
+			continue
+		}
+		// Overdraft interest is charged as a debit and is not interest earned.
+		if transaction.Action == consts.TxActionINTEREST && transaction.Type != consts.TxTypeCREDIT {
+			continue
+		}
+		switch transaction.Action {
+		case consts.TxActionINTEREST, consts.TxActionTAX:
+			certificate.Lines = append(certificate.Lines, entity.TaxCertificateLine{
+				TransactionID: transaction.ID,
+				Date:          transaction.CreatedAt,
+				Action:        transaction.Action,
+				Type:          transaction.Type,
+				Amount:        transaction.Amount,
+			})
+		}
+	}
+
+	return &certificate, nil
+}
@@ -0,0 +1,179 @@
+package taxbusiness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	accountrepo "github.com/hanselacn/banking-transaction/internal/repo/account_repo"
+	taxrepo "github.com/hanselacn/banking-transaction/internal/repo/tax_repo"
+	transactionrepo "github.com/hanselacn/banking-transaction/internal/repo/transaction_repo"
+	usersrepo "github.com/hanselacn/banking-transaction/internal/repo/users_repo"
+)
+
+type fakeUsersRepo struct {
+	usersrepo.UsersRepositories
+	user entity.User
+}
+
+func (f *fakeUsersRepo) FindByUserName(ctx context.Context, username string) (*entity.User, error) {
+	user := f.user
+	return &user, nil
+}
+
+type fakeAccountRepo struct {
+	accountrepo.AccountRepositories
+	account entity.Account
+}
+
+func (f *fakeAccountRepo) FindByUserID(ctx context.Context, id uuid.UUID) (*entity.Account, error) {
+	account := f.account
+	return &account, nil
+}
+
+type fakeTaxRepo struct {
+	taxrepo.TaxRepositories
+	// withholdings are the yearly totals by year.
+	withholdings map[int]entity.TaxWithholding
+}
+
+func (f *fakeTaxRepo) FindByUserIDAndYear(ctx context.Context, userID uuid.UUID, year int) (*entity.TaxWithholding, error) {
+	withholding, ok := f.withholdings[year]
+	if !ok {
+		return nil, errbank.NewErrNotFound("tax withholding not found")
+	}
+	return &withholding, nil
+}
+
+type fakeTransactionRepo struct {
+	transactionrepo.TransactionRepo
+	transactions []entity.Transaction
+}
+
+func (f *fakeTransactionRepo) GetListByAccountID(ctx context.Context, accountID uuid.UUID, dr meta.DateRange) ([]entity.Transaction, error) {
+	var result []entity.Transaction
+	for _, transaction := range f.transactions {
+		if !transaction.CreatedAt.Before(dr.Start) && transaction.CreatedAt.Before(dr.End) {
+			result = append(result, transaction)
+		}
+	}
+	return result, nil
+}
+
+func newTaxBusiness(withholdings map[int]entity.TaxWithholding, transactions []entity.Transaction) *taxBusiness {
+	user := entity.User{ID: uuid.New(), Username: "jane", TaxStatus: "RESIDENT"}
+	return &taxBusiness{
+		repo: repo.Repo{
+			Users:       &fakeUsersRepo{user: user},
+			Account:     &fakeAccountRepo{account: entity.Account{ID: uuid.New(), UserID: user.ID}},
+			Tax:         &fakeTaxRepo{withholdings: withholdings},
+			Transaction: &fakeTransactionRepo{transactions: transactions},
+		},
+	}
+}
+
+func taxTransaction(action, txType string, amount float64, createdAt time.Time) entity.Transaction {
+	return entity.Transaction{
+		ID:        uuid.New(),
+		Type:      txType,
+		Action:    action,
+		Amount:    amount,
+		Status:    consts.TxStatusCOMPLETED,
+		CreatedAt: createdAt,
+	}
+}
+
+func TestTaxCertificateLeavesOutOverdraftInterest(t *testing.T) {
+	date := time.Date(2025, time.June, 30, 0, 0, 0, 0, time.Local)
+	b := newTaxBusiness(nil, []entity.Transaction{
+		taxTransaction(consts.TxActionINTEREST, consts.TxTypeCREDIT, 500, date),
+		taxTransaction(consts.TxActionTAX, consts.TxTypeDEBIT, 100, date),
+		taxTransaction(consts.TxActionINTEREST, consts.TxTypeDEBIT, 300, date),
+	})
+
+	certificate, err := b.GetTaxCertificate(context.Background(), "jane", 2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certificate.Lines) != 2 {
+		t.Fatalf("lines = %+v, want the interest credit and the tax", certificate.Lines)
+	}
+	for _, line := range certificate.Lines {
+		if line.Action == consts.TxActionINTEREST && line.Type != consts.TxTypeCREDIT {
+			t.Errorf("line = %+v, want no debit interest", line)
+		}
+		if line.Action == consts.TxActionTAX && line.Type != consts.TxTypeDEBIT {
+			t.Errorf("line = %+v, want the tax as a debit", line)
+		}
+	}
+}
+
+func TestGetTaxCertificate(t *testing.T) {
+	var (
+		start = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.Local)
+		end   = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.Local)
+		june  = time.Date(2025, time.June, 30, 12, 0, 0, 0, time.Local)
+	)
+	failed := taxTransaction(consts.TxActionINTEREST, consts.TxTypeCREDIT, 900, june)
+	failed.Status = consts.TxStatusFAILED
+	pending := taxTransaction(consts.TxActionTAX, consts.TxTypeDEBIT, 180, june)
+	pending.Status = consts.TxStatusINPROGRESS
+
+	transactions := []entity.Transaction{
+		taxTransaction(consts.TxActionINTEREST, consts.TxTypeCREDIT, 100, start.Add(-time.Second)),
+		taxTransaction(consts.TxActionINTEREST, consts.TxTypeCREDIT, 200, start),
+		taxTransaction(consts.TxActionTAX, consts.TxTypeDEBIT, 40, start),
+		taxTransaction(consts.TxActionDEPOSIT, consts.TxTypeCREDIT, 5000, june),
+		failed,
+		pending,
+		taxTransaction(consts.TxActionINTEREST, consts.TxTypeCREDIT, 300, end.Add(-time.Second)),
+		taxTransaction(consts.TxActionTAX, consts.TxTypeDEBIT, 60, end.Add(-time.Second)),
+		taxTransaction(consts.TxActionINTEREST, consts.TxTypeCREDIT, 400, end),
+	}
+	withholdings := map[int]entity.TaxWithholding{
+		2025: {Year: 2025, GrossInterest: 500, TaxWithheld: 100},
+		2026: {Year: 2026, GrossInterest: 400, TaxWithheld: 80},
+	}
+
+	cases := []struct {
+		name      string
+		year      int
+		wantGross float64
+		wantTax   float64
+		wantNet   float64
+		wantLines []float64
+	}{
+		{name: "lines from the first to the last second of the year", year: 2025, wantGross: 500, wantTax: 100, wantNet: 400,
+			wantLines: []float64{200, 40, 300, 60}},
+		{name: "next year starts at midnight", year: 2026, wantGross: 400, wantTax: 80, wantNet: 320,
+			wantLines: []float64{400}},
+		{name: "year without withholding", year: 2023, wantLines: []float64{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := newTaxBusiness(withholdings, transactions)
+
+			certificate, err := b.GetTaxCertificate(context.Background(), "jane", c.year)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if certificate.Year != c.year || certificate.GrossInterest != c.wantGross || certificate.TaxWithheld != c.wantTax || certificate.NetInterest != c.wantNet {
+				t.Errorf("certificate = %+v, want gross %v, tax %v and net %v", certificate, c.wantGross, c.wantTax, c.wantNet)
+			}
+			if len(certificate.Lines) != len(c.wantLines) {
+				t.Fatalf("lines = %+v, want amounts %v", certificate.Lines, c.wantLines)
+			}
+			for i, line := range certificate.Lines {
+				if line.Amount != c.wantLines[i] || line.Date.Year() != c.year {
+					t.Errorf("line %d = %+v, want %v in %d", i, line, c.wantLines[i], c.year)
+				}
+			}
+		})
+	}
+}
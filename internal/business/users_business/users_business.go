@@ -13,26 +13,44 @@ import (
 	"math/rand"
 
 	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	notificationbusiness "github.com/hanselacn/banking-transaction/internal/business/notification_business"
+	outboxbusiness "github.com/hanselacn/banking-transaction/internal/business/outbox_business"
+	sanctionsbusiness "github.com/hanselacn/banking-transaction/internal/business/sanctions_business"
 	"github.com/hanselacn/banking-transaction/internal/consts"
 	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
 	"github.com/hanselacn/banking-transaction/internal/repo"
+	"github.com/pkg/errors"
 )
 
 type UsersBusiness interface {
 	CreateUser(ctx context.Context, input entity.CreateUserInput) (*entity.User, error)
 	UpdateRoleByUserName(ctx context.Context, input entity.User) error
 	GetUserDetail(ctx context.Context, username string) (*entity.User, error)
+	UpdateTaxProfile(ctx context.Context, input entity.UpdateTaxProfile) error
+	UpdateProfile(ctx context.Context, input entity.UpdateUserProfile) (*entity.UserProfile, error)
+	GetProfile(ctx context.Context, username string, unmasked bool) (*entity.UserProfile, error)
 }
 
 type usersBusiness struct {
-	repo repo.Repo
-	db   *sql.DB
+	repo         repo.Repo
+	db           *sql.DB
+	audit        auditbusiness.AuditBusiness
+	outbox       outboxbusiness.OutboxBusiness
+	notification notificationbusiness.NotificationBusiness
+	sanctions    sanctionsbusiness.SanctionsBusiness
 }
 
 func NewUsersBusiness(db *sql.DB) UsersBusiness {
 	return &usersBusiness{
-		repo: repo.NewRepositories(db),
-		db:   db,
+		repo:         repo.NewRepositories(db),
+		db:           db,
+		audit:        auditbusiness.NewAuditBusiness(db),
+		outbox:       outboxbusiness.NewOutboxBusiness(db),
+		notification: notificationbusiness.NewNotificationBusiness(db),
+		sanctions:    sanctionsbusiness.NewSanctionsBusiness(db),
 	}
 }
 
@@ -40,12 +58,23 @@ func (b *usersBusiness) CreateUser(ctx context.Context, input entity.CreateUserI
 	var (
 		eventName = "business.users.create_user"
 		user      = entity.User{
-			ID:       uuid.New(),
-			Username: input.Username,
-			Fullname: input.Fullname,
-			Role:     consts.RoleCustomer,
+			ID:              uuid.New(),
+			Username:        input.Username,
+			Fullname:        input.Fullname,
+			Role:            consts.RoleCustomer,
+			SanctionsStatus: consts.SanctionsStatusCLEAR,
 		}
 	)
+	// A name on the watchlist is onboarded on hold until an admin reviews the hit.
+	match, err := b.sanctions.Screen(ctx, input.Fullname)
+	if err != nil {
+		log.Println(eventName, "Screen", err)
+		return nil, err
+	}
+	if match != nil {
+		user.SanctionsStatus = consts.SanctionsStatusHOLD
+	}
+
 	interestEnv := os.Getenv("DEFAULT_INTEREST_RATE")
 	defaultInterestRate, err := strconv.ParseFloat(interestEnv, 64)
 	if err != nil {
@@ -70,6 +99,25 @@ func (b *usersBusiness) CreateUser(ctx context.Context, input entity.CreateUserI
 		return nil, err
 	}
 
+	if match != nil {
+		if _, err := b.sanctions.OpenHit(ctx, entity.SanctionsHit{
+			Subject:      consts.SanctionsSubjectUSER,
+			UserID:       user.ID,
+			ScreenedName: user.Fullname,
+			EntryUID:     match.Entry.UID,
+			EntryName:    match.Entry.Name,
+			Program:      match.Entry.Program,
+			Score:        match.Score,
+		}, tx); err != nil {
+			if err := tx.Rollback(); err != nil {
+				log.Println(eventName, err)
+				return nil, err
+			}
+			log.Println(eventName, err)
+			return nil, err
+		}
+	}
+
 	if err := b.repo.Authorization.Create(ctx, entity.Authorization{
 		ID:       uuid.New(),
 		UserID:   user.ID,
@@ -83,18 +131,42 @@ func (b *usersBusiness) CreateUser(ctx context.Context, input entity.CreateUserI
 		return nil, err
 	}
 
+	if err := b.repo.Authorization.CreatePasswordHistory(ctx, user.ID, input.Password, tx); err != nil {
+		if err := tx.Rollback(); err != nil {
+			log.Println(eventName, err)
+			return nil, err
+		}
+		log.Println(eventName, err)
+		return nil, err
+	}
+
 	num := big.NewInt(0).SetBytes(user.ID[:])
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	randomNumber := big.NewInt(0).Rand(r, num)
 
 	// Format the random number as a string with leading zeros
 	randomNumberString := fmt.Sprintf("%036d", randomNumber)
-	if err = b.repo.Account.Create(ctx, entity.Account{
+	account := entity.Account{
 		ID:            uuid.New(),
 		UserID:        user.ID,
 		AccountNumber: randomNumberString,
 		Balance:       0,
 		InterestRate:  defaultInterestRate,
+	}
+	if err = b.repo.Account.Create(ctx, account, tx); err != nil {
+		if err := tx.Rollback(); err != nil {
+			log.Println(eventName, err)
+			return nil, err
+		}
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	if err := b.outbox.Enqueue(ctx, consts.EventAccountCreated, account.ID, entity.AccountCreatedEvent{
+		UserID:        user.ID,
+		Username:      user.Username,
+		AccountID:     account.ID,
+		AccountNumber: account.AccountNumber,
 	}, tx); err != nil {
 		if err := tx.Rollback(); err != nil {
 			log.Println(eventName, err)
@@ -104,6 +176,19 @@ func (b *usersBusiness) CreateUser(ctx context.Context, input entity.CreateUserI
 		return nil, err
 	}
 
+	if err := b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionCREATEUSER,
+		Target: user.Username,
+		After:  user,
+	}, tx); err != nil {
+		if err := tx.Rollback(); err != nil {
+			log.Println(eventName, err)
+			return nil, err
+		}
+		log.Println(eventName, "audit", err)
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		if err := tx.Rollback(); err != nil {
 			log.Println(eventName, err)
@@ -112,7 +197,6 @@ func (b *usersBusiness) CreateUser(ctx context.Context, input entity.CreateUserI
 		log.Println(eventName, err)
 		return nil, err
 	}
-
 	return &user, nil
 }
 
@@ -120,10 +204,70 @@ func (b *usersBusiness) UpdateRoleByUserName(ctx context.Context, input entity.U
 	var (
 		eventName = "business.users.update_role"
 	)
-	if err := b.repo.Users.UpdateRoleByUserName(ctx, input); err != nil {
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	account, err := b.repo.Account.FindByUserID(ctx, user.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.Users.UpdateRoleByUserName(ctx, input, tx)
+	if err != nil {
 		log.Println(eventName, err)
 		return err
 	}
+	err = b.outbox.Enqueue(ctx, consts.EventRoleChanged, account.ID, entity.RoleChangedEvent{
+		UserID:   user.ID,
+		Username: user.Username,
+		OldRole:  user.Role,
+		NewRole:  input.Role,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "Enqueue", err)
+		return err
+	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionUPDATEROLE,
+		Target: input.Username,
+		Before: map[string]string{"role": user.Role},
+		After:  map[string]string{"role": input.Role},
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	b.notification.Notify(ctx, entity.NotificationInput{
+		UserID: user.ID,
+		Type:   consts.NotificationTypeROLECHANGED,
+		Data:   map[string]string{"OldRole": user.Role, "NewRole": input.Role},
+	})
 	return nil
 }
 
@@ -134,3 +278,148 @@ func (b *usersBusiness) GetUserDetail(ctx context.Context, username string) (*en
 	}
 	return user, nil
 }
+
+func (b *usersBusiness) UpdateTaxProfile(ctx context.Context, input entity.UpdateTaxProfile) error {
+	var (
+		eventName = "business.users.update_tax_profile"
+	)
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: 0,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Println(eventName, "Rollback", rollbackErr)
+			}
+		}
+	}()
+
+	err = b.repo.Users.UpdateTaxProfile(ctx, entity.User{
+		Username:  input.Username,
+		TaxStatus: input.TaxStatus,
+		TaxExempt: input.TaxExempt,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	err = b.audit.Record(ctx, entity.AuditRecord{
+		Action: consts.AuditActionUPDATETAXPROFILE,
+		Target: input.Username,
+		Before: entity.UpdateTaxProfile{Username: user.Username, TaxStatus: user.TaxStatus, TaxExempt: user.TaxExempt},
+		After:  input,
+	}, tx)
+	if err != nil {
+		log.Println(eventName, "audit", err)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Println(eventName, "commiting transaction error", err)
+		return err
+	}
+	return nil
+}
+
+// UpdateProfile updates the personal data of the user. Only the fields sent are
+// changed, the others keep their stored value. The profile returned to the owner
+// is not masked.
+func (b *usersBusiness) UpdateProfile(ctx context.Context, input entity.UpdateUserProfile) (*entity.UserProfile, error) {
+	var (
+		eventName = "business.users.update_profile"
+		now       = time.Now()
+	)
+	user, err := b.repo.Users.FindByUserName(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	profile, err := b.repo.Users.FindProfileByUserID(ctx, user.ID)
+	switch errors.Cause(err).(type) {
+	case nil:
+	case errbank.ErrNotFound:
+		profile = &entity.UserProfile{}
+	default:
+		log.Println(eventName, err)
+		return nil, err
+	}
+	profile.UserID = user.ID
+	profile.Username = user.Username
+	profile.Fullname = user.Fullname
+	profile.UpdatedAt = &now
+	for _, field := range []struct {
+		value  string
+		stored *string
+	}{
+		{input.Email, &profile.Email},
+		{input.Phone, &profile.Phone},
+		{input.DateOfBirth, &profile.DateOfBirth},
+		{input.NationalID, &profile.NationalID},
+		{input.Address, &profile.Address},
+	} {
+		if field.value != "" {
+			*field.stored = field.value
+		}
+	}
+
+	if err := b.repo.Users.UpsertProfile(ctx, *profile); err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return profile, nil
+}
+
+// GetProfile returns the profile of username, masked unless unmasked is set.
+// Unmasked views of someone else's profile are recorded in the audit log.
+func (b *usersBusiness) GetProfile(ctx context.Context, username string, unmasked bool) (*entity.UserProfile, error) {
+	var (
+		eventName = "business.users.get_profile"
+	)
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	profile, err := b.repo.Users.FindProfileByUserID(ctx, user.ID)
+	switch errors.Cause(err).(type) {
+	case nil:
+	case errbank.ErrNotFound:
+		profile = &entity.UserProfile{UserID: user.ID}
+	default:
+		log.Println(eventName, err)
+		return nil, err
+	}
+	profile.Username = user.Username
+	profile.Fullname = user.Fullname
+
+	if !unmasked {
+		masked := profile.Mask()
+		return &masked, nil
+	}
+	// The personal data is not shown unless the view is recorded.
+	if viewer, _ := ctx.Value(middleware.CtxValueUserName).(string); viewer != username {
+		if err := b.audit.Record(ctx, entity.AuditRecord{
+			Action: consts.AuditActionVIEWPROFILE,
+			Target: username,
+		}, nil); err != nil {
+			log.Println(eventName, "audit", err)
+			return nil, err
+		}
+	}
+	return profile, nil
+}
@@ -0,0 +1,140 @@
+package usersbusiness
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	auditbusiness "github.com/hanselacn/banking-transaction/internal/business/audit_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	usersrepo "github.com/hanselacn/banking-transaction/internal/repo/users_repo"
+)
+
+type fakeUsersRepo struct {
+	usersrepo.UsersRepositories
+	user    entity.User
+	profile *entity.UserProfile
+}
+
+func (f *fakeUsersRepo) FindByUserName(ctx context.Context, username string) (*entity.User, error) {
+	user := f.user
+	return &user, nil
+}
+
+func (f *fakeUsersRepo) FindProfileByUserID(ctx context.Context, userID uuid.UUID) (*entity.UserProfile, error) {
+	if f.profile == nil {
+		return nil, errbank.NewErrNotFound("profile not found")
+	}
+	profile := *f.profile
+	return &profile, nil
+}
+
+func (f *fakeUsersRepo) UpsertProfile(ctx context.Context, profile entity.UserProfile) error {
+	f.profile = &profile
+	return nil
+}
+
+type fakeAudit struct {
+	auditbusiness.AuditBusiness
+	records []entity.AuditRecord
+}
+
+func (f *fakeAudit) Record(ctx context.Context, record entity.AuditRecord, tx *sql.Tx) error {
+	f.records = append(f.records, record)
+	return nil
+}
+
+var storedProfile = entity.UserProfile{
+	Email:       "alice@example.com",
+	Phone:       "+6281234567890",
+	DateOfBirth: "1990-05-17",
+	NationalID:  "3171012345678901",
+	Address:     "Jl Sudirman 1 Jakarta",
+}
+
+func TestUpdateProfile(t *testing.T) {
+	cases := []struct {
+		name   string
+		stored *entity.UserProfile
+		input  entity.UpdateUserProfile
+		want   entity.UserProfile
+	}{
+		{name: "partial update keeps the other fields", stored: &storedProfile,
+			input: entity.UpdateUserProfile{Phone: "+6289876543210"},
+			want: entity.UserProfile{Email: "alice@example.com", Phone: "+6289876543210", DateOfBirth: "1990-05-17",
+				NationalID: "3171012345678901", Address: "Jl Sudirman 1 Jakarta"}},
+		{name: "full update", stored: &storedProfile,
+			input: entity.UpdateUserProfile{Email: "a@example.org", Phone: "+6289876543210", DateOfBirth: "1991-01-01",
+				NationalID: "3171019999999999", Address: "Jl Thamrin 2 Jakarta"},
+			want: entity.UserProfile{Email: "a@example.org", Phone: "+6289876543210", DateOfBirth: "1991-01-01",
+				NationalID: "3171019999999999", Address: "Jl Thamrin 2 Jakarta"}},
+		{name: "first profile", input: entity.UpdateUserProfile{Email: "alice@example.com"},
+			want: entity.UserProfile{Email: "alice@example.com"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				user  = entity.User{ID: uuid.New(), Username: "alice", Fullname: "Alice"}
+				users = &fakeUsersRepo{user: user, profile: c.stored}
+				b     = &usersBusiness{repo: repo.Repo{Users: users}}
+			)
+			c.input.Username = user.Username
+
+			profile, err := b.UpdateProfile(context.Background(), c.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, got := range []entity.UserProfile{*profile, *users.profile} {
+				if got.UserID != user.ID || got.Email != c.want.Email || got.Phone != c.want.Phone || got.DateOfBirth != c.want.DateOfBirth ||
+					got.NationalID != c.want.NationalID || got.Address != c.want.Address || got.Masked {
+					t.Errorf("profile = %+v, want %+v", got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetProfile(t *testing.T) {
+	cases := []struct {
+		name      string
+		viewer    string
+		unmasked  bool
+		wantAudit bool
+	}{
+		{name: "masked for staff", viewer: "admin"},
+		{name: "owner in full", viewer: "alice", unmasked: true},
+		{name: "super admin in full is audited", viewer: "root", unmasked: true, wantAudit: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				stored = storedProfile
+				users  = &fakeUsersRepo{user: entity.User{ID: uuid.New(), Username: "alice"}, profile: &stored}
+				audit  = &fakeAudit{}
+				b      = &usersBusiness{repo: repo.Repo{Users: users}, audit: audit}
+				ctx    = context.WithValue(context.Background(), middleware.CtxValueUserName, c.viewer)
+			)
+
+			profile, err := b.GetProfile(ctx, "alice", c.unmasked)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.unmasked {
+				if profile.Masked || profile.Email != stored.Email || profile.NationalID != stored.NationalID {
+					t.Errorf("profile = %+v, want it in full", profile)
+				}
+			} else if !profile.Masked || profile.Email != "al***@example.com" || profile.Phone != "+*********7890" ||
+				profile.DateOfBirth != "1990-**-**" || profile.NationalID != "************8901" || profile.Address != "********" {
+				t.Errorf("profile = %+v, want it masked", profile)
+			}
+			if c.wantAudit != (len(audit.records) == 1 && audit.records[0].Action == consts.AuditActionVIEWPROFILE) {
+				t.Errorf("audit records = %+v, want audited %v", audit.records, c.wantAudit)
+			}
+		})
+	}
+}
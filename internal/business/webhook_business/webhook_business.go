@@ -0,0 +1,540 @@
+package webhookbusiness
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/eventx"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/netx"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+)
+
+const (
+	defaultWebhookMaxAttempts = 8
+	defaultWebhookRetryBase   = 30 * time.Second
+	webhookMaxRetryDelay      = 6 * time.Hour
+	webhookTimeout            = 10 * time.Second
+	webhookBatchSize          = 100
+	webhookConcurrency        = 4
+	webhookMaxMessage         = 255
+	webhookMaxResponse        = 64 << 10
+	webhookSecretBytes        = 32
+)
+
+type WebhookBusiness interface {
+	CreateSubscription(ctx context.Context, input entity.CreateWebhookSubscription) (*entity.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context, username string) ([]entity.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, username string, id uuid.UUID) error
+	GetDeliveries(ctx context.Context, username string, subscriptionID uuid.UUID, status string, m meta.Metadata) ([]entity.WebhookDelivery, error)
+	GetDeliveryLogs(ctx context.Context, username string, deliveryID uuid.UUID) ([]entity.WebhookDeliveryLog, error)
+	RetryDelivery(ctx context.Context, input entity.RetryWebhookDelivery) (*entity.WebhookDelivery, error)
+	// Publish queues a delivery of the outbox event to every subscription of its account.
+	Publish(ctx context.Context, message eventx.Message) error
+	DeliveryWorker(ctx context.Context) (int, int, error)
+}
+
+type webhookBusiness struct {
+	repo   repo.Repo
+	db     *sql.DB
+	client *http.Client
+}
+
+func NewWebhookBusiness(db *sql.DB) WebhookBusiness {
+	return &webhookBusiness{
+		repo:   repo.NewRepositories(db),
+		db:     db,
+		client: newClient(&net.Dialer{Timeout: webhookTimeout, Control: netx.PublicControl}),
+	}
+}
+
+// newClient connects to the receivers with dialer and does not follow
+// redirects, a receiver answering 3xx is retried. No proxy is used, it would
+// connect to the receivers past the address checks of dialer.
+func newClient(dialer *net.Dialer) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   webhookTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// webhookAllowHTTP is whether receivers may be plain http URLs, for development.
+func webhookAllowHTTP() bool {
+	allow, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_HTTP"))
+	return allow
+}
+
+// webhookMaxAttempts is how many times a delivery is tried before it is dead.
+func webhookMaxAttempts() int {
+	attempts, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	if err != nil || attempts <= 0 {
+		return defaultWebhookMaxAttempts
+	}
+	return attempts
+}
+
+// webhookRetryBase is the delay before the first retry, doubled on every retry.
+func webhookRetryBase() time.Duration {
+	base, err := time.ParseDuration(os.Getenv("WEBHOOK_RETRY_BASE"))
+	if err != nil || base <= 0 {
+		return defaultWebhookRetryBase
+	}
+	return base
+}
+
+// retryDelay is the wait after the given failed attempt: base, 2*base, 4*base...
+// up to webhookMaxRetryDelay.
+func retryDelay(attempts int, base time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= webhookMaxRetryDelay {
+			return webhookMaxRetryDelay
+		}
+	}
+	return delay
+}
+
+// sign is the X-Webhook-Signature of body sent at timestamp: the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the subscription secret.
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func (b *webhookBusiness) findAccount(ctx context.Context, username string) (*entity.Account, error) {
+	user, err := b.repo.Users.FindByUserName(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return b.repo.Account.FindByUserID(ctx, user.ID)
+}
+
+// findOwnedSubscription returns the subscription only when it belongs to username's account.
+func (b *webhookBusiness) findOwnedSubscription(ctx context.Context, username string, id uuid.UUID) (*entity.WebhookSubscription, error) {
+	account, err := b.findAccount(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := b.repo.Webhook.FindSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.AccountID != account.ID {
+		return nil, errbank.NewErrNotFound("webhook subscription not found!")
+	}
+	return subscription, nil
+}
+
+// findOwnedDelivery returns the delivery only when its subscription belongs to username's account.
+func (b *webhookBusiness) findOwnedDelivery(ctx context.Context, username string, id uuid.UUID) (*entity.WebhookDelivery, error) {
+	delivery, err := b.repo.Webhook.FindDeliveryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.findOwnedSubscription(ctx, username, delivery.SubscriptionID); err != nil {
+		if _, ok := err.(errbank.ErrNotFound); ok {
+			return nil, errbank.NewErrNotFound("webhook delivery not found!")
+		}
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// CreateSubscription registers the URL for the events of the user's account. The
+// generated signing secret is only returned here.
+func (b *webhookBusiness) CreateSubscription(ctx context.Context, input entity.CreateWebhookSubscription) (*entity.WebhookSubscription, error) {
+	var (
+		eventName = "business.webhook.create_subscription"
+		events    []string
+		seen      = map[string]bool{}
+	)
+
+	if !webhookAllowHTTP() && !strings.HasPrefix(strings.ToLower(input.URL), "https://") {
+		return nil, errbank.NewErrUnprocessableEntity("url: must be an https URL")
+	}
+	account, err := b.findAccount(ctx, input.Username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	for _, event := range input.Events {
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	secret, err := newSecret()
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+
+	subscription := entity.WebhookSubscription{
+		ID:        uuid.New(),
+		AccountID: account.ID,
+		URL:       input.URL,
+		Secret:    secret,
+		Events:    events,
+		Active:    true,
+		CreatedBy: input.CreatedBy,
+		CreatedAt: time.Now(),
+	}
+	subscription.UpdatedAt = subscription.CreatedAt
+	err = b.repo.Webhook.CreateSubscription(ctx, subscription)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (b *webhookBusiness) GetSubscriptions(ctx context.Context, username string) ([]entity.WebhookSubscription, error) {
+	var eventName = "business.webhook.get_subscriptions"
+
+	account, err := b.findAccount(ctx, username)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	subscriptions, err := b.repo.Webhook.GetSubscriptionsByAccountID(ctx, account.ID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+// DeleteSubscription deactivates the subscription, its pending deliveries are dropped as DEAD.
+func (b *webhookBusiness) DeleteSubscription(ctx context.Context, username string, id uuid.UUID) error {
+	var eventName = "business.webhook.delete_subscription"
+
+	subscription, err := b.findOwnedSubscription(ctx, username, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	if !subscription.Active {
+		return errbank.NewErrConflict("webhook subscription is already deleted")
+	}
+	err = b.repo.Webhook.DeactivateSubscription(ctx, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	return nil
+}
+
+func (b *webhookBusiness) GetDeliveries(ctx context.Context, username string, subscriptionID uuid.UUID, status string, m meta.Metadata) ([]entity.WebhookDelivery, error) {
+	var eventName = "business.webhook.get_deliveries"
+
+	if _, err := b.findOwnedSubscription(ctx, username, subscriptionID); err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	deliveries, err := b.repo.Webhook.GetDeliveries(ctx, subscriptionID, status, m)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (b *webhookBusiness) GetDeliveryLogs(ctx context.Context, username string, deliveryID uuid.UUID) ([]entity.WebhookDeliveryLog, error) {
+	var eventName = "business.webhook.get_delivery_logs"
+
+	if _, err := b.findOwnedDelivery(ctx, username, deliveryID); err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	logs, err := b.repo.Webhook.GetLogs(ctx, deliveryID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return logs, nil
+}
+
+// RetryDelivery puts a dead delivery back in the queue with a fresh set of attempts.
+func (b *webhookBusiness) RetryDelivery(ctx context.Context, input entity.RetryWebhookDelivery) (*entity.WebhookDelivery, error) {
+	var eventName = "business.webhook.retry_delivery"
+
+	delivery, err := b.findOwnedDelivery(ctx, input.Username, input.DeliveryID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if delivery.Status != consts.WebhookDeliveryStatusDEAD {
+		return nil, errbank.NewErrConflict("only dead deliveries can be retried, delivery is " + delivery.Status)
+	}
+	subscription, err := b.repo.Webhook.FindSubscriptionByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	if !subscription.Active {
+		return nil, errbank.NewErrConflict("webhook subscription is deleted")
+	}
+
+	delivery.Status = consts.WebhookDeliveryStatusPENDING
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	err = b.repo.Webhook.UpdateDelivery(ctx, *delivery)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (b *webhookBusiness) Publish(ctx context.Context, message eventx.Message) error {
+	var eventName = "business.webhook.publish"
+
+	accountID, err := uuid.Parse(message.Key)
+	if err != nil {
+		return nil
+	}
+	eventID, err := uuid.Parse(message.ID)
+	if err != nil {
+		return nil
+	}
+	subscriptions, err := b.repo.Webhook.GetActiveSubscriptions(ctx, accountID, message.Type)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(struct {
+		ID        string          `json:"id"`
+		Type      string          `json:"type"`
+		CreatedAt time.Time       `json:"created_at"`
+		Data      json.RawMessage `json:"data"`
+	}{
+		ID:        message.ID,
+		Type:      message.Type,
+		CreatedAt: message.CreatedAt,
+		Data:      message.Payload,
+	})
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	for _, subscription := range subscriptions {
+		err = b.repo.Webhook.CreateDelivery(ctx, entity.WebhookDelivery{
+			ID:             uuid.New(),
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      message.Type,
+			Payload:        body,
+			Status:         consts.WebhookDeliveryStatusPENDING,
+			NextAttemptAt:  time.Now(),
+		})
+		if err != nil {
+			log.Println(eventName, "CreateDelivery", subscription.ID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// send posts the delivery payload to url, signed with secret. Any answer other
+// than 2xx is an error.
+func (b *webhookBusiness) send(ctx context.Context, url, secret string, delivery entity.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(consts.WebhookHeaderID, delivery.EventID.String())
+	req.Header.Set(consts.WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(consts.WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(consts.WebhookHeaderSignature, sign(secret, timestamp, delivery.Payload))
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, webhookMaxResponse))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// deliver makes one attempt, logs it and schedules the next one with an
+// exponential backoff, or marks the delivery DEAD once the attempts are used up.
+// It reports whether the receiver accepted the payload; the error is about
+// storing the outcome.
+func (b *webhookBusiness) deliver(ctx context.Context, delivery entity.WebhookDelivery, subscription entity.WebhookSubscription) (bool, error) {
+	var (
+		eventName = "business.webhook.deliver"
+		start     = time.Now()
+	)
+
+	if !subscription.Active {
+		delivery.Status = consts.WebhookDeliveryStatusDEAD
+		delivery.LastError = "webhook subscription is deleted"
+		return false, b.repo.Webhook.UpdateDelivery(ctx, delivery)
+	}
+
+	statusCode, sendErr := b.send(ctx, subscription.URL, subscription.Secret, delivery)
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	entry := entity.WebhookDeliveryLog{
+		ID:         uuid.New(),
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		StatusCode: statusCode,
+		DurationMs: now.Sub(start).Milliseconds(),
+	}
+	if sendErr != nil {
+		entry.Error = truncate(sendErr.Error(), webhookMaxMessage)
+	}
+	if err := b.repo.Webhook.CreateLog(ctx, entry); err != nil {
+		log.Println(eventName, "CreateLog", err)
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = consts.WebhookDeliveryStatusDELIVERED
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= webhookMaxAttempts():
+		delivery.Status = consts.WebhookDeliveryStatusDEAD
+		delivery.LastError = entry.Error
+	default:
+		delivery.LastError = entry.Error
+		delivery.NextAttemptAt = now.Add(retryDelay(delivery.Attempts, webhookRetryBase()))
+	}
+	err := b.repo.Webhook.UpdateDelivery(ctx, delivery)
+	if err != nil {
+		log.Println(eventName, "UpdateDelivery", err)
+		return false, err
+	}
+	return sendErr == nil, nil
+}
+
+// DeliveryWorker attempts every due delivery and returns how many were attempted
+// and how many were delivered.
+func (b *webhookBusiness) DeliveryWorker(ctx context.Context) (int, int, error) {
+	var (
+		eventName     = "business.webhook.delivery_worker"
+		total         int
+		delivered     int
+		subscriptions = map[uuid.UUID]*entity.WebhookSubscription{}
+		mu            sync.Mutex
+	)
+
+	for {
+		deliveries, err := b.repo.Webhook.GetDueDeliveries(ctx, webhookBatchSize)
+		if err != nil {
+			log.Println(eventName, "GetDueDeliveries", err)
+			return total, delivered, err
+		}
+
+		var (
+			wg        sync.WaitGroup
+			jobs      = make(chan entity.WebhookDelivery)
+			updateErr bool
+		)
+		for i := 0; i < webhookConcurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for delivery := range jobs {
+					mu.Lock()
+					subscription, ok := subscriptions[delivery.SubscriptionID]
+					mu.Unlock()
+					if !ok {
+						found, err := b.repo.Webhook.FindSubscriptionByID(ctx, delivery.SubscriptionID)
+						if err != nil {
+							log.Println(eventName, "FindSubscriptionByID", delivery.SubscriptionID, err)
+							mu.Lock()
+							updateErr = true
+							mu.Unlock()
+							continue
+						}
+						mu.Lock()
+						subscriptions[delivery.SubscriptionID] = found
+						mu.Unlock()
+						subscription = found
+					}
+
+					ok, err := b.deliver(ctx, delivery, *subscription)
+					mu.Lock()
+					total++
+					if ok {
+						delivered++
+					}
+					if err != nil {
+						updateErr = true
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		for _, delivery := range deliveries {
+			jobs <- delivery
+		}
+		close(jobs)
+		wg.Wait()
+
+		// A delivery that could not be updated would be fetched again right away.
+		if len(deliveries) < webhookBatchSize || updateErr || ctx.Err() != nil {
+			return total, delivered, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package webhookbusiness
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/eventx"
+	"github.com/hanselacn/banking-transaction/internal/pkg/netx"
+	"github.com/hanselacn/banking-transaction/internal/repo"
+	webhookrepo "github.com/hanselacn/banking-transaction/internal/repo/webhook_repo"
+)
+
+// fakeWebhookRepo keeps subscriptions, deliveries and logs in memory. Methods
+// the worker does not use are left to the embedded nil interface.
+type fakeWebhookRepo struct {
+	webhookrepo.WebhookRepositories
+	mu            sync.Mutex
+	subscriptions []entity.WebhookSubscription
+	deliveries    []entity.WebhookDelivery
+	logs          []entity.WebhookDeliveryLog
+}
+
+func (f *fakeWebhookRepo) FindSubscriptionByID(ctx context.Context, id uuid.UUID) (*entity.WebhookSubscription, error) {
+	for _, subscription := range f.subscriptions {
+		if subscription.ID == id {
+			return &subscription, nil
+		}
+	}
+	return nil, errbank.NewErrNotFound("webhook subscription not found!")
+}
+
+func (f *fakeWebhookRepo) GetActiveSubscriptions(ctx context.Context, accountID uuid.UUID, eventType string) ([]entity.WebhookSubscription, error) {
+	var subscriptions []entity.WebhookSubscription
+	for _, subscription := range f.subscriptions {
+		for _, event := range subscription.Events {
+			if subscription.Active && subscription.AccountID == accountID && event == eventType {
+				subscriptions = append(subscriptions, subscription)
+			}
+		}
+	}
+	return subscriptions, nil
+}
+
+func (f *fakeWebhookRepo) CreateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	f.deliveries = append(f.deliveries, delivery)
+	return nil
+}
+
+func (f *fakeWebhookRepo) GetDueDeliveries(ctx context.Context, size int) ([]entity.WebhookDelivery, error) {
+	var due []entity.WebhookDelivery
+	for _, delivery := range f.deliveries {
+		if delivery.Status == consts.WebhookDeliveryStatusPENDING && !delivery.NextAttemptAt.After(time.Now()) && len(due) < size {
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeWebhookRepo) UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.deliveries {
+		if f.deliveries[i].ID == delivery.ID {
+			f.deliveries[i] = delivery
+		}
+	}
+	return nil
+}
+
+func (f *fakeWebhookRepo) CreateLog(ctx context.Context, entry entity.WebhookDeliveryLog) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs = append(f.logs, entry)
+	return nil
+}
+
+// receiver is an httptest endpoint that checks the signature the way a
+// subscriber would and answers status.
+type receiver struct {
+	secret   string
+	status   int
+	mu       sync.Mutex
+	received []map[string]interface{}
+	invalid  int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	mac := hmac.New(sha256.New, []byte(rc.secret))
+	mac.Write([]byte(r.Header.Get("X-Webhook-Timestamp") + "." + string(body)))
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Webhook-Signature"))) {
+		rc.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var payload map[string]interface{}
+	json.Unmarshal(body, &payload)
+	rc.received = append(rc.received, payload)
+	w.WriteHeader(rc.status)
+}
+
+func newTestBusiness(t *testing.T, rc *receiver) (*webhookBusiness, *fakeWebhookRepo, entity.WebhookSubscription) {
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	subscription := entity.WebhookSubscription{
+		ID:        uuid.New(),
+		AccountID: uuid.New(),
+		URL:       server.URL,
+		Secret:    rc.secret,
+		Events:    []string{consts.EventDepositCompleted},
+		Active:    true,
+	}
+	fake := &fakeWebhookRepo{subscriptions: []entity.WebhookSubscription{subscription}}
+	b := &webhookBusiness{
+		repo:   repo.Repo{Webhook: fake},
+		client: newClient(&net.Dialer{}),
+	}
+	return b, fake, subscription
+}
+
+func publishDeposit(t *testing.T, b *webhookBusiness, accountID uuid.UUID) {
+	err := b.Publish(context.Background(), eventx.Message{
+		ID:        uuid.New().String(),
+		Key:       accountID.String(),
+		Type:      consts.EventDepositCompleted,
+		Payload:   json.RawMessage(`{"amount":50000}`),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+}
+
+func TestDeliveryWorkerSendsSignedPayload(t *testing.T) {
+	var (
+		ctx = context.Background()
+		rc  = &receiver{secret: "s3cret", status: http.StatusOK}
+	)
+	b, fake, subscription := newTestBusiness(t, rc)
+
+	publishDeposit(t, b, subscription.AccountID)
+	// Events of other accounts or types do not reach the subscription.
+	publishDeposit(t, b, uuid.New())
+	if len(fake.deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(fake.deliveries))
+	}
+
+	total, delivered, err := b.DeliveryWorker(ctx)
+	if err != nil || total != 1 || delivered != 1 {
+		t.Fatalf("DeliveryWorker() = %d, %d, %v, want 1, 1, nil", total, delivered, err)
+	}
+	if rc.invalid != 0 || len(rc.received) != 1 {
+		t.Fatalf("receiver got %d valid and %d invalid signatures", len(rc.received), rc.invalid)
+	}
+	if got := rc.received[0]["type"]; got != consts.EventDepositCompleted {
+		t.Errorf("type = %v, want %s", got, consts.EventDepositCompleted)
+	}
+	if data, _ := rc.received[0]["data"].(map[string]interface{}); data["amount"] != float64(50000) {
+		t.Errorf("data = %v, want the event payload", rc.received[0]["data"])
+	}
+	delivery := fake.deliveries[0]
+	if delivery.Status != consts.WebhookDeliveryStatusDELIVERED || delivery.DeliveredAt == nil || delivery.Attempts != 1 {
+		t.Errorf("delivery = %s after %d attempts, want DELIVERED after 1", delivery.Status, delivery.Attempts)
+	}
+	if len(fake.logs) != 1 || fake.logs[0].StatusCode != http.StatusOK {
+		t.Errorf("logs = %+v, want one 200 attempt", fake.logs)
+	}
+}
+
+func TestSendRefusesInternalReceivers(t *testing.T) {
+	rc := &receiver{secret: "s3cret", status: http.StatusOK}
+	b, _, subscription := newTestBusiness(t, rc)
+	b.client = NewWebhookBusiness(nil).(*webhookBusiness).client
+
+	_, err := b.send(context.Background(), subscription.URL, subscription.Secret, entity.WebhookDelivery{EventID: uuid.New()})
+	if !errors.Is(err, netx.ErrNotPublic) {
+		t.Errorf("send() error = %v, want %v", err, netx.ErrNotPublic)
+	}
+	if len(rc.received) != 0 {
+		t.Errorf("the loopback receiver got %d payloads", len(rc.received))
+	}
+}
+
+func TestDeliveryWorkerRetriesThenDeadLetters(t *testing.T) {
+	var (
+		ctx = context.Background()
+		rc  = &receiver{secret: "s3cret", status: http.StatusInternalServerError}
+	)
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "3")
+	t.Setenv("WEBHOOK_RETRY_BASE", "1m")
+	b, fake, subscription := newTestBusiness(t, rc)
+	publishDeposit(t, b, subscription.AccountID)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		before := time.Now()
+		total, delivered, err := b.DeliveryWorker(ctx)
+		if err != nil || total != 1 || delivered != 0 {
+			t.Fatalf("attempt %d: DeliveryWorker() = %d, %d, %v, want 1, 0, nil", attempt, total, delivered, err)
+		}
+		delivery := fake.deliveries[0]
+		if delivery.Attempts != attempt || delivery.LastStatusCode != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: attempts = %d, last status = %d", attempt, delivery.Attempts, delivery.LastStatusCode)
+		}
+		if attempt == 3 {
+			if delivery.Status != consts.WebhookDeliveryStatusDEAD {
+				t.Fatalf("status = %s after the last attempt, want DEAD", delivery.Status)
+			}
+			break
+		}
+		wait := delivery.NextAttemptAt.Sub(before)
+		want := retryDelay(attempt, time.Minute)
+		if delivery.Status != consts.WebhookDeliveryStatusPENDING || wait < want || wait > want+time.Second {
+			t.Fatalf("attempt %d: %s, next attempt in %s, want PENDING in %s", attempt, delivery.Status, wait, want)
+		}
+
+		// Not due yet: the worker leaves it alone.
+		if total, _, _ := b.DeliveryWorker(ctx); total != 0 {
+			t.Fatalf("attempt %d: delivery retried before its backoff", attempt)
+		}
+		fake.deliveries[0].NextAttemptAt = time.Now()
+	}
+
+	if total, _, _ := b.DeliveryWorker(ctx); total != 0 {
+		t.Errorf("dead delivery was attempted again")
+	}
+	if len(fake.logs) != 3 {
+		t.Errorf("got %d log rows, want one per attempt", len(fake.logs))
+	}
+	if rc.invalid != 0 {
+		t.Errorf("receiver rejected %d signatures", rc.invalid)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{8, 64 * time.Minute},
+		{20, webhookMaxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts, 30*time.Second); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package consts
+
+const (
+	AMLAlertTypeLARGECASH   = "LARGE_CASH"
+	AMLAlertTypeSTRUCTURING = "STRUCTURING"
+
+	AMLAlertStatusOPEN      = "OPEN"
+	AMLAlertStatusDISMISSED = "DISMISSED"
+	AMLAlertStatusREPORTED  = "REPORTED"
+)
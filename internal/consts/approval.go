@@ -0,0 +1,16 @@
+package consts
+
+const (
+	ApprovalOperationUPDATEROLE         = "UPDATE_ROLE"
+	ApprovalOperationUPDATEINTERESTRATE = "UPDATE_INTEREST_RATE"
+	ApprovalOperationINTERESTPAYOUT     = "INTEREST_PAYOUT"
+	ApprovalOperationWITHDRAWAL         = "WITHDRAWAL"
+
+	ApprovalStatusPENDING  = "PENDING"
+	ApprovalStatusREJECTED = "REJECTED"
+	ApprovalStatusEXPIRED  = "EXPIRED"
+	// ApprovalStatusAPPROVED is held while the approved operation is running.
+	ApprovalStatusAPPROVED = "APPROVED"
+	ApprovalStatusEXECUTED = "EXECUTED"
+	ApprovalStatusFAILED   = "FAILED"
+)
@@ -0,0 +1,32 @@
+package consts
+
+const (
+	// AuditActorSYSTEM is recorded for actions run by the scheduler or from the command line.
+	AuditActorSYSTEM = "system"
+	// AuditGenesisHash is the previous hash of the first entry of the chain.
+	AuditGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	AuditActionCREATEUSER         = "CREATE_USER"
+	AuditActionUPDATEROLE         = "UPDATE_ROLE"
+	AuditActionUPDATETAXPROFILE   = "UPDATE_TAX_PROFILE"
+	AuditActionUPDATEINTERESTRATE = "UPDATE_INTEREST_RATE"
+	AuditActionUPDATEOVERDRAFT    = "UPDATE_OVERDRAFT"
+	AuditActionINTERESTPAYOUT     = "INTEREST_PAYOUT"
+	AuditActionUPDATEFEESCHEDULE  = "UPDATE_FEE_SCHEDULE"
+	AuditActionWAIVEFEE           = "WAIVE_FEE"
+	AuditActionDISBURSELOAN       = "DISBURSE_LOAN"
+	AuditActionCORRECTDISCREPANCY = "CORRECT_DISCREPANCY"
+	AuditActionUPLOADPAYROLL      = "UPLOAD_PAYROLL"
+	AuditActionREVIEWPAYROLL      = "REVIEW_PAYROLL"
+	AuditActionSUBMITAPPROVAL     = "SUBMIT_APPROVAL"
+	AuditActionDECIDEAPPROVAL     = "DECIDE_APPROVAL"
+	AuditActionVIEWPROFILE        = "VIEW_PROFILE"
+	AuditActionREVIEWKYC          = "REVIEW_KYC"
+	AuditActionUPDATEFRAUDRULE    = "UPDATE_FRAUD_RULE"
+	AuditActionRESOLVEFRAUDCASE   = "RESOLVE_FRAUD_CASE"
+	AuditActionREVIEWAMLALERT     = "REVIEW_AML_ALERT"
+	AuditActionEXPORTAMLREPORT    = "EXPORT_AML_REPORT"
+	AuditActionRELOADSANCTIONS    = "RELOAD_SANCTIONS"
+	AuditActionREVIEWSANCTIONSHIT = "REVIEW_SANCTIONS_HIT"
+	AuditActionRESETPASSWORD      = "RESET_PASSWORD"
+)
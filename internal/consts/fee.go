@@ -0,0 +1,10 @@
+package consts
+
+const (
+	FeeCodeMAINTENANCE     = "MAINTENANCE"
+	FeeCodeWITHDRAWAL      = "WITHDRAWAL"
+	FeeCodeBELOWMINBALANCE = "BELOW_MIN_BALANCE"
+
+	FeeStatusCHARGED = "CHARGED"
+	FeeStatusWAIVED  = "WAIVED"
+)
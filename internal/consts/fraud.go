@@ -0,0 +1,16 @@
+package consts
+
+const (
+	FraudRuleVELOCITY                 = "VELOCITY"
+	FraudRuleAMOUNTSPIKE              = "AMOUNT_SPIKE"
+	FraudRulePASSWORDCHANGEWITHDRAWAL = "PASSWORD_CHANGE_WITHDRAWAL"
+	FraudRuleNEWDEVICE                = "NEW_DEVICE"
+
+	FraudDecisionALLOW  = "ALLOW"
+	FraudDecisionREVIEW = "REVIEW"
+	FraudDecisionBLOCK  = "BLOCK"
+
+	FraudCaseStatusOPEN      = "OPEN"
+	FraudCaseStatusCLEARED   = "CLEARED"
+	FraudCaseStatusCONFIRMED = "CONFIRMED"
+)
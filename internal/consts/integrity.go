@@ -0,0 +1,12 @@
+package consts
+
+const (
+	DiscrepancyStatusOPEN      = "OPEN"
+	DiscrepancyStatusCORRECTED = "CORRECTED"
+	DiscrepancyStatusCLEARED   = "CLEARED"
+
+	// DiscrepancyResolutionRESETBALANCE sets the stored balance to the one computed from the transaction history.
+	DiscrepancyResolutionRESETBALANCE = "RESET_BALANCE"
+	// DiscrepancyResolutionPOSTADJUSTMENT keeps the stored balance and posts an ADJUSTMENT transaction so the history matches it.
+	DiscrepancyResolutionPOSTADJUSTMENT = "POST_ADJUSTMENT"
+)
@@ -0,0 +1,13 @@
+package consts
+
+const (
+	KYCStatusUNVERIFIED = "UNVERIFIED"
+	KYCStatusPENDING    = "PENDING"
+	KYCStatusVERIFIED   = "VERIFIED"
+	KYCStatusREJECTED   = "REJECTED"
+
+	KYCDocumentIDCARD         = "ID_CARD"
+	KYCDocumentPASSPORT       = "PASSPORT"
+	KYCDocumentPROOFOFADDRESS = "PROOF_OF_ADDRESS"
+	KYCDocumentSELFIE         = "SELFIE"
+)
@@ -0,0 +1,22 @@
+package consts
+
+const (
+	LedgerCASH             = "CASH"
+	LedgerLOANSRECEIVABLE  = "LOANS_RECEIVABLE"
+	LedgerCUSTOMERDEPOSITS = "CUSTOMER_DEPOSITS"
+	LedgerTERMDEPOSITS     = "TERM_DEPOSITS"
+	LedgerTAXPAYABLE       = "TAX_PAYABLE"
+	LedgerTRANSFERCLEARING = "TRANSFER_CLEARING"
+	LedgerFEEINCOME        = "FEE_INCOME"
+	LedgerINTERESTINCOME   = "INTEREST_INCOME"
+	LedgerPENALTYINCOME    = "PENALTY_INCOME"
+	LedgerINTERESTEXPENSE  = "INTEREST_EXPENSE"
+	LedgerRECONCILIATION   = "RECONCILIATION_SUSPENSE"
+	LedgerOPENINGBALANCES  = "OPENING_BALANCES"
+
+	LedgerTypeASSET     = "ASSET"
+	LedgerTypeLIABILITY = "LIABILITY"
+	LedgerTypeEQUITY    = "EQUITY"
+	LedgerTypeINCOME    = "INCOME"
+	LedgerTypeEXPENSE   = "EXPENSE"
+)
@@ -0,0 +1,13 @@
+package consts
+
+const (
+	LoanMethodANNUITY = "ANNUITY"
+	LoanMethodFLAT    = "FLAT"
+
+	LoanStatusACTIVE  = "ACTIVE"
+	LoanStatusPAIDOFF = "PAID_OFF"
+
+	InstallmentStatusPENDING = "PENDING"
+	InstallmentStatusLATE    = "LATE"
+	InstallmentStatusPAID    = "PAID"
+)
@@ -0,0 +1,21 @@
+package consts
+
+const (
+	NotificationChannelEMAIL = "EMAIL"
+	NotificationChannelSMS   = "SMS"
+
+	NotificationTypeDEPOSIT         = "DEPOSIT"
+	NotificationTypeWITHDRAWAL      = "WITHDRAWAL"
+	NotificationTypePASSWORDCHANGED = "PASSWORD_CHANGED"
+	NotificationTypePASSWORDRESET   = "PASSWORD_RESET"
+	NotificationTypeROLECHANGED     = "ROLE_CHANGED"
+
+	NotificationTypeSTANDINGORDEREXECUTED = "STANDING_ORDER_EXECUTED"
+	NotificationTypeSTANDINGORDERFAILED   = "STANDING_ORDER_FAILED"
+
+	NotificationStatusSENT   = "SENT"
+	NotificationStatusFAILED = "FAILED"
+
+	NotifierSMTP = "smtp"
+	NotifierLOG  = "log"
+)
@@ -0,0 +1,13 @@
+package consts
+
+const (
+	EventAccountCreated      = "AccountCreated"
+	EventDepositCompleted    = "DepositCompleted"
+	EventWithdrawalCompleted = "WithdrawalCompleted"
+	EventInterestPaid        = "InterestPaid"
+	EventLowBalance          = "LowBalance"
+	EventRoleChanged         = "RoleChanged"
+
+	OutboxPublisherMEMORY = "memory"
+	OutboxPublisherJSONL  = "jsonl"
+)
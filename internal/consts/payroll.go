@@ -0,0 +1,17 @@
+package consts
+
+const (
+	PayrollFormatCSV     = "CSV"
+	PayrollFormatPAIN001 = "PAIN001"
+
+	PayrollBatchStatusPENDINGAPPROVAL    = "PENDING_APPROVAL"
+	PayrollBatchStatusREJECTED           = "REJECTED"
+	PayrollBatchStatusPROCESSING         = "PROCESSING"
+	PayrollBatchStatusCOMPLETED          = "COMPLETED"
+	PayrollBatchStatusPARTIALLYCOMPLETED = "PARTIALLY_COMPLETED"
+
+	PayrollLineStatusVALID     = "VALID"
+	PayrollLineStatusINVALID   = "INVALID"
+	PayrollLineStatusCOMPLETED = "COMPLETED"
+	PayrollLineStatusFAILED    = "FAILED"
+)
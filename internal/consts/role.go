@@ -4,4 +4,5 @@ const (
 	RoleSuperAdmin = "super_admin"
 	RoleAdmin      = "admin"
 	RoleCustomer   = "customer"
+	RoleAuditor    = "auditor"
 )
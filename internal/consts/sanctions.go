@@ -0,0 +1,16 @@
+package consts
+
+const (
+	// SanctionsStatusHOLD keeps a user from moving money until an admin reviews
+	// the watchlist hit, BLOCKED is kept once the hit is confirmed.
+	SanctionsStatusCLEAR   = "CLEAR"
+	SanctionsStatusHOLD    = "HOLD"
+	SanctionsStatusBLOCKED = "BLOCKED"
+
+	SanctionsSubjectUSER     = "USER"
+	SanctionsSubjectTRANSFER = "TRANSFER"
+
+	SanctionsHitStatusOPEN      = "OPEN"
+	SanctionsHitStatusCLEARED   = "CLEARED"
+	SanctionsHitStatusCONFIRMED = "CONFIRMED"
+)
@@ -0,0 +1,22 @@
+package consts
+
+const (
+	StandingOrderTypeTRANSFER   = "TRANSFER"
+	StandingOrderTypeWITHDRAWAL = "WITHDRAWAL"
+
+	StandingOrderFrequencyONCE    = "ONCE"
+	StandingOrderFrequencyWEEKLY  = "WEEKLY"
+	StandingOrderFrequencyMONTHLY = "MONTHLY"
+
+	StandingOrderStatusACTIVE    = "ACTIVE"
+	StandingOrderStatusPAUSED    = "PAUSED"
+	StandingOrderStatusCANCELLED = "CANCELLED"
+	StandingOrderStatusCOMPLETED = "COMPLETED"
+	StandingOrderStatusFAILED    = "FAILED"
+
+	ExecutionStatusINPROGRESS = "IN_PROGRESS"
+	ExecutionStatusSUCCESS    = "SUCCESS"
+	ExecutionStatusRETRY      = "RETRY"
+	ExecutionStatusFAILED     = "FAILED"
+	ExecutionStatusHELD       = "HELD"
+)
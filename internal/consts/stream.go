@@ -0,0 +1,8 @@
+package consts
+
+const (
+	StreamChannelACCOUNT = "account_stream"
+
+	StreamEventBALANCE     = "balance"
+	StreamEventTRANSACTION = "transaction"
+)
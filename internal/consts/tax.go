@@ -0,0 +1,7 @@
+package consts
+
+const (
+	TaxStatusResident    = "resident"
+	TaxStatusNonResident = "non_resident"
+	TaxStatusCorporate   = "corporate"
+)
@@ -0,0 +1,15 @@
+package consts
+
+const (
+	TermDepositStatusACTIVE     = "ACTIVE"
+	TermDepositStatusMATURED    = "MATURED"
+	TermDepositStatusROLLEDOVER = "ROLLED_OVER"
+	TermDepositStatusWITHDRAWN  = "WITHDRAWN"
+
+	// TermDepositInstructionPAYOUT pays principal and interest back to the account at maturity.
+	TermDepositInstructionPAYOUT = "PAYOUT"
+	// TermDepositInstructionROLLOVER renews the principal for the same term and pays the interest out.
+	TermDepositInstructionROLLOVER = "ROLLOVER"
+	// TermDepositInstructionROLLOVERALL renews the principal together with the net interest.
+	TermDepositInstructionROLLOVERALL = "ROLLOVER_WITH_INTEREST"
+)
@@ -4,11 +4,21 @@ const (
 	TxTypeDEBIT  = "D"
 	TxTypeCREDIT = "C"
 
-	TxActionWITHDRAWAL = "WITHDRAWAL"
-	TxActionDEPOSIT    = "DEPOSIT"
-	TxActionTRANSFER   = "TRANSFER"
-	TxActionPURCHASE   = "PURCHASE"
-	TxActionINTEREST   = "INTEREST"
+	TxActionWITHDRAWAL  = "WITHDRAWAL"
+	TxActionDEPOSIT     = "DEPOSIT"
+	TxActionTRANSFER    = "TRANSFER"
+	TxActionPURCHASE    = "PURCHASE"
+	TxActionINTEREST    = "INTEREST"
+	TxActionTAX         = "TAX"
+	TxActionFEE         = "FEE"
+	TxActionTERMDEPOSIT = "TERM_DEPOSIT"
+	TxActionPENALTY     = "PENALTY"
+	TxActionLOAN        = "LOAN"
+	TxActionADJUSTMENT  = "ADJUSTMENT"
+	TxActionPAYROLL     = "PAYROLL"
+	// TxActionOPENINGBALANCE carries the balance of an account whose earlier
+	// history predates transactions.account_id.
+	TxActionOPENINGBALANCE = "OPENING_BALANCE"
 
 	TxStatusINPROGRESS = "IN_PROGRESS"
 	TxStatusCOMPLETED  = "COMPLETED"
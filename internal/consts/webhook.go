@@ -0,0 +1,12 @@
+package consts
+
+const (
+	WebhookDeliveryStatusPENDING   = "PENDING"
+	WebhookDeliveryStatusDELIVERED = "DELIVERED"
+	WebhookDeliveryStatusDEAD      = "DEAD"
+
+	WebhookHeaderID        = "X-Webhook-Id"
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
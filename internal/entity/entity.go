@@ -1,24 +1,33 @@
 package entity
 
 import (
+	"encoding/json"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/pkg/maskx"
 	"github.com/hanselacn/banking-transaction/internal/pkg/rule"
 )
 
 type User struct {
-	ID       uuid.UUID `json:"id"`
-	Username string    `json:"user_name"`
-	Fullname string    `json:"full_name"`
-	Role     string    `json:"role"`
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"user_name"`
+	Fullname  string    `json:"full_name"`
+	Role      string    `json:"role"`
+	TaxStatus string    `json:"tax_status,omitempty"`
+	TaxExempt bool      `json:"tax_exempt"`
+	KYCStatus string    `json:"kyc_status,omitempty"`
+	KYCNote   string    `json:"kyc_note,omitempty"`
+	// SanctionsStatus is CLEAR, HOLD while a watchlist hit awaits review or
+	// BLOCKED once it is confirmed.
+	SanctionsStatus string `json:"sanctions_status,omitempty"`
 }
 
 func (s *User) Validate() error {
 	return validation.ValidateStruct(s,
 		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
-		validation.Field(&s.Role, validation.In("admin", "super_admin", "customer").Error("invalid role input")),
+		validation.Field(&s.Role, validation.In("admin", "super_admin", "customer", "auditor").Error("invalid role input")),
 	)
 }
 
@@ -62,6 +71,20 @@ func (s *Deposit) Validate() error {
 	)
 }
 
+type Transfer struct {
+	Username        string  `json:"user_name"`
+	ToAccountNumber string  `json:"to_account_number"`
+	Amount          float64 `json:"amount"`
+}
+
+func (s *Transfer) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.ToAccountNumber, validation.Required, rule.AlphabetNumericSpaceCharRule),
+		validation.Field(&s.Amount, validation.Required, validation.Min(1.0), validation.Max(1000000000000.0)),
+	)
+}
+
 type UpdateInterestRate struct {
 	Username     string  `json:"user_name"`
 	InterestRate float64 `json:"interest_rate"`
@@ -74,40 +97,1212 @@ func (s *UpdateInterestRate) Validate() error {
 }
 
 type Account struct {
-	ID                 uuid.UUID `json:"id"`
-	UserID             uuid.UUID `json:"user_id"`
-	AccountNumber      string    `json:"account_number"`
-	Balance            float64   `json:"balance"`
-	InterestRate       float64   `json:"interest_rate"`
-	CreatedAt          time.Time `json:"created_at"`
-	LastInterestPayout time.Time `json:"last_interest_payout,omitempty"`
+	ID                    uuid.UUID `json:"id"`
+	UserID                uuid.UUID `json:"user_id"`
+	AccountNumber         string    `json:"account_number"`
+	Balance               float64   `json:"balance"`
+	InterestRate          float64   `json:"interest_rate"`
+	CreatedAt             time.Time `json:"created_at"`
+	LastInterestPayout    time.Time `json:"last_interest_payout,omitempty"`
+	OverdraftLimit        float64   `json:"overdraft_limit"`
+	OverdraftInterestRate float64   `json:"overdraft_interest_rate"`
+}
+
+// AvailableBalance is the amount that can be debited, including the approved overdraft.
+func (a Account) AvailableBalance() float64 {
+	return a.Balance + a.OverdraftLimit
 }
 
 type AccountPresentation struct {
-	ID                 uuid.UUID `json:"id"`
-	UserID             uuid.UUID `json:"user_id"`
-	AccountNumber      string    `json:"account_number"`
-	Balance            string    `json:"balance"`
-	InterestRate       string    `json:"interest_rate"`
-	CreatedAt          time.Time `json:"created_at"`
-	LastInterestPayout time.Time `json:"last_interest_payout,omitempty"`
+	ID                    uuid.UUID `json:"id"`
+	UserID                uuid.UUID `json:"user_id"`
+	AccountNumber         string    `json:"account_number"`
+	Balance               string    `json:"balance"`
+	InterestRate          string    `json:"interest_rate"`
+	CreatedAt             time.Time `json:"created_at"`
+	LastInterestPayout    time.Time `json:"last_interest_payout,omitempty"`
+	OverdraftLimit        float64   `json:"overdraft_limit"`
+	OverdraftInterestRate float64   `json:"overdraft_interest_rate"`
 }
 
 type ChangePasswordInput struct {
-	Username string `json:"user_name"`
-	Password string `json:"password"`
+	Username    string `json:"-"`
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+func (s *ChangePasswordInput) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.OldPassword, validation.Required),
+		validation.Field(&s.NewPassword, validation.Required),
+		validation.Field(&s.NewPassword, rule.SpecialCharRegexRule),
+		validation.Field(&s.NewPassword, rule.LengthRegexRule),
+		validation.Field(&s.NewPassword, rule.LowercaseRegexRule),
+		validation.Field(&s.NewPassword, rule.UppercaseRegexRule),
+		validation.Field(&s.NewPassword, rule.DigitRegexRule),
+	)
+}
+
+type RequestPasswordReset struct {
+	Username    string `json:"user_name"`
+	RequestedBy string `json:"-"`
+}
+
+func (s *RequestPasswordReset) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+	)
+}
+
+type ResetPasswordInput struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+func (s *ResetPasswordInput) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Token, validation.Required, validation.Length(1, 255)),
+		validation.Field(&s.NewPassword, validation.Required),
+		validation.Field(&s.NewPassword, rule.SpecialCharRegexRule),
+		validation.Field(&s.NewPassword, rule.LengthRegexRule),
+		validation.Field(&s.NewPassword, rule.LowercaseRegexRule),
+		validation.Field(&s.NewPassword, rule.UppercaseRegexRule),
+		validation.Field(&s.NewPassword, rule.DigitRegexRule),
+	)
+}
+
+// PasswordResetToken is a one-time token to set a new password, only its
+// sha256 is stored.
+type PasswordResetToken struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	TokenHash   string     `json:"-"`
+	RequestedBy string     `json:"requested_by"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	UsedAt      *time.Time `json:"used_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
 }
 
 type Authorization struct {
+	ID                uuid.UUID  `json:"id"`
+	UserID            uuid.UUID  `json:"user_id"`
+	Password          string     `json:"password"`
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// PasswordSetAt is when the current password was set.
+func (a Authorization) PasswordSetAt() time.Time {
+	if a.PasswordChangedAt != nil {
+		return *a.PasswordChangedAt
+	}
+	return a.CreatedAt
+}
+
+type Transaction struct {
+	ID        uuid.UUID `json:"id"`
+	AccountID uuid.UUID `json:"account_id"`
+	Type      string    `json:"type"`
+	Amount    float64   `json:"amount"`
+	Action    string    `json:"action"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Lease struct {
+	Name       string    `json:"name"`
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type LeaseStatus struct {
+	Lease      *Lease `json:"lease,omitempty"`
+	InstanceID string `json:"instance_id"`
+	IsLeader   bool   `json:"is_leader"`
+}
+
+type InterestPayoutProgress struct {
+	Running     bool       `json:"running"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Concurrency int        `json:"concurrency"`
+	BatchSize   int        `json:"batch_size"`
+	Batches     int64      `json:"batches"`
+	Processed   int64      `json:"processed"`
+	Succeeded   int64      `json:"succeeded"`
+	Failed      int64      `json:"failed"`
+	Retried     int64      `json:"retried"`
+}
+
+type UpdateTaxProfile struct {
+	Username  string `json:"user_name"`
+	TaxStatus string `json:"tax_status"`
+	TaxExempt bool   `json:"tax_exempt"`
+}
+
+func (s *UpdateTaxProfile) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.TaxStatus, validation.Required, validation.In("resident", "non_resident", "corporate").Error("invalid tax status input")),
+	)
+}
+
+type TaxWithholding struct {
+	UserID        uuid.UUID `json:"user_id"`
+	Year          int       `json:"year"`
+	GrossInterest float64   `json:"gross_interest"`
+	TaxWithheld   float64   `json:"tax_withheld"`
+}
+
+type TaxCertificate struct {
+	Username      string               `json:"user_name"`
+	Fullname      string               `json:"full_name"`
+	AccountNumber string               `json:"account_number"`
+	TaxStatus     string               `json:"tax_status"`
+	TaxExempt     bool                 `json:"tax_exempt"`
+	Year          int                  `json:"year"`
+	GrossInterest float64              `json:"gross_interest"`
+	TaxWithheld   float64              `json:"tax_withheld"`
+	NetInterest   float64              `json:"net_interest"`
+	Lines         []TaxCertificateLine `json:"lines"`
+	GeneratedAt   time.Time            `json:"generated_at"`
+}
+
+type TaxCertificateLine struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Action        string    `json:"action"`
+	Type          string    `json:"type"`
+	Amount        float64   `json:"amount"`
+}
+
+type FeeSchedule struct {
+	Code       string    `json:"code"`
+	Amount     float64   `json:"amount"`
+	FreeCount  int       `json:"free_count"`
+	MinBalance float64   `json:"min_balance"`
+	Active     bool      `json:"active"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (s *FeeSchedule) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Code, validation.Required, validation.In("MAINTENANCE", "WITHDRAWAL", "BELOW_MIN_BALANCE").Error("invalid fee code input")),
+		validation.Field(&s.Amount, validation.Min(0.0), validation.Max(1000000000000.0)),
+		validation.Field(&s.FreeCount, validation.Min(0)),
+		validation.Field(&s.MinBalance, validation.Min(0.0), validation.Max(1000000000000.0)),
+	)
+}
+
+type Fee struct {
+	ID                 uuid.UUID  `json:"id"`
+	AccountID          uuid.UUID  `json:"account_id"`
+	TransactionID      uuid.UUID  `json:"transaction_id"`
+	Code               string     `json:"code"`
+	Amount             float64    `json:"amount"`
+	Period             string     `json:"period"`
+	Status             string     `json:"status"`
+	WaivedBy           string     `json:"waived_by,omitempty"`
+	WaiveReason        string     `json:"waive_reason,omitempty"`
+	WaiveTransactionID *uuid.UUID `json:"waive_transaction_id,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+type WaiveFee struct {
+	FeeID    uuid.UUID `json:"fee_id"`
+	Reason   string    `json:"reason"`
+	WaivedBy string    `json:"-"`
+}
+
+func (s *WaiveFee) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.FeeID, rule.RequiredUUIDRule),
+		validation.Field(&s.Reason, validation.Required, rule.AlphabetNumericSpaceCharRule),
+	)
+}
+
+type UpdateOverdraft struct {
+	Username              string  `json:"user_name"`
+	OverdraftLimit        float64 `json:"overdraft_limit"`
+	OverdraftInterestRate float64 `json:"overdraft_interest_rate"`
+}
+
+func (s *UpdateOverdraft) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.OverdraftLimit, validation.Min(0.0), validation.Max(1000000000000.0)),
+		validation.Field(&s.OverdraftInterestRate, validation.Min(0.0), validation.Max(1.0)),
+	)
+}
+
+type OverdrawnAccount struct {
+	AccountID             uuid.UUID `json:"account_id"`
+	AccountNumber         string    `json:"account_number"`
+	Username              string    `json:"user_name"`
+	Fullname              string    `json:"full_name"`
+	Balance               float64   `json:"balance"`
+	OverdraftLimit        float64   `json:"overdraft_limit"`
+	OverdraftInterestRate float64   `json:"overdraft_interest_rate"`
+	AvailableBalance      float64   `json:"available_balance"`
+}
+
+type TermDeposit struct {
+	ID                  uuid.UUID  `json:"id"`
+	AccountID           uuid.UUID  `json:"account_id"`
+	Principal           float64    `json:"principal"`
+	InterestRate        float64    `json:"interest_rate"`
+	TermMonths          int        `json:"term_months"`
+	MaturityInstruction string     `json:"maturity_instruction"`
+	Status              string     `json:"status"`
+	StartDate           time.Time  `json:"start_date"`
+	MaturityDate        time.Time  `json:"maturity_date"`
+	InterestPaid        float64    `json:"interest_paid"`
+	TaxWithheld         float64    `json:"tax_withheld"`
+	Penalty             float64    `json:"penalty"`
+	RolledOverTo        *uuid.UUID `json:"rolled_over_to,omitempty"`
+	ClosedAt            *time.Time `json:"closed_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+type OpenTermDeposit struct {
+	Username            string  `json:"user_name"`
+	Amount              float64 `json:"amount"`
+	TermMonths          int     `json:"term_months"`
+	MaturityInstruction string  `json:"maturity_instruction"`
+}
+
+func (s *OpenTermDeposit) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.Amount, validation.Required, validation.Min(1.0), validation.Max(1000000000000.0)),
+		validation.Field(&s.TermMonths, validation.Required, validation.Min(1), validation.Max(120)),
+		validation.Field(&s.MaturityInstruction, validation.Required, validation.In("PAYOUT", "ROLLOVER", "ROLLOVER_WITH_INTEREST").Error("invalid maturity instruction input")),
+	)
+}
+
+type UpdateTermDepositInstruction struct {
+	ID                  uuid.UUID `json:"id"`
+	Username            string    `json:"user_name"`
+	MaturityInstruction string    `json:"maturity_instruction"`
+}
+
+func (s *UpdateTermDepositInstruction) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.ID, rule.RequiredUUIDRule),
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.MaturityInstruction, validation.Required, validation.In("PAYOUT", "ROLLOVER", "ROLLOVER_WITH_INTEREST").Error("invalid maturity instruction input")),
+	)
+}
+
+type WithdrawTermDeposit struct {
 	ID       uuid.UUID `json:"id"`
+	Username string    `json:"user_name"`
+}
+
+func (s *WithdrawTermDeposit) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.ID, rule.RequiredUUIDRule),
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+	)
+}
+
+type Loan struct {
+	ID                   uuid.UUID `json:"id"`
+	AccountID            uuid.UUID `json:"account_id"`
+	Principal            float64   `json:"principal"`
+	InterestRate         float64   `json:"interest_rate"`
+	PenaltyRate          float64   `json:"penalty_rate"`
+	TermMonths           int       `json:"term_months"`
+	Method               string    `json:"method"`
+	OutstandingPrincipal float64   `json:"outstanding_principal"`
+	Status               string    `json:"status"`
+	DisbursedBy          string    `json:"disbursed_by"`
+	DisbursedAt          time.Time `json:"disbursed_at"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+type LoanInstallment struct {
+	ID               uuid.UUID  `json:"id"`
+	LoanID           uuid.UUID  `json:"loan_id"`
+	Seq              int        `json:"seq"`
+	DueDate          time.Time  `json:"due_date"`
+	PrincipalDue     float64    `json:"principal_due"`
+	InterestDue      float64    `json:"interest_due"`
+	PenaltyDue       float64    `json:"penalty_due"`
+	PrincipalPaid    float64    `json:"principal_paid"`
+	InterestPaid     float64    `json:"interest_paid"`
+	PenaltyPaid      float64    `json:"penalty_paid"`
+	Status           string     `json:"status"`
+	PenaltyAccruedAt *time.Time `json:"penalty_accrued_at,omitempty"`
+	PaidAt           *time.Time `json:"paid_at,omitempty"`
+}
+
+// Remaining returns the amount still owed on the installment, penalty included.
+func (i LoanInstallment) Remaining() float64 {
+	return (i.PrincipalDue - i.PrincipalPaid) + (i.InterestDue - i.InterestPaid) + (i.PenaltyDue - i.PenaltyPaid)
+}
+
+type LoanPayment struct {
+	ID            uuid.UUID `json:"id"`
+	LoanID        uuid.UUID `json:"loan_id"`
+	InstallmentID uuid.UUID `json:"installment_id"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Amount        float64   `json:"amount"`
+	Principal     float64   `json:"principal"`
+	Interest      float64   `json:"interest"`
+	Penalty       float64   `json:"penalty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type LoanSchedule struct {
+	Loan                 Loan              `json:"loan"`
+	OutstandingPrincipal float64           `json:"outstanding_principal"`
+	OverdueAmount        float64           `json:"overdue_amount"`
+	Installments         []LoanInstallment `json:"installments"`
+}
+
+type DisburseLoan struct {
+	Username     string  `json:"user_name"`
+	Principal    float64 `json:"principal"`
+	InterestRate float64 `json:"interest_rate"`
+	PenaltyRate  float64 `json:"penalty_rate"`
+	TermMonths   int     `json:"term_months"`
+	Method       string  `json:"method"`
+	DisbursedBy  string  `json:"-"`
+}
+
+func (s *DisburseLoan) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.Principal, validation.Required, validation.Min(1.0), validation.Max(1000000000000.0)),
+		validation.Field(&s.InterestRate, validation.Min(0.0), validation.Max(1.0)),
+		validation.Field(&s.PenaltyRate, validation.Min(0.0), validation.Max(1.0)),
+		validation.Field(&s.TermMonths, validation.Required, validation.Min(1), validation.Max(360)),
+		validation.Field(&s.Method, validation.Required, validation.In("ANNUITY", "FLAT").Error("invalid loan method input")),
+	)
+}
+
+type StandingOrder struct {
+	ID              uuid.UUID  `json:"id"`
+	AccountID       uuid.UUID  `json:"account_id"`
+	Type            string     `json:"type"`
+	ToAccountNumber string     `json:"to_account_number,omitempty"`
+	Amount          float64    `json:"amount"`
+	Frequency       string     `json:"frequency"`
+	DayOfMonth      int        `json:"day_of_month,omitempty"`
+	ScheduledFor    time.Time  `json:"scheduled_for"`
+	NextRunAt       time.Time  `json:"next_run_at"`
+	EndDate         *time.Time `json:"end_date,omitempty"`
+	RetryCount      int        `json:"retry_count"`
+	Status          string     `json:"status"`
+	Description     string     `json:"description,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type StandingOrderExecution struct {
+	ID              uuid.UUID `json:"id"`
+	StandingOrderID uuid.UUID `json:"standing_order_id"`
+	ScheduledFor    time.Time `json:"scheduled_for"`
+	Attempt         int       `json:"attempt"`
+	Status          string    `json:"status"`
+	Message         string    `json:"message,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type CreateStandingOrder struct {
+	Username        string  `json:"user_name"`
+	Type            string  `json:"type"`
+	ToAccountNumber string  `json:"to_account_number"`
+	Amount          float64 `json:"amount"`
+	Frequency       string  `json:"frequency"`
+	DayOfMonth      int     `json:"day_of_month"`
+	StartDate       string  `json:"start_date"`
+	EndDate         string  `json:"end_date"`
+	Description     string  `json:"description"`
+}
+
+func (s *CreateStandingOrder) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.Type, validation.Required, validation.In("TRANSFER", "WITHDRAWAL").Error("invalid standing order type input")),
+		validation.Field(&s.ToAccountNumber, rule.AlphabetNumericSpaceCharRule),
+		validation.Field(&s.Amount, validation.Required, validation.Min(1.0), validation.Max(1000000000000.0)),
+		validation.Field(&s.Frequency, validation.Required, validation.In("ONCE", "WEEKLY", "MONTHLY").Error("invalid frequency input")),
+		validation.Field(&s.DayOfMonth, validation.Min(0), validation.Max(31)),
+		validation.Field(&s.StartDate, validation.Required, validation.Date("2006-01-02")),
+		validation.Field(&s.EndDate, validation.Date("2006-01-02")),
+		validation.Field(&s.Description, rule.AlphabetNumericSpaceCharRule),
+	)
+}
+
+type UpdateStandingOrder struct {
+	ID              uuid.UUID `json:"id"`
+	Username        string    `json:"user_name"`
+	ToAccountNumber string    `json:"to_account_number"`
+	Amount          float64   `json:"amount"`
+	EndDate         string    `json:"end_date"`
+	Status          string    `json:"status"`
+	Description     string    `json:"description"`
+}
+
+func (s *UpdateStandingOrder) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.ID, rule.RequiredUUIDRule),
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.ToAccountNumber, rule.AlphabetNumericSpaceCharRule),
+		validation.Field(&s.Amount, validation.Min(0.0), validation.Max(1000000000000.0)),
+		validation.Field(&s.EndDate, validation.Date("2006-01-02")),
+		validation.Field(&s.Status, validation.In("ACTIVE", "PAUSED").Error("invalid status input")),
+		validation.Field(&s.Description, rule.AlphabetNumericSpaceCharRule),
+	)
+}
+
+type JournalEntry struct {
+	ID            uuid.UUID     `json:"id"`
+	TransactionID *uuid.UUID    `json:"transaction_id,omitempty"`
+	Description   string        `json:"description"`
+	PostedAt      time.Time     `json:"posted_at"`
+	Lines         []JournalLine `json:"lines"`
+}
+
+type JournalLine struct {
+	ID                uuid.UUID  `json:"id"`
+	JournalEntryID    uuid.UUID  `json:"journal_entry_id"`
+	LedgerAccountCode string     `json:"ledger_account_code"`
+	AccountID         *uuid.UUID `json:"account_id,omitempty"`
+	Debit             float64    `json:"debit"`
+	Credit            float64    `json:"credit"`
+}
+
+type TrialBalanceLine struct {
+	Code    string  `json:"code"`
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	Debit   float64 `json:"debit"`
+	Credit  float64 `json:"credit"`
+	Balance float64 `json:"balance"`
+}
+
+type TrialBalance struct {
+	Lines       []TrialBalanceLine `json:"lines"`
+	TotalDebit  float64            `json:"total_debit"`
+	TotalCredit float64            `json:"total_credit"`
+	Balanced    bool               `json:"balanced"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+type UnbalancedJournal struct {
+	JournalEntryID uuid.UUID `json:"journal_entry_id"`
+	Debit          float64   `json:"debit"`
+	Credit         float64   `json:"credit"`
+}
+
+type BalanceDiscrepancy struct {
+	ID                   uuid.UUID  `json:"id"`
+	AccountID            uuid.UUID  `json:"account_id"`
+	StoredBalance        float64    `json:"stored_balance"`
+	ComputedBalance      float64    `json:"computed_balance"`
+	Difference           float64    `json:"difference"`
+	Status               string     `json:"status"`
+	Resolution           string     `json:"resolution,omitempty"`
+	ResolvedBy           string     `json:"resolved_by,omitempty"`
+	ResolveTransactionID *uuid.UUID `json:"resolve_transaction_id,omitempty"`
+	ResolvedAt           *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+type IntegrityReport struct {
+	Checked       int                  `json:"checked"`
+	Mismatched    int                  `json:"mismatched"`
+	Failed        int                  `json:"failed"`
+	Discrepancies []BalanceDiscrepancy `json:"discrepancies"`
+}
+
+type CorrectDiscrepancy struct {
+	DiscrepancyID uuid.UUID `json:"discrepancy_id"`
+	Resolution    string    `json:"resolution"`
+	ResolvedBy    string    `json:"-"`
+}
+
+func (s *CorrectDiscrepancy) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.DiscrepancyID, rule.RequiredUUIDRule),
+		validation.Field(&s.Resolution, validation.Required, validation.In("RESET_BALANCE", "POST_ADJUSTMENT").Error("invalid resolution input")),
+	)
+}
+
+type StatementLine struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Action        string    `json:"action"`
+	Description   string    `json:"description"`
+	Debit         float64   `json:"debit"`
+	Credit        float64   `json:"credit"`
+	Balance       float64   `json:"balance"`
+}
+
+type Statement struct {
+	AccountNumber   string          `json:"account_number"`
+	Username        string          `json:"user_name"`
+	Fullname        string          `json:"full_name"`
+	Period          string          `json:"period"`
+	PeriodStart     time.Time       `json:"period_start"`
+	PeriodEnd       time.Time       `json:"period_end"`
+	OpeningBalance  float64         `json:"opening_balance"`
+	ClosingBalance  float64         `json:"closing_balance"`
+	TotalCredit     float64         `json:"total_credit"`
+	TotalDebit      float64         `json:"total_debit"`
+	InterestEarned  float64         `json:"interest_earned"`
+	InterestCharged float64         `json:"interest_charged"`
+	TaxWithheld     float64         `json:"tax_withheld"`
+	Fees            float64         `json:"fees"`
+	Lines           []StatementLine `json:"lines"`
+	GeneratedAt     time.Time       `json:"generated_at"`
+}
+
+type StatementFile struct {
+	ID             uuid.UUID `json:"id"`
+	AccountID      uuid.UUID `json:"account_id"`
+	Period         string    `json:"period"`
+	OpeningBalance float64   `json:"opening_balance"`
+	ClosingBalance float64   `json:"closing_balance"`
+	PDF            []byte    `json:"-"`
+	CSV            []byte    `json:"-"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+type StatementExport struct {
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"-"`
+}
+
+type PayrollBatch struct {
+	ID             uuid.UUID     `json:"id"`
+	FileName       string        `json:"file_name"`
+	Format         string        `json:"format"`
+	Checksum       string        `json:"checksum"`
+	Status         string        `json:"status"`
+	LineCount      int           `json:"line_count"`
+	ValidCount     int           `json:"valid_count"`
+	TotalAmount    float64       `json:"total_amount"`
+	CompletedCount int           `json:"completed_count"`
+	FailedCount    int           `json:"failed_count"`
+	UploadedBy     string        `json:"uploaded_by"`
+	ReviewedBy     string        `json:"reviewed_by,omitempty"`
+	ReviewNote     string        `json:"review_note,omitempty"`
+	ReviewedAt     *time.Time    `json:"reviewed_at,omitempty"`
+	ExecutedAt     *time.Time    `json:"executed_at,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Lines          []PayrollLine `json:"lines,omitempty"`
+}
+
+type PayrollLine struct {
+	ID            uuid.UUID  `json:"id"`
+	BatchID       uuid.UUID  `json:"batch_id"`
+	LineNo        int        `json:"line_no"`
+	AccountNumber string     `json:"account_number"`
+	AccountID     *uuid.UUID `json:"account_id,omitempty"`
+	Amount        float64    `json:"amount"`
+	Reference     string     `json:"reference,omitempty"`
+	Status        string     `json:"status"`
+	Message       string     `json:"message,omitempty"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// PayrollRecord is a credit instruction as read from an uploaded payroll file,
+// before the account is looked up.
+type PayrollRecord struct {
+	LineNo        int    `json:"line_no"`
+	AccountNumber string `json:"account_number"`
+	Amount        string `json:"amount"`
+	Reference     string `json:"reference"`
+}
+
+func (s *PayrollRecord) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.AccountNumber, validation.Required, validation.Length(1, 255), rule.AlphabetNumericSpaceCharRule),
+		validation.Field(&s.Amount, validation.Required, rule.AmountRule),
+		validation.Field(&s.Reference, validation.Length(0, 140), rule.AlphabetNumericSpaceCharRule),
+	)
+}
+
+type PayrollUpload struct {
+	FileName   string
+	Format     string
+	Content    []byte
+	UploadedBy string
+}
+
+type ReviewPayrollBatch struct {
+	BatchID    uuid.UUID `json:"batch_id"`
+	Note       string    `json:"note"`
+	ReviewedBy string    `json:"-"`
+}
+
+func (s *ReviewPayrollBatch) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.BatchID, rule.RequiredUUIDRule),
+		validation.Field(&s.Note, validation.Length(0, 255), rule.AlphabetNumericSpaceCharRule),
+	)
+}
+
+type ApprovalRequest struct {
+	ID         uuid.UUID       `json:"id"`
+	Operation  string          `json:"operation"`
+	Payload    json.RawMessage `json:"payload"`
+	Amount     float64         `json:"amount"`
+	Maker      string          `json:"maker"`
+	Status     string          `json:"status"`
+	Checker    string          `json:"checker,omitempty"`
+	Note       string          `json:"note,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	DecidedAt  *time.Time      `json:"decided_at,omitempty"`
+	ExecutedAt *time.Time      `json:"executed_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	Result     interface{}     `json:"result,omitempty"`
+}
+
+type SubmitApproval struct {
+	Operation string
+	Amount    float64
+	Payload   interface{}
+	Maker     string
+}
+
+type ReviewApproval struct {
+	RequestID   uuid.UUID `json:"request_id"`
+	Note        string    `json:"note"`
+	Checker     string    `json:"-"`
+	CheckerRole string    `json:"-"`
+}
+
+func (s *ReviewApproval) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.RequestID, rule.RequiredUUIDRule),
+		validation.Field(&s.Note, validation.Length(0, 255), rule.AlphabetNumericSpaceCharRule),
+	)
+}
+
+type AuditLog struct {
+	Sequence  int64           `json:"sequence"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	IP        string          `json:"ip"`
+	CreatedAt time.Time       `json:"created_at"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// AuditRecord is a privileged action to append to the audit log. The actor and
+// ip are taken from the request context.
+type AuditRecord struct {
+	Action string
+	Target string
+	Before interface{}
+	After  interface{}
+}
+
+type AuditChainBreak struct {
+	Sequence int64  `json:"sequence"`
+	Reason   string `json:"reason"`
+}
+
+type AuditVerification struct {
+	Checked  int               `json:"checked"`
+	Valid    bool              `json:"valid"`
+	LastHash string            `json:"last_hash"`
+	Breaks   []AuditChainBreak `json:"breaks"`
+}
+
+type OutboxEvent struct {
+	ID          uuid.UUID       `json:"id"`
+	Sequence    int64           `json:"sequence"`
+	AccountID   uuid.UUID       `json:"account_id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	PublishedAt *time.Time      `json:"published_at,omitempty"`
+}
+
+type OutboxRelayResult struct {
+	Published int `json:"published"`
+	Failed    int `json:"failed"`
+	Deferred  int `json:"deferred"`
+}
+
+type AccountCreatedEvent struct {
+	UserID        uuid.UUID `json:"user_id"`
+	Username      string    `json:"user_name"`
+	AccountID     uuid.UUID `json:"account_id"`
+	AccountNumber string    `json:"account_number"`
+}
+
+type TransactionCompletedEvent struct {
+	AccountID     uuid.UUID `json:"account_id"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Amount        float64   `json:"amount"`
+}
+
+type InterestPaidEvent struct {
+	AccountID     uuid.UUID `json:"account_id"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	GrossAmount   float64   `json:"gross_amount"`
+	TaxAmount     float64   `json:"tax_amount"`
+	NetAmount     float64   `json:"net_amount"`
+}
+
+type RoleChangedEvent struct {
 	UserID   uuid.UUID `json:"user_id"`
-	Password string    `json:"password"`
+	Username string    `json:"user_name"`
+	OldRole  string    `json:"old_role"`
+	NewRole  string    `json:"new_role"`
 }
 
-type Transaction struct {
-	ID     uuid.UUID `json:"id"`
-	Type   string    `json:"type"`
-	Amount float64   `json:"amount"`
-	Action string    `json:"action"`
-	Status string    `json:"status"`
+type LowBalanceEvent struct {
+	AccountID     uuid.UUID `json:"account_id"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Threshold     float64   `json:"threshold"`
+}
+
+type WebhookSubscription struct {
+	ID        uuid.UUID `json:"id"`
+	AccountID uuid.UUID `json:"account_id"`
+	URL       string    `json:"url"`
+	// Secret is only returned when the subscription is created.
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateWebhookSubscription struct {
+	Username  string   `json:"user_name"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	CreatedBy string   `json:"-"`
+}
+
+func (s *CreateWebhookSubscription) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.URL, validation.Required, rule.WebhookURLRule),
+		validation.Field(&s.Events, validation.Required, validation.Each(validation.In("DepositCompleted", "WithdrawalCompleted", "InterestPaid", "LowBalance").Error("invalid event input"))),
+	)
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type WebhookDeliveryLog struct {
+	ID         uuid.UUID `json:"id"`
+	DeliveryID uuid.UUID `json:"delivery_id"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type RetryWebhookDelivery struct {
+	Username   string    `json:"user_name"`
+	DeliveryID uuid.UUID `json:"delivery_id"`
+}
+
+func (s *RetryWebhookDelivery) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.DeliveryID, validation.Required, rule.RequiredUUIDRule),
+	)
+}
+
+// AccountStreamEvent is notified to every instance when a transaction of the
+// account commits. Balances are not part of it, the stream reads them back.
+type AccountStreamEvent struct {
+	AccountID     uuid.UUID `json:"account_id"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Type          string    `json:"type"`
+	Action        string    `json:"action"`
+	Amount        float64   `json:"amount"`
+	Status        string    `json:"status"`
+}
+
+type StreamMessage struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+type BalanceUpdate struct {
+	AccountID        uuid.UUID `json:"account_id"`
+	AccountNumber    string    `json:"account_number"`
+	Balance          float64   `json:"balance"`
+	AvailableBalance float64   `json:"available_balance"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type NotificationPreference struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Channel   string    `json:"channel"`
+	Address   string    `json:"address"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type UpdateNotificationPreference struct {
+	Username string `json:"user_name"`
+	Channel  string `json:"channel"`
+	Address  string `json:"address"`
+	Enabled  bool   `json:"enabled"`
+}
+
+func (s *UpdateNotificationPreference) Validate() error {
+	addressRule := rule.EmailRule
+	if s.Channel == "SMS" {
+		addressRule = rule.PhoneRule
+	}
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.Channel, validation.Required, validation.In("EMAIL", "SMS").Error("invalid channel input")),
+		validation.Field(&s.Address, validation.Required, validation.Length(0, 254), addressRule),
+	)
+}
+
+// NotificationInput asks to notify a user, Data fills the template of Type.
+type NotificationInput struct {
+	UserID uuid.UUID
+	Type   string
+	Data   map[string]string
+}
+
+type Notification struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Type      string    `json:"type"`
+	Channel   string    `json:"channel"`
+	Recipient string    `json:"recipient"`
+	Subject   string    `json:"subject,omitempty"`
+	Body      string    `json:"body"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserProfile holds the personal data of a user, encrypted at rest.
+type UserProfile struct {
+	UserID      uuid.UUID  `json:"user_id"`
+	Username    string     `json:"user_name"`
+	Fullname    string     `json:"full_name"`
+	Email       string     `json:"email"`
+	Phone       string     `json:"phone"`
+	DateOfBirth string     `json:"date_of_birth"`
+	NationalID  string     `json:"national_id"`
+	Address     string     `json:"address"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	Masked      bool       `json:"masked"`
+}
+
+// Mask hides the personal data from staff who do not need it in full.
+func (p UserProfile) Mask() UserProfile {
+	p.Email = maskx.Email(p.Email)
+	p.Phone = maskx.Phone(p.Phone)
+	p.DateOfBirth = maskx.Date(p.DateOfBirth)
+	p.NationalID = maskx.Tail(p.NationalID, 4)
+	p.Address = maskx.All(p.Address)
+	p.Masked = true
+	return p
+}
+
+type UpdateUserProfile struct {
+	Username    string `json:"user_name"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	DateOfBirth string `json:"date_of_birth"`
+	NationalID  string `json:"national_id"`
+	Address     string `json:"address"`
+}
+
+func (s *UpdateUserProfile) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.Email, validation.Length(0, 254), rule.EmailRule),
+		validation.Field(&s.Phone, rule.PhoneRule),
+		validation.Field(&s.DateOfBirth, validation.Date("2006-01-02").Min(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)).Max(time.Now()).Error("date of birth must be yyyy-mm-dd").RangeError("date of birth must be between 1900-01-01 and today")),
+		validation.Field(&s.NationalID, rule.NationalIDRule),
+		validation.Field(&s.Address, validation.Length(0, 255), rule.AlphabetNumericSpaceCharRule),
+	)
+}
+
+// KYCDocument is an identity document submitted for the KYC review. Content is
+// only read when the document itself is downloaded.
+type KYCDocument struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"user_id"`
+	DocumentType string    `json:"document_type"`
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	Content      []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type SubmitKYCDocument struct {
+	Username     string
+	DocumentType string
+	FileName     string
+	ContentType  string
+	Content      []byte
+}
+
+func (s *SubmitKYCDocument) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.DocumentType, validation.Required, validation.In("ID_CARD", "PASSPORT", "PROOF_OF_ADDRESS", "SELFIE").Error("document_type must be ID_CARD, PASSPORT, PROOF_OF_ADDRESS or SELFIE")),
+		validation.Field(&s.FileName, validation.Required, validation.Length(1, 255)),
+		validation.Field(&s.ContentType, validation.In("image/jpeg", "image/png", "application/pdf").Error("document must be a JPEG, PNG or PDF file")),
+		validation.Field(&s.Content, validation.Required),
+	)
+}
+
+type KYCDetail struct {
+	Username  string        `json:"user_name"`
+	Status    string        `json:"kyc_status"`
+	Note      string        `json:"kyc_note"`
+	Documents []KYCDocument `json:"documents"`
+}
+
+type ReviewKYC struct {
+	Username   string `json:"user_name"`
+	Status     string `json:"kyc_status"`
+	Note       string `json:"note"`
+	ReviewedBy string `json:"-"`
+}
+
+func (s *ReviewKYC) Validate() error {
+	noteRules := []validation.Rule{validation.Length(0, 255), rule.AlphabetNumericSpaceCharRule}
+	if s.Status == "REJECTED" {
+		noteRules = append(noteRules, validation.Required.Error("a rejection needs a note for the customer"))
+	}
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Username, validation.Required, rule.UserNameRule),
+		validation.Field(&s.Status, validation.Required, validation.In("VERIFIED", "REJECTED").Error("kyc_status must be VERIFIED or REJECTED")),
+		validation.Field(&s.Note, noteRules...),
+	)
+}
+
+type FraudRule struct {
+	Code          string    `json:"code"`
+	Action        string    `json:"action"`
+	Threshold     float64   `json:"threshold"`
+	WindowMinutes int       `json:"window_minutes"`
+	Active        bool      `json:"active"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (s *FraudRule) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.Code, validation.Required, validation.In("VELOCITY", "AMOUNT_SPIKE", "PASSWORD_CHANGE_WITHDRAWAL", "NEW_DEVICE").Error("invalid rule code input")),
+		validation.Field(&s.Action, validation.Required, validation.In("REVIEW", "BLOCK").Error("action must be REVIEW or BLOCK")),
+		validation.Field(&s.Threshold, validation.Min(0.0), validation.Max(1000000000000.0)),
+		validation.Field(&s.WindowMinutes, validation.Min(0), validation.Max(525600)),
+	)
+}
+
+// FraudCheck is a transaction about to be screened by the fraud rules.
+type FraudCheck struct {
+	User    User
+	Account Account
+	Action  string
+	Amount  float64
+	// Device identifies the device of the account owner, empty when the
+	// transaction is not run by the owner.
+	Device string
+}
+
+// FraudHit is a rule triggered by a transaction.
+type FraudHit struct {
+	Code   string `json:"code"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+type FraudDecision struct {
+	Decision string     `json:"decision"`
+	Hits     []FraudHit `json:"hits"`
+}
+
+type FraudCase struct {
+	ID            uuid.UUID  `json:"id"`
+	AccountID     uuid.UUID  `json:"account_id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	Action        string     `json:"action"`
+	Amount        float64    `json:"amount"`
+	Decision      string     `json:"decision"`
+	Hits          []FraudHit `json:"hits"`
+	Device        string     `json:"-"`
+	Status        string     `json:"status"`
+	ResolvedBy    string     `json:"resolved_by,omitempty"`
+	Note          string     `json:"note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+}
+
+type ResolveFraudCase struct {
+	CaseID     uuid.UUID `json:"case_id"`
+	Status     string    `json:"status"`
+	Note       string    `json:"note"`
+	ResolvedBy string    `json:"-"`
+}
+
+func (s *ResolveFraudCase) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.CaseID, rule.RequiredUUIDRule),
+		validation.Field(&s.Status, validation.Required, validation.In("CLEARED", "CONFIRMED").Error("status must be CLEARED or CONFIRMED")),
+		validation.Field(&s.Note, validation.Length(0, 255), rule.AlphabetNumericSpaceCharRule),
+	)
+}
+
+// TransactionStats summarises the transactions of an account.
+type TransactionStats struct {
+	Count   int     `json:"count"`
+	Average float64 `json:"average"`
+	Total   float64 `json:"total"`
+}
+
+// AMLTransaction is a completed cash deposit or withdrawal scanned by the AML detection.
+type AMLTransaction struct {
+	ID        uuid.UUID `json:"id"`
+	AccountID uuid.UUID `json:"account_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Action    string    `json:"action"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AMLAlert struct {
+	ID               uuid.UUID   `json:"id"`
+	Type             string      `json:"type"`
+	AccountID        uuid.UUID   `json:"account_id"`
+	AccountNumber    string      `json:"account_number"`
+	UserID           uuid.UUID   `json:"user_id"`
+	Username         string      `json:"user_name"`
+	Fullname         string      `json:"full_name"`
+	TransactionIDs   []uuid.UUID `json:"transaction_ids"`
+	TransactionCount int         `json:"transaction_count"`
+	TotalAmount      float64     `json:"total_amount"`
+	WindowStart      time.Time   `json:"window_start"`
+	WindowEnd        time.Time   `json:"window_end"`
+	Status           string      `json:"status"`
+	ReviewedBy       string      `json:"reviewed_by,omitempty"`
+	Note             string      `json:"note,omitempty"`
+	DedupeKey        string      `json:"-"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+type ReviewAMLAlert struct {
+	AlertID    uuid.UUID `json:"alert_id"`
+	Status     string    `json:"status"`
+	Note       string    `json:"note"`
+	ReviewedBy string    `json:"-"`
+}
+
+func (s *ReviewAMLAlert) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.AlertID, rule.RequiredUUIDRule),
+		validation.Field(&s.Status, validation.Required, validation.In("DISMISSED", "REPORTED").Error("status must be DISMISSED or REPORTED")),
+		validation.Field(&s.Note, validation.Required, validation.Length(1, 255), rule.AlphabetNumericSpaceCharRule),
+	)
+}
+
+type AMLScanResult struct {
+	Scanned     int `json:"scanned"`
+	LargeCash   int `json:"large_cash"`
+	Structuring int `json:"structuring"`
+}
+
+// SanctionsEntry is a name on the watchlist, aliases share the uid of their entry.
+type SanctionsEntry struct {
+	UID     string `json:"uid"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Program string `json:"program"`
+}
+
+type SanctionsMatch struct {
+	Entry SanctionsEntry `json:"entry"`
+	Score float64        `json:"score"`
+}
+
+type SanctionsHit struct {
+	ID           uuid.UUID       `json:"id"`
+	Subject      string          `json:"subject"`
+	UserID       uuid.UUID       `json:"user_id"`
+	Username     string          `json:"user_name"`
+	ScreenedName string          `json:"screened_name"`
+	EntryUID     string          `json:"entry_uid"`
+	EntryName    string          `json:"entry_name"`
+	Program      string          `json:"program"`
+	Score        float64         `json:"score"`
+	Payload      json.RawMessage `json:"payload,omitempty"`
+	Status       string          `json:"status"`
+	ReviewedBy   string          `json:"reviewed_by,omitempty"`
+	Note         string          `json:"note,omitempty"`
+	Message      string          `json:"message,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	ReviewedAt   *time.Time      `json:"reviewed_at,omitempty"`
+	ExecutedAt   *time.Time      `json:"executed_at,omitempty"`
+}
+
+type ReviewSanctionsHit struct {
+	HitID      uuid.UUID `json:"hit_id"`
+	Status     string    `json:"status"`
+	Note       string    `json:"note"`
+	ReviewedBy string    `json:"-"`
+}
+
+func (s *ReviewSanctionsHit) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.HitID, rule.RequiredUUIDRule),
+		validation.Field(&s.Status, validation.Required, validation.In("CLEARED", "CONFIRMED").Error("status must be CLEARED or CONFIRMED")),
+		validation.Field(&s.Note, validation.Required, validation.Length(1, 255), rule.AlphabetNumericSpaceCharRule),
+	)
+}
+
+type SanctionsReloadResult struct {
+	File     string    `json:"file"`
+	Entries  int       `json:"entries"`
+	LoadedAt time.Time `json:"loaded_at"`
 }
@@ -24,6 +24,8 @@ func MountAccountHandler(r *mux.Router, h handler, m middleware.Middleware) {
 	r.Handle("/banking-transaction/account/balance/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.AccountHandler.GetAccountBalance)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
 	r.Handle("/banking-transaction/account/interest/payout", m.AuthenticationMiddleware((http.HandlerFunc(h.AccountHandler.InterestPayout)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
 	r.Handle("/banking-transaction/account/interest/update", m.AuthenticationMiddleware((http.HandlerFunc(h.AccountHandler.UpdateInterestRate)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("PUT")
+	r.Handle("/banking-transaction/account/overdraft/update", m.AuthenticationMiddleware((http.HandlerFunc(h.AccountHandler.UpdateOverdraft)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("PUT")
+	r.Handle("/banking-transaction/account/overdrawn", m.AuthenticationMiddleware((http.HandlerFunc(h.AccountHandler.GetOverdrawnAccounts)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
 }
 
 type AccountHandler struct {
@@ -64,6 +66,10 @@ func (h *AccountHandler) Withdrawal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if requestApproval(w, r, h.business, eventName, consts.ApprovalOperationWITHDRAWAL, payload.Amount, payload) {
+		return
+	}
+
 	err = h.business.AccountBusiness.Withdrawal(ctx, payload)
 	if err != nil {
 		var statusCode = http.StatusInternalServerError
@@ -165,6 +171,10 @@ func (h *AccountHandler) UpdateInterestRate(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if requestApproval(w, r, h.business, eventName, consts.ApprovalOperationUPDATEINTERESTRATE, 0, payload) {
+		return
+	}
+
 	err = h.business.AccountBusiness.UpdateInterestRate(ctx, payload)
 	if err != nil {
 		var statusCode = http.StatusInternalServerError
@@ -244,6 +254,10 @@ func (h *AccountHandler) InterestPayout(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if requestApproval(w, r, h.business, eventName, consts.ApprovalOperationINTERESTPAYOUT, 0, struct{}{}) {
+		return
+	}
+
 	account, err := h.business.AccountBusiness.InterestPayout(ctx)
 	if err != nil {
 		var statusCode = http.StatusInternalServerError
@@ -266,3 +280,75 @@ func (h *AccountHandler) InterestPayout(w http.ResponseWriter, r *http.Request)
 	}
 	response.JsonResponse(w, "success interest payout", account, nil, http.StatusOK)
 }
+
+func (h *AccountHandler) UpdateOverdraft(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.account.update_overdraft"
+		payload   entity.UpdateOverdraft
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "update overdraft error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.business.AccountBusiness.UpdateOverdraft(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "update overdraft error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success update overdraft", nil, nil, http.StatusOK)
+}
+
+func (h *AccountHandler) GetOverdrawnAccounts(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.account.get_overdrawn_accounts"
+	)
+
+	accounts, err := h.business.AccountBusiness.GetOverdrawnAccounts(ctx)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get overdrawn accounts error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get overdrawn accounts", accounts, nil, http.StatusOK)
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+func MountAMLHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/aml/alerts", m.AuthenticationMiddleware((http.HandlerFunc(h.AMLHandler.GetAlerts)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleAuditor}...)).Methods("GET")
+	r.Handle("/banking-transaction/aml/alerts/export", m.AuthenticationMiddleware((http.HandlerFunc(h.AMLHandler.ExportAlerts)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleAuditor}...)).Methods("GET")
+	r.Handle("/banking-transaction/aml/alert/review", m.AuthenticationMiddleware((http.HandlerFunc(h.AMLHandler.ReviewAlert)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/aml/alert/{alert_id}", m.AuthenticationMiddleware((http.HandlerFunc(h.AMLHandler.GetAlert)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleAuditor}...)).Methods("GET")
+}
+
+type AMLHandler struct {
+	business business.Business
+}
+
+func NewAMLHandler(db *sql.DB) AMLHandler {
+	return AMLHandler{business: business.NewBusiness(db)}
+}
+
+// amlAlertFilter reads the status, type and start/end (yyyy-mm-dd) filters shared
+// by the alert list and its export.
+func amlAlertFilter(u url.Values) (string, string, *meta.DateRange, error) {
+	var (
+		status    = u.Get("status")
+		alertType = u.Get("type")
+	)
+
+	if err := validation.Validate(status, validation.In(consts.AMLAlertStatusOPEN, consts.AMLAlertStatusDISMISSED, consts.AMLAlertStatusREPORTED).Error("invalid status input")); err != nil {
+		return "", "", nil, err
+	}
+	if err := validation.Validate(alertType, validation.In(consts.AMLAlertTypeLARGECASH, consts.AMLAlertTypeSTRUCTURING).Error("invalid type input")); err != nil {
+		return "", "", nil, err
+	}
+	dateRange, err := meta.DateRangeFromURL(u, "field", "start", "end")
+	if err != nil {
+		return "", "", nil, errors.New("start and end must be yyyy-mm-dd")
+	}
+	return status, alertType, dateRange, nil
+}
+
+func (h *AMLHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.aml.get_alerts"
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	status, alertType, dateRange, err := amlAlertFilter(r.URL.Query())
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get aml alerts error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	metadata.DateRange = dateRange
+
+	alerts, err := h.business.AMLBusiness.GetAlerts(ctx, status, alertType, metadata)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get aml alerts error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success get aml alerts", alerts, nil, http.StatusOK)
+}
+
+func (h *AMLHandler) GetAlert(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.aml.get_alert"
+		pathVar   = mux.Vars(r)
+	)
+
+	alertID, err := uuid.Parse(pathVar["alert_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get aml alert error", nil, "invalid alert id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	alert, err := h.business.AMLBusiness.GetAlert(ctx, alertID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get aml alert error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get aml alert", alert, nil, http.StatusOK)
+}
+
+func (h *AMLHandler) ReviewAlert(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.aml.review_alert"
+		payload   entity.ReviewAMLAlert
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "review aml alert error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.ReviewedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	alert, err := h.business.AMLBusiness.ReviewAlert(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "review aml alert error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success review aml alert", alert, nil, http.StatusOK)
+}
+
+// ExportAlerts downloads the alerts matching the list filters as CSV.
+func (h *AMLHandler) ExportAlerts(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.aml.export_alerts"
+	)
+
+	status, alertType, dateRange, err := amlAlertFilter(r.URL.Query())
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "export aml alerts error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	report, err := h.business.AMLBusiness.ExportAlerts(ctx, status, alertType, dateRange)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "export aml alerts error", nil, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="aml_alerts_%s.csv"`, time.Now().Format("20060102")))
+	w.Write(report)
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+func MountApprovalHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/approval/requests", m.AuthenticationMiddleware((http.HandlerFunc(h.ApprovalHandler.GetRequests)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/approval/request/approve", m.AuthenticationMiddleware((http.HandlerFunc(h.ApprovalHandler.Approve)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/approval/request/reject", m.AuthenticationMiddleware((http.HandlerFunc(h.ApprovalHandler.Reject)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/approval/request/{request_id}", m.AuthenticationMiddleware((http.HandlerFunc(h.ApprovalHandler.GetRequest)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+}
+
+type ApprovalHandler struct {
+	business business.Business
+}
+
+func NewApprovalHandler(db *sql.DB) ApprovalHandler {
+	return ApprovalHandler{business: business.NewBusiness(db)}
+}
+
+// requestApproval submits the operation for a checker when the approval policy
+// requires one and answers 202 with the pending request. It returns false when
+// the caller executes the operation right away.
+func requestApproval(w http.ResponseWriter, r *http.Request, b business.Business, eventName, operation string, amount float64, payload interface{}) bool {
+	var ctx = r.Context()
+
+	if !b.ApprovalBusiness.RequiresApproval(operation, amount) {
+		return false
+	}
+
+	maker, _ := ctx.Value(middleware.CtxValueUserName).(string)
+	request, err := b.ApprovalBusiness.Submit(ctx, entity.SubmitApproval{
+		Operation: operation,
+		Amount:    amount,
+		Payload:   payload,
+		Maker:     maker,
+	})
+	if err != nil {
+		log.Println(eventName, "Submit", err)
+		response.JsonResponse(w, "submit approval request error", nil, err, http.StatusInternalServerError)
+		return true
+	}
+	response.JsonResponse(w, "waiting for approval", request, nil, http.StatusAccepted)
+	return true
+}
+
+func (h *ApprovalHandler) GetRequests(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.approval.get_requests"
+		status    = r.URL.Query().Get("status")
+		operation = r.URL.Query().Get("operation")
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	if err := validation.Validate(status, validation.In(consts.ApprovalStatusPENDING, consts.ApprovalStatusREJECTED, consts.ApprovalStatusEXPIRED, consts.ApprovalStatusAPPROVED, consts.ApprovalStatusEXECUTED, consts.ApprovalStatusFAILED).Error("invalid status input")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get approval requests error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err := validation.Validate(operation, validation.In(consts.ApprovalOperationUPDATEROLE, consts.ApprovalOperationUPDATEINTERESTRATE, consts.ApprovalOperationINTERESTPAYOUT, consts.ApprovalOperationWITHDRAWAL).Error("invalid operation input")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get approval requests error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	requests, err := h.business.ApprovalBusiness.GetRequests(ctx, status, operation, metadata)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get approval requests error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success get approval requests", requests, nil, http.StatusOK)
+}
+
+func (h *ApprovalHandler) GetRequest(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.approval.get_request"
+		pathVar   = mux.Vars(r)
+	)
+
+	requestID, err := uuid.Parse(pathVar["request_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get approval request error", nil, "invalid request id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	request, err := h.business.ApprovalBusiness.GetRequest(ctx, requestID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get approval request error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get approval request", request, nil, http.StatusOK)
+}
+
+func (h *ApprovalHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, "handler.approval.approve", "approve request", h.business.ApprovalBusiness.Approve)
+}
+
+func (h *ApprovalHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, "handler.approval.reject", "reject request", h.business.ApprovalBusiness.Reject)
+}
+
+func (h *ApprovalHandler) decide(w http.ResponseWriter, r *http.Request, eventName, action string, decide func(ctx context.Context, input entity.ReviewApproval) (*entity.ApprovalRequest, error)) {
+	var (
+		ctx     = r.Context()
+		payload entity.ReviewApproval
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, action+" error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.Checker, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	payload.CheckerRole, _ = ctx.Value(middleware.CtxValueRole).(string)
+	request, err := decide(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, action+" error", request, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success "+action, request, nil, http.StatusOK)
+}
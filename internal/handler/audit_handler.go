@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+)
+
+func MountAuditHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/audit/logs", m.AuthenticationMiddleware((http.HandlerFunc(h.AuditHandler.GetLogs)), []string{consts.RoleSuperAdmin, consts.RoleAuditor}...)).Methods("GET")
+	r.Handle("/banking-transaction/audit/verify", m.AuthenticationMiddleware((http.HandlerFunc(h.AuditHandler.Verify)), []string{consts.RoleSuperAdmin, consts.RoleAuditor}...)).Methods("GET")
+}
+
+type AuditHandler struct {
+	business business.Business
+}
+
+func NewAuditHandler(db *sql.DB) AuditHandler {
+	return AuditHandler{business: business.NewBusiness(db)}
+}
+
+// GetLogs lists the audit log in sequence order. filter_by (actor, action or
+// target) with filter narrows it down, search matches part of the target and
+// start/end (yyyy-mm-dd) limit the creation date.
+func (h *AuditHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.audit.get_logs"
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	if err := validation.Validate(metadata.FilterBy, validation.In("actor", "action", "target").Error("filter_by must be actor, action or target")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get audit logs error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	dateRange, err := meta.DateRangeFromURL(r.URL.Query(), "field", "start", "end")
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get audit logs error", nil, "start and end must be yyyy-mm-dd", http.StatusUnprocessableEntity)
+		return
+	}
+	metadata.DateRange = dateRange
+
+	logs, err := h.business.AuditBusiness.GetLogs(ctx, metadata)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get audit logs error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success get audit logs", logs, nil, http.StatusOK)
+}
+
+func (h *AuditHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.audit.verify"
+	)
+
+	report, err := h.business.AuditBusiness.Verify(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "verify audit log error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	if !report.Valid {
+		response.JsonResponse(w, "audit log chain is broken", report, nil, http.StatusOK)
+		return
+	}
+	response.JsonResponse(w, "audit log chain is intact", report, nil, http.StatusOK)
+}
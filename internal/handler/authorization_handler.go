@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+func MountAuthorizationHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/password/change", m.AllowExpiredPassword(m.AuthenticationMiddleware((http.HandlerFunc(h.AuthorizationHandler.ChangePassword)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer, consts.RoleAuditor}...))).Methods("PUT")
+	r.HandleFunc("/banking-transaction/password/forgot", h.AuthorizationHandler.ForgotPassword).Methods("POST")
+	r.HandleFunc("/banking-transaction/password/reset", h.AuthorizationHandler.ResetPassword).Methods("POST")
+	r.Handle("/banking-transaction/password/reset/admin", m.AuthenticationMiddleware((http.HandlerFunc(h.AuthorizationHandler.AdminResetPassword)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+}
+
+type AuthorizationHandler struct {
+	business business.Business
+}
+
+func NewAuthorizationHandler(db *sql.DB) AuthorizationHandler {
+	return AuthorizationHandler{business: business.NewBusiness(db)}
+}
+
+func (h *AuthorizationHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.authorization.change_password"
+		payload   entity.ChangePasswordInput
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "change password error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.Username, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	if err := h.business.AuthorizationBusiness.ChangePassword(ctx, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		}
+		response.JsonResponse(w, "change password error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success change password", nil, nil, http.StatusOK)
+}
+
+// ForgotPassword answers the same whether the user exists or not.
+func (h *AuthorizationHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.authorization.forgot_password"
+		payload   entity.RequestPasswordReset
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "forgot password error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := h.business.AuthorizationBusiness.ForgotPassword(ctx, payload); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "forgot password error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "if the user exists, a reset token was sent to its notification channels", nil, nil, http.StatusOK)
+}
+
+func (h *AuthorizationHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.authorization.reset_password"
+		payload   entity.ResetPasswordInput
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "reset password error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := h.business.AuthorizationBusiness.ResetPassword(ctx, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		}
+		response.JsonResponse(w, "reset password error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success reset password", nil, nil, http.StatusOK)
+}
+
+func (h *AuthorizationHandler) AdminResetPassword(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.authorization.admin_reset_password"
+		payload   entity.RequestPasswordReset
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "reset password error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.RequestedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	resetToken, err := h.business.AuthorizationBusiness.AdminResetPassword(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "reset password error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success send reset token", resetToken, nil, http.StatusOK)
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/hanselacn/banking-transaction/internal/pkg/rule"
+	"github.com/pkg/errors"
+)
+
+func MountFeeHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/fee/schedules", m.AuthenticationMiddleware((http.HandlerFunc(h.FeeHandler.GetFeeSchedules)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/fee/schedule/update", m.AuthenticationMiddleware((http.HandlerFunc(h.FeeHandler.UpdateFeeSchedule)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("PUT")
+	r.Handle("/banking-transaction/fee/list/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.FeeHandler.GetAccountFees)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/fee/waive", m.AuthenticationMiddleware((http.HandlerFunc(h.FeeHandler.WaiveFee)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+}
+
+type FeeHandler struct {
+	business business.Business
+}
+
+func NewFeeHandler(db *sql.DB) FeeHandler {
+	return FeeHandler{business: business.NewBusiness(db)}
+}
+
+func (h *FeeHandler) GetFeeSchedules(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.fee.get_fee_schedules"
+	)
+
+	schedules, err := h.business.FeeBusiness.GetFeeSchedules(ctx)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get fee schedules error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get fee schedules", schedules, nil, http.StatusOK)
+}
+
+func (h *FeeHandler) UpdateFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.fee.update_fee_schedule"
+		payload   entity.FeeSchedule
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "update fee schedule error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.business.FeeBusiness.UpdateFeeSchedule(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "update fee schedule error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success update fee schedule", nil, nil, http.StatusOK)
+}
+
+func (h *FeeHandler) GetAccountFees(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.fee.get_account_fees"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get account fees error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	fees, err := h.business.FeeBusiness.GetAccountFees(ctx, username, metadata)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get account fees error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get account fees", fees, nil, http.StatusOK)
+}
+
+func (h *FeeHandler) WaiveFee(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.fee.waive_fee"
+		payload   entity.WaiveFee
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "waive fee error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.WaivedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	err = h.business.FeeBusiness.WaiveFee(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "waive fee error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success waive fee", nil, nil, http.StatusOK)
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+func MountFraudHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/fraud/rules", m.AuthenticationMiddleware((http.HandlerFunc(h.FraudHandler.GetRules)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/fraud/rule/update", m.AuthenticationMiddleware((http.HandlerFunc(h.FraudHandler.UpdateRule)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("PUT")
+	r.Handle("/banking-transaction/fraud/cases", m.AuthenticationMiddleware((http.HandlerFunc(h.FraudHandler.GetCases)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/fraud/case/resolve", m.AuthenticationMiddleware((http.HandlerFunc(h.FraudHandler.ResolveCase)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/fraud/case/{case_id}", m.AuthenticationMiddleware((http.HandlerFunc(h.FraudHandler.GetCase)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+}
+
+type FraudHandler struct {
+	business business.Business
+}
+
+func NewFraudHandler(db *sql.DB) FraudHandler {
+	return FraudHandler{business: business.NewBusiness(db)}
+}
+
+func (h *FraudHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.fraud.get_rules"
+	)
+
+	rules, err := h.business.FraudBusiness.GetRules(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get fraud rules error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success get fraud rules", rules, nil, http.StatusOK)
+}
+
+func (h *FraudHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.fraud.update_rule"
+		payload   entity.FraudRule
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "update fraud rule error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.business.FraudBusiness.UpdateRule(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "update fraud rule error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success update fraud rule", nil, nil, http.StatusOK)
+}
+
+func (h *FraudHandler) GetCases(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.fraud.get_cases"
+		status    = r.URL.Query().Get("status")
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	if err := validation.Validate(status, validation.In(consts.FraudCaseStatusOPEN, consts.FraudCaseStatusCLEARED, consts.FraudCaseStatusCONFIRMED).Error("invalid status input")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get fraud cases error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	cases, err := h.business.FraudBusiness.GetCases(ctx, status, metadata)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get fraud cases error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success get fraud cases", cases, nil, http.StatusOK)
+}
+
+func (h *FraudHandler) GetCase(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.fraud.get_case"
+		pathVar   = mux.Vars(r)
+	)
+
+	caseID, err := uuid.Parse(pathVar["case_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get fraud case error", nil, "invalid case id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	fraudCase, err := h.business.FraudBusiness.GetCase(ctx, caseID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get fraud case error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get fraud case", fraudCase, nil, http.StatusOK)
+}
+
+func (h *FraudHandler) ResolveCase(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.fraud.resolve_case"
+		payload   entity.ResolveFraudCase
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "resolve fraud case error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.ResolvedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	fraudCase, err := h.business.FraudBusiness.ResolveCase(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "resolve fraud case error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success resolve fraud case", fraudCase, nil, http.StatusOK)
+}
@@ -1,15 +1,59 @@
 package handler
 
-import "database/sql"
+import (
+	"database/sql"
+
+	leaderbusiness "github.com/hanselacn/banking-transaction/internal/business/leader_business"
+)
 
 type handler struct {
-	UsersHandler   UsersHandler
-	AccountHandler AccountHandler
+	UsersHandler         UsersHandler
+	AccountHandler       AccountHandler
+	WorkerHandler        WorkerHandler
+	TaxHandler           TaxHandler
+	FeeHandler           FeeHandler
+	TermDepositHandler   TermDepositHandler
+	LoanHandler          LoanHandler
+	StandingOrderHandler StandingOrderHandler
+	LedgerHandler        LedgerHandler
+	IntegrityHandler     IntegrityHandler
+	StatementHandler     StatementHandler
+	PayrollHandler       PayrollHandler
+	ApprovalHandler      ApprovalHandler
+	AuditHandler         AuditHandler
+	WebhookHandler       WebhookHandler
+	StreamHandler        StreamHandler
+	NotificationHandler  NotificationHandler
+	KYCHandler           KYCHandler
+	FraudHandler         FraudHandler
+	AMLHandler           AMLHandler
+	SanctionsHandler     SanctionsHandler
+	AuthorizationHandler AuthorizationHandler
 }
 
-func NewHandler(db *sql.DB) handler {
+func NewHandler(db *sql.DB, leader leaderbusiness.LeaderBusiness) handler {
 	return handler{
-		UsersHandler:   NewUsersHandler(db),
-		AccountHandler: NewAccountHandler(db),
+		UsersHandler:         NewUsersHandler(db),
+		AccountHandler:       NewAccountHandler(db),
+		WorkerHandler:        NewWorkerHandler(db, leader),
+		TaxHandler:           NewTaxHandler(db),
+		FeeHandler:           NewFeeHandler(db),
+		TermDepositHandler:   NewTermDepositHandler(db),
+		LoanHandler:          NewLoanHandler(db),
+		StandingOrderHandler: NewStandingOrderHandler(db),
+		LedgerHandler:        NewLedgerHandler(db),
+		IntegrityHandler:     NewIntegrityHandler(db),
+		StatementHandler:     NewStatementHandler(db),
+		PayrollHandler:       NewPayrollHandler(db),
+		ApprovalHandler:      NewApprovalHandler(db),
+		AuditHandler:         NewAuditHandler(db),
+		WebhookHandler:       NewWebhookHandler(db),
+		StreamHandler:        NewStreamHandler(db),
+		NotificationHandler:  NewNotificationHandler(db),
+		KYCHandler:           NewKYCHandler(db),
+		FraudHandler:         NewFraudHandler(db),
+		AMLHandler:           NewAMLHandler(db),
+		SanctionsHandler:     NewSanctionsHandler(db),
+		AuthorizationHandler: NewAuthorizationHandler(db),
 	}
 }
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+func MountIntegrityHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/integrity/check", m.AuthenticationMiddleware((http.HandlerFunc(h.IntegrityHandler.CheckBalances)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/integrity/discrepancies", m.AuthenticationMiddleware((http.HandlerFunc(h.IntegrityHandler.GetDiscrepancies)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/integrity/discrepancy/correct", m.AuthenticationMiddleware((http.HandlerFunc(h.IntegrityHandler.CorrectDiscrepancy)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+}
+
+type IntegrityHandler struct {
+	business business.Business
+}
+
+func NewIntegrityHandler(db *sql.DB) IntegrityHandler {
+	return IntegrityHandler{business: business.NewBusiness(db)}
+}
+
+func (h *IntegrityHandler) CheckBalances(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.integrity.check_balances"
+	)
+
+	report, err := h.business.IntegrityBusiness.CheckBalances(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "check balances error", report, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success check balances", report, nil, http.StatusOK)
+}
+
+func (h *IntegrityHandler) GetDiscrepancies(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.integrity.get_discrepancies"
+		status    = r.URL.Query().Get("status")
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	if err := validation.Validate(status, validation.In(consts.DiscrepancyStatusOPEN, consts.DiscrepancyStatusCORRECTED, consts.DiscrepancyStatusCLEARED).Error("invalid status input")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get discrepancies error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	discrepancies, err := h.business.IntegrityBusiness.GetDiscrepancies(ctx, status, metadata)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get discrepancies error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success get discrepancies", discrepancies, nil, http.StatusOK)
+}
+
+func (h *IntegrityHandler) CorrectDiscrepancy(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.integrity.correct_discrepancy"
+		payload   entity.CorrectDiscrepancy
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "correct discrepancy error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.ResolvedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	discrepancy, err := h.business.IntegrityBusiness.CorrectDiscrepancy(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "correct discrepancy error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success correct discrepancy", discrepancy, nil, http.StatusOK)
+}
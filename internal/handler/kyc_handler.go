@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/hanselacn/banking-transaction/internal/pkg/rule"
+	"github.com/pkg/errors"
+)
+
+const kycMaxUploadSize = 5 << 20
+
+func MountKYCHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/kyc/document", m.AuthenticationMiddleware((http.HandlerFunc(h.KYCHandler.SubmitDocument)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("POST")
+	r.Handle("/banking-transaction/kyc/review", m.AuthenticationMiddleware((http.HandlerFunc(h.KYCHandler.Review)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/kyc/applicants", m.AuthenticationMiddleware((http.HandlerFunc(h.KYCHandler.GetApplicants)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/kyc/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.KYCHandler.GetKYC)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/kyc/{user_name}/document/{document_id}", m.AuthenticationMiddleware((http.HandlerFunc(h.KYCHandler.GetDocument)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+}
+
+type KYCHandler struct {
+	business business.Business
+}
+
+func NewKYCHandler(db *sql.DB) KYCHandler {
+	return KYCHandler{business: business.NewBusiness(db)}
+}
+
+// SubmitDocument reads the document of the authenticated user from the
+// multipart field "file" and its type from the field "document_type".
+func (h *KYCHandler) SubmitDocument(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.kyc.submit_document"
+		payload   entity.SubmitKYCDocument
+	)
+
+	r.Body = http.MaxBytesReader(w, r.Body, kycMaxUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, "a document of at most 5MB is required in the \"file\" field", http.StatusUnprocessableEntity)
+		return
+	}
+	defer file.Close()
+
+	payload.Content, err = io.ReadAll(file)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	payload.Username, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	payload.DocumentType = strings.ToUpper(r.FormValue("document_type"))
+	payload.FileName = header.Filename
+	payload.ContentType = http.DetectContentType(payload.Content)
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "submit kyc document error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	document, err := h.business.KYCBusiness.SubmitDocument(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "submit kyc document error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success submit kyc document, waiting for review", document, nil, http.StatusCreated)
+}
+
+func (h *KYCHandler) GetKYC(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.kyc.get_kyc"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+	if !ownAccount(w, r, username) {
+		return
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get kyc error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	detail, err := h.business.KYCBusiness.GetKYC(ctx, username)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get kyc error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get kyc", detail, nil, http.StatusOK)
+}
+
+func (h *KYCHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.kyc.get_document"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+	if !ownAccount(w, r, username) {
+		return
+	}
+
+	documentID, err := uuid.Parse(pathVar["document_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get kyc document error", nil, "invalid document id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	document, err := h.business.KYCBusiness.GetDocument(ctx, username, documentID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get kyc document error", nil, err, statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", document.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="kyc_%s"`, document.ID))
+	w.Write(document.Content)
+}
+
+// GetApplicants lists the users at a KYC status, PENDING by default.
+func (h *KYCHandler) GetApplicants(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.kyc.get_applicants"
+		status    = r.URL.Query().Get("status")
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	if status == "" {
+		status = consts.KYCStatusPENDING
+	}
+	if err := validation.Validate(status, validation.In(consts.KYCStatusUNVERIFIED, consts.KYCStatusPENDING, consts.KYCStatusVERIFIED, consts.KYCStatusREJECTED).Error("invalid status input")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get kyc applicants error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	users, err := h.business.KYCBusiness.GetApplicants(ctx, status, metadata)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get kyc applicants error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success get kyc applicants", users, nil, http.StatusOK)
+}
+
+func (h *KYCHandler) Review(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.kyc.review"
+		payload   entity.ReviewKYC
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "review kyc error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.ReviewedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	detail, err := h.business.KYCBusiness.Review(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		}
+		response.JsonResponse(w, "review kyc error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success review kyc", detail, nil, http.StatusOK)
+}
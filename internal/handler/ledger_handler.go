@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+)
+
+func MountLedgerHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/ledger/trial-balance", m.AuthenticationMiddleware((http.HandlerFunc(h.LedgerHandler.GetTrialBalance)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/ledger/check", m.AuthenticationMiddleware((http.HandlerFunc(h.LedgerHandler.CheckJournals)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+}
+
+type LedgerHandler struct {
+	business business.Business
+}
+
+func NewLedgerHandler(db *sql.DB) LedgerHandler {
+	return LedgerHandler{business: business.NewBusiness(db)}
+}
+
+func (h *LedgerHandler) GetTrialBalance(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.ledger.get_trial_balance"
+	)
+
+	trialBalance, err := h.business.LedgerBusiness.GetTrialBalance(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get trial balance error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	if !trialBalance.Balanced {
+		log.Println(eventName, "UNBALANCED LEDGER", trialBalance.TotalDebit, trialBalance.TotalCredit)
+		response.JsonResponse(w, "trial balance does not balance", trialBalance, "total debit does not equal total credit", http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success get trial balance", trialBalance, nil, http.StatusOK)
+}
+
+func (h *LedgerHandler) CheckJournals(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.ledger.check_journals"
+	)
+
+	journals, err := h.business.LedgerBusiness.CheckJournals(ctx)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "ledger check failed", journals, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "ledger is balanced", nil, nil, http.StatusOK)
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/hanselacn/banking-transaction/internal/pkg/rule"
+	"github.com/pkg/errors"
+)
+
+func MountLoanHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/loan/disburse", m.AuthenticationMiddleware((http.HandlerFunc(h.LoanHandler.DisburseLoan)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/loan/list/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.LoanHandler.GetLoans)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/loan/{user_name}/{loan_id}/schedule", m.AuthenticationMiddleware((http.HandlerFunc(h.LoanHandler.GetLoanSchedule)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/loan/{user_name}/{loan_id}/payments", m.AuthenticationMiddleware((http.HandlerFunc(h.LoanHandler.GetLoanPayments)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+}
+
+type LoanHandler struct {
+	business business.Business
+}
+
+func NewLoanHandler(db *sql.DB) LoanHandler {
+	return LoanHandler{business: business.NewBusiness(db)}
+}
+
+func (h *LoanHandler) DisburseLoan(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.loan.disburse_loan"
+		payload   entity.DisburseLoan
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "disburse loan error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.DisbursedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	schedule, err := h.business.LoanBusiness.DisburseLoan(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "disburse loan error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success disburse loan", schedule, nil, http.StatusOK)
+}
+
+func (h *LoanHandler) GetLoans(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.loan.get_loans"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get loans error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	loans, err := h.business.LoanBusiness.GetLoans(ctx, username)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get loans error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get loans", loans, nil, http.StatusOK)
+}
+
+func (h *LoanHandler) GetLoanSchedule(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.loan.get_loan_schedule"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get loan schedule error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	loanID, err := uuid.Parse(pathVar["loan_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get loan schedule error", nil, "invalid loan id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	schedule, err := h.business.LoanBusiness.GetLoanSchedule(ctx, username, loanID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get loan schedule error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get loan schedule", schedule, nil, http.StatusOK)
+}
+
+func (h *LoanHandler) GetLoanPayments(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.loan.get_loan_payments"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get loan payments error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	loanID, err := uuid.Parse(pathVar["loan_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get loan payments error", nil, "invalid loan id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	payments, err := h.business.LoanBusiness.GetLoanPayments(ctx, username, loanID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get loan payments error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get loan payments", payments, nil, http.StatusOK)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+func MountNotificationHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/notification/preferences", m.AuthenticationMiddleware((http.HandlerFunc(h.NotificationHandler.UpdatePreference)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("PUT")
+	r.Handle("/banking-transaction/notification/preferences/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.NotificationHandler.GetPreferences)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/notification/list/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.NotificationHandler.GetNotifications)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+}
+
+type NotificationHandler struct {
+	business business.Business
+}
+
+func NewNotificationHandler(db *sql.DB) NotificationHandler {
+	return NotificationHandler{business: business.NewBusiness(db)}
+}
+
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.notification.get_preferences"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	if !ownAccount(w, r, username) {
+		return
+	}
+
+	preferences, err := h.business.NotificationBusiness.GetPreferences(ctx, username)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get notification preferences error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get notification preferences", preferences, nil, http.StatusOK)
+}
+
+// UpdatePreference sets the address of a channel (an email address for EMAIL,
+// an E.164 phone number for SMS) and whether notifications are sent on it.
+func (h *NotificationHandler) UpdatePreference(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.notification.update_preference"
+		payload   entity.UpdateNotificationPreference
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		response.JsonResponse(w, "update notification preference error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if !ownAccount(w, r, payload.Username) {
+		return
+	}
+
+	preference, err := h.business.NotificationBusiness.UpdatePreference(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "update notification preference error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success update notification preference", preference, nil, http.StatusOK)
+}
+
+func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.notification.get_notifications"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	if !ownAccount(w, r, username) {
+		return
+	}
+
+	notifications, err := h.business.NotificationBusiness.GetNotifications(ctx, username, metadata)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get notifications error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get notifications", notifications, nil, http.StatusOK)
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+const payrollMaxUploadSize = 10 << 20
+
+func MountPayrollHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/payroll/upload", m.AuthenticationMiddleware((http.HandlerFunc(h.PayrollHandler.UploadBatch)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/payroll/batches", m.AuthenticationMiddleware((http.HandlerFunc(h.PayrollHandler.GetBatches)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/payroll/batch/approve", m.AuthenticationMiddleware((http.HandlerFunc(h.PayrollHandler.ApproveBatch)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/payroll/batch/reject", m.AuthenticationMiddleware((http.HandlerFunc(h.PayrollHandler.RejectBatch)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/payroll/batch/{batch_id}", m.AuthenticationMiddleware((http.HandlerFunc(h.PayrollHandler.GetBatch)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/payroll/batch/{batch_id}/report", m.AuthenticationMiddleware((http.HandlerFunc(h.PayrollHandler.GetReport)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+}
+
+type PayrollHandler struct {
+	business business.Business
+}
+
+func NewPayrollHandler(db *sql.DB) PayrollHandler {
+	return PayrollHandler{business: business.NewBusiness(db)}
+}
+
+// UploadBatch reads the payroll file from the multipart field "file". The
+// optional field "format" (CSV or PAIN001) overrides the detection from the file name.
+func (h *PayrollHandler) UploadBatch(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.payroll.upload_batch"
+		payload   entity.PayrollUpload
+	)
+
+	r.Body = http.MaxBytesReader(w, r.Body, payrollMaxUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, "a payroll file of at most 10MB is required in the \"file\" field", http.StatusUnprocessableEntity)
+		return
+	}
+	defer file.Close()
+
+	payload.FileName = header.Filename
+	payload.Format = strings.ToUpper(r.FormValue("format"))
+	if err := validation.Validate(payload.Format, validation.In(consts.PayrollFormatCSV, consts.PayrollFormatPAIN001).Error("invalid format input")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "upload payroll error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	payload.Content, err = io.ReadAll(file)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.UploadedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	batch, err := h.business.PayrollBusiness.UploadBatch(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "upload payroll error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success upload payroll, waiting for approval", batch, nil, http.StatusCreated)
+}
+
+func (h *PayrollHandler) GetBatches(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.payroll.get_batches"
+		status    = r.URL.Query().Get("status")
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	if err := validation.Validate(status, validation.In(consts.PayrollBatchStatusPENDINGAPPROVAL, consts.PayrollBatchStatusREJECTED, consts.PayrollBatchStatusPROCESSING, consts.PayrollBatchStatusCOMPLETED, consts.PayrollBatchStatusPARTIALLYCOMPLETED).Error("invalid status input")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get payroll batches error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	batches, err := h.business.PayrollBusiness.GetBatches(ctx, status, metadata)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get payroll batches error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success get payroll batches", batches, nil, http.StatusOK)
+}
+
+func (h *PayrollHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.payroll.get_batch"
+		pathVar   = mux.Vars(r)
+	)
+
+	batchID, err := uuid.Parse(pathVar["batch_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get payroll batch error", nil, "invalid batch id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	batch, err := h.business.PayrollBusiness.GetBatch(ctx, batchID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get payroll batch error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get payroll batch", batch, nil, http.StatusOK)
+}
+
+func (h *PayrollHandler) ApproveBatch(w http.ResponseWriter, r *http.Request) {
+	h.reviewBatch(w, r, "handler.payroll.approve_batch", "approve payroll", h.business.PayrollBusiness.ApproveBatch)
+}
+
+func (h *PayrollHandler) RejectBatch(w http.ResponseWriter, r *http.Request) {
+	h.reviewBatch(w, r, "handler.payroll.reject_batch", "reject payroll", h.business.PayrollBusiness.RejectBatch)
+}
+
+func (h *PayrollHandler) reviewBatch(w http.ResponseWriter, r *http.Request, eventName, action string, review func(ctx context.Context, input entity.ReviewPayrollBatch) (*entity.PayrollBatch, error)) {
+	var (
+		ctx     = r.Context()
+		payload entity.ReviewPayrollBatch
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, action+" error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.ReviewedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	batch, err := review(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, action+" error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success "+action, batch, nil, http.StatusOK)
+}
+
+func (h *PayrollHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.payroll.get_report"
+		pathVar   = mux.Vars(r)
+	)
+
+	batchID, err := uuid.Parse(pathVar["batch_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get payroll report error", nil, "invalid batch id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	batch, report, err := h.business.PayrollBusiness.GetReport(ctx, batchID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get payroll report error", nil, err, statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payroll_%s.csv"`, batch.ID))
+	w.Write(report)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+func MountSanctionsHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/sanctions/hits", m.AuthenticationMiddleware((http.HandlerFunc(h.SanctionsHandler.GetHits)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/sanctions/hit/review", m.AuthenticationMiddleware((http.HandlerFunc(h.SanctionsHandler.ReviewHit)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("POST")
+	r.Handle("/banking-transaction/sanctions/hit/{hit_id}", m.AuthenticationMiddleware((http.HandlerFunc(h.SanctionsHandler.GetHit)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+}
+
+type SanctionsHandler struct {
+	business business.Business
+}
+
+func NewSanctionsHandler(db *sql.DB) SanctionsHandler {
+	return SanctionsHandler{business: business.NewBusiness(db)}
+}
+
+func (h *SanctionsHandler) GetHits(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.sanctions.get_hits"
+		status    = r.URL.Query().Get("status")
+		subject   = r.URL.Query().Get("subject")
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	if err := validation.Validate(status, validation.In(consts.SanctionsHitStatusOPEN, consts.SanctionsHitStatusCLEARED, consts.SanctionsHitStatusCONFIRMED).Error("invalid status input")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get sanctions hits error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err := validation.Validate(subject, validation.In(consts.SanctionsSubjectUSER, consts.SanctionsSubjectTRANSFER).Error("invalid subject input")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get sanctions hits error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	hits, err := h.business.SanctionsBusiness.GetHits(ctx, status, subject, metadata)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get sanctions hits error", nil, err, http.StatusInternalServerError)
+		return
+	}
+	response.JsonResponse(w, "success get sanctions hits", hits, nil, http.StatusOK)
+}
+
+func (h *SanctionsHandler) GetHit(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.sanctions.get_hit"
+		pathVar   = mux.Vars(r)
+	)
+
+	hitID, err := uuid.Parse(pathVar["hit_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get sanctions hit error", nil, "invalid hit id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	hit, err := h.business.SanctionsBusiness.GetHit(ctx, hitID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get sanctions hit error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get sanctions hit", hit, nil, http.StatusOK)
+}
+
+func (h *SanctionsHandler) ReviewHit(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.sanctions.review_hit"
+		payload   entity.ReviewSanctionsHit
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "review sanctions hit error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.ReviewedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	hit, err := h.business.SanctionsBusiness.ReviewHit(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "review sanctions hit error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success review sanctions hit", hit, nil, http.StatusOK)
+}
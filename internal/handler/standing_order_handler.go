@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/hanselacn/banking-transaction/internal/pkg/rule"
+	"github.com/pkg/errors"
+)
+
+func MountStandingOrderHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/standing-order/create", m.AuthenticationMiddleware((http.HandlerFunc(h.StandingOrderHandler.CreateStandingOrder)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("POST")
+	r.Handle("/banking-transaction/standing-order/list/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.StandingOrderHandler.GetStandingOrders)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/standing-order/update", m.AuthenticationMiddleware((http.HandlerFunc(h.StandingOrderHandler.UpdateStandingOrder)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("PUT")
+	r.Handle("/banking-transaction/standing-order/{user_name}/{id}", m.AuthenticationMiddleware((http.HandlerFunc(h.StandingOrderHandler.CancelStandingOrder)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("DELETE")
+	r.Handle("/banking-transaction/standing-order/{user_name}/{id}/executions", m.AuthenticationMiddleware((http.HandlerFunc(h.StandingOrderHandler.GetStandingOrderExecutions)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+}
+
+type StandingOrderHandler struct {
+	business business.Business
+}
+
+func NewStandingOrderHandler(db *sql.DB) StandingOrderHandler {
+	return StandingOrderHandler{business: business.NewBusiness(db)}
+}
+
+func (h *StandingOrderHandler) CreateStandingOrder(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.standing_order.create_standing_order"
+		payload   entity.CreateStandingOrder
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		response.JsonResponse(w, "create standing order error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	ctxUserName := ctx.Value(middleware.CtxValueUserName)
+	if ctxUserName != payload.Username {
+		response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+		return
+	}
+
+	order, err := h.business.StandingOrderBusiness.CreateStandingOrder(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "create standing order error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success create standing order", order, nil, http.StatusOK)
+}
+
+func (h *StandingOrderHandler) GetStandingOrders(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.standing_order.get_standing_orders"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get standing orders error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	orders, err := h.business.StandingOrderBusiness.GetStandingOrders(ctx, username)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get standing orders error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get standing orders", orders, nil, http.StatusOK)
+}
+
+func (h *StandingOrderHandler) UpdateStandingOrder(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.standing_order.update_standing_order"
+		payload   entity.UpdateStandingOrder
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		response.JsonResponse(w, "update standing order error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	ctxUserName := ctx.Value(middleware.CtxValueUserName)
+	if ctxUserName != payload.Username {
+		response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+		return
+	}
+
+	err = h.business.StandingOrderBusiness.UpdateStandingOrder(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "update standing order error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success update standing order", nil, nil, http.StatusOK)
+}
+
+func (h *StandingOrderHandler) CancelStandingOrder(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.standing_order.cancel_standing_order"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	ctxUserName := ctx.Value(middleware.CtxValueUserName)
+	if ctxUserName != username {
+		response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+		return
+	}
+
+	id, err := uuid.Parse(pathVar["id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "cancel standing order error", nil, "invalid standing order id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.business.StandingOrderBusiness.CancelStandingOrder(ctx, username, id)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "cancel standing order error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success cancel standing order", nil, nil, http.StatusOK)
+}
+
+func (h *StandingOrderHandler) GetStandingOrderExecutions(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.standing_order.get_standing_order_executions"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get standing order executions error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	id, err := uuid.Parse(pathVar["id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get standing order executions error", nil, "invalid standing order id", http.StatusUnprocessableEntity)
+		return
+	}
+
+	executions, err := h.business.StandingOrderBusiness.GetStandingOrderExecutions(ctx, username, id)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get standing order executions error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get standing order executions", executions, nil, http.StatusOK)
+}
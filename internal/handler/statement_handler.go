@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/hanselacn/banking-transaction/internal/pkg/rule"
+	"github.com/pkg/errors"
+)
+
+func MountStatementHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/account/{user_name}/statements/{period}", m.AuthenticationMiddleware((http.HandlerFunc(h.StatementHandler.GetStatement)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/account/{user_name}/export", m.AuthenticationMiddleware((http.HandlerFunc(h.StatementHandler.ExportStatement)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+}
+
+type StatementHandler struct {
+	business business.Business
+}
+
+func NewStatementHandler(db *sql.DB) StatementHandler {
+	return StatementHandler{business: business.NewBusiness(db)}
+}
+
+func (h *StatementHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.statement.get_statement"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+		period    = pathVar["period"]
+		format    = r.URL.Query().Get("format")
+	)
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get statement error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err := validation.Validate(format, validation.In("pdf", "csv").Error("format must be pdf or csv")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get statement error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	statement, err := h.business.StatementBusiness.GetStatement(ctx, username, period)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get statement error", nil, err, statusCode)
+		return
+	}
+
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement_%s_%s.csv"`, username, statement.Period))
+		w.Write(statement.CSV)
+	default:
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement_%s_%s.pdf"`, username, statement.Period))
+		w.Write(statement.PDF)
+	}
+}
+
+func (h *StatementHandler) ExportStatement(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.statement.export_statement"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+		format    = r.URL.Query().Get("format")
+	)
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "export statement error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err := validation.Validate(format, validation.Required, validation.In("mt940", "camt053", "ofx").Error("format must be mt940, camt053 or ofx")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "export statement error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	dateRange, err := meta.DateRangeFromURL(r.URL.Query(), "field", "start", "end")
+	if err != nil || dateRange == nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "export statement error", nil, "start and end are required as yyyy-mm-dd", http.StatusUnprocessableEntity)
+		return
+	}
+
+	export, err := h.business.StatementBusiness.ExportStatement(ctx, username, *dateRange, format)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "export statement error", nil, err, statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.FileName))
+	w.Write(export.Content)
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/hanselacn/banking-transaction/internal/pkg/rule"
+	"github.com/hanselacn/banking-transaction/internal/pkg/wsx"
+	"github.com/pkg/errors"
+)
+
+// streamHeartbeat keeps proxies from closing idle streams and finds dead clients.
+const streamHeartbeat = 25 * time.Second
+
+func MountStreamHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/account/stream/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.StreamHandler.Stream)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+}
+
+type StreamHandler struct {
+	business business.Business
+}
+
+func NewStreamHandler(db *sql.DB) StreamHandler {
+	return StreamHandler{business: business.NewBusiness(db)}
+}
+
+// Stream pushes the balance of the caller's own account and every committed
+// transaction on it. Requests asking for a WebSocket upgrade get a WebSocket,
+// the others Server-Sent Events.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	var (
+		eventName = "handler.stream.stream"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	ctxUserName := ctx.Value(middleware.CtxValueUserName)
+	if ctxUserName != username {
+		response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+		return
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "stream account error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	flusher, isFlusher := w.(http.Flusher)
+	if !wsx.IsUpgrade(r) && !isFlusher {
+		response.JsonResponse(w, "stream account error", nil, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	messages, err := h.business.StreamBusiness.Subscribe(ctx, username)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "stream account error", nil, err, statusCode)
+		return
+	}
+
+	if wsx.IsUpgrade(r) {
+		conn, err := wsx.Upgrade(w, r)
+		if err != nil {
+			log.Println(eventName, err)
+			response.JsonResponse(w, "stream account error", nil, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer conn.Close()
+		streamWebSocket(ctx, conn, messages)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	streamEvents(ctx, w, flusher, messages)
+}
+
+func streamEvents(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, messages <-chan entity.StreamMessage) {
+	var eventName = "handler.stream.stream_events"
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(message.Data)
+			if err != nil {
+				log.Println(eventName, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Event, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func streamWebSocket(ctx context.Context, conn *wsx.Conn, messages <-chan entity.StreamMessage) {
+	var eventName = "handler.stream.stream_websocket"
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-conn.Done():
+			return
+		case <-heartbeat.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(message)
+			if err != nil {
+				log.Println(eventName, err)
+				continue
+			}
+			if err := conn.WriteText(data); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/hanselacn/banking-transaction/internal/pkg/rule"
+	"github.com/pkg/errors"
+)
+
+func MountTaxHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/tax/certificate/{user_name}/{year}", m.AuthenticationMiddleware((http.HandlerFunc(h.TaxHandler.GetTaxCertificate)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+}
+
+type TaxHandler struct {
+	business business.Business
+}
+
+func NewTaxHandler(db *sql.DB) TaxHandler {
+	return TaxHandler{business: business.NewBusiness(db)}
+}
+
+func (h *TaxHandler) GetTaxCertificate(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.tax.get_tax_certificate"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+		format    = r.URL.Query().Get("format")
+	)
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get tax certificate error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	year, err := strconv.Atoi(pathVar["year"])
+	if err != nil || year < 1900 || year > time.Now().Year() {
+		response.JsonResponse(w, "get tax certificate error", nil, "year: invalid year", http.StatusUnprocessableEntity)
+		return
+	}
+
+	certificate, err := h.business.TaxBusiness.GetTaxCertificate(ctx, username, year)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get tax certificate error", nil, err, statusCode)
+		return
+	}
+
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tax_certificate_%s_%d.csv"`, certificate.Username, certificate.Year))
+		if err := writeTaxCertificateCSV(w, certificate); err != nil {
+			log.Println(eventName, err)
+		}
+	default:
+		response.JsonResponse(w, "success get tax certificate", certificate, nil, http.StatusOK)
+	}
+}
+
+func writeTaxCertificateCSV(w http.ResponseWriter, certificate *entity.TaxCertificate) error {
+	cw := csv.NewWriter(w)
+	records := [][]string{
+		{"user_name", certificate.Username},
+		{"full_name", certificate.Fullname},
+		{"account_number", certificate.AccountNumber},
+		{"tax_status", certificate.TaxStatus},
+		{"tax_exempt", strconv.FormatBool(certificate.TaxExempt)},
+		{"year", strconv.Itoa(certificate.Year)},
+		{"gross_interest", fmt.Sprintf("%.2f", certificate.GrossInterest)},
+		{"tax_withheld", fmt.Sprintf("%.2f", certificate.TaxWithheld)},
+		{"net_interest", fmt.Sprintf("%.2f", certificate.NetInterest)},
+		{"generated_at", certificate.GeneratedAt.Format(time.RFC3339)},
+		{},
+		{"transaction_id", "date", "action", "type", "amount"},
+	}
+	for _, line := range certificate.Lines {
+		records = append(records, []string{
+			line.TransactionID.String(),
+			line.Date.Format(time.RFC3339),
+			line.Action,
+			line.Type,
+			fmt.Sprintf("%.2f", line.Amount),
+		})
+	}
+	if err := cw.WriteAll(records); err != nil {
+		return err
+	}
+	return cw.Error()
+}
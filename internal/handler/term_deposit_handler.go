@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/hanselacn/banking-transaction/internal/pkg/rule"
+	"github.com/pkg/errors"
+)
+
+func MountTermDepositHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/term-deposit/open", m.AuthenticationMiddleware((http.HandlerFunc(h.TermDepositHandler.OpenTermDeposit)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("POST")
+	r.Handle("/banking-transaction/term-deposit/list/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.TermDepositHandler.GetTermDeposits)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/term-deposit/instruction/update", m.AuthenticationMiddleware((http.HandlerFunc(h.TermDepositHandler.UpdateTermDepositInstruction)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("PUT")
+	r.Handle("/banking-transaction/term-deposit/withdraw", m.AuthenticationMiddleware((http.HandlerFunc(h.TermDepositHandler.WithdrawTermDeposit)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("POST")
+}
+
+type TermDepositHandler struct {
+	business business.Business
+}
+
+func NewTermDepositHandler(db *sql.DB) TermDepositHandler {
+	return TermDepositHandler{business: business.NewBusiness(db)}
+}
+
+func (h *TermDepositHandler) OpenTermDeposit(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.term_deposit.open_term_deposit"
+		payload   entity.OpenTermDeposit
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		response.JsonResponse(w, "open term deposit error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	ctxUserName := ctx.Value(middleware.CtxValueUserName)
+	if ctxUserName != payload.Username {
+		response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+		return
+	}
+
+	deposit, err := h.business.AccountBusiness.OpenTermDeposit(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "open term deposit error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success open term deposit", deposit, nil, http.StatusOK)
+}
+
+func (h *TermDepositHandler) GetTermDeposits(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.term_deposit.get_term_deposits"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get term deposits error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	deposits, err := h.business.AccountBusiness.GetTermDeposits(ctx, username)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get term deposits error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get term deposits", deposits, nil, http.StatusOK)
+}
+
+func (h *TermDepositHandler) UpdateTermDepositInstruction(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.term_deposit.update_term_deposit_instruction"
+		payload   entity.UpdateTermDepositInstruction
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		response.JsonResponse(w, "update term deposit instruction error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	ctxUserName := ctx.Value(middleware.CtxValueUserName)
+	if ctxUserName != payload.Username {
+		response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+		return
+	}
+
+	err = h.business.AccountBusiness.UpdateTermDepositInstruction(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "update term deposit instruction error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success update term deposit instruction", nil, nil, http.StatusOK)
+}
+
+func (h *TermDepositHandler) WithdrawTermDeposit(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.term_deposit.withdraw_term_deposit"
+		payload   entity.WithdrawTermDeposit
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		response.JsonResponse(w, "withdraw term deposit error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	ctxUserName := ctx.Value(middleware.CtxValueUserName)
+	if ctxUserName != payload.Username {
+		response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+		return
+	}
+
+	deposit, err := h.business.AccountBusiness.WithdrawTermDeposit(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "withdraw term deposit error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success withdraw term deposit", deposit, nil, http.StatusOK)
+}
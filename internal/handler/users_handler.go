@@ -22,7 +22,10 @@ import (
 func MountUserHandler(r *mux.Router, h handler, m middleware.Middleware) {
 	r.Handle("/banking-transaction/users/create", m.AuthenticationMiddleware((http.HandlerFunc(h.UsersHandler.CreateUserHandler)), consts.RoleSuperAdmin)).Methods("POST")
 	r.Handle("/banking-transaction/users/detail/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.UsersHandler.GetUserDetail)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
-	r.Handle("/banking-transaction/users/update/role", http.HandlerFunc(h.UsersHandler.UpdateRoleByUserName)).Methods("PUT")
+	r.Handle("/banking-transaction/users/update/role", m.AuthenticationMiddleware((http.HandlerFunc(h.UsersHandler.UpdateRoleByUserName)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("PUT")
+	r.Handle("/banking-transaction/users/profile", m.AuthenticationMiddleware((http.HandlerFunc(h.UsersHandler.UpdateProfile)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleAuditor, consts.RoleCustomer}...)).Methods("PUT")
+	r.Handle("/banking-transaction/users/profile/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.UsersHandler.GetProfile)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleAuditor, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/users/update/tax", m.AuthenticationMiddleware((http.HandlerFunc(h.UsersHandler.UpdateTaxProfile)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("PUT")
 
 	// r.Handle("/banking-transaction/users/create/supadmin", http.HandlerFunc(h.UsersHandler.CreateUserHandler)).Methods("POST")
 }
@@ -156,6 +159,10 @@ func (h *UsersHandler) UpdateRoleByUserName(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if requestApproval(w, r, h.business, eventName, consts.ApprovalOperationUPDATEROLE, 0, payload) {
+		return
+	}
+
 	err = h.business.UserBusiness.UpdateRoleByUserName(ctx, payload)
 	if err != nil {
 		var statusCode = http.StatusInternalServerError
@@ -178,3 +185,119 @@ func (h *UsersHandler) UpdateRoleByUserName(w http.ResponseWriter, r *http.Reque
 	}
 	response.JsonResponse(w, "success update role", nil, nil, http.StatusOK)
 }
+
+func (h *UsersHandler) UpdateTaxProfile(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.users.update_tax_profile"
+		payload   entity.UpdateTaxProfile
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "update tax profile error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.business.UserBusiness.UpdateTaxProfile(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "update tax profile error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success update tax profile", nil, nil, http.StatusOK)
+}
+
+// UpdateProfile updates the profile of the authenticated user only.
+func (h *UsersHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.users.update_profile"
+		payload   entity.UpdateUserProfile
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	payload.Username, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	if err := payload.Validate(); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "update profile error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	profile, err := h.business.UserBusiness.UpdateProfile(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "update profile error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success update profile", profile, nil, http.StatusOK)
+}
+
+// GetProfile shows the profile unmasked to its owner and to super admins;
+// admins and auditors see the personal data masked.
+func (h *UsersHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.users.get_profile"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+	if !ownAccount(w, r, username) {
+		return
+	}
+
+	if err := validation.Validate(username, rule.UserNameRule); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get profile error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	ctxUserName, _ := ctx.Value(middleware.CtxValueUserName).(string)
+	unmasked := ctxUserName == username || ctx.Value(middleware.CtxValueRole) == consts.RoleSuperAdmin
+	profile, err := h.business.UserBusiness.GetProfile(ctx, username, unmasked)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get profile error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get profile", profile, nil, http.StatusOK)
+}
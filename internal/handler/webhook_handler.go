@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+func MountWebhookHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/webhook/create", m.AuthenticationMiddleware((http.HandlerFunc(h.WebhookHandler.CreateSubscription)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("POST")
+	r.Handle("/banking-transaction/webhook/delivery/retry", m.AuthenticationMiddleware((http.HandlerFunc(h.WebhookHandler.RetryDelivery)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("POST")
+	r.Handle("/banking-transaction/webhook/list/{user_name}", m.AuthenticationMiddleware((http.HandlerFunc(h.WebhookHandler.GetSubscriptions)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/webhook/{user_name}/delivery/{delivery_id}/logs", m.AuthenticationMiddleware((http.HandlerFunc(h.WebhookHandler.GetDeliveryLogs)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/webhook/{user_name}/{subscription_id}/deliveries", m.AuthenticationMiddleware((http.HandlerFunc(h.WebhookHandler.GetDeliveries)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("GET")
+	r.Handle("/banking-transaction/webhook/{user_name}/{subscription_id}", m.AuthenticationMiddleware((http.HandlerFunc(h.WebhookHandler.DeleteSubscription)), []string{consts.RoleSuperAdmin, consts.RoleAdmin, consts.RoleCustomer}...)).Methods("DELETE")
+}
+
+type WebhookHandler struct {
+	business business.Business
+}
+
+func NewWebhookHandler(db *sql.DB) WebhookHandler {
+	return WebhookHandler{business: business.NewBusiness(db)}
+}
+
+// ownAccount answers 403 when a customer reaches for another account than their own.
+func ownAccount(w http.ResponseWriter, r *http.Request, username string) bool {
+	var ctx = r.Context()
+
+	role := ctx.Value(middleware.CtxValueRole)
+	switch role {
+	case "customer":
+		ctxUserName := ctx.Value(middleware.CtxValueUserName)
+		if ctxUserName != username {
+			response.JsonResponse(w, "Forbidden", nil, "You Have to Access your own Account", http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+// CreateSubscription answers the signing secret of the subscription, it is not
+// shown again afterwards.
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.webhook.create_subscription"
+		payload   entity.CreateWebhookSubscription
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		response.JsonResponse(w, "create webhook error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if !ownAccount(w, r, payload.Username) {
+		return
+	}
+
+	payload.CreatedBy, _ = ctx.Value(middleware.CtxValueUserName).(string)
+	subscription, err := h.business.WebhookBusiness.CreateSubscription(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "create webhook error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success create webhook, keep the secret to verify the signatures", subscription, nil, http.StatusCreated)
+}
+
+func (h *WebhookHandler) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.webhook.get_subscriptions"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	if !ownAccount(w, r, username) {
+		return
+	}
+
+	subscriptions, err := h.business.WebhookBusiness.GetSubscriptions(ctx, username)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get webhooks error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get webhooks", subscriptions, nil, http.StatusOK)
+}
+
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.webhook.delete_subscription"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	subscriptionID, err := uuid.Parse(pathVar["subscription_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "delete webhook error", nil, "invalid subscription id", http.StatusUnprocessableEntity)
+		return
+	}
+	if !ownAccount(w, r, username) {
+		return
+	}
+
+	err = h.business.WebhookBusiness.DeleteSubscription(ctx, username, subscriptionID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "delete webhook error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success delete webhook", nil, nil, http.StatusOK)
+}
+
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.webhook.get_deliveries"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+		status    = r.URL.Query().Get("status")
+		metadata  = meta.MetadataFromURL(r.URL.Query())
+	)
+
+	subscriptionID, err := uuid.Parse(pathVar["subscription_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get webhook deliveries error", nil, "invalid subscription id", http.StatusUnprocessableEntity)
+		return
+	}
+	if err := validation.Validate(status, validation.In(consts.WebhookDeliveryStatusPENDING, consts.WebhookDeliveryStatusDELIVERED, consts.WebhookDeliveryStatusDEAD).Error("invalid status input")); err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get webhook deliveries error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if !ownAccount(w, r, username) {
+		return
+	}
+
+	deliveries, err := h.business.WebhookBusiness.GetDeliveries(ctx, username, subscriptionID, status, metadata)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get webhook deliveries error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get webhook deliveries", deliveries, nil, http.StatusOK)
+}
+
+func (h *WebhookHandler) GetDeliveryLogs(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.webhook.get_delivery_logs"
+		pathVar   = mux.Vars(r)
+		username  = pathVar["user_name"]
+	)
+
+	deliveryID, err := uuid.Parse(pathVar["delivery_id"])
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "get webhook delivery logs error", nil, "invalid delivery id", http.StatusUnprocessableEntity)
+		return
+	}
+	if !ownAccount(w, r, username) {
+		return
+	}
+
+	logs, err := h.business.WebhookBusiness.GetDeliveryLogs(ctx, username, deliveryID)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "get webhook delivery logs error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get webhook delivery logs", logs, nil, http.StatusOK)
+}
+
+func (h *WebhookHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.webhook.retry_delivery"
+		payload   entity.RetryWebhookDelivery
+	)
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		log.Println(eventName, err)
+		response.JsonResponse(w, "request body malformed", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		response.JsonResponse(w, "retry webhook delivery error", nil, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if !ownAccount(w, r, payload.Username) {
+		return
+	}
+
+	delivery, err := h.business.WebhookBusiness.RetryDelivery(ctx, payload)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		}
+		response.JsonResponse(w, "retry webhook delivery error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success retry webhook delivery", delivery, nil, http.StatusOK)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/hanselacn/banking-transaction/internal/business"
+	leaderbusiness "github.com/hanselacn/banking-transaction/internal/business/leader_business"
+	"github.com/hanselacn/banking-transaction/internal/consts"
+	"github.com/hanselacn/banking-transaction/internal/middleware"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+func MountWorkerHandler(r *mux.Router, h handler, m middleware.Middleware) {
+	r.Handle("/banking-transaction/worker/leader", m.AuthenticationMiddleware((http.HandlerFunc(h.WorkerHandler.GetLeaderStatus)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+	r.Handle("/banking-transaction/worker/interest/progress", m.AuthenticationMiddleware((http.HandlerFunc(h.WorkerHandler.GetInterestPayoutProgress)), []string{consts.RoleSuperAdmin, consts.RoleAdmin}...)).Methods("GET")
+}
+
+type WorkerHandler struct {
+	business business.Business
+	leader   leaderbusiness.LeaderBusiness
+}
+
+// NewWorkerHandler reports on leader, the lease the scheduler of this instance campaigns with.
+func NewWorkerHandler(db *sql.DB, leader leaderbusiness.LeaderBusiness) WorkerHandler {
+	return WorkerHandler{business: business.NewBusiness(db), leader: leader}
+}
+
+func (h *WorkerHandler) GetLeaderStatus(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		eventName = "handler.worker.get_leader_status"
+	)
+
+	status, err := h.leader.GetLeaseStatus(ctx)
+	if err != nil {
+		var statusCode = http.StatusInternalServerError
+		log.Println(eventName, err)
+		causer := errors.Cause(err)
+		switch causer.(type) {
+		case errbank.ErrConflict:
+			statusCode = http.StatusConflict
+		case errbank.ErrNotFound:
+			statusCode = http.StatusNotFound
+		case errbank.ErrUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+		case errbank.ErrForbidden:
+			statusCode = http.StatusForbidden
+		case errbank.ErrTooManyRequest:
+			statusCode = http.StatusTooManyRequests
+		}
+		response.JsonResponse(w, "get leader status error", nil, err, statusCode)
+		return
+	}
+	response.JsonResponse(w, "success get leader status", status, nil, http.StatusOK)
+}
+
+func (h *WorkerHandler) GetInterestPayoutProgress(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx = r.Context()
+	)
+
+	progress := h.business.AccountBusiness.GetInterestPayoutProgress(ctx)
+	response.JsonResponse(w, "success get interest payout progress", progress, nil, http.StatusOK)
+}
@@ -2,10 +2,14 @@ package middleware
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/base64"
+	"encoding/hex"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -16,18 +20,47 @@ import (
 
 type Middleware interface {
 	AuthenticationMiddleware(next http.Handler, roles ...string) http.Handler
+	AllowExpiredPassword(next http.Handler) http.Handler
 }
 
 type middleware struct {
-	repo repo.Repo
+	repo           repo.Repo
+	trustedProxies []*net.IPNet
 }
 
 func NewMiddleware(db *sql.DB) Middleware {
 	return &middleware{
-		repo: repo.NewRepositories(db),
+		repo:           repo.NewRepositories(db),
+		trustedProxies: parseTrustedProxies(os.Getenv("TRUSTED_PROXIES")),
 	}
 }
 
+// parseTrustedProxies reads a comma separated list of addresses and CIDR
+// ranges, skipping the invalid ones.
+func parseTrustedProxies(value string) []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			if strings.Contains(item, ":") {
+				item += "/128"
+			} else {
+				item += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			log.Println("middleware.trusted_proxies", "invalid proxy", item)
+			continue
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies
+}
+
 func (m *middleware) AuthenticationMiddleware(next http.Handler, roles ...string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var (
@@ -85,13 +118,91 @@ func (m *middleware) AuthenticationMiddleware(next http.Handler, roles ...string
 			response.JsonResponse(w, "Forbidden", nil, "Wrong Password", http.StatusForbidden)
 			return
 		}
+
+		allowExpired, _ := ctx.Value(CtxValueAllowExpiredPassword).(bool)
+		if maxAge := passwordMaxAge(); !allowExpired && maxAge > 0 && time.Since(auth.PasswordSetAt()) > maxAge {
+			response.JsonResponse(w, "Forbidden", nil, "Your Password is Expired! Please change your password.", http.StatusForbidden)
+			return
+		}
 		r = r.WithContext(context.WithValue(ctx, CtxValueUserName, user.Username))
 		r = r.WithContext(context.WithValue(r.Context(), CtxValueRole, user.Role))
+		r = r.WithContext(context.WithValue(r.Context(), CtxValueIP, clientIP(r, m.trustedProxies)))
+		r = r.WithContext(context.WithValue(r.Context(), CtxValueDevice, deviceID(r)))
 		log.Println("User Authenticated!")
 		next.ServeHTTP(w, r)
 	})
 }
 
+// AllowExpiredPassword lets the users authenticated by the wrapped handler in
+// with an expired password, so they can still change it.
+func (m *middleware) AllowExpiredPassword(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), CtxValueAllowExpiredPassword, true)))
+	})
+}
+
+// passwordMaxAge is how long a password stays valid. Passwords never expire
+// when PASSWORD_MAX_AGE is unset or zero.
+func passwordMaxAge() time.Duration {
+	v, err := time.ParseDuration(os.Getenv("PASSWORD_MAX_AGE"))
+	if err != nil || v < 0 {
+		return 0
+	}
+	return v
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only read
+// when the request comes from a trusted proxy: its hops are walked from the
+// nearest one and the first address that is not a trusted proxy is the client.
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !trusted(ip, trustedProxies) {
+		return ip
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !trusted(hop, trustedProxies) {
+			break
+		}
+	}
+	return ip
+}
+
+func trusted(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceID identifies the client device from the X-Device-ID header sent by the
+// apps, or from the User-Agent otherwise. It is empty when neither is sent.
+func deviceID(r *http.Request) string {
+	device := r.Header.Get("X-Device-ID")
+	if device == "" {
+		device = r.UserAgent()
+	}
+	if device == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(device))
+	return hex.EncodeToString(sum[:])
+}
+
 func (m *middleware) LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
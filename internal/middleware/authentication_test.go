@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies := parseTrustedProxies("10.0.0.1, 172.16.0.0/12, invalid")
+	if len(proxies) != 2 {
+		t.Fatalf("got %d trusted proxies, want 2", len(proxies))
+	}
+
+	cases := []struct {
+		name      string
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{name: "direct client", remote: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "forged header from a client", remote: "203.0.113.7:5000", forwarded: "198.51.100.1", want: "203.0.113.7"},
+		{name: "client behind the proxy", remote: "10.0.0.1:443", forwarded: "198.51.100.1", want: "198.51.100.1"},
+		{name: "forged hop before the proxy", remote: "10.0.0.1:443", forwarded: "1.2.3.4, 198.51.100.1", want: "198.51.100.1"},
+		{name: "chain of trusted proxies", remote: "10.0.0.1:443", forwarded: "198.51.100.1, 172.16.5.5", want: "198.51.100.1"},
+		{name: "proxy without header", remote: "10.0.0.1:443", want: "10.0.0.1"},
+		{name: "invalid hop", remote: "10.0.0.1:443", forwarded: "198.51.100.1, garbage", want: "10.0.0.1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = c.remote
+			if c.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", c.forwarded)
+			}
+			if got := clientIP(r, proxies); got != c.want {
+				t.Errorf("clientIP() = %s, want %s", got, c.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@ package middleware
 type key string
 
 const (
-	CtxValueUserName key = "user_name"
-	CtxValueRole     key = "role"
+	CtxValueUserName             key = "user_name"
+	CtxValueRole                 key = "role"
+	CtxValueIP                   key = "ip"
+	CtxValueDevice               key = "device"
+	CtxValueAllowExpiredPassword key = "allow_expired_password"
 )
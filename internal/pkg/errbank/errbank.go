@@ -3,6 +3,7 @@ package errbank
 import (
 	"database/sql"
 	"log"
+	"strings"
 
 	"github.com/lib/pq"
 )
@@ -97,6 +98,28 @@ func (e ErrServiceUnavailable) Error() string {
 	return string(e)
 }
 
+type ErrSerializationFailure string
+
+func NewErrSerializationFailure(message string) ErrSerializationFailure {
+	return ErrSerializationFailure(message)
+}
+
+func (e ErrSerializationFailure) Error() string {
+	return string(e)
+}
+
+// ErrAccepted means the request was taken but not carried out yet, for example a
+// transfer held for review.
+type ErrAccepted string
+
+func NewErrAccepted(message string) ErrAccepted {
+	return ErrAccepted(message)
+}
+
+func (e ErrAccepted) Error() string {
+	return string(e)
+}
+
 func TranslateDBError(err error) error {
 	if err == sql.ErrNoRows {
 		return NewErrNotFound("data not found!")
@@ -109,8 +132,29 @@ func TranslateDBError(err error) error {
 	switch pgErr.Code {
 	case "23505":
 		return NewErrConflict("already exist!")
+	case "40001", "40P01":
+		return NewErrSerializationFailure("could not serialize access, retry the transaction")
 	default:
 		log.Fatalf("Error executing query: %v", err)
 	}
 	return err
 }
+
+// IsSerializationFailure reports whether err means the transaction lost a
+// serialization conflict and can safely be retried from the beginning.
+func IsSerializationFailure(err error) bool {
+	switch e := err.(type) {
+	case ErrSerializationFailure:
+		return true
+	case *pq.Error:
+		return e.Code == "40001" || e.Code == "40P01"
+	}
+	return false
+}
+
+// IsInsufficientBalance reports whether err refused a debit the balance could not
+// cover, which may go through once the account is topped up.
+func IsInsufficientBalance(err error) bool {
+	e, ok := err.(ErrUnprocessableEntity)
+	return ok && strings.HasPrefix(string(e), "insufficient balance")
+}
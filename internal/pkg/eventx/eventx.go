@@ -0,0 +1,37 @@
+package eventx
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Message is a domain event as handed to a Publisher. Delivery is at least
+// once, consumers drop the IDs they have already seen.
+type Message struct {
+	ID        string          `json:"id"`
+	Key       string          `json:"key"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Publisher delivers messages downstream. Publish returns only once the
+// message is accepted; messages with the same key are published in order.
+type Publisher interface {
+	Publish(ctx context.Context, message Message) error
+}
+
+// Fanout publishes every message to each publisher in turn and stops at the
+// first failure. A message retried after a failure reaches the publishers
+// before the failing one again, they have to tolerate duplicates.
+type Fanout []Publisher
+
+func (f Fanout) Publish(ctx context.Context, message Message) error {
+	for _, publisher := range f {
+		if err := publisher.Publish(ctx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
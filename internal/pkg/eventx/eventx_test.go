@@ -0,0 +1,106 @@
+package eventx
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func message(i int) Message {
+	return Message{
+		ID:        fmt.Sprintf("id-%d", i),
+		Key:       "account",
+		Type:      "DepositCompleted",
+		Payload:   json.RawMessage(fmt.Sprintf(`{"amount":%d}`, i)),
+		CreatedAt: time.Date(2024, 6, 1, 0, 0, i, 0, time.UTC),
+	}
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	p := NewMemoryPublisher(2)
+	ch, unsubscribe := p.Subscribe(3)
+
+	for i := 1; i <= 3; i++ {
+		if err := p.Publish(context.Background(), message(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	kept := p.Messages()
+	if len(kept) != 2 || kept[0].ID != "id-2" || kept[1].ID != "id-3" {
+		t.Errorf("expected the last 2 messages, got %+v", kept)
+	}
+	for i := 1; i <= 3; i++ {
+		if got := <-ch; got.ID != message(i).ID {
+			t.Errorf("subscriber got %s, want %s", got.ID, message(i).ID)
+		}
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, open := <-ch; open {
+		t.Errorf("channel still open after unsubscribe")
+	}
+	if err := p.Publish(context.Background(), message(4)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONLPublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	p := NewJSONLPublisher(path)
+
+	for i := 1; i <= 2; i++ {
+		if err := p.Publish(context.Background(), message(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []Message
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var m Message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, m)
+	}
+	if len(lines) != 2 || lines[0].ID != "id-1" || lines[1].ID != "id-2" {
+		t.Fatalf("unexpected lines %+v", lines)
+	}
+	if string(lines[1].Payload) != `{"amount":2}` || !lines[1].CreatedAt.Equal(message(2).CreatedAt) {
+		t.Errorf("message not kept as is: %+v", lines[1])
+	}
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(ctx context.Context, message Message) error {
+	return errors.New("unavailable")
+}
+
+func TestFanoutStopsAtFirstFailure(t *testing.T) {
+	first, last := NewMemoryPublisher(0), NewMemoryPublisher(0)
+
+	if err := (Fanout{first, last}).Publish(context.Background(), message(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Fanout{first, failingPublisher{}, last}).Publish(context.Background(), message(2)); err == nil {
+		t.Fatal("expected the failure to be returned")
+	}
+	if len(first.Messages()) != 2 || len(last.Messages()) != 1 {
+		t.Errorf("got %d and %d messages, want 2 and 1", len(first.Messages()), len(last.Messages()))
+	}
+}
@@ -0,0 +1,46 @@
+package eventx
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// JSONLPublisher appends every message as one JSON line to a file, for local
+// runs where a consumer tails the file.
+type JSONLPublisher struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewJSONLPublisher(path string) *JSONLPublisher {
+	return &JSONLPublisher{path: path}
+}
+
+func (p *JSONLPublisher) Publish(ctx context.Context, message Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	file, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	// The relay marks the message published right after, it must be on disk.
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,73 @@
+package eventx
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultMemoryCapacity = 1000
+
+// MemoryPublisher keeps the last messages in memory and fans them out to the
+// in-process subscribers, for local runs and tests.
+type MemoryPublisher struct {
+	mu          sync.RWMutex
+	capacity    int
+	messages    []Message
+	subscribers map[chan Message]struct{}
+}
+
+func NewMemoryPublisher(capacity int) *MemoryPublisher {
+	if capacity <= 0 {
+		capacity = defaultMemoryCapacity
+	}
+	return &MemoryPublisher{
+		capacity:    capacity,
+		subscribers: map[chan Message]struct{}{},
+	}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, message Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, message)
+	if len(p.messages) > p.capacity {
+		p.messages = p.messages[len(p.messages)-p.capacity:]
+	}
+	for ch := range p.subscribers {
+		// A slow subscriber misses messages rather than blocking the relay.
+		select {
+		case ch <- message:
+		default:
+		}
+	}
+	return nil
+}
+
+// Messages returns the kept messages, oldest first.
+func (p *MemoryPublisher) Messages() []Message {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]Message(nil), p.messages...)
+}
+
+// Subscribe returns a channel receiving the messages published from now on and
+// a function closing it.
+func (p *MemoryPublisher) Subscribe(buffer int) (<-chan Message, func()) {
+	ch := make(chan Message, buffer)
+	p.mu.Lock()
+	p.subscribers[ch] = struct{}{}
+	p.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			p.mu.Lock()
+			delete(p.subscribers, ch)
+			p.mu.Unlock()
+			close(ch)
+		})
+	}
+}
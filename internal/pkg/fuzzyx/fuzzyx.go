@@ -0,0 +1,100 @@
+// Package fuzzyx scores how alike two person or entity names are, tolerating
+// typos, transliteration differences and a different order of the names.
+package fuzzyx
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Normalize upper cases name and replaces punctuation and repeated spaces by a
+// single space: "O'Brien,  john-paul" becomes "O BRIEN JOHN PAUL".
+func Normalize(name string) string {
+	fields := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// sortTokens orders the words of a normalized name, so "SMITH JOHN" and
+// "JOHN SMITH" compare equal.
+func sortTokens(name string) string {
+	fields := strings.Fields(name)
+	sort.Strings(fields)
+	return strings.Join(fields, " ")
+}
+
+// Jaro returns the Jaro similarity of a and b, from 0 (nothing in common) to 1.
+func Jaro(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(len(ra), len(rb))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	var (
+		matchedA = make([]bool, len(ra))
+		matchedB = make([]bool, len(rb))
+		matches  int
+	)
+	for i := range ra {
+		lo, hi := max(0, i-window), min(len(rb), i+window+1)
+		for j := lo; j < hi; j++ {
+			if matchedB[j] || ra[i] != rb[j] {
+				continue
+			}
+			matchedA[i], matchedB[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	var transpositions, j int
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+}
+
+// JaroWinkler boosts the Jaro similarity of strings sharing a prefix of up to
+// four characters.
+func JaroWinkler(a, b string) float64 {
+	jaro := Jaro(a, b)
+	ra, rb := []rune(a), []rune(b)
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// NameSimilarity compares two names after normalizing them, in the given word
+// order and in alphabetical word order, and returns the best score.
+func NameSimilarity(a, b string) float64 {
+	a, b = Normalize(a), Normalize(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	return max(JaroWinkler(a, b), JaroWinkler(sortTokens(a), sortTokens(b)))
+}
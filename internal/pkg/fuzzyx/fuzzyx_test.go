@@ -0,0 +1,51 @@
+package fuzzyx
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	if got := Normalize("O'Brien,  john-paul "); got != "O BRIEN JOHN PAUL" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"MARTHA", "MARHTA", 0.9611},
+		{"DWAYNE", "DUANE", 0.84},
+		{"DIXON", "DICKSONX", 0.8133},
+		{"ABC", "ABC", 1},
+		{"ABC", "XYZ", 0},
+		{"", "", 1},
+		{"ABC", "", 0},
+	}
+	for _, tt := range tests {
+		if got := JaroWinkler(tt.a, tt.b); math.Abs(got-tt.want) > 0.0001 {
+			t.Errorf("JaroWinkler(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		likely bool
+	}{
+		{"SMITH, John", "john smith", true},
+		{"Usama Bin Ladin", "Osama bin Laden", true},
+		{"Mohammed Al-Hassan", "Muhammad Al Hasan", true},
+		{"Jane Doe", "Robert Kowalski", false},
+		{"", "John Smith", false},
+	}
+	for _, tt := range tests {
+		got := NameSimilarity(tt.a, tt.b)
+		if (got >= 0.9) != tt.likely {
+			t.Errorf("NameSimilarity(%q, %q) = %.4f, want likely %v", tt.a, tt.b, got, tt.likely)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package maskx hides most of a personal value while keeping enough of it to be
+// recognised by its owner.
+package maskx
+
+import "strings"
+
+const mask = "*"
+
+// Tail keeps the last visible characters of s.
+func Tail(s string, visible int) string {
+	runes := []rune(s)
+	if len(runes) <= visible {
+		return strings.Repeat(mask, len(runes))
+	}
+	return strings.Repeat(mask, len(runes)-visible) + string(runes[len(runes)-visible:])
+}
+
+// Email keeps the first characters of the local part and the domain:
+// alice@example.com becomes al***@example.com.
+func Email(s string) string {
+	at := strings.LastIndex(s, "@")
+	if at < 0 {
+		return Tail(s, 0)
+	}
+	local, domain := []rune(s[:at]), s[at:]
+	keep := 2
+	if len(local) <= 3 {
+		keep = 1
+	}
+	if len(local) <= 1 {
+		keep = 0
+	}
+	return string(local[:keep]) + strings.Repeat(mask, 3) + domain
+}
+
+// Phone keeps the plus sign and the last four digits of an E.164 number.
+func Phone(s string) string {
+	if strings.HasPrefix(s, "+") {
+		return "+" + Tail(s[1:], 4)
+	}
+	return Tail(s, 4)
+}
+
+// Date keeps the year of a yyyy-mm-dd date.
+func Date(s string) string {
+	if len(s) != len("2006-01-02") {
+		return Tail(s, 0)
+	}
+	return s[:4] + "-**-**"
+}
+
+// All hides the whole value, an empty value stays empty.
+func All(s string) string {
+	if s == "" {
+		return ""
+	}
+	return strings.Repeat(mask, 8)
+}
@@ -0,0 +1,26 @@
+package maskx
+
+import "testing"
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"tail", Tail("3174012345678901", 4), "************8901"},
+		{"short tail", Tail("123", 4), "***"},
+		{"email", Email("alice@example.com"), "al***@example.com"},
+		{"short email", Email("bob@example.com"), "b***@example.com"},
+		{"one letter email", Email("a@example.com"), "***@example.com"},
+		{"phone", Phone("+6281234567890"), "+*********7890"},
+		{"date", Date("1990-05-17"), "1990-**-**"},
+		{"all", All("Jl. Sudirman 1, Jakarta"), "********"},
+		{"empty", All(""), ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
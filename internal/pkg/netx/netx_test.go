@@ -0,0 +1,69 @@
+package netx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	cases := map[string]bool{
+		"8.8.8.8":              true,
+		"2606:4700::1111":      true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"0.0.0.0":              false,
+		"::":                   false,
+		"100.64.0.1":           false,
+		"224.0.0.1":            false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:169.254.169.1": false,
+	}
+	for addr, want := range cases {
+		if got := IsPublic(net.ParseIP(addr)); got != want {
+			t.Errorf("IsPublic(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestIsInternalHost(t *testing.T) {
+	cases := map[string]bool{
+		"hooks.example.com":  false,
+		"93.184.216.34":      false,
+		"localhost":          true,
+		"api.localhost":      true,
+		"vault":              true,
+		"printer.local":      true,
+		"metadata.internal":  true,
+		"METADATA.INTERNAL.": true,
+		"127.0.0.1":          true,
+		"[::1]":              true,
+		"169.254.169.254":    true,
+	}
+	for host, want := range cases {
+		if got := IsInternalHost(host); got != want {
+			t.Errorf("IsInternalHost(%s) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestPublicControlRefusesLoopback(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	dialer := &net.Dialer{Control: PublicControl}
+	_, err = dialer.DialContext(context.Background(), "tcp", l.Addr().String())
+	if !errors.Is(err, ErrNotPublic) {
+		t.Errorf("DialContext() error = %v, want %v", err, ErrNotPublic)
+	}
+}
@@ -0,0 +1,50 @@
+package notifyx
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogNotifier appends every message as a JSON line to a file, or to the
+// standard log when no path is set. It stands in for channels without a provider.
+type LogNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogNotifier(path string) *LogNotifier {
+	return &LogNotifier{path: path}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, message Message) error {
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{
+		Message: message,
+		SentAt:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if n.path == "" {
+		log.Println("notification", string(line))
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	file, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
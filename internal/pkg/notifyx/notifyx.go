@@ -0,0 +1,24 @@
+// Package notifyx sends customer notifications over a delivery channel.
+package notifyx
+
+import (
+	"context"
+	"strings"
+)
+
+// Message is one rendered notification. Subject is empty for channels without one.
+type Message struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"`
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body"`
+}
+
+type Notifier interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// headerValue drops line breaks so a value cannot add headers of its own.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,131 @@
+package notifyx
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// smtpStub accepts a single mail the way a relay without authentication would
+// and hands back the envelope and the data.
+func smtpStub(t *testing.T) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var (
+			reader = bufio.NewReader(conn)
+			lines  []string
+			inData bool
+		)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 stub ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if inData {
+				if line == "." {
+					inData = false
+					reply("250 queued")
+					continue
+				}
+				lines = append(lines, line)
+				continue
+			}
+			switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+			case "EHLO", "HELO":
+				reply("250 stub")
+			case "MAIL", "RCPT":
+				lines = append(lines, line)
+				reply("250 ok")
+			case "DATA":
+				inData = true
+				reply("354 go ahead")
+			case "QUIT":
+				reply("221 bye")
+				received <- lines
+				return
+			default:
+				reply("502 unsupported")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, received := smtpStub(t)
+	host, port, _ := net.SplitHostPort(addr)
+	notifier := NewSMTPNotifier(SMTPConfig{Host: host, Port: port, From: "bank@example.com"})
+
+	err := notifier.Send(context.Background(), Message{
+		Channel: "EMAIL",
+		To:      "alice@example.com",
+		Subject: "Deposit received\r\nBcc: mallory@example.com",
+		Body:    "Hello Alice\n.hidden line",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := <-received
+	mail := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"MAIL FROM:<bank@example.com>",
+		"RCPT TO:<alice@example.com>",
+		"To: alice@example.com",
+		"Subject: Deposit receivedBcc: mallory@example.com",
+		"Hello Alice",
+		"..hidden line",
+	} {
+		if !strings.Contains(mail, want) {
+			t.Errorf("mail does not contain %q:\n%s", want, mail)
+		}
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Errorf("subject injected a header: %q", line)
+		}
+	}
+}
+
+func TestLogNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	notifier := NewLogNotifier(path)
+
+	for _, to := range []string{"+6281234567890", "+6281234567891"} {
+		if err := notifier.Send(context.Background(), Message{Channel: "SMS", To: to, Body: "Deposit received"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	var message Message
+	if err := json.Unmarshal([]byte(lines[1]), &message); err != nil || message.To != "+6281234567891" || message.Body != "Deposit received" {
+		t.Errorf("unexpected line %q: %v", lines[1], err)
+	}
+}
@@ -0,0 +1,52 @@
+package pdfx
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	doc := New()
+	doc.AddPage().Text(40, 800, FontBold, 14, "Statement (draft) 100\\%")
+	doc.AddPage().Line(40, 790, 555, 790)
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("missing header or trailer")
+	}
+	if !bytes.Contains(out, []byte(`(Statement \(draft\) 100\\%) Tj`)) {
+		t.Errorf("text not escaped: %s", out)
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Errorf("expected 2 pages")
+	}
+
+	// Every xref entry must point at the start of its object.
+	match := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(out)
+	if match == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(out[xref:], -1)
+	if len(entries) != 9 {
+		t.Fatalf("expected 9 objects, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("object %d offset %d does not start with %q", i+1, offset, want)
+		}
+	}
+}
+
+func TestEscapeNonASCII(t *testing.T) {
+	if got := escape("Zoë\n"); got != "Zo??" {
+		t.Errorf("escape = %q", got)
+	}
+}
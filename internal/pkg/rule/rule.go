@@ -1,9 +1,13 @@
 package rule
 
 import (
+	"errors"
+	"net/url"
 	"regexp"
 
 	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/pkg/netx"
 )
 
 var (
@@ -17,6 +21,9 @@ var (
 	UppercaseRegex           = regexp.MustCompile("[A-Z]")
 	LengthRegex              = regexp.MustCompile(`^.{8,20}$`)
 	InterestRate             = regexp.MustCompile(`^(0(\.\d+)?|1(\.0+)?)$`)
+	Email                    = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]{1,64}@[a-zA-Z0-9\-]+(\.[a-zA-Z0-9\-]+)*\.[a-zA-Z]{2,}$`)
+	PhoneE164                = regexp.MustCompile(`^\+[1-9]\d{7,14}$`)
+	NationalID               = regexp.MustCompile(`^[A-Z0-9]{5,32}$`)
 )
 
 var (
@@ -25,9 +32,30 @@ var (
 	FullNameRule                 = validation.Match(FullName).Error(`invalid full name`)
 	UserNameRule                 = validation.Match(UserName).Error(`must be among this combination (a-z,A-Z,0-9,dash(-),underscore(_)) with length between 3-100 characters`)
 	InterestRateRule             = validation.Match(InterestRate).Error(`interest rate must be between 0-1`)
+	EmailRule                    = validation.Match(Email).Error(`invalid email address`)
+	PhoneRule                    = validation.Match(PhoneE164).Error(`phone number must be in E.164 format, e.g. +6281234567890`)
+	NationalIDRule               = validation.Match(NationalID).Error(`national id must be 5-32 uppercase letters or digits`)
 	SpecialCharRegexRule         = validation.Match(SpecialCharRegex).Error("password must be a combination of alphanumeric + symbols with length between 8-20 characters")
 	DigitRegexRule               = validation.Match(SpecialCharRegex).Error("password must be a combination of alphanumeric + symbols with length between 8-20 characters")
 	LowercaseRegexRule           = validation.Match(SpecialCharRegex).Error("password must be a combination of alphanumeric + symbols with length between 8-20 characters")
 	UppercaseRegexRule           = validation.Match(UppercaseRegex).Error("password must be a combination of alphanumeric + symbols with length between 8-20 characters")
 	LengthRegexRule              = validation.Match(LengthRegex).Error("password must be a combination of alphanumeric + symbols with length between 8-20 characters")
+	// RequiredUUIDRule rejects uuid.Nil, which validation.Required lets through for uuid.UUID values.
+	RequiredUUIDRule = validation.NotIn(uuid.Nil.String()).Error("cannot be blank")
 )
+
+// WebhookURLRule accepts absolute http and https URLs outside the internal network.
+var WebhookURLRule = validation.By(func(value interface{}) error {
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(s) > 2048 {
+		return errors.New("must be an absolute http or https URL of at most 2048 characters")
+	}
+	if netx.IsInternalHost(u.Hostname()) {
+		return errors.New("must not point to a private or internal host")
+	}
+	return nil
+})
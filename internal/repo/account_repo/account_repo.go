@@ -23,6 +23,11 @@ type AccountRepositories interface {
 	UpdateInterestRate(ctx context.Context, account entity.Account, tx *sql.Tx) error
 	GetListAccount(ctx context.Context, m *meta.Metadata) ([]entity.Account, error)
 	PayoutInterest(ctx context.Context, account entity.Account, tx *sql.Tx) error
+	GetAccountBatch(ctx context.Context, afterID uuid.UUID, limit int) ([]entity.Account, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Account, error)
+	UpdateOverdraft(ctx context.Context, account entity.Account, tx *sql.Tx) error
+	FindByAccountNumber(ctx context.Context, accountNumber string) (*entity.Account, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.Account, error)
 }
 
 type accountRepo struct {
@@ -87,7 +92,7 @@ func (a accountRepo) FindByUserID(ctx context.Context, id uuid.UUID) (*entity.Ac
 	var (
 		eventName = "repo.account.find_user_by_id"
 		query     = `
-		SELECT id, user_id, account_number, balance, interest_rate
+		SELECT id, user_id, account_number, balance, interest_rate, created_at, last_interest_payout::timestamptz, overdraft_limit, overdraft_interest_rate
 		FROM accounts
 		WHERE user_id = $1
 		`
@@ -97,39 +102,15 @@ func (a accountRepo) FindByUserID(ctx context.Context, id uuid.UUID) (*entity.Ac
 		accountPrs entity.AccountPresentation
 	)
 
-	err := a.db.QueryRowContext(ctx, query, args...).Scan(&accountPrs.ID, &accountPrs.UserID, &accountPrs.AccountNumber, &accountPrs.Balance, &accountPrs.InterestRate)
+	err := a.db.QueryRowContext(ctx, query, args...).Scan(&accountPrs.ID, &accountPrs.UserID, &accountPrs.AccountNumber, &accountPrs.Balance, &accountPrs.InterestRate, &accountPrs.CreatedAt, &accountPrs.LastInterestPayout, &accountPrs.OverdraftLimit, &accountPrs.OverdraftInterestRate)
 	if err != nil {
 		log.Println(eventName, err)
 		return nil, errbank.TranslateDBError(err)
 	}
 
-	accountPrs.Balance, err = cryptox.DecryptAES(accountPrs.Balance, os.Getenv("AES_KEY"))
-	if err != nil {
-		return nil, err
-	}
-	accountPrs.InterestRate, err = cryptox.DecryptAES(accountPrs.InterestRate, os.Getenv("AES_KEY"))
-	if err != nil {
-		return nil, err
-	}
-
-	balance, err := strconv.ParseFloat(accountPrs.Balance, 64)
-	if err != nil {
-		return nil, err
-	}
-
-	interest, err := strconv.ParseFloat(accountPrs.InterestRate, 64)
-	if err != nil {
-		return nil, err
-	}
-
-	return &entity.Account{
-		ID:            accountPrs.ID,
-		UserID:        accountPrs.UserID,
-		AccountNumber: accountPrs.AccountNumber,
-		Balance:       balance,
-		InterestRate:  interest,
-	}, nil
+	return decryptAccount(accountPrs)
 }
+
 func (a accountRepo) UpdateBalance(ctx context.Context, account entity.Account, tx *sql.Tx) error {
 	var (
 		eventName = "repo.account.update_balance"
@@ -261,7 +242,7 @@ func (a accountRepo) GetListAccount(ctx context.Context, m *meta.Metadata) ([]en
 	var (
 		eventName = "repo.account.find_user_by_id"
 		query     = `
-		SELECT id, user_id, account_number, balance, interest_rate,created_at, last_interest_payout::timestamptz
+		SELECT id, user_id, account_number, balance, interest_rate,created_at, last_interest_payout::timestamptz, overdraft_limit, overdraft_interest_rate
 		FROM accounts
 		`
 		rows    *sql.Rows
@@ -289,7 +270,7 @@ func (a accountRepo) GetListAccount(ctx context.Context, m *meta.Metadata) ([]en
 	defer rows.Close()
 	for rows.Next() {
 		var accountPrs entity.AccountPresentation
-		err = rows.Scan(&accountPrs.ID, &accountPrs.UserID, &accountPrs.AccountNumber, &accountPrs.Balance, &accountPrs.InterestRate, &accountPrs.CreatedAt, &accountPrs.LastInterestPayout)
+		err = rows.Scan(&accountPrs.ID, &accountPrs.UserID, &accountPrs.AccountNumber, &accountPrs.Balance, &accountPrs.InterestRate, &accountPrs.CreatedAt, &accountPrs.LastInterestPayout, &accountPrs.OverdraftLimit, &accountPrs.OverdraftInterestRate)
 		if err != nil {
 			log.Println(eventName, err)
 			return nil, errbank.TranslateDBError(err)
@@ -313,19 +294,201 @@ func (a accountRepo) GetListAccount(ctx context.Context, m *meta.Metadata) ([]en
 			return nil, errbank.TranslateDBError(err)
 		}
 		account := &entity.Account{
-			ID:                 accountPrs.ID,
-			UserID:             accountPrs.UserID,
-			AccountNumber:      accountPrs.AccountNumber,
-			Balance:            balance,
-			InterestRate:       interest,
-			CreatedAt:          accountPrs.CreatedAt,
-			LastInterestPayout: accountPrs.LastInterestPayout,
+			ID:                    accountPrs.ID,
+			UserID:                accountPrs.UserID,
+			AccountNumber:         accountPrs.AccountNumber,
+			Balance:               balance,
+			InterestRate:          interest,
+			CreatedAt:             accountPrs.CreatedAt,
+			LastInterestPayout:    accountPrs.LastInterestPayout,
+			OverdraftLimit:        accountPrs.OverdraftLimit,
+			OverdraftInterestRate: accountPrs.OverdraftInterestRate,
+		}
+		results = append(results, *account)
+	}
+	return results, nil
+}
+
+// GetAccountBatch returns up to limit accounts ordered by id, starting after afterID.
+// Pass uuid.Nil to read the first batch and the last returned id to read the next one.
+func (a accountRepo) GetAccountBatch(ctx context.Context, afterID uuid.UUID, limit int) ([]entity.Account, error) {
+	var (
+		eventName = "repo.account.get_account_batch"
+		query     = `
+		SELECT id, user_id, account_number, balance, interest_rate, created_at, last_interest_payout::timestamptz, overdraft_limit, overdraft_interest_rate
+		FROM accounts
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+		`
+		args = []interface{}{
+			afterID,
+			limit,
+		}
+		results = make([]entity.Account, 0, limit)
+	)
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var accountPrs entity.AccountPresentation
+		err = rows.Scan(&accountPrs.ID, &accountPrs.UserID, &accountPrs.AccountNumber, &accountPrs.Balance, &accountPrs.InterestRate, &accountPrs.CreatedAt, &accountPrs.LastInterestPayout, &accountPrs.OverdraftLimit, &accountPrs.OverdraftInterestRate)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		account, err := decryptAccount(accountPrs)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, err
 		}
 		results = append(results, *account)
 	}
+	if err := rows.Err(); err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
 	return results, nil
 }
 
+// FindByIDForUpdate reads and locks the account inside tx, so the balance used
+// for the update is the one the transaction is serialized against.
+func (a accountRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Account, error) {
+	var (
+		eventName = "repo.account.find_by_id_for_update"
+		query     = `
+		SELECT id, user_id, account_number, balance, interest_rate, created_at, last_interest_payout::timestamptz, overdraft_limit, overdraft_interest_rate
+		FROM accounts
+		WHERE id = $1
+		FOR UPDATE
+		`
+		args = []interface{}{
+			id,
+		}
+		accountPrs entity.AccountPresentation
+	)
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&accountPrs.ID, &accountPrs.UserID, &accountPrs.AccountNumber, &accountPrs.Balance, &accountPrs.InterestRate, &accountPrs.CreatedAt, &accountPrs.LastInterestPayout, &accountPrs.OverdraftLimit, &accountPrs.OverdraftInterestRate)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return decryptAccount(accountPrs)
+}
+
+func (a accountRepo) FindByAccountNumber(ctx context.Context, accountNumber string) (*entity.Account, error) {
+	var (
+		eventName = "repo.account.find_by_account_number"
+		query     = `
+		SELECT id, user_id, account_number, balance, interest_rate, created_at, last_interest_payout::timestamptz, overdraft_limit, overdraft_interest_rate
+		FROM accounts
+		WHERE account_number = $1
+		`
+		args = []interface{}{
+			accountNumber,
+		}
+		accountPrs entity.AccountPresentation
+	)
+
+	err := a.db.QueryRowContext(ctx, query, args...).Scan(&accountPrs.ID, &accountPrs.UserID, &accountPrs.AccountNumber, &accountPrs.Balance, &accountPrs.InterestRate, &accountPrs.CreatedAt, &accountPrs.LastInterestPayout, &accountPrs.OverdraftLimit, &accountPrs.OverdraftInterestRate)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return decryptAccount(accountPrs)
+}
+
+func (a accountRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.Account, error) {
+	var (
+		eventName = "repo.account.find_by_id"
+		query     = `
+		SELECT id, user_id, account_number, balance, interest_rate, created_at, last_interest_payout::timestamptz, overdraft_limit, overdraft_interest_rate
+		FROM accounts
+		WHERE id = $1
+		`
+		args = []interface{}{
+			id,
+		}
+		accountPrs entity.AccountPresentation
+	)
+
+	err := a.db.QueryRowContext(ctx, query, args...).Scan(&accountPrs.ID, &accountPrs.UserID, &accountPrs.AccountNumber, &accountPrs.Balance, &accountPrs.InterestRate, &accountPrs.CreatedAt, &accountPrs.LastInterestPayout, &accountPrs.OverdraftLimit, &accountPrs.OverdraftInterestRate)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return decryptAccount(accountPrs)
+}
+
+func (a accountRepo) UpdateOverdraft(ctx context.Context, account entity.Account, tx *sql.Tx) error {
+	var (
+		eventName = "repo.account.update_overdraft"
+		query     = `
+		UPDATE accounts
+		SET overdraft_limit = $1, overdraft_interest_rate = $2
+		WHERE user_id = $3
+		`
+		args = []interface{}{
+			account.OverdraftLimit,
+			account.OverdraftInterestRate,
+			account.UserID,
+		}
+	)
+
+	if tx != nil {
+		_, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	} else {
+		_, err := a.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	}
+	return nil
+}
+
+func decryptAccount(accountPrs entity.AccountPresentation) (*entity.Account, error) {
+	var err error
+	accountPrs.Balance, err = cryptox.DecryptAES(accountPrs.Balance, os.Getenv("AES_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	accountPrs.InterestRate, err = cryptox.DecryptAES(accountPrs.InterestRate, os.Getenv("AES_KEY"))
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := strconv.ParseFloat(accountPrs.Balance, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	interest, err := strconv.ParseFloat(accountPrs.InterestRate, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Account{
+		ID:                    accountPrs.ID,
+		UserID:                accountPrs.UserID,
+		AccountNumber:         accountPrs.AccountNumber,
+		Balance:               balance,
+		InterestRate:          interest,
+		CreatedAt:             accountPrs.CreatedAt,
+		LastInterestPayout:    accountPrs.LastInterestPayout,
+		OverdraftLimit:        accountPrs.OverdraftLimit,
+		OverdraftInterestRate: accountPrs.OverdraftInterestRate,
+	}, nil
+}
+
 func NewAccountRepositories(db *sql.DB) AccountRepositories {
 	return accountRepo{db: db}
 }
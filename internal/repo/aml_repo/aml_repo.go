@@ -0,0 +1,264 @@
+package amlrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/lib/pq"
+)
+
+type AMLRepositories interface {
+	GetCashTransactions(ctx context.Context, minAmount, maxAmount float64, since time.Time, alertType string) ([]entity.AMLTransaction, error)
+	CreateAlert(ctx context.Context, alert entity.AMLAlert) (bool, error)
+	GetListAlert(ctx context.Context, status, alertType string, m meta.Metadata) ([]entity.AMLAlert, error)
+	FindAlertByID(ctx context.Context, id uuid.UUID) (*entity.AMLAlert, error)
+	UpdateAlertStatus(ctx context.Context, alert entity.AMLAlert, fromStatus string, tx *sql.Tx) error
+}
+
+type amlRepo struct {
+	db *sql.DB
+}
+
+func NewAMLRepositories(db *sql.DB) AMLRepositories {
+	return amlRepo{db: db}
+}
+
+const amlAlertColumns = `al.id, al.type, al.account_id, a.account_number, al.user_id, u.user_name, u.full_name,
+	al.transaction_ids, al.transaction_count, al.total_amount, al.window_start, al.window_end, al.status,
+	COALESCE(al.reviewed_by, ''), COALESCE(al.note, ''), al.dedupe_key, al.created_at, al.updated_at`
+
+const amlAlertFrom = `
+	FROM aml_alerts al
+	JOIN accounts a ON a.id = al.account_id
+	JOIN users u ON u.id = al.user_id`
+
+// GetCashTransactions returns the completed deposits and withdrawals created since
+// the given time with an amount of at least minAmount and, when maxAmount is set,
+// below maxAmount. Transactions already part of an alert of alertType are left out.
+func (r amlRepo) GetCashTransactions(ctx context.Context, minAmount, maxAmount float64, since time.Time, alertType string) ([]entity.AMLTransaction, error) {
+	var (
+		eventName = "repo.aml.get_cash_transactions"
+		query     = `
+		SELECT t.id, t.account_id, a.user_id, t.action, t.amount, t.created_at
+		FROM transactions t
+		JOIN accounts a ON a.id = t.account_id
+		WHERE t.status = 'COMPLETED' AND t.action IN ('DEPOSIT', 'WITHDRAWAL') AND t.created_at >= $1
+		AND t.amount >= $2 AND ($3 <= 0 OR t.amount < $3)
+		AND NOT EXISTS (SELECT 1 FROM aml_alerts al WHERE al.type = $4 AND al.transaction_ids @> ARRAY[t.id])
+		ORDER BY t.account_id, t.created_at, t.id
+		`
+		args = []interface{}{
+			since,
+			minAmount,
+			maxAmount,
+			alertType,
+		}
+		results []entity.AMLTransaction
+	)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var transaction entity.AMLTransaction
+		if err := rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.UserID, &transaction.Action, &transaction.Amount, &transaction.CreatedAt); err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return results, nil
+}
+
+// CreateAlert stores the alert unless one with the same dedupe key exists, it
+// returns whether the alert was created.
+func (r amlRepo) CreateAlert(ctx context.Context, alert entity.AMLAlert) (bool, error) {
+	var (
+		eventName = "repo.aml.create_alert"
+		query     = `
+		INSERT INTO aml_alerts (
+		id,
+		type,
+		account_id,
+		user_id,
+		transaction_ids,
+		transaction_count,
+		total_amount,
+		window_start,
+		window_end,
+		status,
+		dedupe_key,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$12)
+		ON CONFLICT (dedupe_key) DO NOTHING
+		`
+		transactionIDs = make([]string, len(alert.TransactionIDs))
+	)
+
+	for i, id := range alert.TransactionIDs {
+		transactionIDs[i] = id.String()
+	}
+	args := []interface{}{
+		alert.ID,
+		alert.Type,
+		alert.AccountID,
+		alert.UserID,
+		pq.Array(transactionIDs),
+		alert.TransactionCount,
+		alert.TotalAmount,
+		alert.WindowStart,
+		alert.WindowEnd,
+		alert.Status,
+		alert.DedupeKey,
+		alert.CreatedAt,
+	}
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+func (r amlRepo) GetListAlert(ctx context.Context, status, alertType string, m meta.Metadata) ([]entity.AMLAlert, error) {
+	var (
+		eventName = "repo.aml.get_list_alert"
+		start     sql.NullTime
+		end       sql.NullTime
+		results   []entity.AMLAlert
+	)
+
+	if m.DateRange != nil {
+		start = sql.NullTime{Time: m.DateRange.Start, Valid: true}
+		end = sql.NullTime{Time: m.DateRange.End.AddDate(0, 0, 1), Valid: true}
+	}
+
+	var (
+		query = `
+		SELECT ` + amlAlertColumns + amlAlertFrom + `
+		WHERE ($1 = '' OR al.status = $1) AND ($2 = '' OR al.type = $2)
+		AND ($3::timestamptz IS NULL OR al.created_at >= $3) AND ($4::timestamptz IS NULL OR al.created_at < $4)
+		ORDER BY al.created_at DESC, al.id
+		OFFSET $5 LIMIT $6
+		`
+		args = []interface{}{
+			status,
+			alertType,
+			start,
+			end,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+	)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *alert)
+	}
+	return results, nil
+}
+
+func (r amlRepo) FindAlertByID(ctx context.Context, id uuid.UUID) (*entity.AMLAlert, error) {
+	var (
+		eventName = "repo.aml.find_alert_by_id"
+		query     = `
+		SELECT ` + amlAlertColumns + amlAlertFrom + `
+		WHERE al.id = $1
+		`
+	)
+
+	alert, err := scanAlert(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return alert, nil
+}
+
+// UpdateAlertStatus moves the alert on only while it still is at fromStatus, a
+// concurrent review of the same alert gets an ErrConflict.
+func (r amlRepo) UpdateAlertStatus(ctx context.Context, alert entity.AMLAlert, fromStatus string, tx *sql.Tx) error {
+	var (
+		eventName = "repo.aml.update_alert_status"
+		query     = `
+		UPDATE aml_alerts
+		SET status = $1, reviewed_by = $2, note = $3, updated_at = now()
+		WHERE id = $4 AND status = $5
+		`
+		args = []interface{}{
+			alert.Status,
+			alert.ReviewedBy,
+			alert.Note,
+			alert.ID,
+			fromStatus,
+		}
+		res sql.Result
+		err error
+	)
+
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		res, err = r.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errbank.NewErrConflict("aml alert is no longer " + fromStatus)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAlert(row scanner) (*entity.AMLAlert, error) {
+	var (
+		alert          entity.AMLAlert
+		transactionIDs pq.StringArray
+	)
+	err := row.Scan(&alert.ID, &alert.Type, &alert.AccountID, &alert.AccountNumber, &alert.UserID, &alert.Username, &alert.Fullname,
+		&transactionIDs, &alert.TransactionCount, &alert.TotalAmount, &alert.WindowStart, &alert.WindowEnd, &alert.Status,
+		&alert.ReviewedBy, &alert.Note, &alert.DedupeKey, &alert.CreatedAt, &alert.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range transactionIDs {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		alert.TransactionIDs = append(alert.TransactionIDs, parsed)
+	}
+	return &alert, nil
+}
@@ -0,0 +1,206 @@
+package approvalrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+)
+
+type ApprovalRepositories interface {
+	Create(ctx context.Context, request entity.ApprovalRequest, tx *sql.Tx) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.ApprovalRequest, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.ApprovalRequest, error)
+	GetList(ctx context.Context, status, operation string, m meta.Metadata) ([]entity.ApprovalRequest, error)
+	Update(ctx context.Context, request entity.ApprovalRequest, tx *sql.Tx) error
+}
+
+type approvalRepo struct {
+	db *sql.DB
+}
+
+func NewApprovalRepositories(db *sql.DB) ApprovalRepositories {
+	return approvalRepo{db: db}
+}
+
+const approvalColumns = `id, operation, payload, amount, maker, status, COALESCE(checker, ''), COALESCE(note, ''), COALESCE(message, ''), decided_at, executed_at, created_at, updated_at`
+
+func (a approvalRepo) Create(ctx context.Context, request entity.ApprovalRequest, tx *sql.Tx) error {
+	var (
+		eventName = "repo.approval.create"
+		query     = `
+		INSERT INTO approval_requests (
+		id,
+		operation,
+		payload,
+		amount,
+		maker,
+		status,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,now(),now())
+	`
+		args = []interface{}{
+			request.ID,
+			request.Operation,
+			[]byte(request.Payload),
+			request.Amount,
+			request.Maker,
+			request.Status,
+		}
+		err error
+	)
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = a.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (a approvalRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.ApprovalRequest, error) {
+	var (
+		eventName = "repo.approval.find_by_id"
+		query     = `
+		SELECT ` + approvalColumns + `
+		FROM approval_requests
+		WHERE id = $1
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	request, err := scanRequest(a.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return request, nil
+}
+
+func (a approvalRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.ApprovalRequest, error) {
+	var (
+		eventName = "repo.approval.find_by_id_for_update"
+		query     = `
+		SELECT ` + approvalColumns + `
+		FROM approval_requests
+		WHERE id = $1
+		FOR UPDATE
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	request, err := scanRequest(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return request, nil
+}
+
+// GetList returns the requests matching status and operation, an empty filter matching all, newest first.
+func (a approvalRepo) GetList(ctx context.Context, status, operation string, m meta.Metadata) ([]entity.ApprovalRequest, error) {
+	var (
+		eventName = "repo.approval.get_list"
+		query     = `
+		SELECT ` + approvalColumns + `
+		FROM approval_requests
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR operation = $2)
+		ORDER BY created_at DESC
+		OFFSET $3 LIMIT $4
+		`
+		args = []interface{}{
+			status,
+			operation,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+		results []entity.ApprovalRequest
+	)
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		request, err := scanRequest(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *request)
+	}
+	return results, nil
+}
+
+func (a approvalRepo) Update(ctx context.Context, request entity.ApprovalRequest, tx *sql.Tx) error {
+	var (
+		eventName = "repo.approval.update"
+		query     = `
+		UPDATE approval_requests
+		SET status = $1, checker = $2, note = $3, message = $4, decided_at = $5, executed_at = $6, updated_at = now()
+		WHERE id = $7
+		`
+		args = []interface{}{
+			request.Status,
+			request.Checker,
+			request.Note,
+			request.Message,
+			request.DecidedAt,
+			request.ExecutedAt,
+			request.ID,
+		}
+		err error
+	)
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = a.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRequest(row scanner) (*entity.ApprovalRequest, error) {
+	var (
+		request    entity.ApprovalRequest
+		payload    []byte
+		decidedAt  sql.NullTime
+		executedAt sql.NullTime
+	)
+	err := row.Scan(&request.ID, &request.Operation, &payload, &request.Amount, &request.Maker, &request.Status, &request.Checker, &request.Note, &request.Message, &decidedAt, &executedAt, &request.CreatedAt, &request.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	request.Payload = payload
+	if decidedAt.Valid {
+		request.DecidedAt = &decidedAt.Time
+	}
+	if executedAt.Valid {
+		request.ExecutedAt = &executedAt.Time
+	}
+	return &request, nil
+}
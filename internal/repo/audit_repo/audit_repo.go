@@ -0,0 +1,228 @@
+package auditrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+)
+
+type AuditRepositories interface {
+	LockChain(ctx context.Context, tx *sql.Tx) error
+	FindLast(ctx context.Context, tx *sql.Tx) (*entity.AuditLog, error)
+	Create(ctx context.Context, entry entity.AuditLog, tx *sql.Tx) error
+	GetList(ctx context.Context, m meta.Metadata) ([]entity.AuditLog, error)
+	GetBatch(ctx context.Context, afterSequence int64, size int) ([]entity.AuditLog, error)
+}
+
+type auditRepo struct {
+	db *sql.DB
+}
+
+func NewAuditRepositories(db *sql.DB) AuditRepositories {
+	return auditRepo{db: db}
+}
+
+const auditColumns = `sequence, actor, action, target, before, after, ip, created_at, prev_hash, hash`
+
+// LockChain serializes the appends until tx ends, so every entry links to the
+// one committed right before it.
+func (a auditRepo) LockChain(ctx context.Context, tx *sql.Tx) error {
+	var (
+		eventName = "repo.audit.lock_chain"
+		query     = `SELECT pg_advisory_xact_lock(hashtext('audit_logs'))`
+	)
+
+	_, err := tx.ExecContext(ctx, query)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (a auditRepo) FindLast(ctx context.Context, tx *sql.Tx) (*entity.AuditLog, error) {
+	var (
+		eventName = "repo.audit.find_last"
+		query     = `
+		SELECT ` + auditColumns + `
+		FROM audit_logs
+		ORDER BY sequence DESC
+		LIMIT 1
+		`
+	)
+
+	entry, err := scanAuditLog(tx.QueryRowContext(ctx, query))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return entry, nil
+}
+
+func (a auditRepo) Create(ctx context.Context, entry entity.AuditLog, tx *sql.Tx) error {
+	var (
+		eventName = "repo.audit.create"
+		query     = `
+		INSERT INTO audit_logs (
+		sequence,
+		actor,
+		action,
+		target,
+		before,
+		after,
+		ip,
+		created_at,
+		prev_hash,
+		hash
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+	`
+		args = []interface{}{
+			entry.Sequence,
+			entry.Actor,
+			entry.Action,
+			entry.Target,
+			string(entry.Before),
+			string(entry.After),
+			entry.IP,
+			entry.CreatedAt,
+			entry.PrevHash,
+			entry.Hash,
+		}
+	)
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// GetList returns a page of the log, filtered on actor, action or target with
+// filter_by and filter, on a target fragment with search and on a created_at
+// date range (both days included), ordered by sequence.
+func (a auditRepo) GetList(ctx context.Context, m meta.Metadata) ([]entity.AuditLog, error) {
+	var (
+		eventName = "repo.audit.get_list"
+		order     = "ASC"
+		start     sql.NullTime
+		end       sql.NullTime
+		actor     string
+		action    string
+		target    string
+		results   []entity.AuditLog
+	)
+
+	if m.OrderType == meta.SortDescending {
+		order = "DESC"
+	}
+	if m.DateRange != nil {
+		start = sql.NullTime{Time: m.DateRange.Start, Valid: true}
+		end = sql.NullTime{Time: m.DateRange.End.AddDate(0, 0, 1), Valid: true}
+	}
+	switch m.FilterBy {
+	case "actor":
+		actor = m.Filter
+	case "action":
+		action = m.Filter
+	case "target":
+		target = m.Filter
+	}
+
+	var (
+		query = `
+		SELECT ` + auditColumns + `
+		FROM audit_logs
+		WHERE ($1 = '' OR actor = $1) AND ($2 = '' OR action = $2) AND ($3 = '' OR target = $3)
+		AND ($4 = '' OR target ILIKE '%' || $4 || '%')
+		AND ($5::timestamptz IS NULL OR created_at >= $5) AND ($6::timestamptz IS NULL OR created_at < $6)
+		ORDER BY sequence ` + order + `
+		OFFSET $7 LIMIT $8
+		`
+		args = []interface{}{
+			actor,
+			action,
+			target,
+			m.Search,
+			start,
+			end,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+	)
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		entry, err := scanAuditLog(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *entry)
+	}
+	return results, nil
+}
+
+// GetBatch returns up to size entries following afterSequence, in chain order.
+func (a auditRepo) GetBatch(ctx context.Context, afterSequence int64, size int) ([]entity.AuditLog, error) {
+	var (
+		eventName = "repo.audit.get_batch"
+		query     = `
+		SELECT ` + auditColumns + `
+		FROM audit_logs
+		WHERE sequence > $1
+		ORDER BY sequence
+		LIMIT $2
+		`
+		args = []interface{}{
+			afterSequence,
+			size,
+		}
+		results []entity.AuditLog
+	)
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		entry, err := scanAuditLog(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *entry)
+	}
+	return results, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAuditLog(row scanner) (*entity.AuditLog, error) {
+	var (
+		entry  entity.AuditLog
+		before []byte
+		after  []byte
+	)
+	err := row.Scan(&entry.Sequence, &entry.Actor, &entry.Action, &entry.Target, &before, &after, &entry.IP, &entry.CreatedAt, &entry.PrevHash, &entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+	entry.Before = before
+	entry.After = after
+	return &entry, nil
+}
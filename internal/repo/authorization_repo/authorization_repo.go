@@ -12,8 +12,13 @@ import (
 
 type AuthorizationRepositories interface {
 	FindByUserID(ctx context.Context, userID uuid.UUID) (*entity.Authorization, error)
-	UpdatePassword(ctx context.Context, authorization entity.Authorization) error
+	UpdatePassword(ctx context.Context, authorization entity.Authorization, tx *sql.Tx) error
 	Create(ctx context.Context, authorization entity.Authorization, tx *sql.Tx) error
+	CreatePasswordHistory(ctx context.Context, userID uuid.UUID, password string, tx *sql.Tx) error
+	GetPasswordHistory(ctx context.Context, userID uuid.UUID, limit int) ([]string, error)
+	CreateResetToken(ctx context.Context, token entity.PasswordResetToken, tx *sql.Tx) error
+	RevokeResetTokens(ctx context.Context, userID uuid.UUID, tx *sql.Tx) error
+	UseResetToken(ctx context.Context, tokenHash string, tx *sql.Tx) (*entity.PasswordResetToken, error)
 }
 
 type authorizationrepo struct {
@@ -62,21 +67,26 @@ func (a authorizationrepo) Create(ctx context.Context, authorization entity.Auth
 	return nil
 }
 
-func (a authorizationrepo) UpdatePassword(ctx context.Context, authorization entity.Authorization) error {
+func (a authorizationrepo) UpdatePassword(ctx context.Context, authorization entity.Authorization, tx *sql.Tx) error {
 	var (
 		eventName = "repo.authorization.update_password"
 		query     = `
 		UPDATE authorizations
-		SET password = $1
+		SET password = $1, password_changed_at = now()
 		WHERE user_id = $2
 		`
 		args = []interface{}{
 			authorization.Password,
 			authorization.UserID,
 		}
+		err error
 	)
 
-	_, err := a.db.ExecContext(ctx, query, args...)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = a.db.ExecContext(ctx, query, args...)
+	}
 	if err != nil {
 		log.Println(eventName, err)
 		return errbank.TranslateDBError(err)
@@ -88,7 +98,7 @@ func (a authorizationrepo) FindByUserID(ctx context.Context, userID uuid.UUID) (
 	var (
 		eventName = "repo.authorization.find_user_by_id"
 		query     = `
-		SELECT id, user_id, password
+		SELECT id, user_id, password, password_changed_at, created_at
 		FROM authorizations
 		WHERE user_id = $1
 		`
@@ -98,10 +108,160 @@ func (a authorizationrepo) FindByUserID(ctx context.Context, userID uuid.UUID) (
 		auth entity.Authorization
 	)
 
-	err := a.db.QueryRowContext(ctx, query, args...).Scan(&auth.ID, &auth.UserID, &auth.Password)
+	err := a.db.QueryRowContext(ctx, query, args...).Scan(&auth.ID, &auth.UserID, &auth.Password, &auth.PasswordChangedAt, &auth.CreatedAt)
 	if err != nil {
 		log.Println(eventName, err)
 		return nil, errbank.TranslateDBError(err)
 	}
 	return &auth, nil
 }
+
+func (a authorizationrepo) CreatePasswordHistory(ctx context.Context, userID uuid.UUID, password string, tx *sql.Tx) error {
+	var (
+		eventName = "repo.authorization.create_password_history"
+		query     = `
+		INSERT INTO password_history (
+		id,
+		user_id,
+		password
+		)
+		VALUES ($1,$2,$3)
+		`
+		args = []interface{}{
+			uuid.New(),
+			userID,
+			password,
+		}
+		err error
+	)
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = a.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// GetPasswordHistory returns the hashes of the latest passwords of the user, newest first.
+func (a authorizationrepo) GetPasswordHistory(ctx context.Context, userID uuid.UUID, limit int) ([]string, error) {
+	var (
+		eventName = "repo.authorization.get_password_history"
+		query     = `
+		SELECT password
+		FROM password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+		`
+		args = []interface{}{
+			userID,
+			limit,
+		}
+		results []string
+	)
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var password string
+		if err := rows.Scan(&password); err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, password)
+	}
+	return results, nil
+}
+
+func (a authorizationrepo) CreateResetToken(ctx context.Context, token entity.PasswordResetToken, tx *sql.Tx) error {
+	var (
+		eventName = "repo.authorization.create_reset_token"
+		query     = `
+		INSERT INTO password_reset_tokens (
+		id,
+		user_id,
+		token_hash,
+		requested_by,
+		expires_at,
+		created_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6)
+		`
+		args = []interface{}{
+			token.ID,
+			token.UserID,
+			token.TokenHash,
+			token.RequestedBy,
+			token.ExpiresAt,
+			token.CreatedAt,
+		}
+		err error
+	)
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = a.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// RevokeResetTokens spends the unused reset tokens of the user.
+func (a authorizationrepo) RevokeResetTokens(ctx context.Context, userID uuid.UUID, tx *sql.Tx) error {
+	var (
+		eventName = "repo.authorization.revoke_reset_tokens"
+		query     = `
+		UPDATE password_reset_tokens
+		SET used_at = now()
+		WHERE user_id = $1 AND used_at IS NULL
+		`
+		err error
+	)
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, userID)
+	} else {
+		_, err = a.db.ExecContext(ctx, query, userID)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// UseResetToken spends an unused, unexpired token and returns it. An unknown,
+// used or expired token is an ErrNotFound.
+func (a authorizationrepo) UseResetToken(ctx context.Context, tokenHash string, tx *sql.Tx) (*entity.PasswordResetToken, error) {
+	var (
+		eventName = "repo.authorization.use_reset_token"
+		query     = `
+		UPDATE password_reset_tokens
+		SET used_at = now()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		RETURNING id, user_id, requested_by, expires_at, used_at, created_at
+		`
+		token entity.PasswordResetToken
+	)
+
+	err := tx.QueryRowContext(ctx, query, tokenHash).Scan(&token.ID, &token.UserID, &token.RequestedBy, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	token.TokenHash = tokenHash
+	return &token, nil
+}
@@ -0,0 +1,274 @@
+package feerepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+)
+
+type FeeRepositories interface {
+	GetListSchedule(ctx context.Context) ([]entity.FeeSchedule, error)
+	FindScheduleByCode(ctx context.Context, code string) (*entity.FeeSchedule, error)
+	UpdateSchedule(ctx context.Context, schedule entity.FeeSchedule, tx *sql.Tx) error
+	Create(ctx context.Context, fee entity.Fee, tx *sql.Tx) (bool, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Fee, error)
+	UpdateWaived(ctx context.Context, fee entity.Fee, tx *sql.Tx) error
+	GetListByAccountID(ctx context.Context, accountID uuid.UUID, m meta.Metadata) ([]entity.Fee, error)
+}
+
+type feeRepo struct {
+	db *sql.DB
+}
+
+func NewFeeRepositories(db *sql.DB) FeeRepositories {
+	return feeRepo{db: db}
+}
+
+func (f feeRepo) GetListSchedule(ctx context.Context) ([]entity.FeeSchedule, error) {
+	var (
+		eventName = "repo.fee.get_list_schedule"
+		query     = `
+		SELECT code, amount, free_count, min_balance, active, updated_at
+		FROM fee_schedules
+		ORDER BY code
+		`
+		results []entity.FeeSchedule
+	)
+
+	rows, err := f.db.QueryContext(ctx, query)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var schedule entity.FeeSchedule
+		err = rows.Scan(&schedule.Code, &schedule.Amount, &schedule.FreeCount, &schedule.MinBalance, &schedule.Active, &schedule.UpdatedAt)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, schedule)
+	}
+	return results, nil
+}
+
+func (f feeRepo) FindScheduleByCode(ctx context.Context, code string) (*entity.FeeSchedule, error) {
+	var (
+		eventName = "repo.fee.find_schedule_by_code"
+		query     = `
+		SELECT code, amount, free_count, min_balance, active, updated_at
+		FROM fee_schedules
+		WHERE code = $1
+		`
+		args = []interface{}{
+			code,
+		}
+		schedule entity.FeeSchedule
+	)
+
+	err := f.db.QueryRowContext(ctx, query, args...).Scan(&schedule.Code, &schedule.Amount, &schedule.FreeCount, &schedule.MinBalance, &schedule.Active, &schedule.UpdatedAt)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return &schedule, nil
+}
+
+func (f feeRepo) UpdateSchedule(ctx context.Context, schedule entity.FeeSchedule, tx *sql.Tx) error {
+	var (
+		eventName = "repo.fee.update_schedule"
+		query     = `
+		UPDATE fee_schedules
+		SET amount = $1, free_count = $2, min_balance = $3, active = $4, updated_at = now()
+		WHERE code = $5
+		`
+		args = []interface{}{
+			schedule.Amount,
+			schedule.FreeCount,
+			schedule.MinBalance,
+			schedule.Active,
+			schedule.Code,
+		}
+		res sql.Result
+		err error
+	)
+
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		res, err = f.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errbank.NewErrNotFound("data not found!")
+	}
+	return nil
+}
+
+// Create records a charged fee. It returns false without error when the fee
+// was already charged for the period, so monthly fees are never charged twice.
+func (f feeRepo) Create(ctx context.Context, fee entity.Fee, tx *sql.Tx) (bool, error) {
+	var (
+		eventName = "repo.fee.create"
+		query     = `
+		INSERT INTO fees (
+		id,
+		account_id,
+		transaction_id,
+		code,
+		amount,
+		period,
+		status,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,now(),now())
+		ON CONFLICT DO NOTHING
+	`
+		args = []interface{}{
+			fee.ID,
+			fee.AccountID,
+			fee.TransactionID,
+			fee.Code,
+			fee.Amount,
+			fee.Period,
+			fee.Status,
+		}
+		res sql.Result
+		err error
+	)
+
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		res, err = f.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		log.Println(eventName, err)
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (f feeRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Fee, error) {
+	var (
+		eventName = "repo.fee.find_by_id_for_update"
+		query     = `
+		SELECT id, account_id, transaction_id, code, amount, period, status, COALESCE(waived_by, ''), COALESCE(waive_reason, ''), waive_transaction_id, created_at, updated_at
+		FROM fees
+		WHERE id = $1
+		FOR UPDATE
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	fee, err := scanFee(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return fee, nil
+}
+
+func (f feeRepo) UpdateWaived(ctx context.Context, fee entity.Fee, tx *sql.Tx) error {
+	var (
+		eventName = "repo.fee.update_waived"
+		query     = `
+		UPDATE fees
+		SET status = $1, waived_by = $2, waive_reason = $3, waive_transaction_id = $4, updated_at = now()
+		WHERE id = $5
+		`
+		args = []interface{}{
+			fee.Status,
+			fee.WaivedBy,
+			fee.WaiveReason,
+			fee.WaiveTransactionID,
+			fee.ID,
+		}
+	)
+
+	if tx != nil {
+		_, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	} else {
+		_, err := f.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	}
+	return nil
+}
+
+func (f feeRepo) GetListByAccountID(ctx context.Context, accountID uuid.UUID, m meta.Metadata) ([]entity.Fee, error) {
+	var (
+		eventName = "repo.fee.get_list_by_account_id"
+		query     = `
+		SELECT id, account_id, transaction_id, code, amount, period, status, COALESCE(waived_by, ''), COALESCE(waive_reason, ''), waive_transaction_id, created_at, updated_at
+		FROM fees
+		WHERE account_id = $1
+		ORDER BY created_at DESC
+		OFFSET $2 LIMIT $3
+		`
+		args = []interface{}{
+			accountID,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+		results []entity.Fee
+	)
+
+	rows, err := f.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		fee, err := scanFee(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *fee)
+	}
+	return results, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFee(row scanner) (*entity.Fee, error) {
+	var (
+		fee                entity.Fee
+		waiveTransactionID uuid.NullUUID
+	)
+	err := row.Scan(&fee.ID, &fee.AccountID, &fee.TransactionID, &fee.Code, &fee.Amount, &fee.Period, &fee.Status, &fee.WaivedBy, &fee.WaiveReason, &waiveTransactionID, &fee.CreatedAt, &fee.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if waiveTransactionID.Valid {
+		fee.WaiveTransactionID = &waiveTransactionID.UUID
+	}
+	return &fee, nil
+}
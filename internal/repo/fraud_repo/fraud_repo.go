@@ -0,0 +1,337 @@
+package fraudrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+)
+
+type FraudRepositories interface {
+	GetListRule(ctx context.Context) ([]entity.FraudRule, error)
+	UpdateRule(ctx context.Context, rule entity.FraudRule, tx *sql.Tx) error
+	CreateCase(ctx context.Context, fraudCase entity.FraudCase, tx *sql.Tx) error
+	FindCaseByID(ctx context.Context, id uuid.UUID) (*entity.FraudCase, error)
+	FindCaseByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.FraudCase, error)
+	GetListCase(ctx context.Context, status string, m meta.Metadata) ([]entity.FraudCase, error)
+	ResolveCase(ctx context.Context, fraudCase entity.FraudCase, tx *sql.Tx) error
+	HasDevices(ctx context.Context, userID uuid.UUID) (bool, error)
+	FindDevice(ctx context.Context, userID uuid.UUID, device string) (bool, error)
+	TouchDevice(ctx context.Context, userID uuid.UUID, device string, tx *sql.Tx) error
+}
+
+type fraudRepo struct {
+	db *sql.DB
+}
+
+func NewFraudRepositories(db *sql.DB) FraudRepositories {
+	return fraudRepo{db: db}
+}
+
+const fraudCaseColumns = `id, account_id, user_id, transaction_id, action, amount, decision, hits, device, status, COALESCE(resolved_by, ''), COALESCE(note, ''), created_at, resolved_at`
+
+func (f fraudRepo) GetListRule(ctx context.Context) ([]entity.FraudRule, error) {
+	var (
+		eventName = "repo.fraud.get_list_rule"
+		query     = `
+		SELECT code, action, threshold, window_minutes, active, updated_at
+		FROM fraud_rules
+		ORDER BY code
+		`
+		results []entity.FraudRule
+	)
+
+	rows, err := f.db.QueryContext(ctx, query)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rule entity.FraudRule
+		if err := rows.Scan(&rule.Code, &rule.Action, &rule.Threshold, &rule.WindowMinutes, &rule.Active, &rule.UpdatedAt); err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, rule)
+	}
+	return results, nil
+}
+
+func (f fraudRepo) UpdateRule(ctx context.Context, rule entity.FraudRule, tx *sql.Tx) error {
+	var (
+		eventName = "repo.fraud.update_rule"
+		query     = `
+		UPDATE fraud_rules
+		SET action = $1, threshold = $2, window_minutes = $3, active = $4, updated_at = now()
+		WHERE code = $5
+		`
+		args = []interface{}{
+			rule.Action,
+			rule.Threshold,
+			rule.WindowMinutes,
+			rule.Active,
+			rule.Code,
+		}
+		res sql.Result
+		err error
+	)
+
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		res, err = f.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errbank.NewErrNotFound("data not found!")
+	}
+	return nil
+}
+
+func (f fraudRepo) CreateCase(ctx context.Context, fraudCase entity.FraudCase, tx *sql.Tx) error {
+	var (
+		eventName = "repo.fraud.create_case"
+		query     = `
+		INSERT INTO fraud_cases (
+		id,
+		account_id,
+		user_id,
+		transaction_id,
+		action,
+		amount,
+		decision,
+		hits,
+		device,
+		status,
+		created_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+		`
+	)
+
+	hits, err := json.Marshal(fraudCase.Hits)
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	args := []interface{}{
+		fraudCase.ID,
+		fraudCase.AccountID,
+		fraudCase.UserID,
+		fraudCase.TransactionID,
+		fraudCase.Action,
+		fraudCase.Amount,
+		fraudCase.Decision,
+		hits,
+		fraudCase.Device,
+		fraudCase.Status,
+		fraudCase.CreatedAt,
+	}
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = f.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (f fraudRepo) FindCaseByID(ctx context.Context, id uuid.UUID) (*entity.FraudCase, error) {
+	var (
+		eventName = "repo.fraud.find_case_by_id"
+		query     = `
+		SELECT ` + fraudCaseColumns + `
+		FROM fraud_cases
+		WHERE id = $1
+		`
+	)
+
+	fraudCase, err := scanCase(f.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return fraudCase, nil
+}
+
+func (f fraudRepo) FindCaseByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.FraudCase, error) {
+	var (
+		eventName = "repo.fraud.find_case_by_id_for_update"
+		query     = `
+		SELECT ` + fraudCaseColumns + `
+		FROM fraud_cases
+		WHERE id = $1
+		FOR UPDATE
+		`
+	)
+
+	fraudCase, err := scanCase(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return fraudCase, nil
+}
+
+func (f fraudRepo) GetListCase(ctx context.Context, status string, m meta.Metadata) ([]entity.FraudCase, error) {
+	var (
+		eventName = "repo.fraud.get_list_case"
+		query     = `
+		SELECT ` + fraudCaseColumns + `
+		FROM fraud_cases
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC
+		OFFSET $2 LIMIT $3
+		`
+		args = []interface{}{
+			status,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+		results []entity.FraudCase
+	)
+
+	rows, err := f.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		fraudCase, err := scanCase(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *fraudCase)
+	}
+	return results, nil
+}
+
+func (f fraudRepo) ResolveCase(ctx context.Context, fraudCase entity.FraudCase, tx *sql.Tx) error {
+	var (
+		eventName = "repo.fraud.resolve_case"
+		query     = `
+		UPDATE fraud_cases
+		SET status = $1, resolved_by = $2, note = $3, resolved_at = $4
+		WHERE id = $5
+		`
+		args = []interface{}{
+			fraudCase.Status,
+			fraudCase.ResolvedBy,
+			fraudCase.Note,
+			fraudCase.ResolvedAt,
+			fraudCase.ID,
+		}
+		err error
+	)
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = f.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (f fraudRepo) HasDevices(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var (
+		eventName = "repo.fraud.has_devices"
+		query     = `
+		SELECT EXISTS (SELECT 1 FROM user_devices WHERE user_id = $1)
+		`
+		found bool
+	)
+
+	err := f.db.QueryRowContext(ctx, query, userID).Scan(&found)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	return found, nil
+}
+
+func (f fraudRepo) FindDevice(ctx context.Context, userID uuid.UUID, device string) (bool, error) {
+	var (
+		eventName = "repo.fraud.find_device"
+		query     = `
+		SELECT EXISTS (SELECT 1 FROM user_devices WHERE user_id = $1 AND device = $2)
+		`
+		found bool
+	)
+
+	err := f.db.QueryRowContext(ctx, query, userID, device).Scan(&found)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	return found, nil
+}
+
+// TouchDevice records the device as known for the user.
+func (f fraudRepo) TouchDevice(ctx context.Context, userID uuid.UUID, device string, tx *sql.Tx) error {
+	var (
+		eventName = "repo.fraud.touch_device"
+		query     = `
+		INSERT INTO user_devices (user_id, device)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, device) DO UPDATE SET last_seen_at = now()
+		`
+		args = []interface{}{
+			userID,
+			device,
+		}
+		err error
+	)
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = f.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCase(row scanner) (*entity.FraudCase, error) {
+	var (
+		fraudCase     entity.FraudCase
+		transactionID uuid.NullUUID
+		hits          []byte
+	)
+	err := row.Scan(&fraudCase.ID, &fraudCase.AccountID, &fraudCase.UserID, &transactionID, &fraudCase.Action, &fraudCase.Amount, &fraudCase.Decision, &hits, &fraudCase.Device, &fraudCase.Status, &fraudCase.ResolvedBy, &fraudCase.Note, &fraudCase.CreatedAt, &fraudCase.ResolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	if transactionID.Valid {
+		fraudCase.TransactionID = &transactionID.UUID
+	}
+	if err := json.Unmarshal(hits, &fraudCase.Hits); err != nil {
+		return nil, err
+	}
+	return &fraudCase, nil
+}
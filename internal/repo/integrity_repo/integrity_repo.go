@@ -0,0 +1,227 @@
+package integrityrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+)
+
+type IntegrityRepositories interface {
+	UpsertOpen(ctx context.Context, discrepancy entity.BalanceDiscrepancy, tx *sql.Tx) (*entity.BalanceDiscrepancy, error)
+	ClearOpenByAccountID(ctx context.Context, accountID uuid.UUID, tx *sql.Tx) error
+	NeedsOpeningBalance(ctx context.Context, accountID uuid.UUID, tx *sql.Tx) (bool, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.BalanceDiscrepancy, error)
+	Resolve(ctx context.Context, discrepancy entity.BalanceDiscrepancy, tx *sql.Tx) error
+	GetList(ctx context.Context, status string, m meta.Metadata) ([]entity.BalanceDiscrepancy, error)
+}
+
+type integrityRepo struct {
+	db *sql.DB
+}
+
+func NewIntegrityRepositories(db *sql.DB) IntegrityRepositories {
+	return integrityRepo{db: db}
+}
+
+const discrepancyColumns = `id, account_id, stored_balance, computed_balance, difference, status, COALESCE(resolution, ''), COALESCE(resolved_by, ''), resolve_transaction_id, resolved_at, created_at, updated_at`
+
+// UpsertOpen records a mismatch for the account, refreshing the amounts of its
+// open discrepancy when one was already reported by an earlier check.
+func (i integrityRepo) UpsertOpen(ctx context.Context, discrepancy entity.BalanceDiscrepancy, tx *sql.Tx) (*entity.BalanceDiscrepancy, error) {
+	var (
+		eventName = "repo.integrity.upsert_open"
+		query     = `
+		INSERT INTO balance_discrepancies (
+		id,
+		account_id,
+		stored_balance,
+		computed_balance,
+		difference,
+		status,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,'OPEN',now(),now())
+		ON CONFLICT (account_id) WHERE status = 'OPEN'
+		DO UPDATE SET stored_balance = EXCLUDED.stored_balance, computed_balance = EXCLUDED.computed_balance,
+		difference = EXCLUDED.difference, updated_at = now()
+		RETURNING ` + discrepancyColumns
+		args = []interface{}{
+			discrepancy.ID,
+			discrepancy.AccountID,
+			discrepancy.StoredBalance,
+			discrepancy.ComputedBalance,
+			discrepancy.Difference,
+		}
+	)
+
+	result, err := scanDiscrepancy(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return result, nil
+}
+
+// NeedsOpeningBalance tells whether the account was opened before transactions
+// carried their account, so part of its history cannot be summed, and has no
+// OPENING_BALANCE transaction yet.
+func (i integrityRepo) NeedsOpeningBalance(ctx context.Context, accountID uuid.UUID, tx *sql.Tx) (bool, error) {
+	var (
+		eventName = "repo.integrity.needs_opening_balance"
+		query     = `
+		SELECT a.created_at < COALESCE((SELECT MIN(created_at) FROM transactions WHERE account_id IS NOT NULL AND action <> 'OPENING_BALANCE'), 'infinity')
+		AND NOT EXISTS (SELECT 1 FROM transactions WHERE account_id = a.id AND action = 'OPENING_BALANCE')
+		FROM accounts a
+		WHERE a.id = $1
+		`
+		args = []interface{}{
+			accountID,
+		}
+		needed bool
+	)
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&needed)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	return needed, nil
+}
+
+// ClearOpenByAccountID closes the open discrepancy of an account whose balance matches again.
+func (i integrityRepo) ClearOpenByAccountID(ctx context.Context, accountID uuid.UUID, tx *sql.Tx) error {
+	var (
+		eventName = "repo.integrity.clear_open_by_account_id"
+		query     = `
+		UPDATE balance_discrepancies
+		SET status = 'CLEARED', updated_at = now()
+		WHERE account_id = $1 AND status = 'OPEN'
+		`
+		args = []interface{}{
+			accountID,
+		}
+	)
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (i integrityRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.BalanceDiscrepancy, error) {
+	var (
+		eventName = "repo.integrity.find_by_id_for_update"
+		query     = `
+		SELECT ` + discrepancyColumns + `
+		FROM balance_discrepancies
+		WHERE id = $1
+		FOR UPDATE
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	discrepancy, err := scanDiscrepancy(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return discrepancy, nil
+}
+
+func (i integrityRepo) Resolve(ctx context.Context, discrepancy entity.BalanceDiscrepancy, tx *sql.Tx) error {
+	var (
+		eventName = "repo.integrity.resolve"
+		query     = `
+		UPDATE balance_discrepancies
+		SET status = $1, resolution = $2, resolved_by = $3, resolve_transaction_id = $4,
+		stored_balance = $5, computed_balance = $6, difference = $7, resolved_at = now(), updated_at = now()
+		WHERE id = $8
+		`
+		args = []interface{}{
+			discrepancy.Status,
+			discrepancy.Resolution,
+			discrepancy.ResolvedBy,
+			discrepancy.ResolveTransactionID,
+			discrepancy.StoredBalance,
+			discrepancy.ComputedBalance,
+			discrepancy.Difference,
+			discrepancy.ID,
+		}
+	)
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// GetList returns the discrepancies with the given status, or all of them when status is empty, newest first.
+func (i integrityRepo) GetList(ctx context.Context, status string, m meta.Metadata) ([]entity.BalanceDiscrepancy, error) {
+	var (
+		eventName = "repo.integrity.get_list"
+		query     = `
+		SELECT ` + discrepancyColumns + `
+		FROM balance_discrepancies
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC
+		OFFSET $2 LIMIT $3
+		`
+		args = []interface{}{
+			status,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+		results []entity.BalanceDiscrepancy
+	)
+
+	rows, err := i.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		discrepancy, err := scanDiscrepancy(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *discrepancy)
+	}
+	return results, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDiscrepancy(row scanner) (*entity.BalanceDiscrepancy, error) {
+	var (
+		discrepancy          entity.BalanceDiscrepancy
+		resolveTransactionID uuid.NullUUID
+		resolvedAt           sql.NullTime
+	)
+	err := row.Scan(&discrepancy.ID, &discrepancy.AccountID, &discrepancy.StoredBalance, &discrepancy.ComputedBalance, &discrepancy.Difference, &discrepancy.Status, &discrepancy.Resolution, &discrepancy.ResolvedBy, &resolveTransactionID, &resolvedAt, &discrepancy.CreatedAt, &discrepancy.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if resolveTransactionID.Valid {
+		discrepancy.ResolveTransactionID = &resolveTransactionID.UUID
+	}
+	if resolvedAt.Valid {
+		discrepancy.ResolvedAt = &resolvedAt.Time
+	}
+	return &discrepancy, nil
+}
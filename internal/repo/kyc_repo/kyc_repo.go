@@ -0,0 +1,135 @@
+package kycrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/cryptox"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+type KYCRepositories interface {
+	CreateDocument(ctx context.Context, document entity.KYCDocument, tx *sql.Tx) error
+	GetDocuments(ctx context.Context, userID uuid.UUID) ([]entity.KYCDocument, error)
+	FindDocument(ctx context.Context, userID, id uuid.UUID) (*entity.KYCDocument, error)
+}
+
+type kycRepo struct {
+	db *sql.DB
+}
+
+func NewKYCRepositories(db *sql.DB) KYCRepositories {
+	return kycRepo{db: db}
+}
+
+// CreateDocument stores the document content encrypted with AES_KEY.
+func (k kycRepo) CreateDocument(ctx context.Context, document entity.KYCDocument, tx *sql.Tx) error {
+	var (
+		eventName = "repo.kyc.create_document"
+		query     = `
+		INSERT INTO kyc_documents (
+		id,
+		user_id,
+		document_type,
+		file_name,
+		content_type,
+		content,
+		created_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		`
+	)
+
+	encryptedContent, err := cryptox.EncryptAES(string(document.Content), os.Getenv("AES_KEY"))
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	args := []interface{}{
+		document.ID,
+		document.UserID,
+		document.DocumentType,
+		document.FileName,
+		document.ContentType,
+		encryptedContent,
+		document.CreatedAt,
+	}
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = k.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// GetDocuments lists the documents of the user without their content.
+func (k kycRepo) GetDocuments(ctx context.Context, userID uuid.UUID) ([]entity.KYCDocument, error) {
+	var (
+		eventName = "repo.kyc.get_documents"
+		query     = `
+		SELECT id, user_id, document_type, file_name, content_type, created_at
+		FROM kyc_documents
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		`
+		args = []interface{}{
+			userID,
+		}
+		results = []entity.KYCDocument{}
+	)
+
+	rows, err := k.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var document entity.KYCDocument
+		if err := rows.Scan(&document.ID, &document.UserID, &document.DocumentType, &document.FileName, &document.ContentType, &document.CreatedAt); err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, document)
+	}
+	return results, nil
+}
+
+func (k kycRepo) FindDocument(ctx context.Context, userID, id uuid.UUID) (*entity.KYCDocument, error) {
+	var (
+		eventName = "repo.kyc.find_document"
+		query     = `
+		SELECT id, user_id, document_type, file_name, content_type, content, created_at
+		FROM kyc_documents
+		WHERE user_id = $1 AND id = $2
+		`
+		args = []interface{}{
+			userID,
+			id,
+		}
+		document         entity.KYCDocument
+		encryptedContent string
+	)
+
+	err := k.db.QueryRowContext(ctx, query, args...).Scan(&document.ID, &document.UserID, &document.DocumentType, &document.FileName, &document.ContentType, &encryptedContent, &document.CreatedAt)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	content, err := cryptox.DecryptAES(encryptedContent, os.Getenv("AES_KEY"))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	document.Content = []byte(content)
+	return &document, nil
+}
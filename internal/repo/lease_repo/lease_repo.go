@@ -0,0 +1,111 @@
+package leaserepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+type LeaseRepositories interface {
+	TryAcquire(ctx context.Context, name, holder string, ttl time.Duration) (*entity.Lease, error)
+	Release(ctx context.Context, name, holder string) error
+	FindByName(ctx context.Context, name string) (*entity.Lease, error)
+}
+
+type leaseRepo struct {
+	db *sql.DB
+}
+
+func NewLeaseRepositories(db *sql.DB) LeaseRepositories {
+	return leaseRepo{db: db}
+}
+
+// TryAcquire takes the lease when it is free or expired, or renews it when the
+// holder already owns it. It returns nil lease when another holder still owns it.
+func (l leaseRepo) TryAcquire(ctx context.Context, name, holder string, ttl time.Duration) (*entity.Lease, error) {
+	var (
+		eventName = "repo.lease.try_acquire"
+		query     = `
+		INSERT INTO scheduler_leases (
+		name,
+		holder,
+		acquired_at,
+		renewed_at,
+		expires_at
+		)
+		VALUES ($1, $2, now(), now(), now() + make_interval(secs => $3))
+		ON CONFLICT (name) DO UPDATE
+		SET holder = EXCLUDED.holder,
+			acquired_at = CASE WHEN scheduler_leases.holder = EXCLUDED.holder THEN scheduler_leases.acquired_at ELSE now() END,
+			renewed_at = now(),
+			expires_at = EXCLUDED.expires_at
+		WHERE scheduler_leases.holder = EXCLUDED.holder OR scheduler_leases.expires_at < now()
+		RETURNING name, holder, acquired_at, renewed_at, expires_at
+		`
+		args = []interface{}{
+			name,
+			holder,
+			ttl.Seconds(),
+		}
+		lease entity.Lease
+	)
+
+	err := l.db.QueryRowContext(ctx, query, args...).Scan(&lease.Name, &lease.Holder, &lease.AcquiredAt, &lease.RenewedAt, &lease.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return &lease, nil
+}
+
+// Release expires the lease immediately so another instance can take over on its next attempt.
+func (l leaseRepo) Release(ctx context.Context, name, holder string) error {
+	var (
+		eventName = "repo.lease.release"
+		query     = `
+		UPDATE scheduler_leases
+		SET expires_at = now()
+		WHERE name = $1 AND holder = $2
+		`
+		args = []interface{}{
+			name,
+			holder,
+		}
+	)
+
+	_, err := l.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (l leaseRepo) FindByName(ctx context.Context, name string) (*entity.Lease, error) {
+	var (
+		eventName = "repo.lease.find_by_name"
+		query     = `
+		SELECT name, holder, acquired_at, renewed_at, expires_at
+		FROM scheduler_leases
+		WHERE name = $1
+		`
+		args = []interface{}{
+			name,
+		}
+		lease entity.Lease
+	)
+
+	err := l.db.QueryRowContext(ctx, query, args...).Scan(&lease.Name, &lease.Holder, &lease.AcquiredAt, &lease.RenewedAt, &lease.ExpiresAt)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return &lease, nil
+}
@@ -0,0 +1,136 @@
+package ledgerrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+type LedgerRepositories interface {
+	CreateEntry(ctx context.Context, entry entity.JournalEntry, tx *sql.Tx) error
+	GetTrialBalance(ctx context.Context) ([]entity.TrialBalanceLine, error)
+	GetUnbalancedEntries(ctx context.Context) ([]entity.UnbalancedJournal, error)
+}
+
+type ledgerRepo struct {
+	db *sql.DB
+}
+
+func NewLedgerRepositories(db *sql.DB) LedgerRepositories {
+	return ledgerRepo{db: db}
+}
+
+// CreateEntry writes a journal entry together with its lines. The lines must
+// be written in the same tx as the entry; the balance of the entry is enforced
+// by a deferred constraint trigger when the tx commits.
+func (l ledgerRepo) CreateEntry(ctx context.Context, entry entity.JournalEntry, tx *sql.Tx) error {
+	var (
+		eventName  = "repo.ledger.create_entry"
+		entryQuery = `
+		INSERT INTO journal_entries (
+		id,
+		transaction_id,
+		description,
+		posted_at
+		)
+		VALUES ($1,$2,$3,now())
+	`
+		lineQuery = `
+		INSERT INTO journal_lines (
+		id,
+		journal_entry_id,
+		ledger_account_code,
+		account_id,
+		debit,
+		credit
+		)
+		VALUES ($1,$2,$3,$4,$5,$6)
+	`
+		args = []interface{}{
+			entry.ID,
+			entry.TransactionID,
+			entry.Description,
+		}
+	)
+
+	_, err := tx.ExecContext(ctx, entryQuery, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	for _, line := range entry.Lines {
+		_, err = tx.ExecContext(ctx, lineQuery, line.ID, entry.ID, line.LedgerAccountCode, line.AccountID, line.Debit, line.Credit)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	}
+	return nil
+}
+
+func (l ledgerRepo) GetTrialBalance(ctx context.Context) ([]entity.TrialBalanceLine, error) {
+	var (
+		eventName = "repo.ledger.get_trial_balance"
+		query     = `
+		SELECT la.code, la.name, la.type, COALESCE(SUM(jl.debit), 0), COALESCE(SUM(jl.credit), 0)
+		FROM ledger_accounts la
+		LEFT JOIN journal_lines jl ON jl.ledger_account_code = la.code
+		GROUP BY la.code, la.name, la.type
+		ORDER BY la.code
+		`
+		results []entity.TrialBalanceLine
+	)
+
+	rows, err := l.db.QueryContext(ctx, query)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line entity.TrialBalanceLine
+		err = rows.Scan(&line.Code, &line.Name, &line.Type, &line.Debit, &line.Credit)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, line)
+	}
+	return results, nil
+}
+
+func (l ledgerRepo) GetUnbalancedEntries(ctx context.Context) ([]entity.UnbalancedJournal, error) {
+	var (
+		eventName = "repo.ledger.get_unbalanced_entries"
+		query     = `
+		SELECT je.id, COALESCE(SUM(jl.debit), 0), COALESCE(SUM(jl.credit), 0)
+		FROM journal_entries je
+		LEFT JOIN journal_lines jl ON jl.journal_entry_id = je.id
+		GROUP BY je.id
+		HAVING COALESCE(SUM(jl.debit), 0) <> COALESCE(SUM(jl.credit), 0)
+		OR COUNT(jl.id) < 2
+		ORDER BY je.id
+		`
+		results []entity.UnbalancedJournal
+	)
+
+	rows, err := l.db.QueryContext(ctx, query)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var journal entity.UnbalancedJournal
+		err = rows.Scan(&journal.JournalEntryID, &journal.Debit, &journal.Credit)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, journal)
+	}
+	return results, nil
+}
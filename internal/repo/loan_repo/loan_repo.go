@@ -0,0 +1,417 @@
+package loanrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+const (
+	loanColumns        = `id, account_id, principal, interest_rate, penalty_rate, term_months, method, outstanding_principal, status, disbursed_by, disbursed_at, created_at, updated_at`
+	installmentColumns = `id, loan_id, seq, due_date, principal_due, interest_due, penalty_due, principal_paid, interest_paid, penalty_paid, status, penalty_accrued_at, paid_at`
+)
+
+type LoanRepositories interface {
+	Create(ctx context.Context, loan entity.Loan, tx *sql.Tx) error
+	CreateInstallment(ctx context.Context, installment entity.LoanInstallment, tx *sql.Tx) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.Loan, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Loan, error)
+	GetListByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.Loan, error)
+	GetInstallments(ctx context.Context, loanID uuid.UUID) ([]entity.LoanInstallment, error)
+	GetDueInstallmentsForUpdate(ctx context.Context, loanID uuid.UUID, asOf time.Time, tx *sql.Tx) ([]entity.LoanInstallment, error)
+	GetDueLoanBatch(ctx context.Context, asOf time.Time, afterID uuid.UUID, limit int) ([]uuid.UUID, error)
+	UpdateInstallment(ctx context.Context, installment entity.LoanInstallment, tx *sql.Tx) error
+	UpdateOutstanding(ctx context.Context, loan entity.Loan, tx *sql.Tx) error
+	CreatePayment(ctx context.Context, payment entity.LoanPayment, tx *sql.Tx) error
+	GetPayments(ctx context.Context, loanID uuid.UUID) ([]entity.LoanPayment, error)
+}
+
+type loanRepo struct {
+	db *sql.DB
+}
+
+func NewLoanRepositories(db *sql.DB) LoanRepositories {
+	return loanRepo{db: db}
+}
+
+func (l loanRepo) exec(ctx context.Context, eventName, query string, tx *sql.Tx, args ...interface{}) error {
+	if tx != nil {
+		_, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	} else {
+		_, err := l.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	}
+	return nil
+}
+
+func (l loanRepo) Create(ctx context.Context, loan entity.Loan, tx *sql.Tx) error {
+	var (
+		eventName = "repo.loan.create"
+		query     = `
+		INSERT INTO loans (
+		id,
+		account_id,
+		principal,
+		interest_rate,
+		penalty_rate,
+		term_months,
+		method,
+		outstanding_principal,
+		status,
+		disbursed_by,
+		disbursed_at,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,now(),now())
+	`
+		args = []interface{}{
+			loan.ID,
+			loan.AccountID,
+			loan.Principal,
+			loan.InterestRate,
+			loan.PenaltyRate,
+			loan.TermMonths,
+			loan.Method,
+			loan.OutstandingPrincipal,
+			loan.Status,
+			loan.DisbursedBy,
+			loan.DisbursedAt,
+		}
+	)
+
+	return l.exec(ctx, eventName, query, tx, args...)
+}
+
+func (l loanRepo) CreateInstallment(ctx context.Context, installment entity.LoanInstallment, tx *sql.Tx) error {
+	var (
+		eventName = "repo.loan.create_installment"
+		query     = `
+		INSERT INTO loan_installments (
+		id,
+		loan_id,
+		seq,
+		due_date,
+		principal_due,
+		interest_due,
+		status,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,now(),now())
+	`
+		args = []interface{}{
+			installment.ID,
+			installment.LoanID,
+			installment.Seq,
+			installment.DueDate,
+			installment.PrincipalDue,
+			installment.InterestDue,
+			installment.Status,
+		}
+	)
+
+	return l.exec(ctx, eventName, query, tx, args...)
+}
+
+func (l loanRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.Loan, error) {
+	var (
+		eventName = "repo.loan.find_by_id"
+		query     = `
+		SELECT ` + loanColumns + `
+		FROM loans
+		WHERE id = $1
+		`
+	)
+
+	loan, err := scanLoan(l.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return loan, nil
+}
+
+func (l loanRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.Loan, error) {
+	var (
+		eventName = "repo.loan.find_by_id_for_update"
+		query     = `
+		SELECT ` + loanColumns + `
+		FROM loans
+		WHERE id = $1
+		FOR UPDATE
+		`
+	)
+
+	loan, err := scanLoan(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return loan, nil
+}
+
+func (l loanRepo) GetListByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.Loan, error) {
+	var (
+		eventName = "repo.loan.get_list_by_account_id"
+		query     = `
+		SELECT ` + loanColumns + `
+		FROM loans
+		WHERE account_id = $1
+		ORDER BY disbursed_at DESC
+		`
+		results []entity.Loan
+	)
+
+	rows, err := l.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *loan)
+	}
+	return results, nil
+}
+
+func (l loanRepo) GetInstallments(ctx context.Context, loanID uuid.UUID) ([]entity.LoanInstallment, error) {
+	var (
+		eventName = "repo.loan.get_installments"
+		query     = `
+		SELECT ` + installmentColumns + `
+		FROM loan_installments
+		WHERE loan_id = $1
+		ORDER BY seq
+		`
+	)
+
+	rows, err := l.db.QueryContext(ctx, query, loanID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return scanInstallments(eventName, rows)
+}
+
+// GetDueInstallmentsForUpdate locks the unpaid installments of a loan that are due by asOf, oldest first.
+func (l loanRepo) GetDueInstallmentsForUpdate(ctx context.Context, loanID uuid.UUID, asOf time.Time, tx *sql.Tx) ([]entity.LoanInstallment, error) {
+	var (
+		eventName = "repo.loan.get_due_installments_for_update"
+		query     = `
+		SELECT ` + installmentColumns + `
+		FROM loan_installments
+		WHERE loan_id = $1 AND status <> 'PAID' AND due_date <= $2
+		ORDER BY seq
+		FOR UPDATE
+		`
+	)
+
+	rows, err := tx.QueryContext(ctx, query, loanID, asOf)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return scanInstallments(eventName, rows)
+}
+
+// GetDueLoanBatch returns the ids of active loans with an unpaid installment due by asOf, keyset paginated by id.
+func (l loanRepo) GetDueLoanBatch(ctx context.Context, asOf time.Time, afterID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	var (
+		eventName = "repo.loan.get_due_loan_batch"
+		query     = `
+		SELECT l.id
+		FROM loans l
+		WHERE l.status = 'ACTIVE' AND l.id > $2
+		AND EXISTS (
+			SELECT 1 FROM loan_installments i
+			WHERE i.loan_id = l.id AND i.status <> 'PAID' AND i.due_date <= $1
+		)
+		ORDER BY l.id
+		LIMIT $3
+		`
+		results = make([]uuid.UUID, 0, limit)
+	)
+
+	rows, err := l.db.QueryContext(ctx, query, asOf, afterID, limit)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, id)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return results, nil
+}
+
+func (l loanRepo) UpdateInstallment(ctx context.Context, installment entity.LoanInstallment, tx *sql.Tx) error {
+	var (
+		eventName = "repo.loan.update_installment"
+		query     = `
+		UPDATE loan_installments
+		SET penalty_due = $1, principal_paid = $2, interest_paid = $3, penalty_paid = $4, status = $5, penalty_accrued_at = $6, paid_at = $7, updated_at = now()
+		WHERE id = $8
+		`
+		args = []interface{}{
+			installment.PenaltyDue,
+			installment.PrincipalPaid,
+			installment.InterestPaid,
+			installment.PenaltyPaid,
+			installment.Status,
+			installment.PenaltyAccruedAt,
+			installment.PaidAt,
+			installment.ID,
+		}
+	)
+
+	return l.exec(ctx, eventName, query, tx, args...)
+}
+
+func (l loanRepo) UpdateOutstanding(ctx context.Context, loan entity.Loan, tx *sql.Tx) error {
+	var (
+		eventName = "repo.loan.update_outstanding"
+		query     = `
+		UPDATE loans
+		SET outstanding_principal = $1, status = $2, updated_at = now()
+		WHERE id = $3
+		`
+		args = []interface{}{
+			loan.OutstandingPrincipal,
+			loan.Status,
+			loan.ID,
+		}
+	)
+
+	return l.exec(ctx, eventName, query, tx, args...)
+}
+
+func (l loanRepo) CreatePayment(ctx context.Context, payment entity.LoanPayment, tx *sql.Tx) error {
+	var (
+		eventName = "repo.loan.create_payment"
+		query     = `
+		INSERT INTO loan_payments (
+		id,
+		loan_id,
+		installment_id,
+		transaction_id,
+		amount,
+		principal,
+		interest,
+		penalty,
+		created_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,now())
+	`
+		args = []interface{}{
+			payment.ID,
+			payment.LoanID,
+			payment.InstallmentID,
+			payment.TransactionID,
+			payment.Amount,
+			payment.Principal,
+			payment.Interest,
+			payment.Penalty,
+		}
+	)
+
+	return l.exec(ctx, eventName, query, tx, args...)
+}
+
+func (l loanRepo) GetPayments(ctx context.Context, loanID uuid.UUID) ([]entity.LoanPayment, error) {
+	var (
+		eventName = "repo.loan.get_payments"
+		query     = `
+		SELECT id, loan_id, installment_id, transaction_id, amount, principal, interest, penalty, created_at
+		FROM loan_payments
+		WHERE loan_id = $1
+		ORDER BY created_at
+		`
+		results []entity.LoanPayment
+	)
+
+	rows, err := l.db.QueryContext(ctx, query, loanID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var payment entity.LoanPayment
+		err = rows.Scan(&payment.ID, &payment.LoanID, &payment.InstallmentID, &payment.TransactionID, &payment.Amount, &payment.Principal, &payment.Interest, &payment.Penalty, &payment.CreatedAt)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, payment)
+	}
+	return results, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLoan(row scanner) (*entity.Loan, error) {
+	var loan entity.Loan
+	err := row.Scan(&loan.ID, &loan.AccountID, &loan.Principal, &loan.InterestRate, &loan.PenaltyRate, &loan.TermMonths, &loan.Method, &loan.OutstandingPrincipal, &loan.Status, &loan.DisbursedBy, &loan.DisbursedAt, &loan.CreatedAt, &loan.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+func scanInstallments(eventName string, rows *sql.Rows) ([]entity.LoanInstallment, error) {
+	var results []entity.LoanInstallment
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			installment      entity.LoanInstallment
+			penaltyAccruedAt sql.NullTime
+			paidAt           sql.NullTime
+		)
+		err := rows.Scan(&installment.ID, &installment.LoanID, &installment.Seq, &installment.DueDate, &installment.PrincipalDue, &installment.InterestDue, &installment.PenaltyDue, &installment.PrincipalPaid, &installment.InterestPaid, &installment.PenaltyPaid, &installment.Status, &penaltyAccruedAt, &paidAt)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		if penaltyAccruedAt.Valid {
+			installment.PenaltyAccruedAt = &penaltyAccruedAt.Time
+		}
+		if paidAt.Valid {
+			installment.PaidAt = &paidAt.Time
+		}
+		results = append(results, installment)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return results, nil
+}
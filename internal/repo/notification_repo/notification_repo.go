@@ -0,0 +1,198 @@
+package notificationrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/cryptox"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+)
+
+type NotificationRepositories interface {
+	UpsertPreference(ctx context.Context, preference entity.NotificationPreference) error
+	GetPreferences(ctx context.Context, userID uuid.UUID) ([]entity.NotificationPreference, error)
+	Create(ctx context.Context, notification entity.Notification) error
+	GetList(ctx context.Context, userID uuid.UUID, m meta.Metadata) ([]entity.Notification, error)
+}
+
+type notificationRepo struct {
+	db *sql.DB
+}
+
+func NewNotificationRepositories(db *sql.DB) NotificationRepositories {
+	return notificationRepo{db: db}
+}
+
+const notificationColumns = `id, user_id, type, channel, recipient, subject, body, status, error, created_at`
+
+func (n notificationRepo) UpsertPreference(ctx context.Context, preference entity.NotificationPreference) error {
+	var (
+		eventName = "repo.notification.upsert_preference"
+		query     = `
+		INSERT INTO notification_preferences (
+		user_id,
+		channel,
+		address,
+		enabled,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,now())
+		ON CONFLICT (user_id, channel)
+		DO UPDATE SET address = EXCLUDED.address, enabled = EXCLUDED.enabled, updated_at = now()
+	`
+	)
+
+	encryptedAddress, err := cryptox.EncryptAES(preference.Address, os.Getenv("AES_KEY"))
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	args := []interface{}{
+		preference.UserID,
+		preference.Channel,
+		encryptedAddress,
+		preference.Enabled,
+	}
+
+	_, err = n.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (n notificationRepo) GetPreferences(ctx context.Context, userID uuid.UUID) ([]entity.NotificationPreference, error) {
+	var (
+		eventName = "repo.notification.get_preferences"
+		query     = `
+		SELECT user_id, channel, address, enabled, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1
+		ORDER BY channel
+		`
+		args = []interface{}{
+			userID,
+		}
+		results []entity.NotificationPreference
+	)
+
+	rows, err := n.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			preference       entity.NotificationPreference
+			encryptedAddress string
+		)
+		err := rows.Scan(&preference.UserID, &preference.Channel, &encryptedAddress, &preference.Enabled, &preference.UpdatedAt)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		preference.Address, err = cryptox.DecryptAES(encryptedAddress, os.Getenv("AES_KEY"))
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, err
+		}
+		results = append(results, preference)
+	}
+	return results, nil
+}
+
+func (n notificationRepo) Create(ctx context.Context, notification entity.Notification) error {
+	var (
+		eventName = "repo.notification.create"
+		query     = `
+		INSERT INTO notifications (
+		id,
+		user_id,
+		type,
+		channel,
+		recipient,
+		subject,
+		body,
+		status,
+		error,
+		created_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,now())
+	`
+	)
+
+	encryptedRecipient, err := cryptox.EncryptAES(notification.Recipient, os.Getenv("AES_KEY"))
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	args := []interface{}{
+		notification.ID,
+		notification.UserID,
+		notification.Type,
+		notification.Channel,
+		encryptedRecipient,
+		notification.Subject,
+		notification.Body,
+		notification.Status,
+		notification.Error,
+	}
+
+	_, err = n.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (n notificationRepo) GetList(ctx context.Context, userID uuid.UUID, m meta.Metadata) ([]entity.Notification, error) {
+	var (
+		eventName = "repo.notification.get_list"
+		query     = `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		OFFSET $2 LIMIT $3
+		`
+		args = []interface{}{
+			userID,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+		results []entity.Notification
+	)
+
+	rows, err := n.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			notification       entity.Notification
+			encryptedRecipient string
+		)
+		err := rows.Scan(&notification.ID, &notification.UserID, &notification.Type, &notification.Channel, &encryptedRecipient, &notification.Subject, &notification.Body, &notification.Status, &notification.Error, &notification.CreatedAt)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		notification.Recipient, err = cryptox.DecryptAES(encryptedRecipient, os.Getenv("AES_KEY"))
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, err
+		}
+		results = append(results, notification)
+	}
+	return results, nil
+}
@@ -0,0 +1,175 @@
+package outboxrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+type OutboxRepositories interface {
+	Create(ctx context.Context, event entity.OutboxEvent, tx *sql.Tx) error
+	TryLockRelay(ctx context.Context, tx *sql.Tx) (bool, error)
+	GetPending(ctx context.Context, size int) ([]entity.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, message string) error
+}
+
+type outboxRepo struct {
+	db *sql.DB
+}
+
+func NewOutboxRepositories(db *sql.DB) OutboxRepositories {
+	return outboxRepo{db: db}
+}
+
+const outboxColumns = `id, sequence, account_id, event_type, payload, attempts, last_error, created_at, published_at`
+
+// Create stores the event in tx, the database transaction of the change it
+// describes, so the event exists if and only if the change is committed.
+func (o outboxRepo) Create(ctx context.Context, event entity.OutboxEvent, tx *sql.Tx) error {
+	var (
+		eventName = "repo.outbox.create"
+		query     = `
+		INSERT INTO outbox_events (
+		id,
+		account_id,
+		event_type,
+		payload,
+		created_at
+		)
+		VALUES ($1,$2,$3,$4,now())
+	`
+		args = []interface{}{
+			event.ID,
+			event.AccountID,
+			event.Type,
+			[]byte(event.Payload),
+		}
+	)
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// TryLockRelay takes the relay lock until tx ends, it returns false when another
+// relay holds it.
+func (o outboxRepo) TryLockRelay(ctx context.Context, tx *sql.Tx) (bool, error) {
+	var (
+		eventName = "repo.outbox.try_lock_relay"
+		query     = `SELECT pg_try_advisory_xact_lock(hashtext('outbox_relay'))`
+		locked    bool
+	)
+
+	err := tx.QueryRowContext(ctx, query).Scan(&locked)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	return locked, nil
+}
+
+// GetPending returns the oldest unpublished events in sequence order.
+func (o outboxRepo) GetPending(ctx context.Context, size int) ([]entity.OutboxEvent, error) {
+	var (
+		eventName = "repo.outbox.get_pending"
+		query     = `
+		SELECT ` + outboxColumns + `
+		FROM outbox_events
+		WHERE published_at IS NULL
+		ORDER BY sequence
+		LIMIT $1
+		`
+		args = []interface{}{
+			size,
+		}
+		results []entity.OutboxEvent
+	)
+
+	rows, err := o.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *event)
+	}
+	return results, nil
+}
+
+func (o outboxRepo) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	var (
+		eventName = "repo.outbox.mark_published"
+		query     = `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = '', published_at = now()
+		WHERE id = $1
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	_, err := o.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (o outboxRepo) MarkFailed(ctx context.Context, id uuid.UUID, message string) error {
+	var (
+		eventName = "repo.outbox.mark_failed"
+		query     = `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $1
+		WHERE id = $2
+		`
+		args = []interface{}{
+			message,
+			id,
+		}
+	)
+
+	_, err := o.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEvent(row scanner) (*entity.OutboxEvent, error) {
+	var (
+		event       entity.OutboxEvent
+		payload     []byte
+		publishedAt sql.NullTime
+	)
+	err := row.Scan(&event.ID, &event.Sequence, &event.AccountID, &event.Type, &payload, &event.Attempts, &event.LastError, &event.CreatedAt, &publishedAt)
+	if err != nil {
+		return nil, err
+	}
+	event.Payload = payload
+	if publishedAt.Valid {
+		event.PublishedAt = &publishedAt.Time
+	}
+	return &event, nil
+}
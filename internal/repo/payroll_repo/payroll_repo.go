@@ -0,0 +1,382 @@
+package payrollrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+)
+
+type PayrollRepositories interface {
+	CreateBatch(ctx context.Context, batch entity.PayrollBatch, tx *sql.Tx) error
+	CreateLine(ctx context.Context, line entity.PayrollLine, tx *sql.Tx) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.PayrollBatch, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.PayrollBatch, error)
+	GetList(ctx context.Context, status string, m meta.Metadata) ([]entity.PayrollBatch, error)
+	GetLines(ctx context.Context, batchID uuid.UUID) ([]entity.PayrollLine, error)
+	Review(ctx context.Context, batch entity.PayrollBatch, tx *sql.Tx) error
+	CompleteLine(ctx context.Context, lineID uuid.UUID, transactionID uuid.UUID, tx *sql.Tx) (bool, error)
+	FailLine(ctx context.Context, lineID uuid.UUID, transactionID *uuid.UUID, message string) error
+	Finish(ctx context.Context, id uuid.UUID) (*entity.PayrollBatch, error)
+}
+
+type payrollRepo struct {
+	db *sql.DB
+}
+
+func NewPayrollRepositories(db *sql.DB) PayrollRepositories {
+	return payrollRepo{db: db}
+}
+
+const (
+	batchColumns = `id, file_name, format, checksum, status, line_count, valid_count, total_amount, completed_count, failed_count, uploaded_by, COALESCE(reviewed_by, ''), COALESCE(review_note, ''), reviewed_at, executed_at, created_at, updated_at`
+	lineColumns  = `id, batch_id, line_no, account_number, account_id, amount, COALESCE(reference, ''), status, COALESCE(message, ''), transaction_id, created_at, updated_at`
+)
+
+func (p payrollRepo) CreateBatch(ctx context.Context, batch entity.PayrollBatch, tx *sql.Tx) error {
+	var (
+		eventName = "repo.payroll.create_batch"
+		query     = `
+		INSERT INTO payroll_batches (
+		id,
+		file_name,
+		format,
+		checksum,
+		status,
+		line_count,
+		valid_count,
+		total_amount,
+		uploaded_by,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,now(),now())
+	`
+		args = []interface{}{
+			batch.ID,
+			batch.FileName,
+			batch.Format,
+			batch.Checksum,
+			batch.Status,
+			batch.LineCount,
+			batch.ValidCount,
+			batch.TotalAmount,
+			batch.UploadedBy,
+		}
+	)
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (p payrollRepo) CreateLine(ctx context.Context, line entity.PayrollLine, tx *sql.Tx) error {
+	var (
+		eventName = "repo.payroll.create_line"
+		query     = `
+		INSERT INTO payroll_lines (
+		id,
+		batch_id,
+		line_no,
+		account_number,
+		account_id,
+		amount,
+		reference,
+		status,
+		message,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,now(),now())
+	`
+		args = []interface{}{
+			line.ID,
+			line.BatchID,
+			line.LineNo,
+			line.AccountNumber,
+			line.AccountID,
+			line.Amount,
+			line.Reference,
+			line.Status,
+			line.Message,
+		}
+	)
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (p payrollRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.PayrollBatch, error) {
+	var (
+		eventName = "repo.payroll.find_by_id"
+		query     = `
+		SELECT ` + batchColumns + `
+		FROM payroll_batches
+		WHERE id = $1
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	batch, err := scanBatch(p.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return batch, nil
+}
+
+func (p payrollRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.PayrollBatch, error) {
+	var (
+		eventName = "repo.payroll.find_by_id_for_update"
+		query     = `
+		SELECT ` + batchColumns + `
+		FROM payroll_batches
+		WHERE id = $1
+		FOR UPDATE
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	batch, err := scanBatch(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return batch, nil
+}
+
+// GetList returns the batches with the given status, or all of them when status is empty, newest first.
+func (p payrollRepo) GetList(ctx context.Context, status string, m meta.Metadata) ([]entity.PayrollBatch, error) {
+	var (
+		eventName = "repo.payroll.get_list"
+		query     = `
+		SELECT ` + batchColumns + `
+		FROM payroll_batches
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC
+		OFFSET $2 LIMIT $3
+		`
+		args = []interface{}{
+			status,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+		results []entity.PayrollBatch
+	)
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		batch, err := scanBatch(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *batch)
+	}
+	return results, nil
+}
+
+func (p payrollRepo) GetLines(ctx context.Context, batchID uuid.UUID) ([]entity.PayrollLine, error) {
+	var (
+		eventName = "repo.payroll.get_lines"
+		query     = `
+		SELECT ` + lineColumns + `
+		FROM payroll_lines
+		WHERE batch_id = $1
+		ORDER BY line_no
+		`
+		args = []interface{}{
+			batchID,
+		}
+		results []entity.PayrollLine
+	)
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		line, err := scanLine(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *line)
+	}
+	return results, nil
+}
+
+func (p payrollRepo) Review(ctx context.Context, batch entity.PayrollBatch, tx *sql.Tx) error {
+	var (
+		eventName = "repo.payroll.review"
+		query     = `
+		UPDATE payroll_batches
+		SET status = $1, reviewed_by = $2, review_note = $3, reviewed_at = now(), updated_at = now()
+		WHERE id = $4
+		`
+		args = []interface{}{
+			batch.Status,
+			batch.ReviewedBy,
+			batch.ReviewNote,
+			batch.ID,
+		}
+	)
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// CompleteLine marks a valid line as credited by transactionID. It returns false
+// without error when the line was already processed, so a line is never paid twice.
+func (p payrollRepo) CompleteLine(ctx context.Context, lineID uuid.UUID, transactionID uuid.UUID, tx *sql.Tx) (bool, error) {
+	var (
+		eventName = "repo.payroll.complete_line"
+		query     = `
+		UPDATE payroll_lines
+		SET status = 'COMPLETED', transaction_id = $1, message = NULL, updated_at = now()
+		WHERE id = $2 AND status = 'VALID'
+		`
+		args = []interface{}{
+			transactionID,
+			lineID,
+		}
+	)
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		log.Println(eventName, err)
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (p payrollRepo) FailLine(ctx context.Context, lineID uuid.UUID, transactionID *uuid.UUID, message string) error {
+	var (
+		eventName = "repo.payroll.fail_line"
+		query     = `
+		UPDATE payroll_lines
+		SET status = 'FAILED', transaction_id = $1, message = $2, updated_at = now()
+		WHERE id = $3 AND status = 'VALID'
+		`
+		args = []interface{}{
+			transactionID,
+			message,
+			lineID,
+		}
+	)
+
+	_, err := p.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// Finish counts the results of the executed lines and closes the batch as
+// COMPLETED, or PARTIALLY_COMPLETED when any line was not credited. A batch that
+// is not PROCESSING or still has a VALID line is left as is and not found.
+func (p payrollRepo) Finish(ctx context.Context, id uuid.UUID) (*entity.PayrollBatch, error) {
+	var (
+		eventName = "repo.payroll.finish"
+		query     = `
+		WITH result AS (
+			SELECT
+			COUNT(*) FILTER (WHERE status = 'COMPLETED') AS completed_count,
+			COUNT(*) FILTER (WHERE status IN ('FAILED', 'INVALID')) AS failed_count
+			FROM payroll_lines
+			WHERE batch_id = $1
+		)
+		UPDATE payroll_batches
+		SET completed_count = result.completed_count, failed_count = result.failed_count,
+		status = CASE WHEN result.failed_count = 0 THEN 'COMPLETED' ELSE 'PARTIALLY_COMPLETED' END,
+		executed_at = now(), updated_at = now()
+		FROM result
+		WHERE id = $1 AND status = 'PROCESSING'
+		AND NOT EXISTS (SELECT 1 FROM payroll_lines WHERE batch_id = $1 AND status = 'VALID')
+		RETURNING ` + batchColumns
+		args = []interface{}{
+			id,
+		}
+	)
+
+	batch, err := scanBatch(p.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return batch, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBatch(row scanner) (*entity.PayrollBatch, error) {
+	var (
+		batch      entity.PayrollBatch
+		reviewedAt sql.NullTime
+		executedAt sql.NullTime
+	)
+	err := row.Scan(&batch.ID, &batch.FileName, &batch.Format, &batch.Checksum, &batch.Status, &batch.LineCount, &batch.ValidCount, &batch.TotalAmount, &batch.CompletedCount, &batch.FailedCount, &batch.UploadedBy, &batch.ReviewedBy, &batch.ReviewNote, &reviewedAt, &executedAt, &batch.CreatedAt, &batch.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if reviewedAt.Valid {
+		batch.ReviewedAt = &reviewedAt.Time
+	}
+	if executedAt.Valid {
+		batch.ExecutedAt = &executedAt.Time
+	}
+	return &batch, nil
+}
+
+func scanLine(row scanner) (*entity.PayrollLine, error) {
+	var (
+		line          entity.PayrollLine
+		accountID     uuid.NullUUID
+		transactionID uuid.NullUUID
+	)
+	err := row.Scan(&line.ID, &line.BatchID, &line.LineNo, &line.AccountNumber, &accountID, &line.Amount, &line.Reference, &line.Status, &line.Message, &transactionID, &line.CreatedAt, &line.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if accountID.Valid {
+		line.AccountID = &accountID.UUID
+	}
+	if transactionID.Valid {
+		line.TransactionID = &transactionID.UUID
+	}
+	return &line, nil
+}
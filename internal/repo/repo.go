@@ -4,9 +4,29 @@ import (
 	"database/sql"
 
 	accountrepo "github.com/hanselacn/banking-transaction/internal/repo/account_repo"
+	amlrepo "github.com/hanselacn/banking-transaction/internal/repo/aml_repo"
+	approvalrepo "github.com/hanselacn/banking-transaction/internal/repo/approval_repo"
+	auditrepo "github.com/hanselacn/banking-transaction/internal/repo/audit_repo"
 	authorizationrepo "github.com/hanselacn/banking-transaction/internal/repo/authorization_repo"
+	feerepo "github.com/hanselacn/banking-transaction/internal/repo/fee_repo"
+	fraudrepo "github.com/hanselacn/banking-transaction/internal/repo/fraud_repo"
+	integrityrepo "github.com/hanselacn/banking-transaction/internal/repo/integrity_repo"
+	kycrepo "github.com/hanselacn/banking-transaction/internal/repo/kyc_repo"
+	leaserepo "github.com/hanselacn/banking-transaction/internal/repo/lease_repo"
+	ledgerrepo "github.com/hanselacn/banking-transaction/internal/repo/ledger_repo"
+	loanrepo "github.com/hanselacn/banking-transaction/internal/repo/loan_repo"
+	notificationrepo "github.com/hanselacn/banking-transaction/internal/repo/notification_repo"
+	outboxrepo "github.com/hanselacn/banking-transaction/internal/repo/outbox_repo"
+	payrollrepo "github.com/hanselacn/banking-transaction/internal/repo/payroll_repo"
+	sanctionsrepo "github.com/hanselacn/banking-transaction/internal/repo/sanctions_repo"
+	standingorderrepo "github.com/hanselacn/banking-transaction/internal/repo/standing_order_repo"
+	statementrepo "github.com/hanselacn/banking-transaction/internal/repo/statement_repo"
+	streamrepo "github.com/hanselacn/banking-transaction/internal/repo/stream_repo"
+	taxrepo "github.com/hanselacn/banking-transaction/internal/repo/tax_repo"
+	termdepositrepo "github.com/hanselacn/banking-transaction/internal/repo/term_deposit_repo"
 	transactionrepo "github.com/hanselacn/banking-transaction/internal/repo/transaction_repo"
 	usersrepo "github.com/hanselacn/banking-transaction/internal/repo/users_repo"
+	webhookrepo "github.com/hanselacn/banking-transaction/internal/repo/webhook_repo"
 )
 
 type Repo struct {
@@ -14,6 +34,26 @@ type Repo struct {
 	Authorization authorizationrepo.AuthorizationRepositories
 	Users         usersrepo.UsersRepositories
 	Transaction   transactionrepo.TransactionRepo
+	Lease         leaserepo.LeaseRepositories
+	Tax           taxrepo.TaxRepositories
+	Fee           feerepo.FeeRepositories
+	TermDeposit   termdepositrepo.TermDepositRepositories
+	Loan          loanrepo.LoanRepositories
+	StandingOrder standingorderrepo.StandingOrderRepositories
+	Ledger        ledgerrepo.LedgerRepositories
+	Integrity     integrityrepo.IntegrityRepositories
+	Statement     statementrepo.StatementRepositories
+	Payroll       payrollrepo.PayrollRepositories
+	Approval      approvalrepo.ApprovalRepositories
+	Audit         auditrepo.AuditRepositories
+	Outbox        outboxrepo.OutboxRepositories
+	Webhook       webhookrepo.WebhookRepositories
+	Stream        streamrepo.StreamRepositories
+	Notification  notificationrepo.NotificationRepositories
+	KYC           kycrepo.KYCRepositories
+	Fraud         fraudrepo.FraudRepositories
+	AML           amlrepo.AMLRepositories
+	Sanctions     sanctionsrepo.SanctionsRepositories
 }
 
 func NewRepositories(db *sql.DB) Repo {
@@ -21,5 +61,26 @@ func NewRepositories(db *sql.DB) Repo {
 		Account:       accountrepo.NewAccountRepositories(db),
 		Authorization: authorizationrepo.NewAccountRepositories(db),
 		Users:         usersrepo.NewUsersRepo(db),
-		Transaction:   transactionrepo.NewTransactionRepo(db)}
+		Transaction:   transactionrepo.NewTransactionRepo(db),
+		Lease:         leaserepo.NewLeaseRepositories(db),
+		Tax:           taxrepo.NewTaxRepositories(db),
+		Fee:           feerepo.NewFeeRepositories(db),
+		TermDeposit:   termdepositrepo.NewTermDepositRepositories(db),
+		Loan:          loanrepo.NewLoanRepositories(db),
+		StandingOrder: standingorderrepo.NewStandingOrderRepositories(db),
+		Ledger:        ledgerrepo.NewLedgerRepositories(db),
+		Integrity:     integrityrepo.NewIntegrityRepositories(db),
+		Statement:     statementrepo.NewStatementRepositories(db),
+		Payroll:       payrollrepo.NewPayrollRepositories(db),
+		Approval:      approvalrepo.NewApprovalRepositories(db),
+		Audit:         auditrepo.NewAuditRepositories(db),
+		Outbox:        outboxrepo.NewOutboxRepositories(db),
+		Webhook:       webhookrepo.NewWebhookRepositories(db),
+		Stream:        streamrepo.NewStreamRepositories(db),
+		Notification:  notificationrepo.NewNotificationRepositories(db),
+		KYC:           kycrepo.NewKYCRepositories(db),
+		Fraud:         fraudrepo.NewFraudRepositories(db),
+		AML:           amlrepo.NewAMLRepositories(db),
+		Sanctions:     sanctionsrepo.NewSanctionsRepositories(db),
+	}
 }
@@ -0,0 +1,402 @@
+package sanctionsrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/lib/pq"
+)
+
+type SanctionsRepositories interface {
+	ReplaceEntries(ctx context.Context, entries []entity.SanctionsEntry, loadedAt time.Time, tx *sql.Tx) error
+	GetListVersion(ctx context.Context) (time.Time, error)
+	GetEntries(ctx context.Context) ([]entity.SanctionsEntry, error)
+	CreateHit(ctx context.Context, hit entity.SanctionsHit, tx *sql.Tx) error
+	FindHitByID(ctx context.Context, id uuid.UUID) (*entity.SanctionsHit, error)
+	FindHitByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.SanctionsHit, error)
+	GetListHit(ctx context.Context, status, subject string, m meta.Metadata) ([]entity.SanctionsHit, error)
+	CountOpenHits(ctx context.Context, userID uuid.UUID, subject string, tx *sql.Tx) (int, error)
+	UpdateHit(ctx context.Context, hit entity.SanctionsHit, tx *sql.Tx) error
+	IsCleared(ctx context.Context, screenedName, entryUID string) (bool, error)
+	GetListReleasableHit(ctx context.Context, limit int) ([]entity.SanctionsHit, error)
+	ClaimRelease(ctx context.Context, id uuid.UUID, executedAt time.Time) (bool, error)
+}
+
+type sanctionsRepo struct {
+	db *sql.DB
+}
+
+func NewSanctionsRepositories(db *sql.DB) SanctionsRepositories {
+	return sanctionsRepo{db: db}
+}
+
+const sanctionsHitColumns = `h.id, h.subject, h.user_id, u.user_name, h.screened_name, h.entry_uid, h.entry_name, h.program, h.score, h.payload,
+	h.status, COALESCE(h.reviewed_by, ''), COALESCE(h.note, ''), COALESCE(h.message, ''), h.created_at, h.reviewed_at, h.executed_at`
+
+// ReplaceEntries swaps the whole watchlist for entries, readers keep seeing the
+// previous list until tx commits.
+func (r sanctionsRepo) ReplaceEntries(ctx context.Context, entries []entity.SanctionsEntry, loadedAt time.Time, tx *sql.Tx) error {
+	var (
+		eventName = "repo.sanctions.replace_entries"
+	)
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sanctions_entries`); err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("sanctions_entries", "uid", "name", "entry_type", "program", "loaded_at"))
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	defer stmt.Close()
+	for _, entry := range entries {
+		if _, err := stmt.ExecContext(ctx, entry.UID, entry.Name, entry.Type, entry.Program, loadedAt); err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// GetListVersion returns when the watchlist was loaded, the zero time when it is empty.
+func (r sanctionsRepo) GetListVersion(ctx context.Context) (time.Time, error) {
+	var (
+		eventName = "repo.sanctions.get_list_version"
+		query     = `SELECT MAX(loaded_at) FROM sanctions_entries`
+		version   sql.NullTime
+	)
+
+	if err := r.db.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		log.Println(eventName, err)
+		return time.Time{}, errbank.TranslateDBError(err)
+	}
+	return version.Time, nil
+}
+
+func (r sanctionsRepo) GetEntries(ctx context.Context) ([]entity.SanctionsEntry, error) {
+	var (
+		eventName = "repo.sanctions.get_entries"
+		query     = `
+		SELECT uid, name, entry_type, program
+		FROM sanctions_entries
+		`
+		results []entity.SanctionsEntry
+	)
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry entity.SanctionsEntry
+		if err := rows.Scan(&entry.UID, &entry.Name, &entry.Type, &entry.Program); err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, entry)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return results, nil
+}
+
+func (r sanctionsRepo) CreateHit(ctx context.Context, hit entity.SanctionsHit, tx *sql.Tx) error {
+	var (
+		eventName = "repo.sanctions.create_hit"
+		query     = `
+		INSERT INTO sanctions_hits (
+		id,
+		subject,
+		user_id,
+		screened_name,
+		entry_uid,
+		entry_name,
+		program,
+		score,
+		payload,
+		status,
+		created_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+		`
+		payload interface{}
+		err     error
+	)
+
+	// Only held transfers carry a payload, the others store NULL.
+	if len(hit.Payload) > 0 {
+		payload = []byte(hit.Payload)
+	}
+	args := []interface{}{
+		hit.ID,
+		hit.Subject,
+		hit.UserID,
+		hit.ScreenedName,
+		hit.EntryUID,
+		hit.EntryName,
+		hit.Program,
+		hit.Score,
+		payload,
+		hit.Status,
+		hit.CreatedAt,
+	}
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = r.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (r sanctionsRepo) FindHitByID(ctx context.Context, id uuid.UUID) (*entity.SanctionsHit, error) {
+	var (
+		eventName = "repo.sanctions.find_hit_by_id"
+		query     = `
+		SELECT ` + sanctionsHitColumns + `
+		FROM sanctions_hits h
+		JOIN users u ON u.id = h.user_id
+		WHERE h.id = $1
+		`
+	)
+
+	hit, err := scanHit(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return hit, nil
+}
+
+func (r sanctionsRepo) FindHitByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.SanctionsHit, error) {
+	var (
+		eventName = "repo.sanctions.find_hit_by_id_for_update"
+		query     = `
+		SELECT ` + sanctionsHitColumns + `
+		FROM sanctions_hits h
+		JOIN users u ON u.id = h.user_id
+		WHERE h.id = $1
+		FOR UPDATE OF h
+		`
+	)
+
+	hit, err := scanHit(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return hit, nil
+}
+
+func (r sanctionsRepo) GetListHit(ctx context.Context, status, subject string, m meta.Metadata) ([]entity.SanctionsHit, error) {
+	var (
+		eventName = "repo.sanctions.get_list_hit"
+		query     = `
+		SELECT ` + sanctionsHitColumns + `
+		FROM sanctions_hits h
+		JOIN users u ON u.id = h.user_id
+		WHERE ($1 = '' OR h.status = $1) AND ($2 = '' OR h.subject = $2)
+		ORDER BY h.created_at DESC
+		OFFSET $3 LIMIT $4
+		`
+		args = []interface{}{
+			status,
+			subject,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+		results []entity.SanctionsHit
+	)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		hit, err := scanHit(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *hit)
+	}
+	return results, nil
+}
+
+// CountOpenHits counts the hits of a user still waiting for a review.
+func (r sanctionsRepo) CountOpenHits(ctx context.Context, userID uuid.UUID, subject string, tx *sql.Tx) (int, error) {
+	var (
+		eventName = "repo.sanctions.count_open_hits"
+		query     = `
+		SELECT COUNT(*)
+		FROM sanctions_hits
+		WHERE user_id = $1 AND subject = $2 AND status = 'OPEN'
+		`
+		args = []interface{}{
+			userID,
+			subject,
+		}
+		count int
+		err   error
+	)
+
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&count)
+	} else {
+		err = r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return 0, errbank.TranslateDBError(err)
+	}
+	return count, nil
+}
+
+func (r sanctionsRepo) UpdateHit(ctx context.Context, hit entity.SanctionsHit, tx *sql.Tx) error {
+	var (
+		eventName = "repo.sanctions.update_hit"
+		query     = `
+		UPDATE sanctions_hits
+		SET status = $1, reviewed_by = $2, note = $3, message = $4, reviewed_at = $5, executed_at = $6
+		WHERE id = $7
+		`
+		args = []interface{}{
+			hit.Status,
+			hit.ReviewedBy,
+			hit.Note,
+			hit.Message,
+			hit.ReviewedAt,
+			hit.ExecutedAt,
+			hit.ID,
+		}
+		err error
+	)
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = r.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// IsCleared returns whether a hit of the same name on the same entry was
+// already reviewed as a false positive.
+func (r sanctionsRepo) IsCleared(ctx context.Context, screenedName, entryUID string) (bool, error) {
+	var (
+		eventName = "repo.sanctions.is_cleared"
+		query     = `
+		SELECT EXISTS (
+			SELECT 1 FROM sanctions_hits
+			WHERE screened_name = $1 AND entry_uid = $2 AND status = 'CLEARED'
+		)
+		`
+		cleared bool
+	)
+
+	if err := r.db.QueryRowContext(ctx, query, screenedName, entryUID).Scan(&cleared); err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	return cleared, nil
+}
+
+// GetListReleasableHit returns the cleared transfer hits whose transfer has not
+// run yet, oldest first.
+func (r sanctionsRepo) GetListReleasableHit(ctx context.Context, limit int) ([]entity.SanctionsHit, error) {
+	var (
+		eventName = "repo.sanctions.get_list_releasable_hit"
+		query     = `
+		SELECT ` + sanctionsHitColumns + `
+		FROM sanctions_hits h
+		JOIN users u ON u.id = h.user_id
+		WHERE h.subject = 'TRANSFER' AND h.status = 'CLEARED' AND h.executed_at IS NULL
+		ORDER BY h.reviewed_at
+		LIMIT $1
+		`
+		results []entity.SanctionsHit
+	)
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		hit, err := scanHit(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *hit)
+	}
+	return results, nil
+}
+
+// ClaimRelease marks a held transfer as run, it returns false when another run
+// already claimed it.
+func (r sanctionsRepo) ClaimRelease(ctx context.Context, id uuid.UUID, executedAt time.Time) (bool, error) {
+	var (
+		eventName = "repo.sanctions.claim_release"
+		query     = `
+		UPDATE sanctions_hits
+		SET executed_at = $1
+		WHERE id = $2 AND executed_at IS NULL
+		`
+	)
+
+	res, err := r.db.ExecContext(ctx, query, executedAt, id)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanHit(row scanner) (*entity.SanctionsHit, error) {
+	var (
+		hit     entity.SanctionsHit
+		payload []byte
+	)
+	err := row.Scan(&hit.ID, &hit.Subject, &hit.UserID, &hit.Username, &hit.ScreenedName, &hit.EntryUID, &hit.EntryName, &hit.Program, &hit.Score, &payload,
+		&hit.Status, &hit.ReviewedBy, &hit.Note, &hit.Message, &hit.CreatedAt, &hit.ReviewedAt, &hit.ExecutedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > 0 {
+		hit.Payload = payload
+	}
+	return &hit, nil
+}
@@ -0,0 +1,297 @@
+package standingorderrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+const standingOrderColumns = `id, account_id, type, COALESCE(to_account_number, ''), amount, frequency, day_of_month, scheduled_for, next_run_at, end_date, retry_count, status, COALESCE(description, ''), created_at, updated_at`
+
+type StandingOrderRepositories interface {
+	Create(ctx context.Context, order entity.StandingOrder) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.StandingOrder, error)
+	GetListByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.StandingOrder, error)
+	GetDueBatch(ctx context.Context, asOf time.Time, afterID uuid.UUID, limit int) ([]entity.StandingOrder, error)
+	Update(ctx context.Context, order entity.StandingOrder) error
+	CreateExecution(ctx context.Context, execution entity.StandingOrderExecution) (bool, error)
+	UpdateExecution(ctx context.Context, execution entity.StandingOrderExecution) error
+	GetExecutions(ctx context.Context, orderID uuid.UUID) ([]entity.StandingOrderExecution, error)
+}
+
+type standingOrderRepo struct {
+	db *sql.DB
+}
+
+func NewStandingOrderRepositories(db *sql.DB) StandingOrderRepositories {
+	return standingOrderRepo{db: db}
+}
+
+func (s standingOrderRepo) Create(ctx context.Context, order entity.StandingOrder) error {
+	var (
+		eventName = "repo.standing_order.create"
+		query     = `
+		INSERT INTO standing_orders (
+		id,
+		account_id,
+		type,
+		to_account_number,
+		amount,
+		frequency,
+		day_of_month,
+		scheduled_for,
+		next_run_at,
+		end_date,
+		status,
+		description,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,NULLIF($4, ''),$5,$6,$7,$8,$9,$10,$11,NULLIF($12, ''),now(),now())
+	`
+		args = []interface{}{
+			order.ID,
+			order.AccountID,
+			order.Type,
+			order.ToAccountNumber,
+			order.Amount,
+			order.Frequency,
+			order.DayOfMonth,
+			order.ScheduledFor,
+			order.NextRunAt,
+			order.EndDate,
+			order.Status,
+			order.Description,
+		}
+	)
+
+	_, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (s standingOrderRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.StandingOrder, error) {
+	var (
+		eventName = "repo.standing_order.find_by_id"
+		query     = `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE id = $1
+		`
+	)
+
+	order, err := scanStandingOrder(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return order, nil
+}
+
+func (s standingOrderRepo) GetListByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.StandingOrder, error) {
+	var (
+		eventName = "repo.standing_order.get_list_by_account_id"
+		query     = `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE account_id = $1
+		ORDER BY created_at DESC
+		`
+	)
+
+	return s.queryList(ctx, eventName, query, accountID)
+}
+
+// GetDueBatch returns active orders whose next run is due by asOf, keyset paginated by id.
+func (s standingOrderRepo) GetDueBatch(ctx context.Context, asOf time.Time, afterID uuid.UUID, limit int) ([]entity.StandingOrder, error) {
+	var (
+		eventName = "repo.standing_order.get_due_batch"
+		query     = `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE status = 'ACTIVE' AND next_run_at <= $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+		`
+	)
+
+	return s.queryList(ctx, eventName, query, asOf, afterID, limit)
+}
+
+func (s standingOrderRepo) Update(ctx context.Context, order entity.StandingOrder) error {
+	var (
+		eventName = "repo.standing_order.update"
+		query     = `
+		UPDATE standing_orders
+		SET to_account_number = NULLIF($1, ''), amount = $2, scheduled_for = $3, next_run_at = $4, end_date = $5, retry_count = $6, status = $7, description = NULLIF($8, ''), updated_at = now()
+		WHERE id = $9
+		`
+		args = []interface{}{
+			order.ToAccountNumber,
+			order.Amount,
+			order.ScheduledFor,
+			order.NextRunAt,
+			order.EndDate,
+			order.RetryCount,
+			order.Status,
+			order.Description,
+			order.ID,
+		}
+	)
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errbank.NewErrNotFound("data not found!")
+	}
+	return nil
+}
+
+// CreateExecution claims an attempt of an occurrence. It returns false when the attempt was
+// already claimed, so an order is never executed twice for the same attempt.
+func (s standingOrderRepo) CreateExecution(ctx context.Context, execution entity.StandingOrderExecution) (bool, error) {
+	var (
+		eventName = "repo.standing_order.create_execution"
+		query     = `
+		INSERT INTO standing_order_executions (
+		id,
+		standing_order_id,
+		scheduled_for,
+		attempt,
+		status,
+		message,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,NULLIF($6, ''),now(),now())
+		ON CONFLICT DO NOTHING
+	`
+		args = []interface{}{
+			execution.ID,
+			execution.StandingOrderID,
+			execution.ScheduledFor,
+			execution.Attempt,
+			execution.Status,
+			execution.Message,
+		}
+	)
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		log.Println(eventName, err)
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (s standingOrderRepo) UpdateExecution(ctx context.Context, execution entity.StandingOrderExecution) error {
+	var (
+		eventName = "repo.standing_order.update_execution"
+		query     = `
+		UPDATE standing_order_executions
+		SET status = $1, message = NULLIF($2, ''), updated_at = now()
+		WHERE id = $3
+		`
+		args = []interface{}{
+			execution.Status,
+			execution.Message,
+			execution.ID,
+		}
+	)
+
+	_, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (s standingOrderRepo) GetExecutions(ctx context.Context, orderID uuid.UUID) ([]entity.StandingOrderExecution, error) {
+	var (
+		eventName = "repo.standing_order.get_executions"
+		query     = `
+		SELECT id, standing_order_id, scheduled_for, attempt, status, COALESCE(message, ''), created_at, updated_at
+		FROM standing_order_executions
+		WHERE standing_order_id = $1
+		ORDER BY created_at DESC
+		`
+		results []entity.StandingOrderExecution
+	)
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var execution entity.StandingOrderExecution
+		err = rows.Scan(&execution.ID, &execution.StandingOrderID, &execution.ScheduledFor, &execution.Attempt, &execution.Status, &execution.Message, &execution.CreatedAt, &execution.UpdatedAt)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, execution)
+	}
+	return results, nil
+}
+
+func (s standingOrderRepo) queryList(ctx context.Context, eventName, query string, args ...interface{}) ([]entity.StandingOrder, error) {
+	var results []entity.StandingOrder
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		order, err := scanStandingOrder(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *order)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return results, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStandingOrder(row scanner) (*entity.StandingOrder, error) {
+	var (
+		order   entity.StandingOrder
+		endDate sql.NullTime
+	)
+	err := row.Scan(&order.ID, &order.AccountID, &order.Type, &order.ToAccountNumber, &order.Amount, &order.Frequency, &order.DayOfMonth, &order.ScheduledFor, &order.NextRunAt, &endDate, &order.RetryCount, &order.Status, &order.Description, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if endDate.Valid {
+		order.EndDate = &endDate.Time
+	}
+	return &order, nil
+}
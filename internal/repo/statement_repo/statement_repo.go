@@ -0,0 +1,91 @@
+package statementrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+type StatementRepositories interface {
+	Create(ctx context.Context, statement entity.StatementFile) (bool, error)
+	FindByAccountIDAndPeriod(ctx context.Context, accountID uuid.UUID, period string) (*entity.StatementFile, error)
+}
+
+type statementRepo struct {
+	db *sql.DB
+}
+
+func NewStatementRepositories(db *sql.DB) StatementRepositories {
+	return statementRepo{db: db}
+}
+
+// Create stores a generated statement. Statements of a closed period never
+// change, so it returns false without error when one was already stored.
+func (s statementRepo) Create(ctx context.Context, statement entity.StatementFile) (bool, error) {
+	var (
+		eventName = "repo.statement.create"
+		query     = `
+		INSERT INTO statements (
+		id,
+		account_id,
+		period,
+		opening_balance,
+		closing_balance,
+		pdf,
+		csv,
+		generated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		ON CONFLICT DO NOTHING
+	`
+		args = []interface{}{
+			statement.ID,
+			statement.AccountID,
+			statement.Period,
+			statement.OpeningBalance,
+			statement.ClosingBalance,
+			statement.PDF,
+			statement.CSV,
+			statement.GeneratedAt,
+		}
+	)
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return false, errbank.TranslateDBError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		log.Println(eventName, err)
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (s statementRepo) FindByAccountIDAndPeriod(ctx context.Context, accountID uuid.UUID, period string) (*entity.StatementFile, error) {
+	var (
+		eventName = "repo.statement.find_by_account_id_and_period"
+		query     = `
+		SELECT id, account_id, period, opening_balance, closing_balance, pdf, csv, generated_at
+		FROM statements
+		WHERE account_id = $1 AND period = $2
+		`
+		args = []interface{}{
+			accountID,
+			period,
+		}
+		statement entity.StatementFile
+	)
+
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&statement.ID, &statement.AccountID, &statement.Period, &statement.OpeningBalance, &statement.ClosingBalance, &statement.PDF, &statement.CSV, &statement.GeneratedAt)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return &statement, nil
+}
@@ -0,0 +1,41 @@
+package streamrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+type StreamRepositories interface {
+	Notify(ctx context.Context, channel string, payload []byte, tx *sql.Tx) error
+}
+
+type streamRepo struct {
+	db *sql.DB
+}
+
+func NewStreamRepositories(db *sql.DB) StreamRepositories {
+	return streamRepo{db: db}
+}
+
+// Notify queues payload on channel in tx, postgres delivers it to the listeners
+// of every instance when tx commits and drops it when tx rolls back.
+func (s streamRepo) Notify(ctx context.Context, channel string, payload []byte, tx *sql.Tx) error {
+	var (
+		eventName = "repo.stream.notify"
+		query     = `SELECT pg_notify($1, $2)`
+		args      = []interface{}{
+			channel,
+			string(payload),
+		}
+	)
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package taxrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+type TaxRepositories interface {
+	AccumulateWithholding(ctx context.Context, withholding entity.TaxWithholding, tx *sql.Tx) error
+	FindByUserIDAndYear(ctx context.Context, userID uuid.UUID, year int) (*entity.TaxWithholding, error)
+}
+
+type taxRepo struct {
+	db *sql.DB
+}
+
+func NewTaxRepositories(db *sql.DB) TaxRepositories {
+	return taxRepo{db: db}
+}
+
+// AccumulateWithholding adds the gross interest and withheld tax to the user's yearly totals.
+func (t taxRepo) AccumulateWithholding(ctx context.Context, withholding entity.TaxWithholding, tx *sql.Tx) error {
+	var (
+		eventName = "repo.tax.accumulate_withholding"
+		query     = `
+		INSERT INTO tax_withholdings (
+		user_id,
+		year,
+		gross_interest,
+		tax_withheld,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,now())
+		ON CONFLICT (user_id, year) DO UPDATE
+		SET gross_interest = tax_withholdings.gross_interest + EXCLUDED.gross_interest,
+			tax_withheld = tax_withholdings.tax_withheld + EXCLUDED.tax_withheld,
+			updated_at = now()
+	`
+		args = []interface{}{
+			withholding.UserID,
+			withholding.Year,
+			withholding.GrossInterest,
+			withholding.TaxWithheld,
+		}
+	)
+
+	if tx != nil {
+		_, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	} else {
+		_, err := t.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	}
+	return nil
+}
+
+func (t taxRepo) FindByUserIDAndYear(ctx context.Context, userID uuid.UUID, year int) (*entity.TaxWithholding, error) {
+	var (
+		eventName = "repo.tax.find_by_user_id_and_year"
+		query     = `
+		SELECT user_id, year, gross_interest, tax_withheld
+		FROM tax_withholdings
+		WHERE user_id = $1 AND year = $2
+		`
+		args = []interface{}{
+			userID,
+			year,
+		}
+		withholding entity.TaxWithholding
+	)
+
+	err := t.db.QueryRowContext(ctx, query, args...).Scan(&withholding.UserID, &withholding.Year, &withholding.GrossInterest, &withholding.TaxWithheld)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return &withholding, nil
+}
@@ -0,0 +1,246 @@
+package termdepositrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+)
+
+const termDepositColumns = `id, account_id, principal, interest_rate, term_months, maturity_instruction, status, start_date, maturity_date, interest_paid, tax_withheld, penalty, rolled_over_to, closed_at, created_at, updated_at`
+
+type TermDepositRepositories interface {
+	Create(ctx context.Context, deposit entity.TermDeposit, tx *sql.Tx) error
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.TermDeposit, error)
+	GetListByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.TermDeposit, error)
+	GetMaturedBatch(ctx context.Context, asOf time.Time, afterID uuid.UUID, limit int) ([]entity.TermDeposit, error)
+	UpdateInstruction(ctx context.Context, deposit entity.TermDeposit) error
+	Close(ctx context.Context, deposit entity.TermDeposit, tx *sql.Tx) error
+}
+
+type termDepositRepo struct {
+	db *sql.DB
+}
+
+func NewTermDepositRepositories(db *sql.DB) TermDepositRepositories {
+	return termDepositRepo{db: db}
+}
+
+func (t termDepositRepo) Create(ctx context.Context, deposit entity.TermDeposit, tx *sql.Tx) error {
+	var (
+		eventName = "repo.term_deposit.create"
+		query     = `
+		INSERT INTO term_deposits (
+		id,
+		account_id,
+		principal,
+		interest_rate,
+		term_months,
+		maturity_instruction,
+		status,
+		start_date,
+		maturity_date,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,now(),now())
+	`
+		args = []interface{}{
+			deposit.ID,
+			deposit.AccountID,
+			deposit.Principal,
+			deposit.InterestRate,
+			deposit.TermMonths,
+			deposit.MaturityInstruction,
+			deposit.Status,
+			deposit.StartDate,
+			deposit.MaturityDate,
+		}
+	)
+
+	if tx != nil {
+		_, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	} else {
+		_, err := t.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	}
+	return nil
+}
+
+func (t termDepositRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID, tx *sql.Tx) (*entity.TermDeposit, error) {
+	var (
+		eventName = "repo.term_deposit.find_by_id_for_update"
+		query     = `
+		SELECT ` + termDepositColumns + `
+		FROM term_deposits
+		WHERE id = $1
+		FOR UPDATE
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	deposit, err := scanTermDeposit(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return deposit, nil
+}
+
+func (t termDepositRepo) GetListByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.TermDeposit, error) {
+	var (
+		eventName = "repo.term_deposit.get_list_by_account_id"
+		query     = `
+		SELECT ` + termDepositColumns + `
+		FROM term_deposits
+		WHERE account_id = $1
+		ORDER BY created_at DESC
+		`
+		args = []interface{}{
+			accountID,
+		}
+	)
+
+	return t.queryList(ctx, eventName, query, args...)
+}
+
+// GetMaturedBatch returns active deposits whose maturity date has passed, keyset paginated by id.
+func (t termDepositRepo) GetMaturedBatch(ctx context.Context, asOf time.Time, afterID uuid.UUID, limit int) ([]entity.TermDeposit, error) {
+	var (
+		eventName = "repo.term_deposit.get_matured_batch"
+		query     = `
+		SELECT ` + termDepositColumns + `
+		FROM term_deposits
+		WHERE status = 'ACTIVE' AND maturity_date <= $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+		`
+		args = []interface{}{
+			asOf,
+			afterID,
+			limit,
+		}
+	)
+
+	return t.queryList(ctx, eventName, query, args...)
+}
+
+func (t termDepositRepo) UpdateInstruction(ctx context.Context, deposit entity.TermDeposit) error {
+	var (
+		eventName = "repo.term_deposit.update_instruction"
+		query     = `
+		UPDATE term_deposits
+		SET maturity_instruction = $1, updated_at = now()
+		WHERE id = $2 AND status = 'ACTIVE'
+		`
+		args = []interface{}{
+			deposit.MaturityInstruction,
+			deposit.ID,
+		}
+	)
+
+	res, err := t.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errbank.NewErrNotFound("active term deposit not found!")
+	}
+	return nil
+}
+
+// Close stores the final status and amounts of a deposit that matured, rolled over or was withdrawn.
+func (t termDepositRepo) Close(ctx context.Context, deposit entity.TermDeposit, tx *sql.Tx) error {
+	var (
+		eventName = "repo.term_deposit.close"
+		query     = `
+		UPDATE term_deposits
+		SET status = $1, interest_paid = $2, tax_withheld = $3, penalty = $4, rolled_over_to = $5, closed_at = now(), updated_at = now()
+		WHERE id = $6
+		`
+		args = []interface{}{
+			deposit.Status,
+			deposit.InterestPaid,
+			deposit.TaxWithheld,
+			deposit.Penalty,
+			deposit.RolledOverTo,
+			deposit.ID,
+		}
+	)
+
+	if tx != nil {
+		_, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	} else {
+		_, err := t.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Println(eventName, err)
+			return errbank.TranslateDBError(err)
+		}
+	}
+	return nil
+}
+
+func (t termDepositRepo) queryList(ctx context.Context, eventName, query string, args ...interface{}) ([]entity.TermDeposit, error) {
+	var results []entity.TermDeposit
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		deposit, err := scanTermDeposit(rows)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *deposit)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return results, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTermDeposit(row scanner) (*entity.TermDeposit, error) {
+	var (
+		deposit      entity.TermDeposit
+		rolledOverTo uuid.NullUUID
+		closedAt     sql.NullTime
+	)
+	err := row.Scan(&deposit.ID, &deposit.AccountID, &deposit.Principal, &deposit.InterestRate, &deposit.TermMonths, &deposit.MaturityInstruction, &deposit.Status, &deposit.StartDate, &deposit.MaturityDate, &deposit.InterestPaid, &deposit.TaxWithheld, &deposit.Penalty, &rolledOverTo, &closedAt, &deposit.CreatedAt, &deposit.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if rolledOverTo.Valid {
+		deposit.RolledOverTo = &rolledOverTo.UUID
+	}
+	if closedAt.Valid {
+		deposit.ClosedAt = &closedAt.Time
+	}
+	return &deposit, nil
+}
@@ -9,11 +9,18 @@ import (
 	"github.com/google/uuid"
 	"github.com/hanselacn/banking-transaction/internal/entity"
 	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/lib/pq"
 )
 
 type TransactionRepo interface {
-	CreateTransaction(ctx context.Context, input entity.Transaction) error
+	CreateTransaction(ctx context.Context, input entity.Transaction, tx *sql.Tx) error
 	UpdateTransactionStatus(ctx context.Context, trID uuid.UUID, status string, tx *sql.Tx) error
+	GetListByAccountID(ctx context.Context, accountID uuid.UUID, dr meta.DateRange) ([]entity.Transaction, error)
+	CountByAccountIDAndAction(ctx context.Context, accountID uuid.UUID, action string, dr meta.DateRange, tx *sql.Tx) (int, error)
+	SumCompletedByAccountID(ctx context.Context, accountID uuid.UUID, tx *sql.Tx) (float64, error)
+	SumCompletedByAccountIDBefore(ctx context.Context, accountID uuid.UUID, before time.Time) (float64, error)
+	GetStatsByAccountID(ctx context.Context, accountID uuid.UUID, txType string, actions []string, since time.Time) (*entity.TransactionStats, error)
 }
 
 type transactionRepo struct {
@@ -25,12 +32,13 @@ func NewTransactionRepo(db *sql.DB) TransactionRepo {
 }
 
 // CreateTransaction implements TransactionRepo.
-func (t transactionRepo) CreateTransaction(ctx context.Context, input entity.Transaction) error {
+func (t transactionRepo) CreateTransaction(ctx context.Context, input entity.Transaction, tx *sql.Tx) error {
 	var (
 		eventName = "repo.transaction.create"
 		query     = `
 		INSERT INTO transactions (
 		id,
+		account_id,
 		type,
 		amount,
 		action,
@@ -38,10 +46,11 @@ func (t transactionRepo) CreateTransaction(ctx context.Context, input entity.Tra
 		updated_at,
 		created_at
 		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
 	`
 		args = []interface{}{
 			input.ID,
+			uuid.NullUUID{UUID: input.AccountID, Valid: input.AccountID != uuid.Nil},
 			input.Type,
 			input.Amount,
 			input.Action,
@@ -49,9 +58,14 @@ func (t transactionRepo) CreateTransaction(ctx context.Context, input entity.Tra
 			time.Now(),
 			time.Now(),
 		}
+		err error
 	)
 
-	_, err := t.db.ExecContext(ctx, query, args...)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = t.db.ExecContext(ctx, query, args...)
+	}
 	if err != nil {
 		log.Println(eventName, err)
 		return errbank.TranslateDBError(err)
@@ -89,3 +103,156 @@ func (t transactionRepo) UpdateTransactionStatus(ctx context.Context, trID uuid.
 
 	return nil
 }
+
+// GetListByAccountID returns the account transactions created in [dr.Start, dr.End), oldest first.
+func (t transactionRepo) GetListByAccountID(ctx context.Context, accountID uuid.UUID, dr meta.DateRange) ([]entity.Transaction, error) {
+	var (
+		eventName = "repo.transaction.get_list_by_account_id"
+		query     = `
+		SELECT id, account_id, type, amount, action, status, created_at, updated_at
+		FROM transactions
+		WHERE account_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at, id
+		`
+		args = []interface{}{
+			accountID,
+			dr.Start,
+			dr.End,
+		}
+		results []entity.Transaction
+	)
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var transaction entity.Transaction
+		err = rows.Scan(&transaction.ID, &transaction.AccountID, &transaction.Type, &transaction.Amount, &transaction.Action, &transaction.Status, &transaction.CreatedAt, &transaction.UpdatedAt)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return results, nil
+}
+
+// CountByAccountIDAndAction counts the completed account transactions of an action created in [dr.Start, dr.End).
+func (t transactionRepo) CountByAccountIDAndAction(ctx context.Context, accountID uuid.UUID, action string, dr meta.DateRange, tx *sql.Tx) (int, error) {
+	var (
+		eventName = "repo.transaction.count_by_account_id_and_action"
+		query     = `
+		SELECT COUNT(*)
+		FROM transactions
+		WHERE account_id = $1 AND action = $2 AND status = 'COMPLETED' AND created_at >= $3 AND created_at < $4
+		`
+		args = []interface{}{
+			accountID,
+			action,
+			dr.Start,
+			dr.End,
+		}
+		total int
+		err   error
+	)
+
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&total)
+	} else {
+		err = t.db.QueryRowContext(ctx, query, args...).Scan(&total)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return 0, errbank.TranslateDBError(err)
+	}
+	return total, nil
+}
+
+// SumCompletedByAccountID returns the net amount of the completed account transactions,
+// credits minus debits, i.e. the balance implied by the transaction history.
+func (t transactionRepo) SumCompletedByAccountID(ctx context.Context, accountID uuid.UUID, tx *sql.Tx) (float64, error) {
+	var (
+		eventName = "repo.transaction.sum_completed_by_account_id"
+		query     = `
+		SELECT COALESCE(SUM(CASE WHEN type = 'C' THEN amount ELSE -amount END), 0)
+		FROM transactions
+		WHERE account_id = $1 AND status = 'COMPLETED'
+		`
+		args = []interface{}{
+			accountID,
+		}
+		total float64
+		err   error
+	)
+
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&total)
+	} else {
+		err = t.db.QueryRowContext(ctx, query, args...).Scan(&total)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return 0, errbank.TranslateDBError(err)
+	}
+	return total, nil
+}
+
+// SumCompletedByAccountIDBefore returns the net amount of the completed account
+// transactions created before the given time, i.e. the balance at that time.
+func (t transactionRepo) SumCompletedByAccountIDBefore(ctx context.Context, accountID uuid.UUID, before time.Time) (float64, error) {
+	var (
+		eventName = "repo.transaction.sum_completed_by_account_id_before"
+		query     = `
+		SELECT COALESCE(SUM(CASE WHEN type = 'C' THEN amount ELSE -amount END), 0)
+		FROM transactions
+		WHERE account_id = $1 AND status = 'COMPLETED' AND created_at < $2
+		`
+		args = []interface{}{
+			accountID,
+			before,
+		}
+		total float64
+	)
+
+	err := t.db.QueryRowContext(ctx, query, args...).Scan(&total)
+	if err != nil {
+		log.Println(eventName, err)
+		return 0, errbank.TranslateDBError(err)
+	}
+	return total, nil
+}
+
+// GetStatsByAccountID counts, averages and sums the account transactions of the actions
+// created since the given time that did not fail. An empty txType matches both types.
+func (t transactionRepo) GetStatsByAccountID(ctx context.Context, accountID uuid.UUID, txType string, actions []string, since time.Time) (*entity.TransactionStats, error) {
+	var (
+		eventName = "repo.transaction.get_stats_by_account_id"
+		query     = `
+		SELECT COUNT(*), COALESCE(AVG(amount), 0), COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE account_id = $1 AND ($2 = '' OR type::text = $2) AND action::text = ANY($3)
+		AND status <> 'FAILED' AND created_at >= $4
+		`
+		args = []interface{}{
+			accountID,
+			txType,
+			pq.Array(actions),
+			since,
+		}
+		stats entity.TransactionStats
+	)
+
+	err := t.db.QueryRowContext(ctx, query, args...).Scan(&stats.Count, &stats.Average, &stats.Total)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return &stats, nil
+}
@@ -4,15 +4,26 @@ import (
 	"context"
 	"database/sql"
 	"log"
+	"os"
 
+	"github.com/google/uuid"
 	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/cryptox"
 	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
 )
 
 type UsersRepositories interface {
 	FindByUserName(ctx context.Context, username string) (*entity.User, error)
 	Create(ctx context.Context, user entity.User, tx *sql.Tx) error
-	UpdateRoleByUserName(ctx context.Context, user entity.User) error
+	UpdateRoleByUserName(ctx context.Context, user entity.User, tx *sql.Tx) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
+	UpdateTaxProfile(ctx context.Context, user entity.User, tx *sql.Tx) error
+	UpsertProfile(ctx context.Context, profile entity.UserProfile) error
+	FindProfileByUserID(ctx context.Context, userID uuid.UUID) (*entity.UserProfile, error)
+	UpdateKYCStatus(ctx context.Context, user entity.User, tx *sql.Tx) error
+	GetListByKYCStatus(ctx context.Context, status string, m meta.Metadata) ([]entity.User, error)
+	UpdateSanctionsStatus(ctx context.Context, user entity.User, tx *sql.Tx) error
 }
 
 type usersRepo struct {
@@ -31,15 +42,17 @@ func (a usersRepo) Create(ctx context.Context, user entity.User, tx *sql.Tx) err
 		id,
 		user_name,
 		full_name,
-		role
+		role,
+		sanctions_status
 		)
-		VALUES ($1,$2,$3,$4)
+		VALUES ($1,$2,$3,$4,$5)
 	`
 		args = []interface{}{
 			user.ID,
 			user.Username,
 			user.Fullname,
 			user.Role,
+			user.SanctionsStatus,
 		}
 	)
 
@@ -64,7 +77,7 @@ func (a usersRepo) FindByUserName(ctx context.Context, username string) (*entity
 	var (
 		eventName = "repo.users.find_by_user_name"
 		query     = `
-		SELECT id, user_name, full_name, role
+		SELECT id, user_name, full_name, role, tax_status, tax_exempt, kyc_status, kyc_note, sanctions_status
 		FROM users
 		WHERE user_name = $1
 		`
@@ -74,7 +87,7 @@ func (a usersRepo) FindByUserName(ctx context.Context, username string) (*entity
 		user entity.User
 	)
 
-	err := a.db.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Fullname, &user.Role)
+	err := a.db.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Fullname, &user.Role, &user.TaxStatus, &user.TaxExempt, &user.KYCStatus, &user.KYCNote, &user.SanctionsStatus)
 	if err != nil {
 		log.Println(eventName, err)
 		return nil, errbank.TranslateDBError(err)
@@ -83,7 +96,7 @@ func (a usersRepo) FindByUserName(ctx context.Context, username string) (*entity
 	return &user, nil
 }
 
-func (a usersRepo) UpdateRoleByUserName(ctx context.Context, user entity.User) error {
+func (a usersRepo) UpdateRoleByUserName(ctx context.Context, user entity.User, tx *sql.Tx) error {
 	var (
 		eventName = "repo.users.update_role_by_user_name"
 		query     = `
@@ -95,8 +108,117 @@ func (a usersRepo) UpdateRoleByUserName(ctx context.Context, user entity.User) e
 			user.Role,
 			user.Username,
 		}
+		err error
 	)
 
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = a.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (a usersRepo) FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	var (
+		eventName = "repo.users.find_by_id"
+		query     = `
+		SELECT id, user_name, full_name, role, tax_status, tax_exempt, kyc_status, kyc_note, sanctions_status
+		FROM users
+		WHERE id = $1
+		`
+		args = []interface{}{
+			id,
+		}
+		user entity.User
+	)
+
+	err := a.db.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Fullname, &user.Role, &user.TaxStatus, &user.TaxExempt, &user.KYCStatus, &user.KYCNote, &user.SanctionsStatus)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+
+	return &user, nil
+}
+
+func (a usersRepo) UpdateTaxProfile(ctx context.Context, user entity.User, tx *sql.Tx) error {
+	var (
+		eventName = "repo.users.update_tax_profile"
+		query     = `
+		UPDATE users
+		SET tax_status = $1, tax_exempt = $2
+		WHERE user_name = $3
+		`
+		args = []interface{}{
+			user.TaxStatus,
+			user.TaxExempt,
+			user.Username,
+		}
+		res sql.Result
+		err error
+	)
+
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		res, err = a.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errbank.NewErrNotFound("data not found!")
+	}
+	return nil
+}
+
+// UpsertProfile stores the personal fields of profile encrypted with AES_KEY.
+func (a usersRepo) UpsertProfile(ctx context.Context, profile entity.UserProfile) error {
+	var (
+		eventName = "repo.users.upsert_profile"
+		query     = `
+		INSERT INTO user_profiles (
+		user_id,
+		email,
+		phone,
+		date_of_birth,
+		national_id,
+		address,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		ON CONFLICT (user_id) DO UPDATE
+		SET email = EXCLUDED.email, phone = EXCLUDED.phone, date_of_birth = EXCLUDED.date_of_birth,
+		national_id = EXCLUDED.national_id, address = EXCLUDED.address, updated_at = EXCLUDED.updated_at
+		`
+		fields = []string{
+			profile.Email,
+			profile.Phone,
+			profile.DateOfBirth,
+			profile.NationalID,
+			profile.Address,
+		}
+		args = []interface{}{
+			profile.UserID,
+		}
+	)
+
+	for _, field := range fields {
+		encrypted, err := cryptox.EncryptAES(field, os.Getenv("AES_KEY"))
+		if err != nil {
+			log.Println(eventName, err)
+			return err
+		}
+		args = append(args, encrypted)
+	}
+	args = append(args, profile.UpdatedAt)
+
 	_, err := a.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		log.Println(eventName, err)
@@ -104,3 +226,131 @@ func (a usersRepo) UpdateRoleByUserName(ctx context.Context, user entity.User) e
 	}
 	return nil
 }
+
+func (a usersRepo) FindProfileByUserID(ctx context.Context, userID uuid.UUID) (*entity.UserProfile, error) {
+	var (
+		eventName = "repo.users.find_profile_by_user_id"
+		query     = `
+		SELECT user_id, email, phone, date_of_birth, national_id, address, updated_at
+		FROM user_profiles
+		WHERE user_id = $1
+		`
+		args = []interface{}{
+			userID,
+		}
+		profile   entity.UserProfile
+		encrypted = make([]string, 5)
+		fields    = []*string{
+			&profile.Email,
+			&profile.Phone,
+			&profile.DateOfBirth,
+			&profile.NationalID,
+			&profile.Address,
+		}
+	)
+
+	err := a.db.QueryRowContext(ctx, query, args...).Scan(&profile.UserID, &encrypted[0], &encrypted[1], &encrypted[2], &encrypted[3], &encrypted[4], &profile.UpdatedAt)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	for i, field := range fields {
+		*field, err = cryptox.DecryptAES(encrypted[i], os.Getenv("AES_KEY"))
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, err
+		}
+	}
+
+	return &profile, nil
+}
+
+func (a usersRepo) UpdateKYCStatus(ctx context.Context, user entity.User, tx *sql.Tx) error {
+	var (
+		eventName = "repo.users.update_kyc_status"
+		query     = `
+		UPDATE users
+		SET kyc_status = $1, kyc_note = $2
+		WHERE id = $3
+		`
+		args = []interface{}{
+			user.KYCStatus,
+			user.KYCNote,
+			user.ID,
+		}
+		err error
+	)
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = a.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (a usersRepo) GetListByKYCStatus(ctx context.Context, status string, m meta.Metadata) ([]entity.User, error) {
+	var (
+		eventName = "repo.users.get_list_by_kyc_status"
+		query     = `
+		SELECT id, user_name, full_name, role, tax_status, tax_exempt, kyc_status, kyc_note, sanctions_status
+		FROM users
+		WHERE kyc_status = $1
+		ORDER BY user_name
+		OFFSET $2 LIMIT $3
+		`
+		args = []interface{}{
+			status,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+		results = []entity.User{}
+	)
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user entity.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Fullname, &user.Role, &user.TaxStatus, &user.TaxExempt, &user.KYCStatus, &user.KYCNote, &user.SanctionsStatus); err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, user)
+	}
+	return results, nil
+}
+
+func (a usersRepo) UpdateSanctionsStatus(ctx context.Context, user entity.User, tx *sql.Tx) error {
+	var (
+		eventName = "repo.users.update_sanctions_status"
+		query     = `
+		UPDATE users
+		SET sanctions_status = $1
+		WHERE id = $2
+		`
+		args = []interface{}{
+			user.SanctionsStatus,
+			user.ID,
+		}
+		err error
+	)
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		_, err = a.db.ExecContext(ctx, query, args...)
+	}
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
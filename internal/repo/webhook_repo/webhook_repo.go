@@ -0,0 +1,451 @@
+package webhookrepo
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/hanselacn/banking-transaction/internal/entity"
+	"github.com/hanselacn/banking-transaction/internal/pkg/cryptox"
+	"github.com/hanselacn/banking-transaction/internal/pkg/errbank"
+	"github.com/hanselacn/banking-transaction/internal/pkg/meta"
+	"github.com/lib/pq"
+)
+
+type WebhookRepositories interface {
+	CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) error
+	FindSubscriptionByID(ctx context.Context, id uuid.UUID) (*entity.WebhookSubscription, error)
+	GetSubscriptionsByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.WebhookSubscription, error)
+	GetActiveSubscriptions(ctx context.Context, accountID uuid.UUID, eventType string) ([]entity.WebhookSubscription, error)
+	DeactivateSubscription(ctx context.Context, id uuid.UUID) error
+	CreateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error
+	FindDeliveryByID(ctx context.Context, id uuid.UUID) (*entity.WebhookDelivery, error)
+	GetDueDeliveries(ctx context.Context, size int) ([]entity.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, m meta.Metadata) ([]entity.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error
+	CreateLog(ctx context.Context, entry entity.WebhookDeliveryLog) error
+	GetLogs(ctx context.Context, deliveryID uuid.UUID) ([]entity.WebhookDeliveryLog, error)
+}
+
+type webhookRepo struct {
+	db *sql.DB
+}
+
+func NewWebhookRepositories(db *sql.DB) WebhookRepositories {
+	return webhookRepo{db: db}
+}
+
+const (
+	subscriptionColumns = `id, account_id, url, secret, events, active, created_by, created_at, updated_at`
+	deliveryColumns     = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, delivered_at, created_at, updated_at`
+)
+
+func (w webhookRepo) CreateSubscription(ctx context.Context, subscription entity.WebhookSubscription) error {
+	var (
+		eventName = "repo.webhook.create_subscription"
+		query     = `
+		INSERT INTO webhook_subscriptions (
+		id,
+		account_id,
+		url,
+		secret,
+		events,
+		active,
+		created_by,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,now(),now())
+	`
+	)
+
+	encryptedSecret, err := cryptox.EncryptAES(subscription.Secret, os.Getenv("AES_KEY"))
+	if err != nil {
+		log.Println(eventName, err)
+		return err
+	}
+	args := []interface{}{
+		subscription.ID,
+		subscription.AccountID,
+		subscription.URL,
+		encryptedSecret,
+		pq.Array(subscription.Events),
+		subscription.Active,
+		subscription.CreatedBy,
+	}
+
+	_, err = w.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// FindSubscriptionByID returns the subscription with its decrypted secret.
+func (w webhookRepo) FindSubscriptionByID(ctx context.Context, id uuid.UUID) (*entity.WebhookSubscription, error) {
+	var (
+		eventName = "repo.webhook.find_subscription_by_id"
+		query     = `
+		SELECT ` + subscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE id = $1
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	subscription, err := scanSubscription(w.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return subscription, nil
+}
+
+func (w webhookRepo) GetSubscriptionsByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.WebhookSubscription, error) {
+	var (
+		eventName = "repo.webhook.get_subscriptions_by_account_id"
+		query     = `
+		SELECT ` + subscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE account_id = $1
+		ORDER BY created_at DESC
+		`
+		args = []interface{}{
+			accountID,
+		}
+	)
+
+	results, err := w.querySubscriptions(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetActiveSubscriptions returns the active subscriptions of the account to eventType.
+func (w webhookRepo) GetActiveSubscriptions(ctx context.Context, accountID uuid.UUID, eventType string) ([]entity.WebhookSubscription, error) {
+	var (
+		eventName = "repo.webhook.get_active_subscriptions"
+		query     = `
+		SELECT ` + subscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE account_id = $1 AND active AND $2 = ANY(events)
+		`
+		args = []interface{}{
+			accountID,
+			eventType,
+		}
+	)
+
+	results, err := w.querySubscriptions(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return results, nil
+}
+
+func (w webhookRepo) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]entity.WebhookSubscription, error) {
+	var results []entity.WebhookSubscription
+
+	rows, err := w.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *subscription)
+	}
+	return results, nil
+}
+
+func (w webhookRepo) DeactivateSubscription(ctx context.Context, id uuid.UUID) error {
+	var (
+		eventName = "repo.webhook.deactivate_subscription"
+		query     = `
+		UPDATE webhook_subscriptions
+		SET active = FALSE, updated_at = now()
+		WHERE id = $1
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	_, err := w.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+// CreateDelivery queues the event for the subscription, an event already queued
+// for it is ignored.
+func (w webhookRepo) CreateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	var (
+		eventName = "repo.webhook.create_delivery"
+		query     = `
+		INSERT INTO webhook_deliveries (
+		id,
+		subscription_id,
+		event_id,
+		event_type,
+		payload,
+		status,
+		next_attempt_at,
+		created_at,
+		updated_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,now(),now())
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+		args = []interface{}{
+			delivery.ID,
+			delivery.SubscriptionID,
+			delivery.EventID,
+			delivery.EventType,
+			[]byte(delivery.Payload),
+			delivery.Status,
+			delivery.NextAttemptAt,
+		}
+	)
+
+	_, err := w.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (w webhookRepo) FindDeliveryByID(ctx context.Context, id uuid.UUID) (*entity.WebhookDelivery, error) {
+	var (
+		eventName = "repo.webhook.find_delivery_by_id"
+		query     = `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE id = $1
+		`
+		args = []interface{}{
+			id,
+		}
+	)
+
+	delivery, err := scanDelivery(w.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	return delivery, nil
+}
+
+// GetDueDeliveries returns the pending deliveries whose next attempt is due, oldest first.
+func (w webhookRepo) GetDueDeliveries(ctx context.Context, size int) ([]entity.WebhookDelivery, error) {
+	var (
+		eventName = "repo.webhook.get_due_deliveries"
+		query     = `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = 'PENDING' AND next_attempt_at <= now()
+		ORDER BY next_attempt_at
+		LIMIT $1
+		`
+		args = []interface{}{
+			size,
+		}
+	)
+
+	results, err := w.queryDeliveries(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetDeliveries returns a page of the subscription deliveries matching status,
+// an empty status matching all, newest first.
+func (w webhookRepo) GetDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, m meta.Metadata) ([]entity.WebhookDelivery, error) {
+	var (
+		eventName = "repo.webhook.get_deliveries"
+		query     = `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		OFFSET $3 LIMIT $4
+		`
+		args = []interface{}{
+			subscriptionID,
+			status,
+			(m.Page - 1) * m.PerPage,
+			m.PerPage,
+		}
+	)
+
+	results, err := w.queryDeliveries(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, err
+	}
+	return results, nil
+}
+
+func (w webhookRepo) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]entity.WebhookDelivery, error) {
+	var results []entity.WebhookDelivery
+
+	rows, err := w.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, *delivery)
+	}
+	return results, nil
+}
+
+func (w webhookRepo) UpdateDelivery(ctx context.Context, delivery entity.WebhookDelivery) error {
+	var (
+		eventName = "repo.webhook.update_delivery"
+		query     = `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5, delivered_at = $6, updated_at = now()
+		WHERE id = $7
+		`
+		args = []interface{}{
+			delivery.Status,
+			delivery.Attempts,
+			delivery.NextAttemptAt,
+			delivery.LastStatusCode,
+			delivery.LastError,
+			delivery.DeliveredAt,
+			delivery.ID,
+		}
+	)
+
+	_, err := w.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (w webhookRepo) CreateLog(ctx context.Context, entry entity.WebhookDeliveryLog) error {
+	var (
+		eventName = "repo.webhook.create_log"
+		query     = `
+		INSERT INTO webhook_delivery_logs (
+		id,
+		delivery_id,
+		attempt,
+		status_code,
+		error,
+		duration_ms,
+		created_at
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,now())
+	`
+		args = []interface{}{
+			entry.ID,
+			entry.DeliveryID,
+			entry.Attempt,
+			entry.StatusCode,
+			entry.Error,
+			entry.DurationMs,
+		}
+	)
+
+	_, err := w.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return errbank.TranslateDBError(err)
+	}
+	return nil
+}
+
+func (w webhookRepo) GetLogs(ctx context.Context, deliveryID uuid.UUID) ([]entity.WebhookDeliveryLog, error) {
+	var (
+		eventName = "repo.webhook.get_logs"
+		query     = `
+		SELECT id, delivery_id, attempt, status_code, error, duration_ms, created_at
+		FROM webhook_delivery_logs
+		WHERE delivery_id = $1
+		ORDER BY attempt
+		`
+		args = []interface{}{
+			deliveryID,
+		}
+		results []entity.WebhookDeliveryLog
+	)
+
+	rows, err := w.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Println(eventName, err)
+		return nil, errbank.TranslateDBError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry entity.WebhookDeliveryLog
+		err := rows.Scan(&entry.ID, &entry.DeliveryID, &entry.Attempt, &entry.StatusCode, &entry.Error, &entry.DurationMs, &entry.CreatedAt)
+		if err != nil {
+			log.Println(eventName, err)
+			return nil, errbank.TranslateDBError(err)
+		}
+		results = append(results, entry)
+	}
+	return results, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row scanner) (*entity.WebhookSubscription, error) {
+	var (
+		subscription    entity.WebhookSubscription
+		encryptedSecret string
+	)
+	err := row.Scan(&subscription.ID, &subscription.AccountID, &subscription.URL, &encryptedSecret, pq.Array(&subscription.Events), &subscription.Active, &subscription.CreatedBy, &subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret, err = cryptox.DecryptAES(encryptedSecret, os.Getenv("AES_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func scanDelivery(row scanner) (*entity.WebhookDelivery, error) {
+	var (
+		delivery    entity.WebhookDelivery
+		payload     []byte
+		deliveredAt sql.NullTime
+	)
+	err := row.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &payload, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &deliveredAt, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return &delivery, nil
+}
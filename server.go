@@ -3,18 +3,22 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/go-co-op/gocron"
 	"github.com/gorilla/mux"
 	cfg "github.com/hanselacn/banking-transaction/config"
 	"github.com/hanselacn/banking-transaction/internal/business"
+	leaderbusiness "github.com/hanselacn/banking-transaction/internal/business/leader_business"
 	"github.com/hanselacn/banking-transaction/internal/handler"
 	"github.com/hanselacn/banking-transaction/internal/middleware"
 	"github.com/joho/godotenv"
@@ -48,13 +52,23 @@ func main() {
 		Worker: cfg.Worker{
 			PayoutInterval: os.Getenv("PAYOUT_INTERVAL"),
 			PayoutTimeUnit: os.Getenv("PAYOUT_TIME_UNIT"),
+			InstanceID:     os.Getenv("WORKER_INSTANCE_ID"),
+			LeaseTTL:       os.Getenv("WORKER_LEASE_TTL"),
+			OutboxInterval: os.Getenv("OUTBOX_RELAY_INTERVAL"),
 		},
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	payoutInterval, err := strconv.Atoi(cfg.Worker.PayoutInterval)
 	if err != nil {
 		payoutInterval = 1
 	}
+	outboxInterval, err := strconv.Atoi(cfg.Worker.OutboxInterval)
+	if err != nil || outboxInterval <= 0 {
+		outboxInterval = 5
+	}
 
 	connStr := fmt.Sprintf("%s://%s:%s@%s/%s?sslmode=disable", cfg.DB.Driver, cfg.DB.User, cfg.DB.Password, cfg.DB.Host, cfg.DB.Name)
 	db, err := sql.Open(cfg.DB.Driver, connStr)
@@ -74,58 +88,240 @@ func main() {
 	}
 	fmt.Println("Successfully connected to the database!")
 
-	h := handler.NewHandler(db)
 	b := business.NewBusiness(db)
+	if len(os.Args) > 1 {
+		code := runCommand(ctx, b, os.Args[1:])
+		db.Close()
+		os.Exit(code)
+	}
+
+	// Every instance listens, a stream may be open on any of them.
+	go func() {
+		if err := b.StreamBusiness.Listen(ctx, connStr); err != nil {
+			log.Println(eventName, "[STREAM] Failed Listening for Account Events", err)
+		}
+	}()
+
+	leader := leaderbusiness.NewLeaderBusiness(db, cfg.Worker)
+	h := handler.NewHandler(db, leader)
 	m := middleware.NewMiddleware(db)
 
 	r.Handle("/ping", http.HandlerFunc(pingHandler))
 	handler.MountUserHandler(r, h, m)
 	handler.MountAccountHandler(r, h, m)
+	handler.MountWorkerHandler(r, h, m)
+	handler.MountTaxHandler(r, h, m)
+	handler.MountFeeHandler(r, h, m)
+	handler.MountTermDepositHandler(r, h, m)
+	handler.MountLoanHandler(r, h, m)
+	handler.MountStandingOrderHandler(r, h, m)
+	handler.MountLedgerHandler(r, h, m)
+	handler.MountIntegrityHandler(r, h, m)
+	handler.MountStatementHandler(r, h, m)
+	handler.MountPayrollHandler(r, h, m)
+	handler.MountApprovalHandler(r, h, m)
+	handler.MountAuditHandler(r, h, m)
+	handler.MountWebhookHandler(r, h, m)
+	handler.MountStreamHandler(r, h, m)
+	handler.MountNotificationHandler(r, h, m)
+	handler.MountKYCHandler(r, h, m)
+	handler.MountFraudHandler(r, h, m)
+	handler.MountAMLHandler(r, h, m)
+	handler.MountSanctionsHandler(r, h, m)
+	handler.MountAuthorizationHandler(r, h, m)
 
-	go func() {
-		log.Println(eventName, "[WORKER] Starting Interest Payout Worker...")
-		jobHandler := func() {
-			_, totalData, totalSuccess, err := b.AccountBusiness.InterestPayoutWorker(context.Background())
-			if err != nil {
-				log.Println(eventName, "[WORKER] Failed Occured While Executing Interest Payout")
-			}
-			log.Println(eventName, fmt.Sprintf("[WORKER] Success Execute %d out of %d Interest Payout Data", totalSuccess, totalData))
-		}
-
-		sh := gocron.NewScheduler(time.Local)
-		switch strings.ToUpper(cfg.Worker.PayoutTimeUnit) {
-		case "YEARS", "YEAR", "Y":
-			sh.Every(12 * payoutInterval).Month().Do(jobHandler)
-		case "MONTHS", "MONTH", "M":
-			sh.Every(payoutInterval).Month().Do(jobHandler)
-		case "DAYS", "DAY", "D":
-			sh.Every(payoutInterval).Day().Do(jobHandler)
-		case "MINUTES", "MINUTE", "MIN":
-			sh.Every(payoutInterval).Minute().Do(jobHandler)
-		case "SECONDS", "SECOND", "SEC":
-			sh.Every(payoutInterval).Second().Do(jobHandler)
-		default:
-			sh.Every(payoutInterval).Month().Do(jobHandler)
-		}
-		sh.StartAsync()
-	}()
+	// Every replica runs the scheduler, but only the instance holding the
+	// scheduler lease executes jobs.
+	if err := leader.Campaign(ctx); err != nil {
+		log.Println(eventName, "[WORKER] Failed Campaigning for Scheduler Lease", err)
+	}
 
-	// Start the server
-	fmt.Printf("Starting server on :%s \n", cfg.Server.Port)
-	switch os.Getenv("API_TLS") {
-	case "true":
-		fmt.Println("Starting server using TLS...")
-		err = http.ListenAndServeTLS(fmt.Sprintf(":%s", cfg.Server.Port), "cert.pem", "key.pem", r)
+	log.Println(eventName, "[WORKER] Starting Interest Payout Worker...")
+	jobHandler := func() {
+		progress, err := b.AccountBusiness.InterestPayoutWorker(ctx)
 		if err != nil {
-			log.Println(eventName, err)
-			fmt.Println("Error starting server:", err)
+			log.Println(eventName, "[WORKER] Failed Occured While Executing Interest Payout", err)
+		}
+		if progress != nil {
+			log.Println(eventName, fmt.Sprintf("[WORKER] Success Execute %d out of %d Interest Payout Data (%d retried) in %d batches", progress.Succeeded, progress.Processed, progress.Retried, progress.Batches))
 		}
+	}
+
+	sh := gocron.NewScheduler(time.Local)
+	sh.WithDistributedElector(leader)
+	switch strings.ToUpper(cfg.Worker.PayoutTimeUnit) {
+	case "YEARS", "YEAR", "Y":
+		sh.Every(12 * payoutInterval).Month().Do(jobHandler)
+	case "MONTHS", "MONTH", "M":
+		sh.Every(payoutInterval).Month().Do(jobHandler)
+	case "DAYS", "DAY", "D":
+		sh.Every(payoutInterval).Day().Do(jobHandler)
+	case "MINUTES", "MINUTE", "MIN":
+		sh.Every(payoutInterval).Minute().Do(jobHandler)
+	case "SECONDS", "SECOND", "SEC":
+		sh.Every(payoutInterval).Second().Do(jobHandler)
 	default:
-		err = http.ListenAndServe(fmt.Sprintf(":%s", cfg.Server.Port), r)
+		sh.Every(payoutInterval).Month().Do(jobHandler)
+	}
+
+	feeJobHandler := func() {
+		totalData, totalSuccess, err := b.FeeBusiness.MonthlyFeeWorker(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] Failed Occured While Executing Monthly Fee", err)
+		}
+		log.Println(eventName, fmt.Sprintf("[WORKER] Success Execute %d out of %d Monthly Fee Data", totalSuccess, totalData))
+	}
+	sh.Every(1).Month(1).At("00:05").Do(feeJobHandler)
+
+	termDepositJobHandler := func() {
+		totalData, totalSuccess, err := b.AccountBusiness.TermDepositMaturityWorker(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] Failed Occured While Executing Term Deposit Maturity", err)
+		}
+		log.Println(eventName, fmt.Sprintf("[WORKER] Success Execute %d out of %d Term Deposit Maturity Data", totalSuccess, totalData))
+	}
+	sh.Every(1).Day().At("00:10").Do(termDepositJobHandler)
+
+	loanJobHandler := func() {
+		totalData, totalSuccess, err := b.LoanBusiness.LoanCollectionWorker(ctx)
 		if err != nil {
-			log.Println(eventName, err)
-			fmt.Println("Error starting server:", err)
+			log.Println(eventName, "[WORKER] Failed Occured While Executing Loan Collection", err)
 		}
+		log.Println(eventName, fmt.Sprintf("[WORKER] Success Execute %d out of %d Loan Collection Data", totalSuccess, totalData))
+	}
+	sh.Every(1).Day().At("00:15").Do(loanJobHandler)
+
+	standingOrderJobHandler := func() {
+		totalData, totalSuccess, err := b.StandingOrderBusiness.StandingOrderWorker(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] Failed Occured While Executing Standing Orders", err)
+		}
+		log.Println(eventName, fmt.Sprintf("[WORKER] Success Execute %d out of %d Standing Order Data", totalSuccess, totalData))
+	}
+	sh.Every(1).Hour().Do(standingOrderJobHandler)
+
+	ledgerCheckJobHandler := func() {
+		journals, err := b.LedgerBusiness.CheckJournals(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] LEDGER CHECK FAILED", err, journals)
+			return
+		}
+		log.Println(eventName, "[WORKER] Ledger Check Passed, All Journal Entries Balance")
+	}
+	sh.Every(1).Day().At("00:30").Do(ledgerCheckJobHandler)
+
+	integrityJobHandler := func() {
+		report, err := b.IntegrityBusiness.CheckBalances(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] Failed Occured While Executing Balance Integrity Check", err)
+			return
+		}
+		log.Println(eventName, fmt.Sprintf("[WORKER] Success Check %d Accounts, %d Mismatched, %d Failed Balance Integrity Data", report.Checked, report.Mismatched, report.Failed))
+	}
+	sh.Every(1).Day().At("00:45").Do(integrityJobHandler)
+
+	amlJobHandler := func() {
+		result, err := b.AMLBusiness.DetectionWorker(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] Failed Occured While Scanning Transactions For AML Alerts", err)
+			return
+		}
+		log.Println(eventName, fmt.Sprintf("[WORKER] Success Scan %d Transactions, %d Large Cash and %d Structuring AML Alerts", result.Scanned, result.LargeCash, result.Structuring))
+	}
+	sh.Every(1).Day().At("01:15").Do(amlJobHandler)
+
+	statementJobHandler := func() {
+		totalData, totalSuccess, err := b.StatementBusiness.StatementWorker(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] Failed Occured While Generating Monthly Statements", err)
+		}
+		log.Println(eventName, fmt.Sprintf("[WORKER] Success Execute %d out of %d Monthly Statement Data", totalSuccess, totalData))
+	}
+	sh.Every(1).Month(1).At("01:00").Do(statementJobHandler)
+
+	outboxJobHandler := func() {
+		result, err := b.OutboxBusiness.Relay(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] Failed Occured While Relaying Outbox Events", err)
+		}
+		if result != nil && result.Published+result.Failed > 0 {
+			log.Println(eventName, fmt.Sprintf("[WORKER] Success Publish %d Outbox Events, %d Failed, %d Deferred", result.Published, result.Failed, result.Deferred))
+		}
+	}
+	sh.Every(outboxInterval).Seconds().SingletonMode().Do(outboxJobHandler)
+
+	webhookJobHandler := func() {
+		totalData, totalSuccess, err := b.WebhookBusiness.DeliveryWorker(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] Failed Occured While Delivering Webhooks", err)
+		}
+		if totalData > 0 {
+			log.Println(eventName, fmt.Sprintf("[WORKER] Success Deliver %d out of %d Webhook Data", totalSuccess, totalData))
+		}
+	}
+	sh.Every(10).Seconds().SingletonMode().Do(webhookJobHandler)
+
+	sanctionsReleaseJobHandler := func() {
+		totalData, totalSuccess, err := b.AccountBusiness.SanctionsReleaseWorker(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] Failed Occured While Releasing Cleared Sanctions Holds", err)
+		}
+		if totalData > 0 {
+			log.Println(eventName, fmt.Sprintf("[WORKER] Success Release %d out of %d Held Transfer Data", totalSuccess, totalData))
+		}
+	}
+	sh.Every(1).Minute().SingletonMode().Do(sanctionsReleaseJobHandler)
+
+	payrollJobHandler := func() {
+		totalData, totalSuccess, err := b.PayrollBusiness.PayrollWorker(ctx)
+		if err != nil {
+			log.Println(eventName, "[WORKER] Failed Occured While Executing Payroll Batches", err)
+		}
+		if totalData > 0 {
+			log.Println(eventName, fmt.Sprintf("[WORKER] Success Execute %d out of %d Payroll Batch Data", totalSuccess, totalData))
+		}
+	}
+	sh.Every(10).Seconds().SingletonMode().Do(payrollJobHandler)
+	sh.StartAsync()
+
+	// Start the server
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.Server.Port),
+		Handler: r,
+	}
+	go func() {
+		fmt.Printf("Starting server on :%s \n", cfg.Server.Port)
+		switch os.Getenv("API_TLS") {
+		case "true":
+			fmt.Println("Starting server using TLS...")
+			err := srv.ListenAndServeTLS("cert.pem", "key.pem")
+			if err != nil && err != http.ErrServerClosed {
+				log.Println(eventName, err)
+				fmt.Println("Error starting server:", err)
+			}
+		default:
+			err := srv.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				log.Println(eventName, err)
+				fmt.Println("Error starting server:", err)
+			}
+		}
+		stop()
+	}()
+
+	<-ctx.Done()
+	log.Println(eventName, "Shutting down...")
+
+	// Stop scheduling new jobs and hand the lease over before closing the server,
+	// so another replica can pick the scheduler up right away.
+	sh.Stop()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := leader.Resign(shutdownCtx); err != nil {
+		log.Println(eventName, "[WORKER] Failed Releasing Scheduler Lease", err)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println(eventName, err)
 	}
 }
 
@@ -133,3 +329,104 @@ func pingHandler(w http.ResponseWriter, r *http.Request) {
 	// Respond with a simple message
 	fmt.Fprintf(w, "Pong!")
 }
+
+// runCommand runs a one-off maintenance subcommand instead of the server and
+// returns the process exit code.
+func runCommand(ctx context.Context, b business.Business, args []string) int {
+	switch args[0] {
+	case "check-balances":
+		return checkBalancesCommand(ctx, b)
+	case "verify-audit":
+		return verifyAuditCommand(ctx, b)
+	case "aml-scan":
+		return amlScanCommand(ctx, b)
+	case "reload-sanctions":
+		return reloadSanctionsCommand(ctx, b, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		fmt.Fprintln(os.Stderr, "available commands: check-balances, verify-audit, aml-scan, reload-sanctions [file]")
+		return 2
+	}
+}
+
+// checkBalancesCommand prints the balance integrity report and exits non-zero
+// when any account does not match its transaction history.
+func checkBalancesCommand(ctx context.Context, b business.Business) int {
+	report, err := b.IntegrityBusiness.CheckBalances(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "check balances failed:", err)
+		return 1
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, "check balances failed:", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "checked %d accounts: %d mismatched, %d failed\n", report.Checked, report.Mismatched, report.Failed)
+	if report.Mismatched > 0 || report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// verifyAuditCommand recomputes the audit log hash chain and exits non-zero
+// when an entry was changed, removed or reordered.
+func verifyAuditCommand(ctx context.Context, b business.Business) int {
+	report, err := b.AuditBusiness.Verify(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "verify audit failed:", err)
+		return 1
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, "verify audit failed:", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "checked %d audit entries: %d breaks\n", report.Checked, len(report.Breaks))
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
+
+// amlScanCommand runs the AML detection job once and prints how many alerts it
+// raised.
+func amlScanCommand(ctx context.Context, b business.Business) int {
+	result, err := b.AMLBusiness.DetectionWorker(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "aml scan failed:", err)
+		return 1
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		fmt.Fprintln(os.Stderr, "aml scan failed:", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "scanned %d transactions: %d large cash, %d structuring alerts\n", result.Scanned, result.LargeCash, result.Structuring)
+	return 0
+}
+
+// reloadSanctionsCommand replaces the sanctions watchlist with the given file, or
+// with SANCTIONS_LIST_FILE when none is given.
+func reloadSanctionsCommand(ctx context.Context, b business.Business, args []string) int {
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	}
+	result, err := b.SanctionsBusiness.ReloadList(ctx, path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "reload sanctions failed:", err)
+		return 1
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		fmt.Fprintln(os.Stderr, "reload sanctions failed:", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "loaded %d sanctions entries from %s\n", result.Entries, result.File)
+	return 0
+}
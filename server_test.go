@@ -13,6 +13,7 @@ import (
 
 	"github.com/gorilla/mux"
 	cfg "github.com/hanselacn/banking-transaction/config"
+	leaderbusiness "github.com/hanselacn/banking-transaction/internal/business/leader_business"
 	"github.com/hanselacn/banking-transaction/internal/entity"
 	"github.com/hanselacn/banking-transaction/internal/handler"
 	"github.com/hanselacn/banking-transaction/internal/middleware"
@@ -69,7 +70,7 @@ func TestInvalidUserName(t *testing.T) {
 	}
 	fmt.Println("Successfully connected to the database!")
 
-	h := handler.NewHandler(db)
+	h := handler.NewHandler(db, leaderbusiness.NewLeaderBusiness(db, cfg.Worker))
 	m := middleware.NewMiddleware(db)
 
 	r.Handle("/ping", http.HandlerFunc(pingHandler))
@@ -146,7 +147,7 @@ func TestInvalidPassword(t *testing.T) {
 	}
 	fmt.Println("Successfully connected to the database!")
 
-	h := handler.NewHandler(db)
+	h := handler.NewHandler(db, leaderbusiness.NewLeaderBusiness(db, cfg.Worker))
 	m := middleware.NewMiddleware(db)
 
 	r.Handle("/ping", http.HandlerFunc(pingHandler))
@@ -223,7 +224,7 @@ func TestInvalidAuthorization(t *testing.T) {
 	}
 	fmt.Println("Successfully connected to the database!")
 
-	h := handler.NewHandler(db)
+	h := handler.NewHandler(db, leaderbusiness.NewLeaderBusiness(db, cfg.Worker))
 	m := middleware.NewMiddleware(db)
 
 	r.Handle("/ping", http.HandlerFunc(pingHandler))
@@ -300,7 +301,7 @@ func TestSQLInjection(t *testing.T) {
 	}
 	fmt.Println("Successfully connected to the database!")
 
-	h := handler.NewHandler(db)
+	h := handler.NewHandler(db, leaderbusiness.NewLeaderBusiness(db, cfg.Worker))
 	m := middleware.NewMiddleware(db)
 
 	r.Handle("/ping", http.HandlerFunc(pingHandler))